@@ -0,0 +1,5 @@
+// Package api holds the protobuf definition of the Iris gRPC API,
+// the generated code lives in irispb.
+package api
+
+//go:generate buf generate
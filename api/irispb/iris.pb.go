@@ -0,0 +1,5659 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: iris.proto
+
+package irispb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TopicMode int32
+
+const (
+	TopicMode_TOPIC_MODE_STREAM TopicMode = 0
+	TopicMode_TOPIC_MODE_QUEUE  TopicMode = 1
+)
+
+// Enum value maps for TopicMode.
+var (
+	TopicMode_name = map[int32]string{
+		0: "TOPIC_MODE_STREAM",
+		1: "TOPIC_MODE_QUEUE",
+	}
+	TopicMode_value = map[string]int32{
+		"TOPIC_MODE_STREAM": 0,
+		"TOPIC_MODE_QUEUE":  1,
+	}
+)
+
+func (x TopicMode) Enum() *TopicMode {
+	p := new(TopicMode)
+	*p = x
+	return p
+}
+
+func (x TopicMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TopicMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[0].Descriptor()
+}
+
+func (TopicMode) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[0]
+}
+
+func (x TopicMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TopicMode.Descriptor instead.
+func (TopicMode) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{0}
+}
+
+// How messages are mapped to CloudEvents. In binary mode the attributes are the
+// ce_ headers of a message, the datacontenttype its content-type header, the
+// partitionkey extension its key and the data its value. In structured mode the
+// value is the JSON encoded event and other headers are passed through.
+type CloudEventsMode int32
+
+const (
+	CloudEventsMode_CLOUD_EVENTS_MODE_NONE       CloudEventsMode = 0
+	CloudEventsMode_CLOUD_EVENTS_MODE_BINARY     CloudEventsMode = 1
+	CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED CloudEventsMode = 2
+)
+
+// Enum value maps for CloudEventsMode.
+var (
+	CloudEventsMode_name = map[int32]string{
+		0: "CLOUD_EVENTS_MODE_NONE",
+		1: "CLOUD_EVENTS_MODE_BINARY",
+		2: "CLOUD_EVENTS_MODE_STRUCTURED",
+	}
+	CloudEventsMode_value = map[string]int32{
+		"CLOUD_EVENTS_MODE_NONE":       0,
+		"CLOUD_EVENTS_MODE_BINARY":     1,
+		"CLOUD_EVENTS_MODE_STRUCTURED": 2,
+	}
+)
+
+func (x CloudEventsMode) Enum() *CloudEventsMode {
+	p := new(CloudEventsMode)
+	*p = x
+	return p
+}
+
+func (x CloudEventsMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CloudEventsMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[1].Descriptor()
+}
+
+func (CloudEventsMode) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[1]
+}
+
+func (x CloudEventsMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CloudEventsMode.Descriptor instead.
+func (CloudEventsMode) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{1}
+}
+
+type Acks int32
+
+const (
+	// The response is sent once the leader has appended the messages.
+	Acks_ACKS_LEADER Acks = 0
+	// Handled like ACKS_LEADER, as calls always get a response.
+	Acks_ACKS_NONE Acks = 1
+	// The response is sent once all in-sync replicas have the messages. The messages are
+	// rejected if the partition has less than the min in-sync replicas of the topic.
+	Acks_ACKS_ALL Acks = 2
+)
+
+// Enum value maps for Acks.
+var (
+	Acks_name = map[int32]string{
+		0: "ACKS_LEADER",
+		1: "ACKS_NONE",
+		2: "ACKS_ALL",
+	}
+	Acks_value = map[string]int32{
+		"ACKS_LEADER": 0,
+		"ACKS_NONE":   1,
+		"ACKS_ALL":    2,
+	}
+)
+
+func (x Acks) Enum() *Acks {
+	p := new(Acks)
+	*p = x
+	return p
+}
+
+func (x Acks) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Acks) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[2].Descriptor()
+}
+
+func (Acks) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[2]
+}
+
+func (x Acks) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Acks.Descriptor instead.
+func (Acks) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{2}
+}
+
+type ResourceType int32
+
+const (
+	ResourceType_RESOURCE_TYPE_UNSPECIFIED ResourceType = 0
+	ResourceType_RESOURCE_TYPE_TOPIC       ResourceType = 1
+	ResourceType_RESOURCE_TYPE_GROUP       ResourceType = 2
+	ResourceType_RESOURCE_TYPE_CLUSTER     ResourceType = 3
+)
+
+// Enum value maps for ResourceType.
+var (
+	ResourceType_name = map[int32]string{
+		0: "RESOURCE_TYPE_UNSPECIFIED",
+		1: "RESOURCE_TYPE_TOPIC",
+		2: "RESOURCE_TYPE_GROUP",
+		3: "RESOURCE_TYPE_CLUSTER",
+	}
+	ResourceType_value = map[string]int32{
+		"RESOURCE_TYPE_UNSPECIFIED": 0,
+		"RESOURCE_TYPE_TOPIC":       1,
+		"RESOURCE_TYPE_GROUP":       2,
+		"RESOURCE_TYPE_CLUSTER":     3,
+	}
+)
+
+func (x ResourceType) Enum() *ResourceType {
+	p := new(ResourceType)
+	*p = x
+	return p
+}
+
+func (x ResourceType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ResourceType) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[3].Descriptor()
+}
+
+func (ResourceType) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[3]
+}
+
+func (x ResourceType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ResourceType.Descriptor instead.
+func (ResourceType) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{3}
+}
+
+type PatternType int32
+
+const (
+	PatternType_PATTERN_TYPE_LITERAL PatternType = 0
+	PatternType_PATTERN_TYPE_PREFIX  PatternType = 1
+)
+
+// Enum value maps for PatternType.
+var (
+	PatternType_name = map[int32]string{
+		0: "PATTERN_TYPE_LITERAL",
+		1: "PATTERN_TYPE_PREFIX",
+	}
+	PatternType_value = map[string]int32{
+		"PATTERN_TYPE_LITERAL": 0,
+		"PATTERN_TYPE_PREFIX":  1,
+	}
+)
+
+func (x PatternType) Enum() *PatternType {
+	p := new(PatternType)
+	*p = x
+	return p
+}
+
+func (x PatternType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PatternType) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[4].Descriptor()
+}
+
+func (PatternType) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[4]
+}
+
+func (x PatternType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PatternType.Descriptor instead.
+func (PatternType) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{4}
+}
+
+type Operation int32
+
+const (
+	Operation_OPERATION_UNSPECIFIED Operation = 0
+	Operation_OPERATION_PRODUCE     Operation = 1
+	Operation_OPERATION_CONSUME     Operation = 2
+	// Admin implies all other operations.
+	Operation_OPERATION_ADMIN Operation = 3
+)
+
+// Enum value maps for Operation.
+var (
+	Operation_name = map[int32]string{
+		0: "OPERATION_UNSPECIFIED",
+		1: "OPERATION_PRODUCE",
+		2: "OPERATION_CONSUME",
+		3: "OPERATION_ADMIN",
+	}
+	Operation_value = map[string]int32{
+		"OPERATION_UNSPECIFIED": 0,
+		"OPERATION_PRODUCE":     1,
+		"OPERATION_CONSUME":     2,
+		"OPERATION_ADMIN":       3,
+	}
+)
+
+func (x Operation) Enum() *Operation {
+	p := new(Operation)
+	*p = x
+	return p
+}
+
+func (x Operation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operation) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[5].Descriptor()
+}
+
+func (Operation) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[5]
+}
+
+func (x Operation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operation.Descriptor instead.
+func (Operation) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{5}
+}
+
+type SchemaType int32
+
+const (
+	SchemaType_SCHEMA_TYPE_JSON     SchemaType = 0
+	SchemaType_SCHEMA_TYPE_PROTOBUF SchemaType = 1
+)
+
+// Enum value maps for SchemaType.
+var (
+	SchemaType_name = map[int32]string{
+		0: "SCHEMA_TYPE_JSON",
+		1: "SCHEMA_TYPE_PROTOBUF",
+	}
+	SchemaType_value = map[string]int32{
+		"SCHEMA_TYPE_JSON":     0,
+		"SCHEMA_TYPE_PROTOBUF": 1,
+	}
+)
+
+func (x SchemaType) Enum() *SchemaType {
+	p := new(SchemaType)
+	*p = x
+	return p
+}
+
+func (x SchemaType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SchemaType) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[6].Descriptor()
+}
+
+func (SchemaType) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[6]
+}
+
+func (x SchemaType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SchemaType.Descriptor instead.
+func (SchemaType) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{6}
+}
+
+type Compatibility int32
+
+const (
+	Compatibility_COMPATIBILITY_UNSPECIFIED         Compatibility = 0
+	Compatibility_COMPATIBILITY_NONE                Compatibility = 1
+	Compatibility_COMPATIBILITY_BACKWARD            Compatibility = 2
+	Compatibility_COMPATIBILITY_BACKWARD_TRANSITIVE Compatibility = 3
+	Compatibility_COMPATIBILITY_FORWARD             Compatibility = 4
+	Compatibility_COMPATIBILITY_FORWARD_TRANSITIVE  Compatibility = 5
+	Compatibility_COMPATIBILITY_FULL                Compatibility = 6
+	Compatibility_COMPATIBILITY_FULL_TRANSITIVE     Compatibility = 7
+)
+
+// Enum value maps for Compatibility.
+var (
+	Compatibility_name = map[int32]string{
+		0: "COMPATIBILITY_UNSPECIFIED",
+		1: "COMPATIBILITY_NONE",
+		2: "COMPATIBILITY_BACKWARD",
+		3: "COMPATIBILITY_BACKWARD_TRANSITIVE",
+		4: "COMPATIBILITY_FORWARD",
+		5: "COMPATIBILITY_FORWARD_TRANSITIVE",
+		6: "COMPATIBILITY_FULL",
+		7: "COMPATIBILITY_FULL_TRANSITIVE",
+	}
+	Compatibility_value = map[string]int32{
+		"COMPATIBILITY_UNSPECIFIED":         0,
+		"COMPATIBILITY_NONE":                1,
+		"COMPATIBILITY_BACKWARD":            2,
+		"COMPATIBILITY_BACKWARD_TRANSITIVE": 3,
+		"COMPATIBILITY_FORWARD":             4,
+		"COMPATIBILITY_FORWARD_TRANSITIVE":  5,
+		"COMPATIBILITY_FULL":                6,
+		"COMPATIBILITY_FULL_TRANSITIVE":     7,
+	}
+)
+
+func (x Compatibility) Enum() *Compatibility {
+	p := new(Compatibility)
+	*p = x
+	return p
+}
+
+func (x Compatibility) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compatibility) Descriptor() protoreflect.EnumDescriptor {
+	return file_iris_proto_enumTypes[7].Descriptor()
+}
+
+func (Compatibility) Type() protoreflect.EnumType {
+	return &file_iris_proto_enumTypes[7]
+}
+
+func (x Compatibility) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compatibility.Descriptor instead.
+func (Compatibility) EnumDescriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{7}
+}
+
+type Header struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Header) Reset() {
+	*x = Header{}
+	mi := &file_iris_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Header) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Header) ProtoMessage() {}
+
+func (x *Header) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Header.ProtoReflect.Descriptor instead.
+func (*Header) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Header) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Header) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type Message struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Offset uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Unix timestamp in milliseconds.
+	Timestamp int64     `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Key       []byte    `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value     []byte    `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	Headers   []*Header `protobuf:"bytes,5,rep,name=headers,proto3" json:"headers,omitempty"`
+	// Time to live in milliseconds, set by producers. It counts from the time
+	// the message becomes visible to consumers.
+	Ttl int64 `protobuf:"varint,6,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// Unix timestamp in milliseconds after which the message is not fetched anymore,
+	// set on fetched messages which have a ttl.
+	ExpiresAt     int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_iris_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Message) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Message) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Message) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *Message) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Message) GetHeaders() []*Header {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Message) GetTtl() int64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+func (x *Message) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type CreateTopicRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Topic      string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partitions int32                  `protobuf:"varint,2,opt,name=partitions,proto3" json:"partitions,omitempty"`
+	Mode       TopicMode              `protobuf:"varint,3,opt,name=mode,proto3,enum=iris.v1.TopicMode" json:"mode,omitempty"`
+	// Only used by queues. The visibility timeout is given in milliseconds,
+	// max_deliveries of zero delivers messages until they are acked.
+	VisibilityTimeout int64 `protobuf:"varint,4,opt,name=visibility_timeout,json=visibilityTimeout,proto3" json:"visibility_timeout,omitempty"`
+	MaxDeliveries     int32 `protobuf:"varint,5,opt,name=max_deliveries,json=maxDeliveries,proto3" json:"max_deliveries,omitempty"`
+	// The node ids of the brokers keeping each partition, the first one leads it.
+	// Partitions are only kept by the broker they are created on without replicas.
+	Replicas []*PartitionReplicas `protobuf:"bytes,6,rep,name=replicas,proto3" json:"replicas,omitempty"`
+	// The number of in-sync replicas a partition needs to accept messages produced with ACKS_ALL, zero is one.
+	MinInsyncReplicas int32 `protobuf:"varint,7,opt,name=min_insync_replicas,json=minInsyncReplicas,proto3" json:"min_insync_replicas,omitempty"`
+	// Allows replicas which are not in sync to become the leader, which loses the messages they are missing.
+	UncleanLeaderElection bool `protobuf:"varint,8,opt,name=unclean_leader_election,json=uncleanLeaderElection,proto3" json:"unclean_leader_election,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *CreateTopicRequest) Reset() {
+	*x = CreateTopicRequest{}
+	mi := &file_iris_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTopicRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTopicRequest) ProtoMessage() {}
+
+func (x *CreateTopicRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTopicRequest.ProtoReflect.Descriptor instead.
+func (*CreateTopicRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateTopicRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CreateTopicRequest) GetPartitions() int32 {
+	if x != nil {
+		return x.Partitions
+	}
+	return 0
+}
+
+func (x *CreateTopicRequest) GetMode() TopicMode {
+	if x != nil {
+		return x.Mode
+	}
+	return TopicMode_TOPIC_MODE_STREAM
+}
+
+func (x *CreateTopicRequest) GetVisibilityTimeout() int64 {
+	if x != nil {
+		return x.VisibilityTimeout
+	}
+	return 0
+}
+
+func (x *CreateTopicRequest) GetMaxDeliveries() int32 {
+	if x != nil {
+		return x.MaxDeliveries
+	}
+	return 0
+}
+
+func (x *CreateTopicRequest) GetReplicas() []*PartitionReplicas {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+func (x *CreateTopicRequest) GetMinInsyncReplicas() int32 {
+	if x != nil {
+		return x.MinInsyncReplicas
+	}
+	return 0
+}
+
+func (x *CreateTopicRequest) GetUncleanLeaderElection() bool {
+	if x != nil {
+		return x.UncleanLeaderElection
+	}
+	return false
+}
+
+type PartitionReplicas struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Replicas      []int32                `protobuf:"varint,1,rep,packed,name=replicas,proto3" json:"replicas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartitionReplicas) Reset() {
+	*x = PartitionReplicas{}
+	mi := &file_iris_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartitionReplicas) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartitionReplicas) ProtoMessage() {}
+
+func (x *PartitionReplicas) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartitionReplicas.ProtoReflect.Descriptor instead.
+func (*PartitionReplicas) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PartitionReplicas) GetReplicas() []int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+type CreateTopicResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTopicResponse) Reset() {
+	*x = CreateTopicResponse{}
+	mi := &file_iris_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTopicResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTopicResponse) ProtoMessage() {}
+
+func (x *CreateTopicResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTopicResponse.ProtoReflect.Descriptor instead.
+func (*CreateTopicResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{4}
+}
+
+type ProduceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Topic string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	// The partition is chosen by the key of the first message if it is not set.
+	Partition *int32     `protobuf:"varint,2,opt,name=partition,proto3,oneof" json:"partition,omitempty"`
+	Messages  []*Message `protobuf:"bytes,3,rep,name=messages,proto3" json:"messages,omitempty"`
+	// Delays the delivery until the given unix timestamp in milliseconds,
+	// or by the given number of milliseconds. Only one of them may be set.
+	DeliverAt int64 `protobuf:"varint,4,opt,name=deliver_at,json=deliverAt,proto3" json:"deliver_at,omitempty"`
+	Delay     int64 `protobuf:"varint,5,opt,name=delay,proto3" json:"delay,omitempty"`
+	// Produces CloudEvents, they are stored in binary mode either way.
+	CloudeventsMode CloudEventsMode `protobuf:"varint,6,opt,name=cloudevents_mode,json=cloudeventsMode,proto3,enum=iris.v1.CloudEventsMode" json:"cloudevents_mode,omitempty"`
+	Acks            Acks            `protobuf:"varint,7,opt,name=acks,proto3,enum=iris.v1.Acks" json:"acks,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ProduceRequest) Reset() {
+	*x = ProduceRequest{}
+	mi := &file_iris_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProduceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProduceRequest) ProtoMessage() {}
+
+func (x *ProduceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProduceRequest.ProtoReflect.Descriptor instead.
+func (*ProduceRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ProduceRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ProduceRequest) GetPartition() int32 {
+	if x != nil && x.Partition != nil {
+		return *x.Partition
+	}
+	return 0
+}
+
+func (x *ProduceRequest) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ProduceRequest) GetDeliverAt() int64 {
+	if x != nil {
+		return x.DeliverAt
+	}
+	return 0
+}
+
+func (x *ProduceRequest) GetDelay() int64 {
+	if x != nil {
+		return x.Delay
+	}
+	return 0
+}
+
+func (x *ProduceRequest) GetCloudeventsMode() CloudEventsMode {
+	if x != nil {
+		return x.CloudeventsMode
+	}
+	return CloudEventsMode_CLOUD_EVENTS_MODE_NONE
+}
+
+func (x *ProduceRequest) GetAcks() Acks {
+	if x != nil {
+		return x.Acks
+	}
+	return Acks_ACKS_LEADER
+}
+
+type ProduceResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Partition int32                  `protobuf:"varint,1,opt,name=partition,proto3" json:"partition,omitempty"`
+	// Not set for delayed messages, they get their offset on delivery.
+	BaseOffset uint64 `protobuf:"varint,2,opt,name=base_offset,json=baseOffset,proto3" json:"base_offset,omitempty"`
+	Delayed    bool   `protobuf:"varint,3,opt,name=delayed,proto3" json:"delayed,omitempty"`
+	// Milliseconds the response has been delayed because the client exceeded its quota.
+	ThrottleTimeMs int32 `protobuf:"varint,4,opt,name=throttle_time_ms,json=throttleTimeMs,proto3" json:"throttle_time_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ProduceResponse) Reset() {
+	*x = ProduceResponse{}
+	mi := &file_iris_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProduceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProduceResponse) ProtoMessage() {}
+
+func (x *ProduceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProduceResponse.ProtoReflect.Descriptor instead.
+func (*ProduceResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProduceResponse) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *ProduceResponse) GetBaseOffset() uint64 {
+	if x != nil {
+		return x.BaseOffset
+	}
+	return 0
+}
+
+func (x *ProduceResponse) GetDelayed() bool {
+	if x != nil {
+		return x.Delayed
+	}
+	return false
+}
+
+func (x *ProduceResponse) GetThrottleTimeMs() int32 {
+	if x != nil {
+		return x.ThrottleTimeMs
+	}
+	return 0
+}
+
+type FetchRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Topic       string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition   int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset      uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	MaxMessages int32                  `protobuf:"varint,4,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"`
+	// Only messages matching the filter expression are returned, for example
+	// key prefix 'order-' and header.region in ('eu', 'us').
+	Filter string `protobuf:"bytes,5,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Only CloudEvents are returned if it is set.
+	CloudeventsMode CloudEventsMode `protobuf:"varint,6,opt,name=cloudevents_mode,json=cloudeventsMode,proto3,enum=iris.v1.CloudEventsMode" json:"cloudevents_mode,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *FetchRequest) Reset() {
+	*x = FetchRequest{}
+	mi := &file_iris_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchRequest) ProtoMessage() {}
+
+func (x *FetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchRequest.ProtoReflect.Descriptor instead.
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FetchRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *FetchRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *FetchRequest) GetMaxMessages() int32 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+func (x *FetchRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetCloudeventsMode() CloudEventsMode {
+	if x != nil {
+		return x.CloudeventsMode
+	}
+	return CloudEventsMode_CLOUD_EVENTS_MODE_NONE
+}
+
+type FetchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	HighWatermark uint64                 `protobuf:"varint,2,opt,name=high_watermark,json=highWatermark,proto3" json:"high_watermark,omitempty"`
+	// The offset to fetch next, expired messages are skipped.
+	NextOffset uint64 `protobuf:"varint,3,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"`
+	// Milliseconds the response has been delayed because the client exceeded its quota.
+	ThrottleTimeMs int32 `protobuf:"varint,4,opt,name=throttle_time_ms,json=throttleTimeMs,proto3" json:"throttle_time_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *FetchResponse) Reset() {
+	*x = FetchResponse{}
+	mi := &file_iris_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchResponse) ProtoMessage() {}
+
+func (x *FetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchResponse.ProtoReflect.Descriptor instead.
+func (*FetchResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FetchResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *FetchResponse) GetHighWatermark() uint64 {
+	if x != nil {
+		return x.HighWatermark
+	}
+	return 0
+}
+
+func (x *FetchResponse) GetNextOffset() uint64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+func (x *FetchResponse) GetThrottleTimeMs() int32 {
+	if x != nil {
+		return x.ThrottleTimeMs
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Topic     string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset    uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Maximum number of messages per response.
+	MaxMessages     int32           `protobuf:"varint,4,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"`
+	Filter          string          `protobuf:"bytes,5,opt,name=filter,proto3" json:"filter,omitempty"`
+	CloudeventsMode CloudEventsMode `protobuf:"varint,6,opt,name=cloudevents_mode,json=cloudeventsMode,proto3,enum=iris.v1.CloudEventsMode" json:"cloudevents_mode,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_iris_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SubscribeRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetMaxMessages() int32 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetCloudeventsMode() CloudEventsMode {
+	if x != nil {
+		return x.CloudeventsMode
+	}
+	return CloudEventsMode_CLOUD_EVENTS_MODE_NONE
+}
+
+type SubscribeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Messages      []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	HighWatermark uint64                 `protobuf:"varint,2,opt,name=high_watermark,json=highWatermark,proto3" json:"high_watermark,omitempty"`
+	NextOffset    uint64                 `protobuf:"varint,3,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"`
+	// Milliseconds the response has been delayed because the client exceeded its quota.
+	ThrottleTimeMs int32 `protobuf:"varint,4,opt,name=throttle_time_ms,json=throttleTimeMs,proto3" json:"throttle_time_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SubscribeResponse) Reset() {
+	*x = SubscribeResponse{}
+	mi := &file_iris_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeResponse) ProtoMessage() {}
+
+func (x *SubscribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SubscribeResponse) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *SubscribeResponse) GetHighWatermark() uint64 {
+	if x != nil {
+		return x.HighWatermark
+	}
+	return 0
+}
+
+func (x *SubscribeResponse) GetNextOffset() uint64 {
+	if x != nil {
+		return x.NextOffset
+	}
+	return 0
+}
+
+func (x *SubscribeResponse) GetThrottleTimeMs() int32 {
+	if x != nil {
+		return x.ThrottleTimeMs
+	}
+	return 0
+}
+
+type CommitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitRequest) Reset() {
+	*x = CommitRequest{}
+	mi := &file_iris_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitRequest) ProtoMessage() {}
+
+func (x *CommitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitRequest.ProtoReflect.Descriptor instead.
+func (*CommitRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CommitRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CommitRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *CommitRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type CommitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommitResponse) Reset() {
+	*x = CommitResponse{}
+	mi := &file_iris_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommitResponse) ProtoMessage() {}
+
+func (x *CommitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommitResponse.ProtoReflect.Descriptor instead.
+func (*CommitResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{12}
+}
+
+type CommittedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommittedRequest) Reset() {
+	*x = CommittedRequest{}
+	mi := &file_iris_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommittedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommittedRequest) ProtoMessage() {}
+
+func (x *CommittedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommittedRequest.ProtoReflect.Descriptor instead.
+func (*CommittedRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *CommittedRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *CommittedRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *CommittedRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+type CommittedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommittedResponse) Reset() {
+	*x = CommittedResponse{}
+	mi := &file_iris_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommittedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommittedResponse) ProtoMessage() {}
+
+func (x *CommittedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommittedResponse.ProtoReflect.Descriptor instead.
+func (*CommittedResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CommittedResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *CommittedResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type NackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Topic         string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NackRequest) Reset() {
+	*x = NackRequest{}
+	mi := &file_iris_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NackRequest) ProtoMessage() {}
+
+func (x *NackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NackRequest.ProtoReflect.Descriptor instead.
+func (*NackRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NackRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *NackRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *NackRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *NackRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *NackRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type NackResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attempts      int32                  `protobuf:"varint,1,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	DeadLettered  bool                   `protobuf:"varint,2,opt,name=dead_lettered,json=deadLettered,proto3" json:"dead_lettered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NackResponse) Reset() {
+	*x = NackResponse{}
+	mi := &file_iris_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NackResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NackResponse) ProtoMessage() {}
+
+func (x *NackResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NackResponse.ProtoReflect.Descriptor instead.
+func (*NackResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *NackResponse) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *NackResponse) GetDeadLettered() bool {
+	if x != nil {
+		return x.DeadLettered
+	}
+	return false
+}
+
+type SetDeadLetterPolicyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	MaxAttempts   int32                  `protobuf:"varint,2,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeadLetterPolicyRequest) Reset() {
+	*x = SetDeadLetterPolicyRequest{}
+	mi := &file_iris_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeadLetterPolicyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeadLetterPolicyRequest) ProtoMessage() {}
+
+func (x *SetDeadLetterPolicyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeadLetterPolicyRequest.ProtoReflect.Descriptor instead.
+func (*SetDeadLetterPolicyRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetDeadLetterPolicyRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *SetDeadLetterPolicyRequest) GetMaxAttempts() int32 {
+	if x != nil {
+		return x.MaxAttempts
+	}
+	return 0
+}
+
+type SetDeadLetterPolicyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetDeadLetterPolicyResponse) Reset() {
+	*x = SetDeadLetterPolicyResponse{}
+	mi := &file_iris_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetDeadLetterPolicyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetDeadLetterPolicyResponse) ProtoMessage() {}
+
+func (x *SetDeadLetterPolicyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetDeadLetterPolicyResponse.ProtoReflect.Descriptor instead.
+func (*SetDeadLetterPolicyResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{18}
+}
+
+type ReceiveRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Topic       string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	MaxMessages int32                  `protobuf:"varint,2,opt,name=max_messages,json=maxMessages,proto3" json:"max_messages,omitempty"`
+	// Overrides the visibility timeout of the topic, in milliseconds.
+	VisibilityTimeout int64 `protobuf:"varint,3,opt,name=visibility_timeout,json=visibilityTimeout,proto3" json:"visibility_timeout,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ReceiveRequest) Reset() {
+	*x = ReceiveRequest{}
+	mi := &file_iris_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveRequest) ProtoMessage() {}
+
+func (x *ReceiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveRequest.ProtoReflect.Descriptor instead.
+func (*ReceiveRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ReceiveRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReceiveRequest) GetMaxMessages() int32 {
+	if x != nil {
+		return x.MaxMessages
+	}
+	return 0
+}
+
+func (x *ReceiveRequest) GetVisibilityTimeout() int64 {
+	if x != nil {
+		return x.VisibilityTimeout
+	}
+	return 0
+}
+
+type LeasedMessage struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Partition int32                  `protobuf:"varint,1,opt,name=partition,proto3" json:"partition,omitempty"`
+	Message   *Message               `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Number of deliveries of the message including this one.
+	Deliveries int32 `protobuf:"varint,3,opt,name=deliveries,proto3" json:"deliveries,omitempty"`
+	// Unix timestamp in milliseconds the lease ends.
+	Deadline      int64 `protobuf:"varint,4,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeasedMessage) Reset() {
+	*x = LeasedMessage{}
+	mi := &file_iris_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeasedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeasedMessage) ProtoMessage() {}
+
+func (x *LeasedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeasedMessage.ProtoReflect.Descriptor instead.
+func (*LeasedMessage) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *LeasedMessage) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *LeasedMessage) GetMessage() *Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *LeasedMessage) GetDeliveries() int32 {
+	if x != nil {
+		return x.Deliveries
+	}
+	return 0
+}
+
+func (x *LeasedMessage) GetDeadline() int64 {
+	if x != nil {
+		return x.Deadline
+	}
+	return 0
+}
+
+type ReceiveResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Messages []*LeasedMessage       `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	// Milliseconds the response has been delayed because the client exceeded its quota.
+	ThrottleTimeMs int32 `protobuf:"varint,2,opt,name=throttle_time_ms,json=throttleTimeMs,proto3" json:"throttle_time_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReceiveResponse) Reset() {
+	*x = ReceiveResponse{}
+	mi := &file_iris_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReceiveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReceiveResponse) ProtoMessage() {}
+
+func (x *ReceiveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReceiveResponse.ProtoReflect.Descriptor instead.
+func (*ReceiveResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ReceiveResponse) GetMessages() []*LeasedMessage {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ReceiveResponse) GetThrottleTimeMs() int32 {
+	if x != nil {
+		return x.ThrottleTimeMs
+	}
+	return 0
+}
+
+type AckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckRequest) Reset() {
+	*x = AckRequest{}
+	mi := &file_iris_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckRequest) ProtoMessage() {}
+
+func (x *AckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckRequest.ProtoReflect.Descriptor instead.
+func (*AckRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AckRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *AckRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *AckRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type AckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AckResponse) Reset() {
+	*x = AckResponse{}
+	mi := &file_iris_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AckResponse) ProtoMessage() {}
+
+func (x *AckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AckResponse.ProtoReflect.Descriptor instead.
+func (*AckResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{23}
+}
+
+type ReleaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseRequest) Reset() {
+	*x = ReleaseRequest{}
+	mi := &file_iris_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseRequest) ProtoMessage() {}
+
+func (x *ReleaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ReleaseRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReleaseRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *ReleaseRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReleaseRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ReleaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deliveries    int32                  `protobuf:"varint,1,opt,name=deliveries,proto3" json:"deliveries,omitempty"`
+	DeadLettered  bool                   `protobuf:"varint,2,opt,name=dead_lettered,json=deadLettered,proto3" json:"dead_lettered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseResponse) Reset() {
+	*x = ReleaseResponse{}
+	mi := &file_iris_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseResponse) ProtoMessage() {}
+
+func (x *ReleaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ReleaseResponse) GetDeliveries() int32 {
+	if x != nil {
+		return x.Deliveries
+	}
+	return 0
+}
+
+func (x *ReleaseResponse) GetDeadLettered() bool {
+	if x != nil {
+		return x.DeadLettered
+	}
+	return false
+}
+
+type ACL struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The principal "*" matches all principals.
+	Principal    string       `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	ResourceType ResourceType `protobuf:"varint,2,opt,name=resource_type,json=resourceType,proto3,enum=iris.v1.ResourceType" json:"resource_type,omitempty"`
+	PatternType  PatternType  `protobuf:"varint,3,opt,name=pattern_type,json=patternType,proto3,enum=iris.v1.PatternType" json:"pattern_type,omitempty"`
+	// Name of the resource or prefix of the names, empty for the cluster.
+	Name          string    `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Operation     Operation `protobuf:"varint,5,opt,name=operation,proto3,enum=iris.v1.Operation" json:"operation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ACL) Reset() {
+	*x = ACL{}
+	mi := &file_iris_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ACL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ACL) ProtoMessage() {}
+
+func (x *ACL) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ACL.ProtoReflect.Descriptor instead.
+func (*ACL) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ACL) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *ACL) GetResourceType() ResourceType {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ResourceType_RESOURCE_TYPE_UNSPECIFIED
+}
+
+func (x *ACL) GetPatternType() PatternType {
+	if x != nil {
+		return x.PatternType
+	}
+	return PatternType_PATTERN_TYPE_LITERAL
+}
+
+func (x *ACL) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ACL) GetOperation() Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return Operation_OPERATION_UNSPECIFIED
+}
+
+type CreateACLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Acl           *ACL                   `protobuf:"bytes,1,opt,name=acl,proto3" json:"acl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateACLRequest) Reset() {
+	*x = CreateACLRequest{}
+	mi := &file_iris_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateACLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateACLRequest) ProtoMessage() {}
+
+func (x *CreateACLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateACLRequest.ProtoReflect.Descriptor instead.
+func (*CreateACLRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *CreateACLRequest) GetAcl() *ACL {
+	if x != nil {
+		return x.Acl
+	}
+	return nil
+}
+
+type CreateACLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateACLResponse) Reset() {
+	*x = CreateACLResponse{}
+	mi := &file_iris_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateACLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateACLResponse) ProtoMessage() {}
+
+func (x *CreateACLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateACLResponse.ProtoReflect.Descriptor instead.
+func (*CreateACLResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{28}
+}
+
+type DeleteACLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Acl           *ACL                   `protobuf:"bytes,1,opt,name=acl,proto3" json:"acl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteACLRequest) Reset() {
+	*x = DeleteACLRequest{}
+	mi := &file_iris_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteACLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteACLRequest) ProtoMessage() {}
+
+func (x *DeleteACLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteACLRequest.ProtoReflect.Descriptor instead.
+func (*DeleteACLRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *DeleteACLRequest) GetAcl() *ACL {
+	if x != nil {
+		return x.Acl
+	}
+	return nil
+}
+
+type DeleteACLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Found         bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteACLResponse) Reset() {
+	*x = DeleteACLResponse{}
+	mi := &file_iris_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteACLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteACLResponse) ProtoMessage() {}
+
+func (x *DeleteACLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteACLResponse.ProtoReflect.Descriptor instead.
+func (*DeleteACLResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *DeleteACLResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+type ListACLsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListACLsRequest) Reset() {
+	*x = ListACLsRequest{}
+	mi := &file_iris_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListACLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListACLsRequest) ProtoMessage() {}
+
+func (x *ListACLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListACLsRequest.ProtoReflect.Descriptor instead.
+func (*ListACLsRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{31}
+}
+
+type ListACLsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Acls          []*ACL                 `protobuf:"bytes,1,rep,name=acls,proto3" json:"acls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListACLsResponse) Reset() {
+	*x = ListACLsResponse{}
+	mi := &file_iris_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListACLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListACLsResponse) ProtoMessage() {}
+
+func (x *ListACLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListACLsResponse.ProtoReflect.Descriptor instead.
+func (*ListACLsResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ListACLsResponse) GetAcls() []*ACL {
+	if x != nil {
+		return x.Acls
+	}
+	return nil
+}
+
+type Schema struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Assigned by the registry.
+	Id   int64      `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type SchemaType `protobuf:"varint,2,opt,name=type,proto3,enum=iris.v1.SchemaType" json:"type,omitempty"`
+	// A JSON Schema, or a serialized google.protobuf.FileDescriptorSet.
+	Definition []byte `protobuf:"bytes,3,opt,name=definition,proto3" json:"definition,omitempty"`
+	// Full name of the protobuf message, the first message of the last file if empty.
+	MessageName   string `protobuf:"bytes,4,opt,name=message_name,json=messageName,proto3" json:"message_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schema) Reset() {
+	*x = Schema{}
+	mi := &file_iris_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schema) ProtoMessage() {}
+
+func (x *Schema) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schema.ProtoReflect.Descriptor instead.
+func (*Schema) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Schema) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Schema) GetType() SchemaType {
+	if x != nil {
+		return x.Type
+	}
+	return SchemaType_SCHEMA_TYPE_JSON
+}
+
+func (x *Schema) GetDefinition() []byte {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *Schema) GetMessageName() string {
+	if x != nil {
+		return x.MessageName
+	}
+	return ""
+}
+
+type RegisterSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Schema        *Schema                `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSchemaRequest) Reset() {
+	*x = RegisterSchemaRequest{}
+	mi := &file_iris_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSchemaRequest) ProtoMessage() {}
+
+func (x *RegisterSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSchemaRequest.ProtoReflect.Descriptor instead.
+func (*RegisterSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RegisterSchemaRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *RegisterSchemaRequest) GetSchema() *Schema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type RegisterSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSchemaResponse) Reset() {
+	*x = RegisterSchemaResponse{}
+	mi := &file_iris_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSchemaResponse) ProtoMessage() {}
+
+func (x *RegisterSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSchemaResponse.ProtoReflect.Descriptor instead.
+func (*RegisterSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RegisterSchemaResponse) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *RegisterSchemaResponse) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaRequest) Reset() {
+	*x = GetSchemaRequest{}
+	mi := &file_iris_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaRequest) ProtoMessage() {}
+
+func (x *GetSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaRequest.ProtoReflect.Descriptor instead.
+func (*GetSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetSchemaRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type GetSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        *Schema                `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaResponse) Reset() {
+	*x = GetSchemaResponse{}
+	mi := &file_iris_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaResponse) ProtoMessage() {}
+
+func (x *GetSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaResponse.ProtoReflect.Descriptor instead.
+func (*GetSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *GetSchemaResponse) GetSchema() *Schema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type GetSchemaVersionRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Subject string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	// The latest version if zero.
+	Version       int32 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaVersionRequest) Reset() {
+	*x = GetSchemaVersionRequest{}
+	mi := &file_iris_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaVersionRequest) ProtoMessage() {}
+
+func (x *GetSchemaVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetSchemaVersionRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *GetSchemaVersionRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *GetSchemaVersionRequest) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type GetSchemaVersionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Version       int32                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Schema        *Schema                `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaVersionResponse) Reset() {
+	*x = GetSchemaVersionResponse{}
+	mi := &file_iris_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaVersionResponse) ProtoMessage() {}
+
+func (x *GetSchemaVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetSchemaVersionResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *GetSchemaVersionResponse) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *GetSchemaVersionResponse) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *GetSchemaVersionResponse) GetSchema() *Schema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type ListSubjectsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubjectsRequest) Reset() {
+	*x = ListSubjectsRequest{}
+	mi := &file_iris_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubjectsRequest) ProtoMessage() {}
+
+func (x *ListSubjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubjectsRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{40}
+}
+
+type ListSubjectsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subjects      []string               `protobuf:"bytes,1,rep,name=subjects,proto3" json:"subjects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubjectsResponse) Reset() {
+	*x = ListSubjectsResponse{}
+	mi := &file_iris_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubjectsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubjectsResponse) ProtoMessage() {}
+
+func (x *ListSubjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubjectsResponse.ProtoReflect.Descriptor instead.
+func (*ListSubjectsResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListSubjectsResponse) GetSubjects() []string {
+	if x != nil {
+		return x.Subjects
+	}
+	return nil
+}
+
+type ListVersionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsRequest) Reset() {
+	*x = ListVersionsRequest{}
+	mi := &file_iris_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsRequest) ProtoMessage() {}
+
+func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ListVersionsRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type ListVersionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []int32                `protobuf:"varint,1,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	mi := &file_iris_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ListVersionsResponse) GetVersions() []int32 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type DeleteSubjectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubjectRequest) Reset() {
+	*x = DeleteSubjectRequest{}
+	mi := &file_iris_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubjectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubjectRequest) ProtoMessage() {}
+
+func (x *DeleteSubjectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubjectRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSubjectRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *DeleteSubjectRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type DeleteSubjectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Versions      []int32                `protobuf:"varint,1,rep,packed,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubjectResponse) Reset() {
+	*x = DeleteSubjectResponse{}
+	mi := &file_iris_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubjectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubjectResponse) ProtoMessage() {}
+
+func (x *DeleteSubjectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubjectResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSubjectResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *DeleteSubjectResponse) GetVersions() []int32 {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type CheckCompatibilityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Schema        *Schema                `protobuf:"bytes,2,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckCompatibilityRequest) Reset() {
+	*x = CheckCompatibilityRequest{}
+	mi := &file_iris_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckCompatibilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckCompatibilityRequest) ProtoMessage() {}
+
+func (x *CheckCompatibilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckCompatibilityRequest.ProtoReflect.Descriptor instead.
+func (*CheckCompatibilityRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *CheckCompatibilityRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *CheckCompatibilityRequest) GetSchema() *Schema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type CheckCompatibilityResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Compatible bool                   `protobuf:"varint,1,opt,name=compatible,proto3" json:"compatible,omitempty"`
+	// Why the schema is incompatible.
+	Reason        string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckCompatibilityResponse) Reset() {
+	*x = CheckCompatibilityResponse{}
+	mi := &file_iris_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckCompatibilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckCompatibilityResponse) ProtoMessage() {}
+
+func (x *CheckCompatibilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckCompatibilityResponse.ProtoReflect.Descriptor instead.
+func (*CheckCompatibilityResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *CheckCompatibilityResponse) GetCompatible() bool {
+	if x != nil {
+		return x.Compatible
+	}
+	return false
+}
+
+func (x *CheckCompatibilityResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type GetCompatibilityRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The default compatibility if empty.
+	Subject       string `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCompatibilityRequest) Reset() {
+	*x = GetCompatibilityRequest{}
+	mi := &file_iris_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCompatibilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCompatibilityRequest) ProtoMessage() {}
+
+func (x *GetCompatibilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCompatibilityRequest.ProtoReflect.Descriptor instead.
+func (*GetCompatibilityRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *GetCompatibilityRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+type GetCompatibilityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compatibility Compatibility          `protobuf:"varint,1,opt,name=compatibility,proto3,enum=iris.v1.Compatibility" json:"compatibility,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCompatibilityResponse) Reset() {
+	*x = GetCompatibilityResponse{}
+	mi := &file_iris_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCompatibilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCompatibilityResponse) ProtoMessage() {}
+
+func (x *GetCompatibilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCompatibilityResponse.ProtoReflect.Descriptor instead.
+func (*GetCompatibilityResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *GetCompatibilityResponse) GetCompatibility() Compatibility {
+	if x != nil {
+		return x.Compatibility
+	}
+	return Compatibility_COMPATIBILITY_UNSPECIFIED
+}
+
+type SetCompatibilityRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Sets the default compatibility if empty.
+	Subject       string        `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Compatibility Compatibility `protobuf:"varint,2,opt,name=compatibility,proto3,enum=iris.v1.Compatibility" json:"compatibility,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetCompatibilityRequest) Reset() {
+	*x = SetCompatibilityRequest{}
+	mi := &file_iris_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetCompatibilityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetCompatibilityRequest) ProtoMessage() {}
+
+func (x *SetCompatibilityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetCompatibilityRequest.ProtoReflect.Descriptor instead.
+func (*SetCompatibilityRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *SetCompatibilityRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *SetCompatibilityRequest) GetCompatibility() Compatibility {
+	if x != nil {
+		return x.Compatibility
+	}
+	return Compatibility_COMPATIBILITY_UNSPECIFIED
+}
+
+type SetCompatibilityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetCompatibilityResponse) Reset() {
+	*x = SetCompatibilityResponse{}
+	mi := &file_iris_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetCompatibilityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetCompatibilityResponse) ProtoMessage() {}
+
+func (x *SetCompatibilityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetCompatibilityResponse.ProtoReflect.Descriptor instead.
+func (*SetCompatibilityResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{51}
+}
+
+type DescribePartitionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribePartitionRequest) Reset() {
+	*x = DescribePartitionRequest{}
+	mi := &file_iris_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribePartitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribePartitionRequest) ProtoMessage() {}
+
+func (x *DescribePartitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribePartitionRequest.ProtoReflect.Descriptor instead.
+func (*DescribePartitionRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *DescribePartitionRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *DescribePartitionRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+type ReplicaState struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// The next offset of the replica.
+	Offset uint64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	InSync bool   `protobuf:"varint,3,opt,name=in_sync,json=inSync,proto3" json:"in_sync,omitempty"`
+	// Unix timestamp in milliseconds of the last fetch of a follower.
+	LastFetch     int64 `protobuf:"varint,4,opt,name=last_fetch,json=lastFetch,proto3" json:"last_fetch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaState) Reset() {
+	*x = ReplicaState{}
+	mi := &file_iris_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaState) ProtoMessage() {}
+
+func (x *ReplicaState) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaState.ProtoReflect.Descriptor instead.
+func (*ReplicaState) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *ReplicaState) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ReplicaState) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReplicaState) GetInSync() bool {
+	if x != nil {
+		return x.InSync
+	}
+	return false
+}
+
+func (x *ReplicaState) GetLastFetch() int64 {
+	if x != nil {
+		return x.LastFetch
+	}
+	return 0
+}
+
+type DescribePartitionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The leader is -1 for partitions without replicas.
+	Leader        int32           `protobuf:"varint,1,opt,name=leader,proto3" json:"leader,omitempty"`
+	Replicas      []*ReplicaState `protobuf:"bytes,2,rep,name=replicas,proto3" json:"replicas,omitempty"`
+	HighWatermark uint64          `protobuf:"varint,3,opt,name=high_watermark,json=highWatermark,proto3" json:"high_watermark,omitempty"`
+	StartOffset   uint64          `protobuf:"varint,4,opt,name=start_offset,json=startOffset,proto3" json:"start_offset,omitempty"`
+	EndOffset     uint64          `protobuf:"varint,5,opt,name=end_offset,json=endOffset,proto3" json:"end_offset,omitempty"`
+	LeaderEpoch   int32           `protobuf:"varint,6,opt,name=leader_epoch,json=leaderEpoch,proto3" json:"leader_epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribePartitionResponse) Reset() {
+	*x = DescribePartitionResponse{}
+	mi := &file_iris_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribePartitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribePartitionResponse) ProtoMessage() {}
+
+func (x *DescribePartitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribePartitionResponse.ProtoReflect.Descriptor instead.
+func (*DescribePartitionResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *DescribePartitionResponse) GetLeader() int32 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *DescribePartitionResponse) GetReplicas() []*ReplicaState {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+func (x *DescribePartitionResponse) GetHighWatermark() uint64 {
+	if x != nil {
+		return x.HighWatermark
+	}
+	return 0
+}
+
+func (x *DescribePartitionResponse) GetStartOffset() uint64 {
+	if x != nil {
+		return x.StartOffset
+	}
+	return 0
+}
+
+func (x *DescribePartitionResponse) GetEndOffset() uint64 {
+	if x != nil {
+		return x.EndOffset
+	}
+	return 0
+}
+
+func (x *DescribePartitionResponse) GetLeaderEpoch() int32 {
+	if x != nil {
+		return x.LeaderEpoch
+	}
+	return 0
+}
+
+type ReassignPartitionRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Topic     string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	// The new replicas, the first one leads the partition if the leader is not among them.
+	Replicas      []int32 `protobuf:"varint,3,rep,packed,name=replicas,proto3" json:"replicas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReassignPartitionRequest) Reset() {
+	*x = ReassignPartitionRequest{}
+	mi := &file_iris_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReassignPartitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReassignPartitionRequest) ProtoMessage() {}
+
+func (x *ReassignPartitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReassignPartitionRequest.ProtoReflect.Descriptor instead.
+func (*ReassignPartitionRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *ReassignPartitionRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReassignPartitionRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *ReassignPartitionRequest) GetReplicas() []int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+type ReassignPartitionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReassignPartitionResponse) Reset() {
+	*x = ReassignPartitionResponse{}
+	mi := &file_iris_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReassignPartitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReassignPartitionResponse) ProtoMessage() {}
+
+func (x *ReassignPartitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReassignPartitionResponse.ProtoReflect.Descriptor instead.
+func (*ReassignPartitionResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{56}
+}
+
+type DeleteRecordsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Topic     string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	// The messages before this offset are deleted.
+	Offset        uint64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecordsRequest) Reset() {
+	*x = DeleteRecordsRequest{}
+	mi := &file_iris_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecordsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecordsRequest) ProtoMessage() {}
+
+func (x *DeleteRecordsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecordsRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRecordsRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *DeleteRecordsRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *DeleteRecordsRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *DeleteRecordsRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type DeleteRecordsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The first offset which can still be fetched.
+	StartOffset   uint64 `protobuf:"varint,1,opt,name=start_offset,json=startOffset,proto3" json:"start_offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecordsResponse) Reset() {
+	*x = DeleteRecordsResponse{}
+	mi := &file_iris_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecordsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecordsResponse) ProtoMessage() {}
+
+func (x *DeleteRecordsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecordsResponse.ProtoReflect.Descriptor instead.
+func (*DeleteRecordsResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *DeleteRecordsResponse) GetStartOffset() uint64 {
+	if x != nil {
+		return x.StartOffset
+	}
+	return 0
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_iris_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{59}
+}
+
+type ReloadConfigResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The yaml paths of the changed settings the broker applied.
+	Applied []string `protobuf:"bytes,1,rep,name=applied,proto3" json:"applied,omitempty"`
+	// The yaml paths of the settings which differ from the ones the broker runs with
+	// and only apply after a restart.
+	RestartRequired []string `protobuf:"bytes,2,rep,name=restart_required,json=restartRequired,proto3" json:"restart_required,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_iris_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigResponse) ProtoMessage() {}
+
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *ReloadConfigResponse) GetApplied() []string {
+	if x != nil {
+		return x.Applied
+	}
+	return nil
+}
+
+func (x *ReloadConfigResponse) GetRestartRequired() []string {
+	if x != nil {
+		return x.RestartRequired
+	}
+	return nil
+}
+
+type ReplicaFetchRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ReplicaId int32                  `protobuf:"varint,1,opt,name=replica_id,json=replicaId,proto3" json:"replica_id,omitempty"`
+	Topic     string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition int32                  `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	Offset    uint64                 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	MaxBytes  int32                  `protobuf:"varint,5,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	// How long the leader waits in milliseconds for new messages if the follower has caught up.
+	MaxWait int64 `protobuf:"varint,6,opt,name=max_wait,json=maxWait,proto3" json:"max_wait,omitempty"`
+	// The leader epoch of the last message of the follower, -1 if it has none.
+	LastEpoch     int32 `protobuf:"varint,7,opt,name=last_epoch,json=lastEpoch,proto3" json:"last_epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaFetchRequest) Reset() {
+	*x = ReplicaFetchRequest{}
+	mi := &file_iris_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaFetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaFetchRequest) ProtoMessage() {}
+
+func (x *ReplicaFetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaFetchRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaFetchRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *ReplicaFetchRequest) GetReplicaId() int32 {
+	if x != nil {
+		return x.ReplicaId
+	}
+	return 0
+}
+
+func (x *ReplicaFetchRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReplicaFetchRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *ReplicaFetchRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReplicaFetchRequest) GetMaxBytes() int32 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+func (x *ReplicaFetchRequest) GetMaxWait() int64 {
+	if x != nil {
+		return x.MaxWait
+	}
+	return 0
+}
+
+func (x *ReplicaFetchRequest) GetLastEpoch() int32 {
+	if x != nil {
+		return x.LastEpoch
+	}
+	return 0
+}
+
+type LeaderEpoch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Epoch         int32                  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	StartOffset   uint64                 `protobuf:"varint,2,opt,name=start_offset,json=startOffset,proto3" json:"start_offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaderEpoch) Reset() {
+	*x = LeaderEpoch{}
+	mi := &file_iris_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaderEpoch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaderEpoch) ProtoMessage() {}
+
+func (x *LeaderEpoch) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaderEpoch.ProtoReflect.Descriptor instead.
+func (*LeaderEpoch) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *LeaderEpoch) GetEpoch() int32 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *LeaderEpoch) GetStartOffset() uint64 {
+	if x != nil {
+		return x.StartOffset
+	}
+	return 0
+}
+
+type ReplicaFetchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The messages encoded like the records of the leader's journal.
+	Records       [][]byte `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	HighWatermark uint64   `protobuf:"varint,2,opt,name=high_watermark,json=highWatermark,proto3" json:"high_watermark,omitempty"`
+	// The leader epochs of the messages.
+	Epochs []*LeaderEpoch `protobuf:"bytes,3,rep,name=epochs,proto3" json:"epochs,omitempty"`
+	// Set without records if the follower must truncate its journal to the end offset of the epoch.
+	Diverging *LeaderEpochEnd `protobuf:"bytes,4,opt,name=diverging,proto3,oneof" json:"diverging,omitempty"`
+	// The offset before which the messages were deleted on the leader.
+	LogStartOffset uint64 `protobuf:"varint,5,opt,name=log_start_offset,json=logStartOffset,proto3" json:"log_start_offset,omitempty"`
+	// The in-sync replicas, followers check changes of the leader against them.
+	Isr           []int32 `protobuf:"varint,6,rep,packed,name=isr,proto3" json:"isr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaFetchResponse) Reset() {
+	*x = ReplicaFetchResponse{}
+	mi := &file_iris_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaFetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaFetchResponse) ProtoMessage() {}
+
+func (x *ReplicaFetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaFetchResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaFetchResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *ReplicaFetchResponse) GetRecords() [][]byte {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+func (x *ReplicaFetchResponse) GetHighWatermark() uint64 {
+	if x != nil {
+		return x.HighWatermark
+	}
+	return 0
+}
+
+func (x *ReplicaFetchResponse) GetEpochs() []*LeaderEpoch {
+	if x != nil {
+		return x.Epochs
+	}
+	return nil
+}
+
+func (x *ReplicaFetchResponse) GetDiverging() *LeaderEpochEnd {
+	if x != nil {
+		return x.Diverging
+	}
+	return nil
+}
+
+func (x *ReplicaFetchResponse) GetLogStartOffset() uint64 {
+	if x != nil {
+		return x.LogStartOffset
+	}
+	return 0
+}
+
+func (x *ReplicaFetchResponse) GetIsr() []int32 {
+	if x != nil {
+		return x.Isr
+	}
+	return nil
+}
+
+type LeaderEpochEnd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Epoch         int32                  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	EndOffset     uint64                 `protobuf:"varint,2,opt,name=end_offset,json=endOffset,proto3" json:"end_offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaderEpochEnd) Reset() {
+	*x = LeaderEpochEnd{}
+	mi := &file_iris_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaderEpochEnd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaderEpochEnd) ProtoMessage() {}
+
+func (x *LeaderEpochEnd) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaderEpochEnd.ProtoReflect.Descriptor instead.
+func (*LeaderEpochEnd) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *LeaderEpochEnd) GetEpoch() int32 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *LeaderEpochEnd) GetEndOffset() uint64 {
+	if x != nil {
+		return x.EndOffset
+	}
+	return 0
+}
+
+type SegmentFilesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentFilesRequest) Reset() {
+	*x = SegmentFilesRequest{}
+	mi := &file_iris_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentFilesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentFilesRequest) ProtoMessage() {}
+
+func (x *SegmentFilesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentFilesRequest.ProtoReflect.Descriptor instead.
+func (*SegmentFilesRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *SegmentFilesRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SegmentFilesRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+type SegmentFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Size          int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentFile) Reset() {
+	*x = SegmentFile{}
+	mi := &file_iris_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentFile) ProtoMessage() {}
+
+func (x *SegmentFile) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentFile.ProtoReflect.Descriptor instead.
+func (*SegmentFile) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *SegmentFile) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SegmentFile) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+type SegmentFilesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Files         []*SegmentFile         `protobuf:"bytes,1,rep,name=files,proto3" json:"files,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentFilesResponse) Reset() {
+	*x = SegmentFilesResponse{}
+	mi := &file_iris_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentFilesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentFilesResponse) ProtoMessage() {}
+
+func (x *SegmentFilesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentFilesResponse.ProtoReflect.Descriptor instead.
+func (*SegmentFilesResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *SegmentFilesResponse) GetFiles() []*SegmentFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+type ReadSegmentFileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Position      int64                  `protobuf:"varint,4,opt,name=position,proto3" json:"position,omitempty"`
+	MaxBytes      int32                  `protobuf:"varint,5,opt,name=max_bytes,json=maxBytes,proto3" json:"max_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadSegmentFileRequest) Reset() {
+	*x = ReadSegmentFileRequest{}
+	mi := &file_iris_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadSegmentFileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadSegmentFileRequest) ProtoMessage() {}
+
+func (x *ReadSegmentFileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadSegmentFileRequest.ProtoReflect.Descriptor instead.
+func (*ReadSegmentFileRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *ReadSegmentFileRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *ReadSegmentFileRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *ReadSegmentFileRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReadSegmentFileRequest) GetPosition() int64 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *ReadSegmentFileRequest) GetMaxBytes() int32 {
+	if x != nil {
+		return x.MaxBytes
+	}
+	return 0
+}
+
+type ReadSegmentFileResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Less than max_bytes at the end of the file.
+	Data          []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadSegmentFileResponse) Reset() {
+	*x = ReadSegmentFileResponse{}
+	mi := &file_iris_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadSegmentFileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadSegmentFileResponse) ProtoMessage() {}
+
+func (x *ReadSegmentFileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadSegmentFileResponse.ProtoReflect.Descriptor instead.
+func (*ReadSegmentFileResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *ReadSegmentFileResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SetReplicasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Topic         string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition     int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Replicas      []int32                `protobuf:"varint,3,rep,packed,name=replicas,proto3" json:"replicas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReplicasRequest) Reset() {
+	*x = SetReplicasRequest{}
+	mi := &file_iris_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReplicasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReplicasRequest) ProtoMessage() {}
+
+func (x *SetReplicasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReplicasRequest.ProtoReflect.Descriptor instead.
+func (*SetReplicasRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *SetReplicasRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SetReplicasRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *SetReplicasRequest) GetReplicas() []int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+type SetReplicasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetReplicasResponse) Reset() {
+	*x = SetReplicasResponse{}
+	mi := &file_iris_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetReplicasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetReplicasResponse) ProtoMessage() {}
+
+func (x *SetReplicasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetReplicasResponse.ProtoReflect.Descriptor instead.
+func (*SetReplicasResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{71}
+}
+
+type SetLeaderRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Topic     string                 `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Partition int32                  `protobuf:"varint,2,opt,name=partition,proto3" json:"partition,omitempty"`
+	Leader    int32                  `protobuf:"varint,3,opt,name=leader,proto3" json:"leader,omitempty"`
+	// The epoch of the new leader, older epochs are rejected.
+	Epoch         int32 `protobuf:"varint,4,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLeaderRequest) Reset() {
+	*x = SetLeaderRequest{}
+	mi := &file_iris_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLeaderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLeaderRequest) ProtoMessage() {}
+
+func (x *SetLeaderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLeaderRequest.ProtoReflect.Descriptor instead.
+func (*SetLeaderRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *SetLeaderRequest) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *SetLeaderRequest) GetPartition() int32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *SetLeaderRequest) GetLeader() int32 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *SetLeaderRequest) GetEpoch() int32 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+type SetLeaderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLeaderResponse) Reset() {
+	*x = SetLeaderResponse{}
+	mi := &file_iris_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLeaderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLeaderResponse) ProtoMessage() {}
+
+func (x *SetLeaderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLeaderResponse.ProtoReflect.Descriptor instead.
+func (*SetLeaderResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{73}
+}
+
+type RequestVoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Candidate     int32                  `protobuf:"varint,2,opt,name=candidate,proto3" json:"candidate,omitempty"`
+	LastIndex     uint64                 `protobuf:"varint,3,opt,name=last_index,json=lastIndex,proto3" json:"last_index,omitempty"`
+	LastTerm      uint64                 `protobuf:"varint,4,opt,name=last_term,json=lastTerm,proto3" json:"last_term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestVoteRequest) Reset() {
+	*x = RequestVoteRequest{}
+	mi := &file_iris_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestVoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteRequest) ProtoMessage() {}
+
+func (x *RequestVoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteRequest.ProtoReflect.Descriptor instead.
+func (*RequestVoteRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *RequestVoteRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteRequest) GetCandidate() int32 {
+	if x != nil {
+		return x.Candidate
+	}
+	return 0
+}
+
+func (x *RequestVoteRequest) GetLastIndex() uint64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+func (x *RequestVoteRequest) GetLastTerm() uint64 {
+	if x != nil {
+		return x.LastTerm
+	}
+	return 0
+}
+
+type RequestVoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Granted       bool                   `protobuf:"varint,2,opt,name=granted,proto3" json:"granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestVoteResponse) Reset() {
+	*x = RequestVoteResponse{}
+	mi := &file_iris_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestVoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteResponse) ProtoMessage() {}
+
+func (x *RequestVoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteResponse.ProtoReflect.Descriptor instead.
+func (*RequestVoteResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *RequestVoteResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteResponse) GetGranted() bool {
+	if x != nil {
+		return x.Granted
+	}
+	return false
+}
+
+type RaftEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint64                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RaftEntry) Reset() {
+	*x = RaftEntry{}
+	mi := &file_iris_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RaftEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RaftEntry) ProtoMessage() {}
+
+func (x *RaftEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RaftEntry.ProtoReflect.Descriptor instead.
+func (*RaftEntry) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *RaftEntry) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RaftEntry) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RaftEntry) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type AppendEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Leader        int32                  `protobuf:"varint,2,opt,name=leader,proto3" json:"leader,omitempty"`
+	PrevIndex     uint64                 `protobuf:"varint,3,opt,name=prev_index,json=prevIndex,proto3" json:"prev_index,omitempty"`
+	PrevTerm      uint64                 `protobuf:"varint,4,opt,name=prev_term,json=prevTerm,proto3" json:"prev_term,omitempty"`
+	Entries       []*RaftEntry           `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	Commit        uint64                 `protobuf:"varint,6,opt,name=commit,proto3" json:"commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesRequest) Reset() {
+	*x = AppendEntriesRequest{}
+	mi := &file_iris_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesRequest) ProtoMessage() {}
+
+func (x *AppendEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesRequest.ProtoReflect.Descriptor instead.
+func (*AppendEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *AppendEntriesRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetLeader() int32 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetPrevIndex() uint64 {
+	if x != nil {
+		return x.PrevIndex
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetPrevTerm() uint64 {
+	if x != nil {
+		return x.PrevTerm
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetEntries() []*RaftEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendEntriesRequest) GetCommit() uint64 {
+	if x != nil {
+		return x.Commit
+	}
+	return 0
+}
+
+type AppendEntriesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Term    uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	// The last index of the follower's log, the leader continues from there on a mismatch.
+	LastIndex     uint64 `protobuf:"varint,3,opt,name=last_index,json=lastIndex,proto3" json:"last_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesResponse) Reset() {
+	*x = AppendEntriesResponse{}
+	mi := &file_iris_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesResponse) ProtoMessage() {}
+
+func (x *AppendEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesResponse.ProtoReflect.Descriptor instead.
+func (*AppendEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *AppendEntriesResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendEntriesResponse) GetLastIndex() uint64 {
+	if x != nil {
+		return x.LastIndex
+	}
+	return 0
+}
+
+type InstallSnapshotRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Term   uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Leader int32                  `protobuf:"varint,2,opt,name=leader,proto3" json:"leader,omitempty"`
+	// The index and term of the last entry in the snapshot.
+	Index         uint64 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	LastTerm      uint64 `protobuf:"varint,4,opt,name=last_term,json=lastTerm,proto3" json:"last_term,omitempty"`
+	Data          []byte `protobuf:"bytes,5,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstallSnapshotRequest) Reset() {
+	*x = InstallSnapshotRequest{}
+	mi := &file_iris_proto_msgTypes[79]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallSnapshotRequest) ProtoMessage() {}
+
+func (x *InstallSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[79]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*InstallSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{79}
+}
+
+func (x *InstallSnapshotRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *InstallSnapshotRequest) GetLeader() int32 {
+	if x != nil {
+		return x.Leader
+	}
+	return 0
+}
+
+func (x *InstallSnapshotRequest) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *InstallSnapshotRequest) GetLastTerm() uint64 {
+	if x != nil {
+		return x.LastTerm
+	}
+	return 0
+}
+
+func (x *InstallSnapshotRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type InstallSnapshotResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstallSnapshotResponse) Reset() {
+	*x = InstallSnapshotResponse{}
+	mi := &file_iris_proto_msgTypes[80]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstallSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstallSnapshotResponse) ProtoMessage() {}
+
+func (x *InstallSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_iris_proto_msgTypes[80]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstallSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*InstallSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_iris_proto_rawDescGZIP(), []int{80}
+}
+
+func (x *InstallSnapshotResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+var File_iris_proto protoreflect.FileDescriptor
+
+const file_iris_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"iris.proto\x12\airis.v1\"0\n" +
+	"\x06Header\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"\xc3\x01\n" +
+	"\aMessage\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\fR\x05value\x12)\n" +
+	"\aheaders\x18\x05 \x03(\v2\x0f.iris.v1.HeaderR\aheaders\x12\x10\n" +
+	"\x03ttl\x18\x06 \x01(\x03R\x03ttl\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\"\xe8\x02\n" +
+	"\x12CreateTopicRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1e\n" +
+	"\n" +
+	"partitions\x18\x02 \x01(\x05R\n" +
+	"partitions\x12&\n" +
+	"\x04mode\x18\x03 \x01(\x0e2\x12.iris.v1.TopicModeR\x04mode\x12-\n" +
+	"\x12visibility_timeout\x18\x04 \x01(\x03R\x11visibilityTimeout\x12%\n" +
+	"\x0emax_deliveries\x18\x05 \x01(\x05R\rmaxDeliveries\x126\n" +
+	"\breplicas\x18\x06 \x03(\v2\x1a.iris.v1.PartitionReplicasR\breplicas\x12.\n" +
+	"\x13min_insync_replicas\x18\a \x01(\x05R\x11minInsyncReplicas\x126\n" +
+	"\x17unclean_leader_election\x18\b \x01(\bR\x15uncleanLeaderElection\"/\n" +
+	"\x11PartitionReplicas\x12\x1a\n" +
+	"\breplicas\x18\x01 \x03(\x05R\breplicas\"\x15\n" +
+	"\x13CreateTopicResponse\"\xa2\x02\n" +
+	"\x0eProduceRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12!\n" +
+	"\tpartition\x18\x02 \x01(\x05H\x00R\tpartition\x88\x01\x01\x12,\n" +
+	"\bmessages\x18\x03 \x03(\v2\x10.iris.v1.MessageR\bmessages\x12\x1d\n" +
+	"\n" +
+	"deliver_at\x18\x04 \x01(\x03R\tdeliverAt\x12\x14\n" +
+	"\x05delay\x18\x05 \x01(\x03R\x05delay\x12C\n" +
+	"\x10cloudevents_mode\x18\x06 \x01(\x0e2\x18.iris.v1.CloudEventsModeR\x0fcloudeventsMode\x12!\n" +
+	"\x04acks\x18\a \x01(\x0e2\r.iris.v1.AcksR\x04acksB\f\n" +
+	"\n" +
+	"_partition\"\x94\x01\n" +
+	"\x0fProduceResponse\x12\x1c\n" +
+	"\tpartition\x18\x01 \x01(\x05R\tpartition\x12\x1f\n" +
+	"\vbase_offset\x18\x02 \x01(\x04R\n" +
+	"baseOffset\x12\x18\n" +
+	"\adelayed\x18\x03 \x01(\bR\adelayed\x12(\n" +
+	"\x10throttle_time_ms\x18\x04 \x01(\x05R\x0ethrottleTimeMs\"\xda\x01\n" +
+	"\fFetchRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12!\n" +
+	"\fmax_messages\x18\x04 \x01(\x05R\vmaxMessages\x12\x16\n" +
+	"\x06filter\x18\x05 \x01(\tR\x06filter\x12C\n" +
+	"\x10cloudevents_mode\x18\x06 \x01(\x0e2\x18.iris.v1.CloudEventsModeR\x0fcloudeventsMode\"\xaf\x01\n" +
+	"\rFetchResponse\x12,\n" +
+	"\bmessages\x18\x01 \x03(\v2\x10.iris.v1.MessageR\bmessages\x12%\n" +
+	"\x0ehigh_watermark\x18\x02 \x01(\x04R\rhighWatermark\x12\x1f\n" +
+	"\vnext_offset\x18\x03 \x01(\x04R\n" +
+	"nextOffset\x12(\n" +
+	"\x10throttle_time_ms\x18\x04 \x01(\x05R\x0ethrottleTimeMs\"\xde\x01\n" +
+	"\x10SubscribeRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12!\n" +
+	"\fmax_messages\x18\x04 \x01(\x05R\vmaxMessages\x12\x16\n" +
+	"\x06filter\x18\x05 \x01(\tR\x06filter\x12C\n" +
+	"\x10cloudevents_mode\x18\x06 \x01(\x0e2\x18.iris.v1.CloudEventsModeR\x0fcloudeventsMode\"\xb3\x01\n" +
+	"\x11SubscribeResponse\x12,\n" +
+	"\bmessages\x18\x01 \x03(\v2\x10.iris.v1.MessageR\bmessages\x12%\n" +
+	"\x0ehigh_watermark\x18\x02 \x01(\x04R\rhighWatermark\x12\x1f\n" +
+	"\vnext_offset\x18\x03 \x01(\x04R\n" +
+	"nextOffset\x12(\n" +
+	"\x10throttle_time_ms\x18\x04 \x01(\x05R\x0ethrottleTimeMs\"q\n" +
+	"\rCommitRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x03 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\"\x10\n" +
+	"\x0eCommitResponse\"\\\n" +
+	"\x10CommittedRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x03 \x01(\x05R\tpartition\"A\n" +
+	"\x11CommittedResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\"\x87\x01\n" +
+	"\vNackRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x03 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"O\n" +
+	"\fNackResponse\x12\x1a\n" +
+	"\battempts\x18\x01 \x01(\x05R\battempts\x12#\n" +
+	"\rdead_lettered\x18\x02 \x01(\bR\fdeadLettered\"U\n" +
+	"\x1aSetDeadLetterPolicyRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12!\n" +
+	"\fmax_attempts\x18\x02 \x01(\x05R\vmaxAttempts\"\x1d\n" +
+	"\x1bSetDeadLetterPolicyResponse\"x\n" +
+	"\x0eReceiveRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12!\n" +
+	"\fmax_messages\x18\x02 \x01(\x05R\vmaxMessages\x12-\n" +
+	"\x12visibility_timeout\x18\x03 \x01(\x03R\x11visibilityTimeout\"\x95\x01\n" +
+	"\rLeasedMessage\x12\x1c\n" +
+	"\tpartition\x18\x01 \x01(\x05R\tpartition\x12*\n" +
+	"\amessage\x18\x02 \x01(\v2\x10.iris.v1.MessageR\amessage\x12\x1e\n" +
+	"\n" +
+	"deliveries\x18\x03 \x01(\x05R\n" +
+	"deliveries\x12\x1a\n" +
+	"\bdeadline\x18\x04 \x01(\x03R\bdeadline\"o\n" +
+	"\x0fReceiveResponse\x122\n" +
+	"\bmessages\x18\x01 \x03(\v2\x16.iris.v1.LeasedMessageR\bmessages\x12(\n" +
+	"\x10throttle_time_ms\x18\x02 \x01(\x05R\x0ethrottleTimeMs\"X\n" +
+	"\n" +
+	"AckRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\"\r\n" +
+	"\vAckResponse\"t\n" +
+	"\x0eReleaseRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"V\n" +
+	"\x0fReleaseResponse\x12\x1e\n" +
+	"\n" +
+	"deliveries\x18\x01 \x01(\x05R\n" +
+	"deliveries\x12#\n" +
+	"\rdead_lettered\x18\x02 \x01(\bR\fdeadLettered\"\xde\x01\n" +
+	"\x03ACL\x12\x1c\n" +
+	"\tprincipal\x18\x01 \x01(\tR\tprincipal\x12:\n" +
+	"\rresource_type\x18\x02 \x01(\x0e2\x15.iris.v1.ResourceTypeR\fresourceType\x127\n" +
+	"\fpattern_type\x18\x03 \x01(\x0e2\x14.iris.v1.PatternTypeR\vpatternType\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x120\n" +
+	"\toperation\x18\x05 \x01(\x0e2\x12.iris.v1.OperationR\toperation\"2\n" +
+	"\x10CreateACLRequest\x12\x1e\n" +
+	"\x03acl\x18\x01 \x01(\v2\f.iris.v1.ACLR\x03acl\"\x13\n" +
+	"\x11CreateACLResponse\"2\n" +
+	"\x10DeleteACLRequest\x12\x1e\n" +
+	"\x03acl\x18\x01 \x01(\v2\f.iris.v1.ACLR\x03acl\")\n" +
+	"\x11DeleteACLResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\"\x11\n" +
+	"\x0fListACLsRequest\"4\n" +
+	"\x10ListACLsResponse\x12 \n" +
+	"\x04acls\x18\x01 \x03(\v2\f.iris.v1.ACLR\x04acls\"\x84\x01\n" +
+	"\x06Schema\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.iris.v1.SchemaTypeR\x04type\x12\x1e\n" +
+	"\n" +
+	"definition\x18\x03 \x01(\fR\n" +
+	"definition\x12!\n" +
+	"\fmessage_name\x18\x04 \x01(\tR\vmessageName\"Z\n" +
+	"\x15RegisterSchemaRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12'\n" +
+	"\x06schema\x18\x02 \x01(\v2\x0f.iris.v1.SchemaR\x06schema\"B\n" +
+	"\x16RegisterSchemaResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\"\"\n" +
+	"\x10GetSchemaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"<\n" +
+	"\x11GetSchemaResponse\x12'\n" +
+	"\x06schema\x18\x01 \x01(\v2\x0f.iris.v1.SchemaR\x06schema\"M\n" +
+	"\x17GetSchemaVersionRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\"w\n" +
+	"\x18GetSchemaVersionResponse\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12'\n" +
+	"\x06schema\x18\x03 \x01(\v2\x0f.iris.v1.SchemaR\x06schema\"\x15\n" +
+	"\x13ListSubjectsRequest\"2\n" +
+	"\x14ListSubjectsResponse\x12\x1a\n" +
+	"\bsubjects\x18\x01 \x03(\tR\bsubjects\"/\n" +
+	"\x13ListVersionsRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\"2\n" +
+	"\x14ListVersionsResponse\x12\x1a\n" +
+	"\bversions\x18\x01 \x03(\x05R\bversions\"0\n" +
+	"\x14DeleteSubjectRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\"3\n" +
+	"\x15DeleteSubjectResponse\x12\x1a\n" +
+	"\bversions\x18\x01 \x03(\x05R\bversions\"^\n" +
+	"\x19CheckCompatibilityRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12'\n" +
+	"\x06schema\x18\x02 \x01(\v2\x0f.iris.v1.SchemaR\x06schema\"T\n" +
+	"\x1aCheckCompatibilityResponse\x12\x1e\n" +
+	"\n" +
+	"compatible\x18\x01 \x01(\bR\n" +
+	"compatible\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"3\n" +
+	"\x17GetCompatibilityRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\"X\n" +
+	"\x18GetCompatibilityResponse\x12<\n" +
+	"\rcompatibility\x18\x01 \x01(\x0e2\x16.iris.v1.CompatibilityR\rcompatibility\"q\n" +
+	"\x17SetCompatibilityRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12<\n" +
+	"\rcompatibility\x18\x02 \x01(\x0e2\x16.iris.v1.CompatibilityR\rcompatibility\"\x1a\n" +
+	"\x18SetCompatibilityResponse\"N\n" +
+	"\x18DescribePartitionRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\"n\n" +
+	"\fReplicaState\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x17\n" +
+	"\ain_sync\x18\x03 \x01(\bR\x06inSync\x12\x1d\n" +
+	"\n" +
+	"last_fetch\x18\x04 \x01(\x03R\tlastFetch\"\xf2\x01\n" +
+	"\x19DescribePartitionResponse\x12\x16\n" +
+	"\x06leader\x18\x01 \x01(\x05R\x06leader\x121\n" +
+	"\breplicas\x18\x02 \x03(\v2\x15.iris.v1.ReplicaStateR\breplicas\x12%\n" +
+	"\x0ehigh_watermark\x18\x03 \x01(\x04R\rhighWatermark\x12!\n" +
+	"\fstart_offset\x18\x04 \x01(\x04R\vstartOffset\x12\x1d\n" +
+	"\n" +
+	"end_offset\x18\x05 \x01(\x04R\tendOffset\x12!\n" +
+	"\fleader_epoch\x18\x06 \x01(\x05R\vleaderEpoch\"j\n" +
+	"\x18ReassignPartitionRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x1a\n" +
+	"\breplicas\x18\x03 \x03(\x05R\breplicas\"\x1b\n" +
+	"\x19ReassignPartitionResponse\"b\n" +
+	"\x14DeleteRecordsRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\":\n" +
+	"\x15DeleteRecordsResponse\x12!\n" +
+	"\fstart_offset\x18\x01 \x01(\x04R\vstartOffset\"\x15\n" +
+	"\x13ReloadConfigRequest\"[\n" +
+	"\x14ReloadConfigResponse\x12\x18\n" +
+	"\aapplied\x18\x01 \x03(\tR\aapplied\x12)\n" +
+	"\x10restart_required\x18\x02 \x03(\tR\x0frestartRequired\"\xd7\x01\n" +
+	"\x13ReplicaFetchRequest\x12\x1d\n" +
+	"\n" +
+	"replica_id\x18\x01 \x01(\x05R\treplicaId\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x03 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x04R\x06offset\x12\x1b\n" +
+	"\tmax_bytes\x18\x05 \x01(\x05R\bmaxBytes\x12\x19\n" +
+	"\bmax_wait\x18\x06 \x01(\x03R\amaxWait\x12\x1d\n" +
+	"\n" +
+	"last_epoch\x18\a \x01(\x05R\tlastEpoch\"F\n" +
+	"\vLeaderEpoch\x12\x14\n" +
+	"\x05epoch\x18\x01 \x01(\x05R\x05epoch\x12!\n" +
+	"\fstart_offset\x18\x02 \x01(\x04R\vstartOffset\"\x8b\x02\n" +
+	"\x14ReplicaFetchResponse\x12\x18\n" +
+	"\arecords\x18\x01 \x03(\fR\arecords\x12%\n" +
+	"\x0ehigh_watermark\x18\x02 \x01(\x04R\rhighWatermark\x12,\n" +
+	"\x06epochs\x18\x03 \x03(\v2\x14.iris.v1.LeaderEpochR\x06epochs\x12:\n" +
+	"\tdiverging\x18\x04 \x01(\v2\x17.iris.v1.LeaderEpochEndH\x00R\tdiverging\x88\x01\x01\x12(\n" +
+	"\x10log_start_offset\x18\x05 \x01(\x04R\x0elogStartOffset\x12\x10\n" +
+	"\x03isr\x18\x06 \x03(\x05R\x03isrB\f\n" +
+	"\n" +
+	"_diverging\"E\n" +
+	"\x0eLeaderEpochEnd\x12\x14\n" +
+	"\x05epoch\x18\x01 \x01(\x05R\x05epoch\x12\x1d\n" +
+	"\n" +
+	"end_offset\x18\x02 \x01(\x04R\tendOffset\"I\n" +
+	"\x13SegmentFilesRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\"5\n" +
+	"\vSegmentFile\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\"B\n" +
+	"\x14SegmentFilesResponse\x12*\n" +
+	"\x05files\x18\x01 \x03(\v2\x14.iris.v1.SegmentFileR\x05files\"\x99\x01\n" +
+	"\x16ReadSegmentFileRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1a\n" +
+	"\bposition\x18\x04 \x01(\x03R\bposition\x12\x1b\n" +
+	"\tmax_bytes\x18\x05 \x01(\x05R\bmaxBytes\"-\n" +
+	"\x17ReadSegmentFileResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"d\n" +
+	"\x12SetReplicasRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x1a\n" +
+	"\breplicas\x18\x03 \x03(\x05R\breplicas\"\x15\n" +
+	"\x13SetReplicasResponse\"t\n" +
+	"\x10SetLeaderRequest\x12\x14\n" +
+	"\x05topic\x18\x01 \x01(\tR\x05topic\x12\x1c\n" +
+	"\tpartition\x18\x02 \x01(\x05R\tpartition\x12\x16\n" +
+	"\x06leader\x18\x03 \x01(\x05R\x06leader\x12\x14\n" +
+	"\x05epoch\x18\x04 \x01(\x05R\x05epoch\"\x13\n" +
+	"\x11SetLeaderResponse\"\x82\x01\n" +
+	"\x12RequestVoteRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x1c\n" +
+	"\tcandidate\x18\x02 \x01(\x05R\tcandidate\x12\x1d\n" +
+	"\n" +
+	"last_index\x18\x03 \x01(\x04R\tlastIndex\x12\x1b\n" +
+	"\tlast_term\x18\x04 \x01(\x04R\blastTerm\"C\n" +
+	"\x13RequestVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\agranted\x18\x02 \x01(\bR\agranted\"I\n" +
+	"\tRaftEntry\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"\xc4\x01\n" +
+	"\x14AppendEntriesRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x16\n" +
+	"\x06leader\x18\x02 \x01(\x05R\x06leader\x12\x1d\n" +
+	"\n" +
+	"prev_index\x18\x03 \x01(\x04R\tprevIndex\x12\x1b\n" +
+	"\tprev_term\x18\x04 \x01(\x04R\bprevTerm\x12,\n" +
+	"\aentries\x18\x05 \x03(\v2\x12.iris.v1.RaftEntryR\aentries\x12\x16\n" +
+	"\x06commit\x18\x06 \x01(\x04R\x06commit\"d\n" +
+	"\x15AppendEntriesResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x1d\n" +
+	"\n" +
+	"last_index\x18\x03 \x01(\x04R\tlastIndex\"\x8b\x01\n" +
+	"\x16InstallSnapshotRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x16\n" +
+	"\x06leader\x18\x02 \x01(\x05R\x06leader\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\x04R\x05index\x12\x1b\n" +
+	"\tlast_term\x18\x04 \x01(\x04R\blastTerm\x12\x12\n" +
+	"\x04data\x18\x05 \x01(\fR\x04data\"-\n" +
+	"\x17InstallSnapshotResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term*8\n" +
+	"\tTopicMode\x12\x15\n" +
+	"\x11TOPIC_MODE_STREAM\x10\x00\x12\x14\n" +
+	"\x10TOPIC_MODE_QUEUE\x10\x01*m\n" +
+	"\x0fCloudEventsMode\x12\x1a\n" +
+	"\x16CLOUD_EVENTS_MODE_NONE\x10\x00\x12\x1c\n" +
+	"\x18CLOUD_EVENTS_MODE_BINARY\x10\x01\x12 \n" +
+	"\x1cCLOUD_EVENTS_MODE_STRUCTURED\x10\x02*4\n" +
+	"\x04Acks\x12\x0f\n" +
+	"\vACKS_LEADER\x10\x00\x12\r\n" +
+	"\tACKS_NONE\x10\x01\x12\f\n" +
+	"\bACKS_ALL\x10\x02*z\n" +
+	"\fResourceType\x12\x1d\n" +
+	"\x19RESOURCE_TYPE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13RESOURCE_TYPE_TOPIC\x10\x01\x12\x17\n" +
+	"\x13RESOURCE_TYPE_GROUP\x10\x02\x12\x19\n" +
+	"\x15RESOURCE_TYPE_CLUSTER\x10\x03*@\n" +
+	"\vPatternType\x12\x18\n" +
+	"\x14PATTERN_TYPE_LITERAL\x10\x00\x12\x17\n" +
+	"\x13PATTERN_TYPE_PREFIX\x10\x01*i\n" +
+	"\tOperation\x12\x19\n" +
+	"\x15OPERATION_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11OPERATION_PRODUCE\x10\x01\x12\x15\n" +
+	"\x11OPERATION_CONSUME\x10\x02\x12\x13\n" +
+	"\x0fOPERATION_ADMIN\x10\x03*<\n" +
+	"\n" +
+	"SchemaType\x12\x14\n" +
+	"\x10SCHEMA_TYPE_JSON\x10\x00\x12\x18\n" +
+	"\x14SCHEMA_TYPE_PROTOBUF\x10\x01*\x85\x02\n" +
+	"\rCompatibility\x12\x1d\n" +
+	"\x19COMPATIBILITY_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12COMPATIBILITY_NONE\x10\x01\x12\x1a\n" +
+	"\x16COMPATIBILITY_BACKWARD\x10\x02\x12%\n" +
+	"!COMPATIBILITY_BACKWARD_TRANSITIVE\x10\x03\x12\x19\n" +
+	"\x15COMPATIBILITY_FORWARD\x10\x04\x12$\n" +
+	" COMPATIBILITY_FORWARD_TRANSITIVE\x10\x05\x12\x16\n" +
+	"\x12COMPATIBILITY_FULL\x10\x06\x12!\n" +
+	"\x1dCOMPATIBILITY_FULL_TRANSITIVE\x10\a2\xd0\x05\n" +
+	"\x04Iris\x12H\n" +
+	"\vCreateTopic\x12\x1b.iris.v1.CreateTopicRequest\x1a\x1c.iris.v1.CreateTopicResponse\x12<\n" +
+	"\aProduce\x12\x17.iris.v1.ProduceRequest\x1a\x18.iris.v1.ProduceResponse\x126\n" +
+	"\x05Fetch\x12\x15.iris.v1.FetchRequest\x1a\x16.iris.v1.FetchResponse\x12D\n" +
+	"\tSubscribe\x12\x19.iris.v1.SubscribeRequest\x1a\x1a.iris.v1.SubscribeResponse0\x01\x129\n" +
+	"\x06Commit\x12\x16.iris.v1.CommitRequest\x1a\x17.iris.v1.CommitResponse\x12B\n" +
+	"\tCommitted\x12\x19.iris.v1.CommittedRequest\x1a\x1a.iris.v1.CommittedResponse\x123\n" +
+	"\x04Nack\x12\x14.iris.v1.NackRequest\x1a\x15.iris.v1.NackResponse\x12`\n" +
+	"\x13SetDeadLetterPolicy\x12#.iris.v1.SetDeadLetterPolicyRequest\x1a$.iris.v1.SetDeadLetterPolicyResponse\x12<\n" +
+	"\aReceive\x12\x17.iris.v1.ReceiveRequest\x1a\x18.iris.v1.ReceiveResponse\x120\n" +
+	"\x03Ack\x12\x13.iris.v1.AckRequest\x1a\x14.iris.v1.AckResponse\x12<\n" +
+	"\aRelease\x12\x17.iris.v1.ReleaseRequest\x1a\x18.iris.v1.ReleaseResponse2\xff\x05\n" +
+	"\x12IrisSchemaRegistry\x12Q\n" +
+	"\x0eRegisterSchema\x12\x1e.iris.v1.RegisterSchemaRequest\x1a\x1f.iris.v1.RegisterSchemaResponse\x12B\n" +
+	"\tGetSchema\x12\x19.iris.v1.GetSchemaRequest\x1a\x1a.iris.v1.GetSchemaResponse\x12W\n" +
+	"\x10GetSchemaVersion\x12 .iris.v1.GetSchemaVersionRequest\x1a!.iris.v1.GetSchemaVersionResponse\x12K\n" +
+	"\fListSubjects\x12\x1c.iris.v1.ListSubjectsRequest\x1a\x1d.iris.v1.ListSubjectsResponse\x12K\n" +
+	"\fListVersions\x12\x1c.iris.v1.ListVersionsRequest\x1a\x1d.iris.v1.ListVersionsResponse\x12N\n" +
+	"\rDeleteSubject\x12\x1d.iris.v1.DeleteSubjectRequest\x1a\x1e.iris.v1.DeleteSubjectResponse\x12]\n" +
+	"\x12CheckCompatibility\x12\".iris.v1.CheckCompatibilityRequest\x1a#.iris.v1.CheckCompatibilityResponse\x12W\n" +
+	"\x10GetCompatibility\x12 .iris.v1.GetCompatibilityRequest\x1a!.iris.v1.GetCompatibilityResponse\x12W\n" +
+	"\x10SetCompatibility\x12 .iris.v1.SetCompatibilityRequest\x1a!.iris.v1.SetCompatibilityResponse2\xa9\x04\n" +
+	"\tIrisAdmin\x12B\n" +
+	"\tCreateACL\x12\x19.iris.v1.CreateACLRequest\x1a\x1a.iris.v1.CreateACLResponse\x12B\n" +
+	"\tDeleteACL\x12\x19.iris.v1.DeleteACLRequest\x1a\x1a.iris.v1.DeleteACLResponse\x12?\n" +
+	"\bListACLs\x12\x18.iris.v1.ListACLsRequest\x1a\x19.iris.v1.ListACLsResponse\x12Z\n" +
+	"\x11DescribePartition\x12!.iris.v1.DescribePartitionRequest\x1a\".iris.v1.DescribePartitionResponse\x12Z\n" +
+	"\x11ReassignPartition\x12!.iris.v1.ReassignPartitionRequest\x1a\".iris.v1.ReassignPartitionResponse\x12N\n" +
+	"\rDeleteRecords\x12\x1d.iris.v1.DeleteRecordsRequest\x1a\x1e.iris.v1.DeleteRecordsResponse\x12K\n" +
+	"\fReloadConfig\x12\x1c.iris.v1.ReloadConfigRequest\x1a\x1d.iris.v1.ReloadConfigResponse2\x8f\x03\n" +
+	"\x0fIrisReplication\x12K\n" +
+	"\fReplicaFetch\x12\x1c.iris.v1.ReplicaFetchRequest\x1a\x1d.iris.v1.ReplicaFetchResponse\x12K\n" +
+	"\fSegmentFiles\x12\x1c.iris.v1.SegmentFilesRequest\x1a\x1d.iris.v1.SegmentFilesResponse\x12T\n" +
+	"\x0fReadSegmentFile\x12\x1f.iris.v1.ReadSegmentFileRequest\x1a .iris.v1.ReadSegmentFileResponse\x12H\n" +
+	"\vSetReplicas\x12\x1b.iris.v1.SetReplicasRequest\x1a\x1c.iris.v1.SetReplicasResponse\x12B\n" +
+	"\tSetLeader\x12\x19.iris.v1.SetLeaderRequest\x1a\x1a.iris.v1.SetLeaderResponse2\xfa\x01\n" +
+	"\bIrisRaft\x12H\n" +
+	"\vRequestVote\x12\x1b.iris.v1.RequestVoteRequest\x1a\x1c.iris.v1.RequestVoteResponse\x12N\n" +
+	"\rAppendEntries\x12\x1d.iris.v1.AppendEntriesRequest\x1a\x1e.iris.v1.AppendEntriesResponse\x12T\n" +
+	"\x0fInstallSnapshot\x12\x1f.iris.v1.InstallSnapshotRequest\x1a .iris.v1.InstallSnapshotResponseB\x11Z\x0firis/api/irispbb\x06proto3"
+
+var (
+	file_iris_proto_rawDescOnce sync.Once
+	file_iris_proto_rawDescData []byte
+)
+
+func file_iris_proto_rawDescGZIP() []byte {
+	file_iris_proto_rawDescOnce.Do(func() {
+		file_iris_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_iris_proto_rawDesc), len(file_iris_proto_rawDesc)))
+	})
+	return file_iris_proto_rawDescData
+}
+
+var file_iris_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_iris_proto_msgTypes = make([]protoimpl.MessageInfo, 81)
+var file_iris_proto_goTypes = []any{
+	(TopicMode)(0),                      // 0: iris.v1.TopicMode
+	(CloudEventsMode)(0),                // 1: iris.v1.CloudEventsMode
+	(Acks)(0),                           // 2: iris.v1.Acks
+	(ResourceType)(0),                   // 3: iris.v1.ResourceType
+	(PatternType)(0),                    // 4: iris.v1.PatternType
+	(Operation)(0),                      // 5: iris.v1.Operation
+	(SchemaType)(0),                     // 6: iris.v1.SchemaType
+	(Compatibility)(0),                  // 7: iris.v1.Compatibility
+	(*Header)(nil),                      // 8: iris.v1.Header
+	(*Message)(nil),                     // 9: iris.v1.Message
+	(*CreateTopicRequest)(nil),          // 10: iris.v1.CreateTopicRequest
+	(*PartitionReplicas)(nil),           // 11: iris.v1.PartitionReplicas
+	(*CreateTopicResponse)(nil),         // 12: iris.v1.CreateTopicResponse
+	(*ProduceRequest)(nil),              // 13: iris.v1.ProduceRequest
+	(*ProduceResponse)(nil),             // 14: iris.v1.ProduceResponse
+	(*FetchRequest)(nil),                // 15: iris.v1.FetchRequest
+	(*FetchResponse)(nil),               // 16: iris.v1.FetchResponse
+	(*SubscribeRequest)(nil),            // 17: iris.v1.SubscribeRequest
+	(*SubscribeResponse)(nil),           // 18: iris.v1.SubscribeResponse
+	(*CommitRequest)(nil),               // 19: iris.v1.CommitRequest
+	(*CommitResponse)(nil),              // 20: iris.v1.CommitResponse
+	(*CommittedRequest)(nil),            // 21: iris.v1.CommittedRequest
+	(*CommittedResponse)(nil),           // 22: iris.v1.CommittedResponse
+	(*NackRequest)(nil),                 // 23: iris.v1.NackRequest
+	(*NackResponse)(nil),                // 24: iris.v1.NackResponse
+	(*SetDeadLetterPolicyRequest)(nil),  // 25: iris.v1.SetDeadLetterPolicyRequest
+	(*SetDeadLetterPolicyResponse)(nil), // 26: iris.v1.SetDeadLetterPolicyResponse
+	(*ReceiveRequest)(nil),              // 27: iris.v1.ReceiveRequest
+	(*LeasedMessage)(nil),               // 28: iris.v1.LeasedMessage
+	(*ReceiveResponse)(nil),             // 29: iris.v1.ReceiveResponse
+	(*AckRequest)(nil),                  // 30: iris.v1.AckRequest
+	(*AckResponse)(nil),                 // 31: iris.v1.AckResponse
+	(*ReleaseRequest)(nil),              // 32: iris.v1.ReleaseRequest
+	(*ReleaseResponse)(nil),             // 33: iris.v1.ReleaseResponse
+	(*ACL)(nil),                         // 34: iris.v1.ACL
+	(*CreateACLRequest)(nil),            // 35: iris.v1.CreateACLRequest
+	(*CreateACLResponse)(nil),           // 36: iris.v1.CreateACLResponse
+	(*DeleteACLRequest)(nil),            // 37: iris.v1.DeleteACLRequest
+	(*DeleteACLResponse)(nil),           // 38: iris.v1.DeleteACLResponse
+	(*ListACLsRequest)(nil),             // 39: iris.v1.ListACLsRequest
+	(*ListACLsResponse)(nil),            // 40: iris.v1.ListACLsResponse
+	(*Schema)(nil),                      // 41: iris.v1.Schema
+	(*RegisterSchemaRequest)(nil),       // 42: iris.v1.RegisterSchemaRequest
+	(*RegisterSchemaResponse)(nil),      // 43: iris.v1.RegisterSchemaResponse
+	(*GetSchemaRequest)(nil),            // 44: iris.v1.GetSchemaRequest
+	(*GetSchemaResponse)(nil),           // 45: iris.v1.GetSchemaResponse
+	(*GetSchemaVersionRequest)(nil),     // 46: iris.v1.GetSchemaVersionRequest
+	(*GetSchemaVersionResponse)(nil),    // 47: iris.v1.GetSchemaVersionResponse
+	(*ListSubjectsRequest)(nil),         // 48: iris.v1.ListSubjectsRequest
+	(*ListSubjectsResponse)(nil),        // 49: iris.v1.ListSubjectsResponse
+	(*ListVersionsRequest)(nil),         // 50: iris.v1.ListVersionsRequest
+	(*ListVersionsResponse)(nil),        // 51: iris.v1.ListVersionsResponse
+	(*DeleteSubjectRequest)(nil),        // 52: iris.v1.DeleteSubjectRequest
+	(*DeleteSubjectResponse)(nil),       // 53: iris.v1.DeleteSubjectResponse
+	(*CheckCompatibilityRequest)(nil),   // 54: iris.v1.CheckCompatibilityRequest
+	(*CheckCompatibilityResponse)(nil),  // 55: iris.v1.CheckCompatibilityResponse
+	(*GetCompatibilityRequest)(nil),     // 56: iris.v1.GetCompatibilityRequest
+	(*GetCompatibilityResponse)(nil),    // 57: iris.v1.GetCompatibilityResponse
+	(*SetCompatibilityRequest)(nil),     // 58: iris.v1.SetCompatibilityRequest
+	(*SetCompatibilityResponse)(nil),    // 59: iris.v1.SetCompatibilityResponse
+	(*DescribePartitionRequest)(nil),    // 60: iris.v1.DescribePartitionRequest
+	(*ReplicaState)(nil),                // 61: iris.v1.ReplicaState
+	(*DescribePartitionResponse)(nil),   // 62: iris.v1.DescribePartitionResponse
+	(*ReassignPartitionRequest)(nil),    // 63: iris.v1.ReassignPartitionRequest
+	(*ReassignPartitionResponse)(nil),   // 64: iris.v1.ReassignPartitionResponse
+	(*DeleteRecordsRequest)(nil),        // 65: iris.v1.DeleteRecordsRequest
+	(*DeleteRecordsResponse)(nil),       // 66: iris.v1.DeleteRecordsResponse
+	(*ReloadConfigRequest)(nil),         // 67: iris.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),        // 68: iris.v1.ReloadConfigResponse
+	(*ReplicaFetchRequest)(nil),         // 69: iris.v1.ReplicaFetchRequest
+	(*LeaderEpoch)(nil),                 // 70: iris.v1.LeaderEpoch
+	(*ReplicaFetchResponse)(nil),        // 71: iris.v1.ReplicaFetchResponse
+	(*LeaderEpochEnd)(nil),              // 72: iris.v1.LeaderEpochEnd
+	(*SegmentFilesRequest)(nil),         // 73: iris.v1.SegmentFilesRequest
+	(*SegmentFile)(nil),                 // 74: iris.v1.SegmentFile
+	(*SegmentFilesResponse)(nil),        // 75: iris.v1.SegmentFilesResponse
+	(*ReadSegmentFileRequest)(nil),      // 76: iris.v1.ReadSegmentFileRequest
+	(*ReadSegmentFileResponse)(nil),     // 77: iris.v1.ReadSegmentFileResponse
+	(*SetReplicasRequest)(nil),          // 78: iris.v1.SetReplicasRequest
+	(*SetReplicasResponse)(nil),         // 79: iris.v1.SetReplicasResponse
+	(*SetLeaderRequest)(nil),            // 80: iris.v1.SetLeaderRequest
+	(*SetLeaderResponse)(nil),           // 81: iris.v1.SetLeaderResponse
+	(*RequestVoteRequest)(nil),          // 82: iris.v1.RequestVoteRequest
+	(*RequestVoteResponse)(nil),         // 83: iris.v1.RequestVoteResponse
+	(*RaftEntry)(nil),                   // 84: iris.v1.RaftEntry
+	(*AppendEntriesRequest)(nil),        // 85: iris.v1.AppendEntriesRequest
+	(*AppendEntriesResponse)(nil),       // 86: iris.v1.AppendEntriesResponse
+	(*InstallSnapshotRequest)(nil),      // 87: iris.v1.InstallSnapshotRequest
+	(*InstallSnapshotResponse)(nil),     // 88: iris.v1.InstallSnapshotResponse
+}
+var file_iris_proto_depIdxs = []int32{
+	8,  // 0: iris.v1.Message.headers:type_name -> iris.v1.Header
+	0,  // 1: iris.v1.CreateTopicRequest.mode:type_name -> iris.v1.TopicMode
+	11, // 2: iris.v1.CreateTopicRequest.replicas:type_name -> iris.v1.PartitionReplicas
+	9,  // 3: iris.v1.ProduceRequest.messages:type_name -> iris.v1.Message
+	1,  // 4: iris.v1.ProduceRequest.cloudevents_mode:type_name -> iris.v1.CloudEventsMode
+	2,  // 5: iris.v1.ProduceRequest.acks:type_name -> iris.v1.Acks
+	1,  // 6: iris.v1.FetchRequest.cloudevents_mode:type_name -> iris.v1.CloudEventsMode
+	9,  // 7: iris.v1.FetchResponse.messages:type_name -> iris.v1.Message
+	1,  // 8: iris.v1.SubscribeRequest.cloudevents_mode:type_name -> iris.v1.CloudEventsMode
+	9,  // 9: iris.v1.SubscribeResponse.messages:type_name -> iris.v1.Message
+	9,  // 10: iris.v1.LeasedMessage.message:type_name -> iris.v1.Message
+	28, // 11: iris.v1.ReceiveResponse.messages:type_name -> iris.v1.LeasedMessage
+	3,  // 12: iris.v1.ACL.resource_type:type_name -> iris.v1.ResourceType
+	4,  // 13: iris.v1.ACL.pattern_type:type_name -> iris.v1.PatternType
+	5,  // 14: iris.v1.ACL.operation:type_name -> iris.v1.Operation
+	34, // 15: iris.v1.CreateACLRequest.acl:type_name -> iris.v1.ACL
+	34, // 16: iris.v1.DeleteACLRequest.acl:type_name -> iris.v1.ACL
+	34, // 17: iris.v1.ListACLsResponse.acls:type_name -> iris.v1.ACL
+	6,  // 18: iris.v1.Schema.type:type_name -> iris.v1.SchemaType
+	41, // 19: iris.v1.RegisterSchemaRequest.schema:type_name -> iris.v1.Schema
+	41, // 20: iris.v1.GetSchemaResponse.schema:type_name -> iris.v1.Schema
+	41, // 21: iris.v1.GetSchemaVersionResponse.schema:type_name -> iris.v1.Schema
+	41, // 22: iris.v1.CheckCompatibilityRequest.schema:type_name -> iris.v1.Schema
+	7,  // 23: iris.v1.GetCompatibilityResponse.compatibility:type_name -> iris.v1.Compatibility
+	7,  // 24: iris.v1.SetCompatibilityRequest.compatibility:type_name -> iris.v1.Compatibility
+	61, // 25: iris.v1.DescribePartitionResponse.replicas:type_name -> iris.v1.ReplicaState
+	70, // 26: iris.v1.ReplicaFetchResponse.epochs:type_name -> iris.v1.LeaderEpoch
+	72, // 27: iris.v1.ReplicaFetchResponse.diverging:type_name -> iris.v1.LeaderEpochEnd
+	74, // 28: iris.v1.SegmentFilesResponse.files:type_name -> iris.v1.SegmentFile
+	84, // 29: iris.v1.AppendEntriesRequest.entries:type_name -> iris.v1.RaftEntry
+	10, // 30: iris.v1.Iris.CreateTopic:input_type -> iris.v1.CreateTopicRequest
+	13, // 31: iris.v1.Iris.Produce:input_type -> iris.v1.ProduceRequest
+	15, // 32: iris.v1.Iris.Fetch:input_type -> iris.v1.FetchRequest
+	17, // 33: iris.v1.Iris.Subscribe:input_type -> iris.v1.SubscribeRequest
+	19, // 34: iris.v1.Iris.Commit:input_type -> iris.v1.CommitRequest
+	21, // 35: iris.v1.Iris.Committed:input_type -> iris.v1.CommittedRequest
+	23, // 36: iris.v1.Iris.Nack:input_type -> iris.v1.NackRequest
+	25, // 37: iris.v1.Iris.SetDeadLetterPolicy:input_type -> iris.v1.SetDeadLetterPolicyRequest
+	27, // 38: iris.v1.Iris.Receive:input_type -> iris.v1.ReceiveRequest
+	30, // 39: iris.v1.Iris.Ack:input_type -> iris.v1.AckRequest
+	32, // 40: iris.v1.Iris.Release:input_type -> iris.v1.ReleaseRequest
+	42, // 41: iris.v1.IrisSchemaRegistry.RegisterSchema:input_type -> iris.v1.RegisterSchemaRequest
+	44, // 42: iris.v1.IrisSchemaRegistry.GetSchema:input_type -> iris.v1.GetSchemaRequest
+	46, // 43: iris.v1.IrisSchemaRegistry.GetSchemaVersion:input_type -> iris.v1.GetSchemaVersionRequest
+	48, // 44: iris.v1.IrisSchemaRegistry.ListSubjects:input_type -> iris.v1.ListSubjectsRequest
+	50, // 45: iris.v1.IrisSchemaRegistry.ListVersions:input_type -> iris.v1.ListVersionsRequest
+	52, // 46: iris.v1.IrisSchemaRegistry.DeleteSubject:input_type -> iris.v1.DeleteSubjectRequest
+	54, // 47: iris.v1.IrisSchemaRegistry.CheckCompatibility:input_type -> iris.v1.CheckCompatibilityRequest
+	56, // 48: iris.v1.IrisSchemaRegistry.GetCompatibility:input_type -> iris.v1.GetCompatibilityRequest
+	58, // 49: iris.v1.IrisSchemaRegistry.SetCompatibility:input_type -> iris.v1.SetCompatibilityRequest
+	35, // 50: iris.v1.IrisAdmin.CreateACL:input_type -> iris.v1.CreateACLRequest
+	37, // 51: iris.v1.IrisAdmin.DeleteACL:input_type -> iris.v1.DeleteACLRequest
+	39, // 52: iris.v1.IrisAdmin.ListACLs:input_type -> iris.v1.ListACLsRequest
+	60, // 53: iris.v1.IrisAdmin.DescribePartition:input_type -> iris.v1.DescribePartitionRequest
+	63, // 54: iris.v1.IrisAdmin.ReassignPartition:input_type -> iris.v1.ReassignPartitionRequest
+	65, // 55: iris.v1.IrisAdmin.DeleteRecords:input_type -> iris.v1.DeleteRecordsRequest
+	67, // 56: iris.v1.IrisAdmin.ReloadConfig:input_type -> iris.v1.ReloadConfigRequest
+	69, // 57: iris.v1.IrisReplication.ReplicaFetch:input_type -> iris.v1.ReplicaFetchRequest
+	73, // 58: iris.v1.IrisReplication.SegmentFiles:input_type -> iris.v1.SegmentFilesRequest
+	76, // 59: iris.v1.IrisReplication.ReadSegmentFile:input_type -> iris.v1.ReadSegmentFileRequest
+	78, // 60: iris.v1.IrisReplication.SetReplicas:input_type -> iris.v1.SetReplicasRequest
+	80, // 61: iris.v1.IrisReplication.SetLeader:input_type -> iris.v1.SetLeaderRequest
+	82, // 62: iris.v1.IrisRaft.RequestVote:input_type -> iris.v1.RequestVoteRequest
+	85, // 63: iris.v1.IrisRaft.AppendEntries:input_type -> iris.v1.AppendEntriesRequest
+	87, // 64: iris.v1.IrisRaft.InstallSnapshot:input_type -> iris.v1.InstallSnapshotRequest
+	12, // 65: iris.v1.Iris.CreateTopic:output_type -> iris.v1.CreateTopicResponse
+	14, // 66: iris.v1.Iris.Produce:output_type -> iris.v1.ProduceResponse
+	16, // 67: iris.v1.Iris.Fetch:output_type -> iris.v1.FetchResponse
+	18, // 68: iris.v1.Iris.Subscribe:output_type -> iris.v1.SubscribeResponse
+	20, // 69: iris.v1.Iris.Commit:output_type -> iris.v1.CommitResponse
+	22, // 70: iris.v1.Iris.Committed:output_type -> iris.v1.CommittedResponse
+	24, // 71: iris.v1.Iris.Nack:output_type -> iris.v1.NackResponse
+	26, // 72: iris.v1.Iris.SetDeadLetterPolicy:output_type -> iris.v1.SetDeadLetterPolicyResponse
+	29, // 73: iris.v1.Iris.Receive:output_type -> iris.v1.ReceiveResponse
+	31, // 74: iris.v1.Iris.Ack:output_type -> iris.v1.AckResponse
+	33, // 75: iris.v1.Iris.Release:output_type -> iris.v1.ReleaseResponse
+	43, // 76: iris.v1.IrisSchemaRegistry.RegisterSchema:output_type -> iris.v1.RegisterSchemaResponse
+	45, // 77: iris.v1.IrisSchemaRegistry.GetSchema:output_type -> iris.v1.GetSchemaResponse
+	47, // 78: iris.v1.IrisSchemaRegistry.GetSchemaVersion:output_type -> iris.v1.GetSchemaVersionResponse
+	49, // 79: iris.v1.IrisSchemaRegistry.ListSubjects:output_type -> iris.v1.ListSubjectsResponse
+	51, // 80: iris.v1.IrisSchemaRegistry.ListVersions:output_type -> iris.v1.ListVersionsResponse
+	53, // 81: iris.v1.IrisSchemaRegistry.DeleteSubject:output_type -> iris.v1.DeleteSubjectResponse
+	55, // 82: iris.v1.IrisSchemaRegistry.CheckCompatibility:output_type -> iris.v1.CheckCompatibilityResponse
+	57, // 83: iris.v1.IrisSchemaRegistry.GetCompatibility:output_type -> iris.v1.GetCompatibilityResponse
+	59, // 84: iris.v1.IrisSchemaRegistry.SetCompatibility:output_type -> iris.v1.SetCompatibilityResponse
+	36, // 85: iris.v1.IrisAdmin.CreateACL:output_type -> iris.v1.CreateACLResponse
+	38, // 86: iris.v1.IrisAdmin.DeleteACL:output_type -> iris.v1.DeleteACLResponse
+	40, // 87: iris.v1.IrisAdmin.ListACLs:output_type -> iris.v1.ListACLsResponse
+	62, // 88: iris.v1.IrisAdmin.DescribePartition:output_type -> iris.v1.DescribePartitionResponse
+	64, // 89: iris.v1.IrisAdmin.ReassignPartition:output_type -> iris.v1.ReassignPartitionResponse
+	66, // 90: iris.v1.IrisAdmin.DeleteRecords:output_type -> iris.v1.DeleteRecordsResponse
+	68, // 91: iris.v1.IrisAdmin.ReloadConfig:output_type -> iris.v1.ReloadConfigResponse
+	71, // 92: iris.v1.IrisReplication.ReplicaFetch:output_type -> iris.v1.ReplicaFetchResponse
+	75, // 93: iris.v1.IrisReplication.SegmentFiles:output_type -> iris.v1.SegmentFilesResponse
+	77, // 94: iris.v1.IrisReplication.ReadSegmentFile:output_type -> iris.v1.ReadSegmentFileResponse
+	79, // 95: iris.v1.IrisReplication.SetReplicas:output_type -> iris.v1.SetReplicasResponse
+	81, // 96: iris.v1.IrisReplication.SetLeader:output_type -> iris.v1.SetLeaderResponse
+	83, // 97: iris.v1.IrisRaft.RequestVote:output_type -> iris.v1.RequestVoteResponse
+	86, // 98: iris.v1.IrisRaft.AppendEntries:output_type -> iris.v1.AppendEntriesResponse
+	88, // 99: iris.v1.IrisRaft.InstallSnapshot:output_type -> iris.v1.InstallSnapshotResponse
+	65, // [65:100] is the sub-list for method output_type
+	30, // [30:65] is the sub-list for method input_type
+	30, // [30:30] is the sub-list for extension type_name
+	30, // [30:30] is the sub-list for extension extendee
+	0,  // [0:30] is the sub-list for field type_name
+}
+
+func init() { file_iris_proto_init() }
+func file_iris_proto_init() {
+	if File_iris_proto != nil {
+		return
+	}
+	file_iris_proto_msgTypes[5].OneofWrappers = []any{}
+	file_iris_proto_msgTypes[63].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_iris_proto_rawDesc), len(file_iris_proto_rawDesc)),
+			NumEnums:      8,
+			NumMessages:   81,
+			NumExtensions: 0,
+			NumServices:   5,
+		},
+		GoTypes:           file_iris_proto_goTypes,
+		DependencyIndexes: file_iris_proto_depIdxs,
+		EnumInfos:         file_iris_proto_enumTypes,
+		MessageInfos:      file_iris_proto_msgTypes,
+	}.Build()
+	File_iris_proto = out.File
+	file_iris_proto_goTypes = nil
+	file_iris_proto_depIdxs = nil
+}
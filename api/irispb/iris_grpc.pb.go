@@ -0,0 +1,1731 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: iris.proto
+
+package irispb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Iris_CreateTopic_FullMethodName         = "/iris.v1.Iris/CreateTopic"
+	Iris_Produce_FullMethodName             = "/iris.v1.Iris/Produce"
+	Iris_Fetch_FullMethodName               = "/iris.v1.Iris/Fetch"
+	Iris_Subscribe_FullMethodName           = "/iris.v1.Iris/Subscribe"
+	Iris_Commit_FullMethodName              = "/iris.v1.Iris/Commit"
+	Iris_Committed_FullMethodName           = "/iris.v1.Iris/Committed"
+	Iris_Nack_FullMethodName                = "/iris.v1.Iris/Nack"
+	Iris_SetDeadLetterPolicy_FullMethodName = "/iris.v1.Iris/SetDeadLetterPolicy"
+	Iris_Receive_FullMethodName             = "/iris.v1.Iris/Receive"
+	Iris_Ack_FullMethodName                 = "/iris.v1.Iris/Ack"
+	Iris_Release_FullMethodName             = "/iris.v1.Iris/Release"
+)
+
+// IrisClient is the client API for Iris service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IrisClient interface {
+	CreateTopic(ctx context.Context, in *CreateTopicRequest, opts ...grpc.CallOption) (*CreateTopicResponse, error)
+	Produce(ctx context.Context, in *ProduceRequest, opts ...grpc.CallOption) (*ProduceResponse, error)
+	Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error)
+	// Subscribe streams the messages of a partition from an offset on,
+	// it waits for new messages once it has caught up.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeResponse], error)
+	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	Committed(ctx context.Context, in *CommittedRequest, opts ...grpc.CallOption) (*CommittedResponse, error)
+	// Nack reports a failed delivery, messages are moved to the dead-letter
+	// topic once the dead-letter policy of the group is exhausted.
+	Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*NackResponse, error)
+	SetDeadLetterPolicy(ctx context.Context, in *SetDeadLetterPolicyRequest, opts ...grpc.CallOption) (*SetDeadLetterPolicyResponse, error)
+	// Receive leases messages of a queue topic, they are delivered again
+	// once their visibility timeout is over unless they are acked.
+	Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*ReceiveResponse, error)
+	Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error)
+	// Release hands a leased message back for redelivery at once.
+	Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error)
+}
+
+type irisClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIrisClient(cc grpc.ClientConnInterface) IrisClient {
+	return &irisClient{cc}
+}
+
+func (c *irisClient) CreateTopic(ctx context.Context, in *CreateTopicRequest, opts ...grpc.CallOption) (*CreateTopicResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateTopicResponse)
+	err := c.cc.Invoke(ctx, Iris_CreateTopic_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Produce(ctx context.Context, in *ProduceRequest, opts ...grpc.CallOption) (*ProduceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProduceResponse)
+	err := c.cc.Invoke(ctx, Iris_Produce_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (*FetchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FetchResponse)
+	err := c.cc.Invoke(ctx, Iris_Fetch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SubscribeResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Iris_ServiceDesc.Streams[0], Iris_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, SubscribeResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Iris_SubscribeClient = grpc.ServerStreamingClient[SubscribeResponse]
+
+func (c *irisClient) Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommitResponse)
+	err := c.cc.Invoke(ctx, Iris_Commit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Committed(ctx context.Context, in *CommittedRequest, opts ...grpc.CallOption) (*CommittedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommittedResponse)
+	err := c.cc.Invoke(ctx, Iris_Committed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Nack(ctx context.Context, in *NackRequest, opts ...grpc.CallOption) (*NackResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NackResponse)
+	err := c.cc.Invoke(ctx, Iris_Nack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) SetDeadLetterPolicy(ctx context.Context, in *SetDeadLetterPolicyRequest, opts ...grpc.CallOption) (*SetDeadLetterPolicyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetDeadLetterPolicyResponse)
+	err := c.cc.Invoke(ctx, Iris_SetDeadLetterPolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Receive(ctx context.Context, in *ReceiveRequest, opts ...grpc.CallOption) (*ReceiveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReceiveResponse)
+	err := c.cc.Invoke(ctx, Iris_Receive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AckResponse)
+	err := c.cc.Invoke(ctx, Iris_Ack_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisClient) Release(ctx context.Context, in *ReleaseRequest, opts ...grpc.CallOption) (*ReleaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseResponse)
+	err := c.cc.Invoke(ctx, Iris_Release_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IrisServer is the server API for Iris service.
+// All implementations must embed UnimplementedIrisServer
+// for forward compatibility.
+type IrisServer interface {
+	CreateTopic(context.Context, *CreateTopicRequest) (*CreateTopicResponse, error)
+	Produce(context.Context, *ProduceRequest) (*ProduceResponse, error)
+	Fetch(context.Context, *FetchRequest) (*FetchResponse, error)
+	// Subscribe streams the messages of a partition from an offset on,
+	// it waits for new messages once it has caught up.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SubscribeResponse]) error
+	Commit(context.Context, *CommitRequest) (*CommitResponse, error)
+	Committed(context.Context, *CommittedRequest) (*CommittedResponse, error)
+	// Nack reports a failed delivery, messages are moved to the dead-letter
+	// topic once the dead-letter policy of the group is exhausted.
+	Nack(context.Context, *NackRequest) (*NackResponse, error)
+	SetDeadLetterPolicy(context.Context, *SetDeadLetterPolicyRequest) (*SetDeadLetterPolicyResponse, error)
+	// Receive leases messages of a queue topic, they are delivered again
+	// once their visibility timeout is over unless they are acked.
+	Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error)
+	Ack(context.Context, *AckRequest) (*AckResponse, error)
+	// Release hands a leased message back for redelivery at once.
+	Release(context.Context, *ReleaseRequest) (*ReleaseResponse, error)
+	mustEmbedUnimplementedIrisServer()
+}
+
+// UnimplementedIrisServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIrisServer struct{}
+
+func (UnimplementedIrisServer) CreateTopic(context.Context, *CreateTopicRequest) (*CreateTopicResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTopic not implemented")
+}
+func (UnimplementedIrisServer) Produce(context.Context, *ProduceRequest) (*ProduceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Produce not implemented")
+}
+func (UnimplementedIrisServer) Fetch(context.Context, *FetchRequest) (*FetchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Fetch not implemented")
+}
+func (UnimplementedIrisServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[SubscribeResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedIrisServer) Commit(context.Context, *CommitRequest) (*CommitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Commit not implemented")
+}
+func (UnimplementedIrisServer) Committed(context.Context, *CommittedRequest) (*CommittedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Committed not implemented")
+}
+func (UnimplementedIrisServer) Nack(context.Context, *NackRequest) (*NackResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Nack not implemented")
+}
+func (UnimplementedIrisServer) SetDeadLetterPolicy(context.Context, *SetDeadLetterPolicyRequest) (*SetDeadLetterPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDeadLetterPolicy not implemented")
+}
+func (UnimplementedIrisServer) Receive(context.Context, *ReceiveRequest) (*ReceiveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Receive not implemented")
+}
+func (UnimplementedIrisServer) Ack(context.Context, *AckRequest) (*AckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ack not implemented")
+}
+func (UnimplementedIrisServer) Release(context.Context, *ReleaseRequest) (*ReleaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Release not implemented")
+}
+func (UnimplementedIrisServer) mustEmbedUnimplementedIrisServer() {}
+func (UnimplementedIrisServer) testEmbeddedByValue()              {}
+
+// UnsafeIrisServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IrisServer will
+// result in compilation errors.
+type UnsafeIrisServer interface {
+	mustEmbedUnimplementedIrisServer()
+}
+
+func RegisterIrisServer(s grpc.ServiceRegistrar, srv IrisServer) {
+	// If the following call pancis, it indicates UnimplementedIrisServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Iris_ServiceDesc, srv)
+}
+
+func _Iris_CreateTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTopicRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).CreateTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_CreateTopic_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).CreateTopic(ctx, req.(*CreateTopicRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Produce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProduceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Produce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Produce_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Produce(ctx, req.(*ProduceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Fetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Fetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Fetch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Fetch(ctx, req.(*FetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IrisServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, SubscribeResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Iris_SubscribeServer = grpc.ServerStreamingServer[SubscribeResponse]
+
+func _Iris_Commit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Commit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Commit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Commit(ctx, req.(*CommitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Committed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CommittedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Committed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Committed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Committed(ctx, req.(*CommittedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Nack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Nack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Nack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Nack(ctx, req.(*NackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_SetDeadLetterPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDeadLetterPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).SetDeadLetterPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_SetDeadLetterPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).SetDeadLetterPolicy(ctx, req.(*SetDeadLetterPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Receive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Receive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Receive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Receive(ctx, req.(*ReceiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Ack_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Ack(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Ack_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Ack(ctx, req.(*AckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Iris_Release_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisServer).Release(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Iris_Release_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisServer).Release(ctx, req.(*ReleaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Iris_ServiceDesc is the grpc.ServiceDesc for Iris service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Iris_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iris.v1.Iris",
+	HandlerType: (*IrisServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateTopic",
+			Handler:    _Iris_CreateTopic_Handler,
+		},
+		{
+			MethodName: "Produce",
+			Handler:    _Iris_Produce_Handler,
+		},
+		{
+			MethodName: "Fetch",
+			Handler:    _Iris_Fetch_Handler,
+		},
+		{
+			MethodName: "Commit",
+			Handler:    _Iris_Commit_Handler,
+		},
+		{
+			MethodName: "Committed",
+			Handler:    _Iris_Committed_Handler,
+		},
+		{
+			MethodName: "Nack",
+			Handler:    _Iris_Nack_Handler,
+		},
+		{
+			MethodName: "SetDeadLetterPolicy",
+			Handler:    _Iris_SetDeadLetterPolicy_Handler,
+		},
+		{
+			MethodName: "Receive",
+			Handler:    _Iris_Receive_Handler,
+		},
+		{
+			MethodName: "Ack",
+			Handler:    _Iris_Ack_Handler,
+		},
+		{
+			MethodName: "Release",
+			Handler:    _Iris_Release_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Iris_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "iris.proto",
+}
+
+const (
+	IrisSchemaRegistry_RegisterSchema_FullMethodName     = "/iris.v1.IrisSchemaRegistry/RegisterSchema"
+	IrisSchemaRegistry_GetSchema_FullMethodName          = "/iris.v1.IrisSchemaRegistry/GetSchema"
+	IrisSchemaRegistry_GetSchemaVersion_FullMethodName   = "/iris.v1.IrisSchemaRegistry/GetSchemaVersion"
+	IrisSchemaRegistry_ListSubjects_FullMethodName       = "/iris.v1.IrisSchemaRegistry/ListSubjects"
+	IrisSchemaRegistry_ListVersions_FullMethodName       = "/iris.v1.IrisSchemaRegistry/ListVersions"
+	IrisSchemaRegistry_DeleteSubject_FullMethodName      = "/iris.v1.IrisSchemaRegistry/DeleteSubject"
+	IrisSchemaRegistry_CheckCompatibility_FullMethodName = "/iris.v1.IrisSchemaRegistry/CheckCompatibility"
+	IrisSchemaRegistry_GetCompatibility_FullMethodName   = "/iris.v1.IrisSchemaRegistry/GetCompatibility"
+	IrisSchemaRegistry_SetCompatibility_FullMethodName   = "/iris.v1.IrisSchemaRegistry/SetCompatibility"
+)
+
+// IrisSchemaRegistryClient is the client API for IrisSchemaRegistry service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IrisSchemaRegistry manages the versioned schemas of subjects, the subject of a topic
+// is named like the topic. Messages naming a schema ID in their iris-schema-id header
+// are validated against it on produce. Changing a subject needs admin rights on its topic.
+type IrisSchemaRegistryClient interface {
+	RegisterSchema(ctx context.Context, in *RegisterSchemaRequest, opts ...grpc.CallOption) (*RegisterSchemaResponse, error)
+	GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error)
+	GetSchemaVersion(ctx context.Context, in *GetSchemaVersionRequest, opts ...grpc.CallOption) (*GetSchemaVersionResponse, error)
+	ListSubjects(ctx context.Context, in *ListSubjectsRequest, opts ...grpc.CallOption) (*ListSubjectsResponse, error)
+	ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error)
+	DeleteSubject(ctx context.Context, in *DeleteSubjectRequest, opts ...grpc.CallOption) (*DeleteSubjectResponse, error)
+	CheckCompatibility(ctx context.Context, in *CheckCompatibilityRequest, opts ...grpc.CallOption) (*CheckCompatibilityResponse, error)
+	GetCompatibility(ctx context.Context, in *GetCompatibilityRequest, opts ...grpc.CallOption) (*GetCompatibilityResponse, error)
+	SetCompatibility(ctx context.Context, in *SetCompatibilityRequest, opts ...grpc.CallOption) (*SetCompatibilityResponse, error)
+}
+
+type irisSchemaRegistryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIrisSchemaRegistryClient(cc grpc.ClientConnInterface) IrisSchemaRegistryClient {
+	return &irisSchemaRegistryClient{cc}
+}
+
+func (c *irisSchemaRegistryClient) RegisterSchema(ctx context.Context, in *RegisterSchemaRequest, opts ...grpc.CallOption) (*RegisterSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterSchemaResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_RegisterSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSchemaResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_GetSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) GetSchemaVersion(ctx context.Context, in *GetSchemaVersionRequest, opts ...grpc.CallOption) (*GetSchemaVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSchemaVersionResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_GetSchemaVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) ListSubjects(ctx context.Context, in *ListSubjectsRequest, opts ...grpc.CallOption) (*ListSubjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSubjectsResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_ListSubjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) ListVersions(ctx context.Context, in *ListVersionsRequest, opts ...grpc.CallOption) (*ListVersionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVersionsResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_ListVersions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) DeleteSubject(ctx context.Context, in *DeleteSubjectRequest, opts ...grpc.CallOption) (*DeleteSubjectResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSubjectResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_DeleteSubject_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) CheckCompatibility(ctx context.Context, in *CheckCompatibilityRequest, opts ...grpc.CallOption) (*CheckCompatibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckCompatibilityResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_CheckCompatibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) GetCompatibility(ctx context.Context, in *GetCompatibilityRequest, opts ...grpc.CallOption) (*GetCompatibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCompatibilityResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_GetCompatibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisSchemaRegistryClient) SetCompatibility(ctx context.Context, in *SetCompatibilityRequest, opts ...grpc.CallOption) (*SetCompatibilityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetCompatibilityResponse)
+	err := c.cc.Invoke(ctx, IrisSchemaRegistry_SetCompatibility_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IrisSchemaRegistryServer is the server API for IrisSchemaRegistry service.
+// All implementations must embed UnimplementedIrisSchemaRegistryServer
+// for forward compatibility.
+//
+// IrisSchemaRegistry manages the versioned schemas of subjects, the subject of a topic
+// is named like the topic. Messages naming a schema ID in their iris-schema-id header
+// are validated against it on produce. Changing a subject needs admin rights on its topic.
+type IrisSchemaRegistryServer interface {
+	RegisterSchema(context.Context, *RegisterSchemaRequest) (*RegisterSchemaResponse, error)
+	GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error)
+	GetSchemaVersion(context.Context, *GetSchemaVersionRequest) (*GetSchemaVersionResponse, error)
+	ListSubjects(context.Context, *ListSubjectsRequest) (*ListSubjectsResponse, error)
+	ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error)
+	DeleteSubject(context.Context, *DeleteSubjectRequest) (*DeleteSubjectResponse, error)
+	CheckCompatibility(context.Context, *CheckCompatibilityRequest) (*CheckCompatibilityResponse, error)
+	GetCompatibility(context.Context, *GetCompatibilityRequest) (*GetCompatibilityResponse, error)
+	SetCompatibility(context.Context, *SetCompatibilityRequest) (*SetCompatibilityResponse, error)
+	mustEmbedUnimplementedIrisSchemaRegistryServer()
+}
+
+// UnimplementedIrisSchemaRegistryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIrisSchemaRegistryServer struct{}
+
+func (UnimplementedIrisSchemaRegistryServer) RegisterSchema(context.Context, *RegisterSchemaRequest) (*RegisterSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterSchema not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchema not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) GetSchemaVersion(context.Context, *GetSchemaVersionRequest) (*GetSchemaVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchemaVersion not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) ListSubjects(context.Context, *ListSubjectsRequest) (*ListSubjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSubjects not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) ListVersions(context.Context, *ListVersionsRequest) (*ListVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVersions not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) DeleteSubject(context.Context, *DeleteSubjectRequest) (*DeleteSubjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSubject not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) CheckCompatibility(context.Context, *CheckCompatibilityRequest) (*CheckCompatibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckCompatibility not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) GetCompatibility(context.Context, *GetCompatibilityRequest) (*GetCompatibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCompatibility not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) SetCompatibility(context.Context, *SetCompatibilityRequest) (*SetCompatibilityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCompatibility not implemented")
+}
+func (UnimplementedIrisSchemaRegistryServer) mustEmbedUnimplementedIrisSchemaRegistryServer() {}
+func (UnimplementedIrisSchemaRegistryServer) testEmbeddedByValue()                            {}
+
+// UnsafeIrisSchemaRegistryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IrisSchemaRegistryServer will
+// result in compilation errors.
+type UnsafeIrisSchemaRegistryServer interface {
+	mustEmbedUnimplementedIrisSchemaRegistryServer()
+}
+
+func RegisterIrisSchemaRegistryServer(s grpc.ServiceRegistrar, srv IrisSchemaRegistryServer) {
+	// If the following call pancis, it indicates UnimplementedIrisSchemaRegistryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IrisSchemaRegistry_ServiceDesc, srv)
+}
+
+func _IrisSchemaRegistry_RegisterSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).RegisterSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_RegisterSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).RegisterSchema(ctx, req.(*RegisterSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).GetSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_GetSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).GetSchema(ctx, req.(*GetSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_GetSchemaVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).GetSchemaVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_GetSchemaVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).GetSchemaVersion(ctx, req.(*GetSchemaVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_ListSubjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSubjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).ListSubjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_ListSubjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).ListSubjects(ctx, req.(*ListSubjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_ListVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).ListVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_ListVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).ListVersions(ctx, req.(*ListVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_DeleteSubject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSubjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).DeleteSubject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_DeleteSubject_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).DeleteSubject(ctx, req.(*DeleteSubjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_CheckCompatibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckCompatibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).CheckCompatibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_CheckCompatibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).CheckCompatibility(ctx, req.(*CheckCompatibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_GetCompatibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCompatibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).GetCompatibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_GetCompatibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).GetCompatibility(ctx, req.(*GetCompatibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisSchemaRegistry_SetCompatibility_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCompatibilityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisSchemaRegistryServer).SetCompatibility(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisSchemaRegistry_SetCompatibility_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisSchemaRegistryServer).SetCompatibility(ctx, req.(*SetCompatibilityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IrisSchemaRegistry_ServiceDesc is the grpc.ServiceDesc for IrisSchemaRegistry service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IrisSchemaRegistry_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iris.v1.IrisSchemaRegistry",
+	HandlerType: (*IrisSchemaRegistryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterSchema",
+			Handler:    _IrisSchemaRegistry_RegisterSchema_Handler,
+		},
+		{
+			MethodName: "GetSchema",
+			Handler:    _IrisSchemaRegistry_GetSchema_Handler,
+		},
+		{
+			MethodName: "GetSchemaVersion",
+			Handler:    _IrisSchemaRegistry_GetSchemaVersion_Handler,
+		},
+		{
+			MethodName: "ListSubjects",
+			Handler:    _IrisSchemaRegistry_ListSubjects_Handler,
+		},
+		{
+			MethodName: "ListVersions",
+			Handler:    _IrisSchemaRegistry_ListVersions_Handler,
+		},
+		{
+			MethodName: "DeleteSubject",
+			Handler:    _IrisSchemaRegistry_DeleteSubject_Handler,
+		},
+		{
+			MethodName: "CheckCompatibility",
+			Handler:    _IrisSchemaRegistry_CheckCompatibility_Handler,
+		},
+		{
+			MethodName: "GetCompatibility",
+			Handler:    _IrisSchemaRegistry_GetCompatibility_Handler,
+		},
+		{
+			MethodName: "SetCompatibility",
+			Handler:    _IrisSchemaRegistry_SetCompatibility_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iris.proto",
+}
+
+const (
+	IrisAdmin_CreateACL_FullMethodName         = "/iris.v1.IrisAdmin/CreateACL"
+	IrisAdmin_DeleteACL_FullMethodName         = "/iris.v1.IrisAdmin/DeleteACL"
+	IrisAdmin_ListACLs_FullMethodName          = "/iris.v1.IrisAdmin/ListACLs"
+	IrisAdmin_DescribePartition_FullMethodName = "/iris.v1.IrisAdmin/DescribePartition"
+	IrisAdmin_ReassignPartition_FullMethodName = "/iris.v1.IrisAdmin/ReassignPartition"
+	IrisAdmin_DeleteRecords_FullMethodName     = "/iris.v1.IrisAdmin/DeleteRecords"
+	IrisAdmin_ReloadConfig_FullMethodName      = "/iris.v1.IrisAdmin/ReloadConfig"
+)
+
+// IrisAdminClient is the client API for IrisAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IrisAdmin manages the broker, its calls need admin rights on the cluster.
+type IrisAdminClient interface {
+	CreateACL(ctx context.Context, in *CreateACLRequest, opts ...grpc.CallOption) (*CreateACLResponse, error)
+	DeleteACL(ctx context.Context, in *DeleteACLRequest, opts ...grpc.CallOption) (*DeleteACLResponse, error)
+	ListACLs(ctx context.Context, in *ListACLsRequest, opts ...grpc.CallOption) (*ListACLsResponse, error)
+	// DescribePartition returns the replicas of a partition, as seen by its leader.
+	DescribePartition(ctx context.Context, in *DescribePartitionRequest, opts ...grpc.CallOption) (*DescribePartitionResponse, error)
+	// ReassignPartition moves a partition to other brokers. It must be called on the leader
+	// of the partition and returns once the former replicas have deleted their copy.
+	ReassignPartition(ctx context.Context, in *ReassignPartitionRequest, opts ...grpc.CallOption) (*ReassignPartitionResponse, error)
+	// DeleteRecords deletes the messages of a partition before an offset up to the high watermark,
+	// it must be called on the leader of the partition.
+	DeleteRecords(ctx context.Context, in *DeleteRecordsRequest, opts ...grpc.CallOption) (*DeleteRecordsResponse, error)
+	// ReloadConfig reads the config file of the broker again and applies the settings which
+	// change without a restart, like SIGHUP does.
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+}
+
+type irisAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIrisAdminClient(cc grpc.ClientConnInterface) IrisAdminClient {
+	return &irisAdminClient{cc}
+}
+
+func (c *irisAdminClient) CreateACL(ctx context.Context, in *CreateACLRequest, opts ...grpc.CallOption) (*CreateACLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateACLResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_CreateACL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) DeleteACL(ctx context.Context, in *DeleteACLRequest, opts ...grpc.CallOption) (*DeleteACLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteACLResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_DeleteACL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) ListACLs(ctx context.Context, in *ListACLsRequest, opts ...grpc.CallOption) (*ListACLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListACLsResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_ListACLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) DescribePartition(ctx context.Context, in *DescribePartitionRequest, opts ...grpc.CallOption) (*DescribePartitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribePartitionResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_DescribePartition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) ReassignPartition(ctx context.Context, in *ReassignPartitionRequest, opts ...grpc.CallOption) (*ReassignPartitionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReassignPartitionResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_ReassignPartition_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) DeleteRecords(ctx context.Context, in *DeleteRecordsRequest, opts ...grpc.CallOption) (*DeleteRecordsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRecordsResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_DeleteRecords_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisAdminClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, IrisAdmin_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IrisAdminServer is the server API for IrisAdmin service.
+// All implementations must embed UnimplementedIrisAdminServer
+// for forward compatibility.
+//
+// IrisAdmin manages the broker, its calls need admin rights on the cluster.
+type IrisAdminServer interface {
+	CreateACL(context.Context, *CreateACLRequest) (*CreateACLResponse, error)
+	DeleteACL(context.Context, *DeleteACLRequest) (*DeleteACLResponse, error)
+	ListACLs(context.Context, *ListACLsRequest) (*ListACLsResponse, error)
+	// DescribePartition returns the replicas of a partition, as seen by its leader.
+	DescribePartition(context.Context, *DescribePartitionRequest) (*DescribePartitionResponse, error)
+	// ReassignPartition moves a partition to other brokers. It must be called on the leader
+	// of the partition and returns once the former replicas have deleted their copy.
+	ReassignPartition(context.Context, *ReassignPartitionRequest) (*ReassignPartitionResponse, error)
+	// DeleteRecords deletes the messages of a partition before an offset up to the high watermark,
+	// it must be called on the leader of the partition.
+	DeleteRecords(context.Context, *DeleteRecordsRequest) (*DeleteRecordsResponse, error)
+	// ReloadConfig reads the config file of the broker again and applies the settings which
+	// change without a restart, like SIGHUP does.
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	mustEmbedUnimplementedIrisAdminServer()
+}
+
+// UnimplementedIrisAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIrisAdminServer struct{}
+
+func (UnimplementedIrisAdminServer) CreateACL(context.Context, *CreateACLRequest) (*CreateACLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateACL not implemented")
+}
+func (UnimplementedIrisAdminServer) DeleteACL(context.Context, *DeleteACLRequest) (*DeleteACLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteACL not implemented")
+}
+func (UnimplementedIrisAdminServer) ListACLs(context.Context, *ListACLsRequest) (*ListACLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListACLs not implemented")
+}
+func (UnimplementedIrisAdminServer) DescribePartition(context.Context, *DescribePartitionRequest) (*DescribePartitionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DescribePartition not implemented")
+}
+func (UnimplementedIrisAdminServer) ReassignPartition(context.Context, *ReassignPartitionRequest) (*ReassignPartitionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReassignPartition not implemented")
+}
+func (UnimplementedIrisAdminServer) DeleteRecords(context.Context, *DeleteRecordsRequest) (*DeleteRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecords not implemented")
+}
+func (UnimplementedIrisAdminServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedIrisAdminServer) mustEmbedUnimplementedIrisAdminServer() {}
+func (UnimplementedIrisAdminServer) testEmbeddedByValue()                   {}
+
+// UnsafeIrisAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IrisAdminServer will
+// result in compilation errors.
+type UnsafeIrisAdminServer interface {
+	mustEmbedUnimplementedIrisAdminServer()
+}
+
+func RegisterIrisAdminServer(s grpc.ServiceRegistrar, srv IrisAdminServer) {
+	// If the following call pancis, it indicates UnimplementedIrisAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IrisAdmin_ServiceDesc, srv)
+}
+
+func _IrisAdmin_CreateACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).CreateACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_CreateACL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).CreateACL(ctx, req.(*CreateACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_DeleteACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteACLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).DeleteACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_DeleteACL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).DeleteACL(ctx, req.(*DeleteACLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_ListACLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListACLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).ListACLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_ListACLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).ListACLs(ctx, req.(*ListACLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_DescribePartition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribePartitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).DescribePartition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_DescribePartition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).DescribePartition(ctx, req.(*DescribePartitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_ReassignPartition_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReassignPartitionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).ReassignPartition(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_ReassignPartition_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).ReassignPartition(ctx, req.(*ReassignPartitionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_DeleteRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).DeleteRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_DeleteRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).DeleteRecords(ctx, req.(*DeleteRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisAdmin_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisAdminServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisAdmin_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisAdminServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IrisAdmin_ServiceDesc is the grpc.ServiceDesc for IrisAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IrisAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iris.v1.IrisAdmin",
+	HandlerType: (*IrisAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateACL",
+			Handler:    _IrisAdmin_CreateACL_Handler,
+		},
+		{
+			MethodName: "DeleteACL",
+			Handler:    _IrisAdmin_DeleteACL_Handler,
+		},
+		{
+			MethodName: "ListACLs",
+			Handler:    _IrisAdmin_ListACLs_Handler,
+		},
+		{
+			MethodName: "DescribePartition",
+			Handler:    _IrisAdmin_DescribePartition_Handler,
+		},
+		{
+			MethodName: "ReassignPartition",
+			Handler:    _IrisAdmin_ReassignPartition_Handler,
+		},
+		{
+			MethodName: "DeleteRecords",
+			Handler:    _IrisAdmin_DeleteRecords_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _IrisAdmin_ReloadConfig_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iris.proto",
+}
+
+const (
+	IrisReplication_ReplicaFetch_FullMethodName    = "/iris.v1.IrisReplication/ReplicaFetch"
+	IrisReplication_SegmentFiles_FullMethodName    = "/iris.v1.IrisReplication/SegmentFiles"
+	IrisReplication_ReadSegmentFile_FullMethodName = "/iris.v1.IrisReplication/ReadSegmentFile"
+	IrisReplication_SetReplicas_FullMethodName     = "/iris.v1.IrisReplication/SetReplicas"
+	IrisReplication_SetLeader_FullMethodName       = "/iris.v1.IrisReplication/SetLeader"
+)
+
+// IrisReplicationClient is the client API for IrisReplication service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IrisReplication is used by brokers following partitions, its calls need admin rights on the cluster.
+type IrisReplicationClient interface {
+	// ReplicaFetch returns the messages of a partition from the offset of a follower on,
+	// the offset reports that the follower has all messages before it.
+	ReplicaFetch(ctx context.Context, in *ReplicaFetchRequest, opts ...grpc.CallOption) (*ReplicaFetchResponse, error)
+	// SegmentFiles lists the files of the sealed segments of a partition, which new replicas copy in bulk.
+	SegmentFiles(ctx context.Context, in *SegmentFilesRequest, opts ...grpc.CallOption) (*SegmentFilesResponse, error)
+	// ReadSegmentFile returns a chunk of a file listed by SegmentFiles.
+	ReadSegmentFile(ctx context.Context, in *ReadSegmentFileRequest, opts ...grpc.CallOption) (*ReadSegmentFileResponse, error)
+	// SetReplicas and SetLeader change a partition on the broker during a reassignment.
+	SetReplicas(ctx context.Context, in *SetReplicasRequest, opts ...grpc.CallOption) (*SetReplicasResponse, error)
+	SetLeader(ctx context.Context, in *SetLeaderRequest, opts ...grpc.CallOption) (*SetLeaderResponse, error)
+}
+
+type irisReplicationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIrisReplicationClient(cc grpc.ClientConnInterface) IrisReplicationClient {
+	return &irisReplicationClient{cc}
+}
+
+func (c *irisReplicationClient) ReplicaFetch(ctx context.Context, in *ReplicaFetchRequest, opts ...grpc.CallOption) (*ReplicaFetchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaFetchResponse)
+	err := c.cc.Invoke(ctx, IrisReplication_ReplicaFetch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisReplicationClient) SegmentFiles(ctx context.Context, in *SegmentFilesRequest, opts ...grpc.CallOption) (*SegmentFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SegmentFilesResponse)
+	err := c.cc.Invoke(ctx, IrisReplication_SegmentFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisReplicationClient) ReadSegmentFile(ctx context.Context, in *ReadSegmentFileRequest, opts ...grpc.CallOption) (*ReadSegmentFileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadSegmentFileResponse)
+	err := c.cc.Invoke(ctx, IrisReplication_ReadSegmentFile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisReplicationClient) SetReplicas(ctx context.Context, in *SetReplicasRequest, opts ...grpc.CallOption) (*SetReplicasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetReplicasResponse)
+	err := c.cc.Invoke(ctx, IrisReplication_SetReplicas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisReplicationClient) SetLeader(ctx context.Context, in *SetLeaderRequest, opts ...grpc.CallOption) (*SetLeaderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetLeaderResponse)
+	err := c.cc.Invoke(ctx, IrisReplication_SetLeader_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IrisReplicationServer is the server API for IrisReplication service.
+// All implementations must embed UnimplementedIrisReplicationServer
+// for forward compatibility.
+//
+// IrisReplication is used by brokers following partitions, its calls need admin rights on the cluster.
+type IrisReplicationServer interface {
+	// ReplicaFetch returns the messages of a partition from the offset of a follower on,
+	// the offset reports that the follower has all messages before it.
+	ReplicaFetch(context.Context, *ReplicaFetchRequest) (*ReplicaFetchResponse, error)
+	// SegmentFiles lists the files of the sealed segments of a partition, which new replicas copy in bulk.
+	SegmentFiles(context.Context, *SegmentFilesRequest) (*SegmentFilesResponse, error)
+	// ReadSegmentFile returns a chunk of a file listed by SegmentFiles.
+	ReadSegmentFile(context.Context, *ReadSegmentFileRequest) (*ReadSegmentFileResponse, error)
+	// SetReplicas and SetLeader change a partition on the broker during a reassignment.
+	SetReplicas(context.Context, *SetReplicasRequest) (*SetReplicasResponse, error)
+	SetLeader(context.Context, *SetLeaderRequest) (*SetLeaderResponse, error)
+	mustEmbedUnimplementedIrisReplicationServer()
+}
+
+// UnimplementedIrisReplicationServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIrisReplicationServer struct{}
+
+func (UnimplementedIrisReplicationServer) ReplicaFetch(context.Context, *ReplicaFetchRequest) (*ReplicaFetchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicaFetch not implemented")
+}
+func (UnimplementedIrisReplicationServer) SegmentFiles(context.Context, *SegmentFilesRequest) (*SegmentFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SegmentFiles not implemented")
+}
+func (UnimplementedIrisReplicationServer) ReadSegmentFile(context.Context, *ReadSegmentFileRequest) (*ReadSegmentFileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadSegmentFile not implemented")
+}
+func (UnimplementedIrisReplicationServer) SetReplicas(context.Context, *SetReplicasRequest) (*SetReplicasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetReplicas not implemented")
+}
+func (UnimplementedIrisReplicationServer) SetLeader(context.Context, *SetLeaderRequest) (*SetLeaderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLeader not implemented")
+}
+func (UnimplementedIrisReplicationServer) mustEmbedUnimplementedIrisReplicationServer() {}
+func (UnimplementedIrisReplicationServer) testEmbeddedByValue()                         {}
+
+// UnsafeIrisReplicationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IrisReplicationServer will
+// result in compilation errors.
+type UnsafeIrisReplicationServer interface {
+	mustEmbedUnimplementedIrisReplicationServer()
+}
+
+func RegisterIrisReplicationServer(s grpc.ServiceRegistrar, srv IrisReplicationServer) {
+	// If the following call pancis, it indicates UnimplementedIrisReplicationServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IrisReplication_ServiceDesc, srv)
+}
+
+func _IrisReplication_ReplicaFetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaFetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisReplicationServer).ReplicaFetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisReplication_ReplicaFetch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisReplicationServer).ReplicaFetch(ctx, req.(*ReplicaFetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisReplication_SegmentFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SegmentFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisReplicationServer).SegmentFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisReplication_SegmentFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisReplicationServer).SegmentFiles(ctx, req.(*SegmentFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisReplication_ReadSegmentFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadSegmentFileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisReplicationServer).ReadSegmentFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisReplication_ReadSegmentFile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisReplicationServer).ReadSegmentFile(ctx, req.(*ReadSegmentFileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisReplication_SetReplicas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetReplicasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisReplicationServer).SetReplicas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisReplication_SetReplicas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisReplicationServer).SetReplicas(ctx, req.(*SetReplicasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisReplication_SetLeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisReplicationServer).SetLeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisReplication_SetLeader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisReplicationServer).SetLeader(ctx, req.(*SetLeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IrisReplication_ServiceDesc is the grpc.ServiceDesc for IrisReplication service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IrisReplication_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iris.v1.IrisReplication",
+	HandlerType: (*IrisReplicationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReplicaFetch",
+			Handler:    _IrisReplication_ReplicaFetch_Handler,
+		},
+		{
+			MethodName: "SegmentFiles",
+			Handler:    _IrisReplication_SegmentFiles_Handler,
+		},
+		{
+			MethodName: "ReadSegmentFile",
+			Handler:    _IrisReplication_ReadSegmentFile_Handler,
+		},
+		{
+			MethodName: "SetReplicas",
+			Handler:    _IrisReplication_SetReplicas_Handler,
+		},
+		{
+			MethodName: "SetLeader",
+			Handler:    _IrisReplication_SetLeader_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iris.proto",
+}
+
+const (
+	IrisRaft_RequestVote_FullMethodName     = "/iris.v1.IrisRaft/RequestVote"
+	IrisRaft_AppendEntries_FullMethodName   = "/iris.v1.IrisRaft/AppendEntries"
+	IrisRaft_InstallSnapshot_FullMethodName = "/iris.v1.IrisRaft/InstallSnapshot"
+)
+
+// IrisRaftClient is the client API for IrisRaft service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// IrisRaft is used by the controllers replicating the cluster metadata, its calls need admin rights on the cluster.
+type IrisRaftClient interface {
+	RequestVote(ctx context.Context, in *RequestVoteRequest, opts ...grpc.CallOption) (*RequestVoteResponse, error)
+	AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error)
+	InstallSnapshot(ctx context.Context, in *InstallSnapshotRequest, opts ...grpc.CallOption) (*InstallSnapshotResponse, error)
+}
+
+type irisRaftClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIrisRaftClient(cc grpc.ClientConnInterface) IrisRaftClient {
+	return &irisRaftClient{cc}
+}
+
+func (c *irisRaftClient) RequestVote(ctx context.Context, in *RequestVoteRequest, opts ...grpc.CallOption) (*RequestVoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestVoteResponse)
+	err := c.cc.Invoke(ctx, IrisRaft_RequestVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisRaftClient) AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendEntriesResponse)
+	err := c.cc.Invoke(ctx, IrisRaft_AppendEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *irisRaftClient) InstallSnapshot(ctx context.Context, in *InstallSnapshotRequest, opts ...grpc.CallOption) (*InstallSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InstallSnapshotResponse)
+	err := c.cc.Invoke(ctx, IrisRaft_InstallSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IrisRaftServer is the server API for IrisRaft service.
+// All implementations must embed UnimplementedIrisRaftServer
+// for forward compatibility.
+//
+// IrisRaft is used by the controllers replicating the cluster metadata, its calls need admin rights on the cluster.
+type IrisRaftServer interface {
+	RequestVote(context.Context, *RequestVoteRequest) (*RequestVoteResponse, error)
+	AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error)
+	InstallSnapshot(context.Context, *InstallSnapshotRequest) (*InstallSnapshotResponse, error)
+	mustEmbedUnimplementedIrisRaftServer()
+}
+
+// UnimplementedIrisRaftServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIrisRaftServer struct{}
+
+func (UnimplementedIrisRaftServer) RequestVote(context.Context, *RequestVoteRequest) (*RequestVoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedIrisRaftServer) AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedIrisRaftServer) InstallSnapshot(context.Context, *InstallSnapshotRequest) (*InstallSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InstallSnapshot not implemented")
+}
+func (UnimplementedIrisRaftServer) mustEmbedUnimplementedIrisRaftServer() {}
+func (UnimplementedIrisRaftServer) testEmbeddedByValue()                  {}
+
+// UnsafeIrisRaftServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IrisRaftServer will
+// result in compilation errors.
+type UnsafeIrisRaftServer interface {
+	mustEmbedUnimplementedIrisRaftServer()
+}
+
+func RegisterIrisRaftServer(s grpc.ServiceRegistrar, srv IrisRaftServer) {
+	// If the following call pancis, it indicates UnimplementedIrisRaftServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&IrisRaft_ServiceDesc, srv)
+}
+
+func _IrisRaft_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisRaftServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisRaft_RequestVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisRaftServer).RequestVote(ctx, req.(*RequestVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisRaft_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisRaftServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisRaft_AppendEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisRaftServer).AppendEntries(ctx, req.(*AppendEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IrisRaft_InstallSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InstallSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IrisRaftServer).InstallSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IrisRaft_InstallSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IrisRaftServer).InstallSnapshot(ctx, req.(*InstallSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IrisRaft_ServiceDesc is the grpc.ServiceDesc for IrisRaft service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IrisRaft_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "iris.v1.IrisRaft",
+	HandlerType: (*IrisRaftServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RequestVote",
+			Handler:    _IrisRaft_RequestVote_Handler,
+		},
+		{
+			MethodName: "AppendEntries",
+			Handler:    _IrisRaft_AppendEntries_Handler,
+		},
+		{
+			MethodName: "InstallSnapshot",
+			Handler:    _IrisRaft_InstallSnapshot_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "iris.proto",
+}
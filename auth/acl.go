@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Operation string
+
+const (
+	OperationProduce Operation = "produce"
+	OperationConsume Operation = "consume"
+	// Admin allows changing the resource and implies all other operations on it.
+	OperationAdmin Operation = "admin"
+)
+
+type ResourceType string
+
+const (
+	ResourceTopic ResourceType = "topic"
+	ResourceGroup ResourceType = "group"
+	// The cluster is a single resource without a name, admin on it allows managing ACLs.
+	ResourceCluster ResourceType = "cluster"
+)
+
+type PatternType string
+
+const (
+	PatternLiteral PatternType = "literal"
+	PatternPrefix  PatternType = "prefix"
+)
+
+const (
+	// WildcardPrincipal grants an ACL to every principal, including the anonymous one.
+	WildcardPrincipal = "*"
+
+	// The ACL table is labelled like the internal topics of the broker.
+	aclsTableName = "__acls"
+)
+
+var (
+	PermissionDenied = errors.New("Permission denied")
+	InvalidACL       = errors.New("Invalid ACL")
+)
+
+// ACL grants a principal an operation on the resources whose name matches.
+type ACL struct {
+	Principal    string       `json:"principal"`
+	ResourceType ResourceType `json:"resourceType"`
+	PatternType  PatternType  `json:"patternType"`
+	Name         string       `json:"name"`
+	Operation    Operation    `json:"operation"`
+}
+
+func (a ACL) validate() error {
+	if a.Principal == "" {
+		return errors.Wrap(InvalidACL, "no principal")
+	}
+
+	switch a.ResourceType {
+	case ResourceTopic, ResourceGroup:
+		if a.Name == "" && a.PatternType != PatternPrefix {
+			return errors.Wrapf(InvalidACL, "no %s name", a.ResourceType)
+		}
+	case ResourceCluster:
+		if a.Name != "" {
+			return errors.Wrap(InvalidACL, "the cluster has no name")
+		}
+	default:
+		return errors.Wrapf(InvalidACL, "unknown resource type %q", a.ResourceType)
+	}
+
+	switch a.PatternType {
+	case PatternLiteral, PatternPrefix:
+	default:
+		return errors.Wrapf(InvalidACL, "unknown pattern type %q", a.PatternType)
+	}
+
+	switch a.Operation {
+	case OperationProduce, OperationConsume, OperationAdmin:
+	default:
+		return errors.Wrapf(InvalidACL, "unknown operation %q", a.Operation)
+	}
+
+	return nil
+}
+
+func (a ACL) key() string {
+	// Field order is fixed, so the same ACL always has the same key.
+	key, _ := json.Marshal(a)
+	return string(key)
+}
+
+func (a ACL) matches(principal string, op Operation, resourceType ResourceType, name string) bool {
+	if a.Principal != WildcardPrincipal && a.Principal != principal {
+		return false
+	}
+
+	if a.ResourceType != resourceType || (a.Operation != op && a.Operation != OperationAdmin) {
+		return false
+	}
+
+	if a.PatternType == PatternPrefix {
+		return strings.HasPrefix(name, a.Name)
+	}
+
+	return a.Name == name
+}
+
+// Authorizer decides by ACLs whether principals may perform operations on resources.
+// ACLs are kept in a table, super users are allowed everything.
+type Authorizer struct {
+	logger     log.Logger
+	metrics    *AuthorizerMetrics
+	superUsers map[string]bool
+
+	table *storage.Table
+	mutex sync.RWMutex
+	acls  map[string]ACL
+}
+
+type AuthorizerMetrics struct {
+	denied *prometheus.CounterVec
+}
+
+func OpenAuthorizer(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int, superUsers []string) (*Authorizer, error) {
+	tableRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"topic": aclsTableName, "partition": "0"}, registerer)
+	table, err := storage.OpenTable(logger, tableRegisterer, dir, segmentSize)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open ACL table")
+	}
+
+	a := &Authorizer{
+		logger:     logger,
+		metrics:    NewAuthorizerMetrics(prometheus.WrapRegistererWithPrefix("auth_", registerer)),
+		superUsers: make(map[string]bool),
+		table:      table,
+		acls:       make(map[string]ACL),
+	}
+
+	for _, name := range superUsers {
+		a.superUsers[name] = true
+	}
+
+	var loadErr error
+
+	table.Range("", func(key string, value []byte) bool {
+		var acl ACL
+
+		if loadErr = json.Unmarshal(value, &acl); loadErr != nil {
+			loadErr = errors.Wrapf(loadErr, "invalid ACL %s", key)
+			return false
+		}
+
+		a.acls[key] = acl
+
+		return true
+	})
+
+	if loadErr != nil {
+		table.Stop()
+		return nil, loadErr
+	}
+
+	return a, nil
+}
+
+func NewAuthorizerMetrics(registerer prometheus.Registerer) *AuthorizerMetrics {
+	m := &AuthorizerMetrics{}
+
+	m.denied = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authorization_denied_total",
+		Help: "Total number of operations denied by ACLs.",
+	}, []string{"operation", "resource_type"})
+
+	if registerer != nil {
+		registerer.MustRegister(m.denied)
+	}
+
+	return m
+}
+
+// Authorize returns PermissionDenied unless the principal may perform the operation on the resource.
+func (a *Authorizer) Authorize(p Principal, op Operation, resourceType ResourceType, name string) error {
+	if !p.Anonymous() && a.superUsers[p.Name] {
+		return nil
+	}
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	principal := p.Name
+
+	// The anonymous principal is only matched by its own name, so a certificate
+	// or token principal can't be named like it to gain its rights.
+	if p.Anonymous() {
+		principal = AnonymousName
+	}
+
+	for _, acl := range a.acls {
+		if acl.matches(principal, op, resourceType, name) {
+			return nil
+		}
+	}
+
+	a.metrics.denied.WithLabelValues(string(op), string(resourceType)).Inc()
+	level.Debug(a.logger).Log("msg", "operation denied", "principal", p, "operation", op, "resourceType", resourceType, "name", name)
+
+	if name == "" {
+		return errors.Wrapf(PermissionDenied, "%s may not %s the %s", p, op, resourceType)
+	}
+
+	return errors.Wrapf(PermissionDenied, "%s may not %s %s %s", p, op, resourceType, name)
+}
+
+// Grant adds the ACL, granting it again has no effect.
+func (a *Authorizer) Grant(acl ACL) error {
+	if err := acl.validate(); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(acl)
+
+	if err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := acl.key()
+
+	if _, ok := a.acls[key]; ok {
+		return nil
+	}
+
+	if err := a.table.Put(key, value); err != nil {
+		return err
+	}
+
+	a.acls[key] = acl
+
+	level.Info(a.logger).Log("msg", "ACL granted", "principal", acl.Principal, "operation", acl.Operation, "resourceType", acl.ResourceType, "pattern", acl.PatternType, "name", acl.Name)
+
+	return nil
+}
+
+// Revoke removes the ACL and reports whether it existed.
+func (a *Authorizer) Revoke(acl ACL) (bool, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	key := acl.key()
+
+	if _, ok := a.acls[key]; !ok {
+		return false, nil
+	}
+
+	if err := a.table.Delete(key); err != nil {
+		return false, err
+	}
+
+	delete(a.acls, key)
+
+	level.Info(a.logger).Log("msg", "ACL revoked", "principal", acl.Principal, "operation", acl.Operation, "resourceType", acl.ResourceType, "pattern", acl.PatternType, "name", acl.Name)
+
+	return true, nil
+}
+
+// ACLs returns all ACLs, sorted by principal, resource and operation.
+func (a *Authorizer) ACLs() []ACL {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	acls := make([]ACL, 0, len(a.acls))
+
+	for _, acl := range a.acls {
+		acls = append(acls, acl)
+	}
+
+	sort.Slice(acls, func(i, j int) bool {
+		return acls[i].key() < acls[j].key()
+	})
+
+	return acls
+}
+
+func (a *Authorizer) Stop() error {
+	return a.table.Stop()
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	MethodAnonymous   = "anonymous"
+	MethodCertificate = "certificate"
+	MethodToken       = "token"
+	MethodJWT         = "jwt"
+
+	// AnonymousName is the name of the principal of unauthenticated connections.
+	AnonymousName = "anonymous"
+)
+
+var (
+	Unauthenticated = errors.New("Unauthenticated")
+	InvalidToken    = errors.New("Invalid token")
+)
+
+// Principal is the authenticated identity of a client.
+type Principal struct {
+	Name string
+	// Method is how the principal has been authenticated.
+	Method string
+}
+
+func (p Principal) String() string {
+	return p.Method + ":" + p.Name
+}
+
+// Anonymous reports whether the client has not been authenticated.
+func (p Principal) Anonymous() bool {
+	return p.Method == MethodAnonymous
+}
+
+type principalKey struct{}
+
+// NewContext returns a context carrying the principal.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of the request the context belongs to.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type Options struct {
+	// TokensFile holds static bearer tokens, see LoadTokens.
+	TokensFile string
+	JWT        JWTOptions
+	// AllowAnonymous lets clients without credentials in as the anonymous principal.
+	AllowAnonymous bool
+}
+
+// Authenticator finds out the principal of a request from its bearer token
+// or the verified client certificate of its connection.
+type Authenticator struct {
+	logger  log.Logger
+	options Options
+	metrics *AuthMetrics
+
+	tokens *Tokens
+	jwt    *JWTVerifier
+}
+
+type AuthMetrics struct {
+	authentications *prometheus.CounterVec
+}
+
+func NewAuthenticator(logger log.Logger, registerer prometheus.Registerer, options Options) (*Authenticator, error) {
+	a := &Authenticator{
+		logger:  logger,
+		options: options,
+		metrics: NewAuthMetrics(prometheus.WrapRegistererWithPrefix("auth_", registerer)),
+	}
+
+	if options.TokensFile != "" {
+		tokens, err := LoadTokens(options.TokensFile)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load tokens")
+		}
+
+		a.tokens = tokens
+	}
+
+	if options.JWT.JWKSFile != "" {
+		v, err := NewJWTVerifier(options.JWT)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to load JWKS")
+		}
+
+		a.jwt = v
+	}
+
+	return a, nil
+}
+
+func NewAuthMetrics(registerer prometheus.Registerer) *AuthMetrics {
+	m := &AuthMetrics{}
+
+	m.authentications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "authentications_total",
+		Help: "Total number of authenticated requests by method and result.",
+	}, []string{"method", "result"})
+
+	if registerer != nil {
+		registerer.MustRegister(m.authentications)
+	}
+
+	return m
+}
+
+// Authenticate returns the principal of a request with the given authorization
+// header on a connection with the given TLS state, which is nil without TLS.
+// A bearer token takes precedence over the client certificate.
+func (a *Authenticator) Authenticate(authorization string, state *tls.ConnectionState) (Principal, error) {
+	p, err := a.authenticate(authorization, state)
+
+	result := "success"
+
+	if err != nil {
+		result = "failure"
+		level.Debug(a.logger).Log("msg", "authentication failed", "method", p.Method, "err", err)
+	}
+
+	a.metrics.authentications.WithLabelValues(p.Method, result).Inc()
+
+	return p, err
+}
+
+func (a *Authenticator) authenticate(authorization string, state *tls.ConnectionState) (Principal, error) {
+	if authorization != "" {
+		token, ok := bearerToken(authorization)
+
+		if !ok {
+			return Principal{Method: MethodToken}, errors.Wrap(Unauthenticated, "expected a bearer token")
+		}
+
+		return a.authenticateToken(token)
+	}
+
+	if name, ok := certificatePrincipal(state); ok {
+		return Principal{Name: name, Method: MethodCertificate}, nil
+	}
+
+	if a.options.AllowAnonymous {
+		return Principal{Name: AnonymousName, Method: MethodAnonymous}, nil
+	}
+
+	return Principal{Method: MethodAnonymous}, errors.Wrap(Unauthenticated, "no credentials")
+}
+
+// authenticateToken checks static tokens before JWTs, which are recognized by their three parts.
+func (a *Authenticator) authenticateToken(token string) (Principal, error) {
+	if a.tokens != nil {
+		if name, ok := a.tokens.Lookup(token); ok {
+			return Principal{Name: name, Method: MethodToken}, nil
+		}
+	}
+
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		name, err := a.jwt.Verify(token)
+
+		if err != nil {
+			return Principal{Method: MethodJWT}, errors.Wrap(Unauthenticated, err.Error())
+		}
+
+		return Principal{Name: name, Method: MethodJWT}, nil
+	}
+
+	return Principal{Method: MethodToken}, errors.Wrap(Unauthenticated, InvalidToken.Error())
+}
+
+func bearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
+
+// certificatePrincipal returns the common name of a verified client certificate.
+func certificatePrincipal(state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	name := state.VerifiedChains[0][0].Subject.CommonName
+
+	return name, name != ""
+}
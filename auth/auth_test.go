@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJWKS(t *testing.T, dir string, key *ecdsa.PrivateKey) string {
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &key.PublicKey, KeyID: "test", Algorithm: string(jose.ES256), Use: "sig"},
+	}})
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+
+	return file
+}
+
+func signJWT(t *testing.T, key *ecdsa.PrivateKey, claims jwt.Claims) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "test"),
+	)
+	require.NoError(t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestAuthenticator(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auth_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokensFile := filepath.Join(dir, "tokens")
+	require.NoError(t, os.WriteFile(tokensFile, []byte("# principal token\nbilling secret-1\n\nshipping secret-2\n"), 0o600))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	a, err := NewAuthenticator(log.NewNopLogger(), prometheus.NewRegistry(), Options{
+		TokensFile: tokensFile,
+		JWT:        JWTOptions{JWKSFile: writeJWKS(t, dir, key), Issuer: "https://issuer", Audience: "iris"},
+	})
+	require.NoError(t, err)
+
+	p, err := a.Authenticate("Bearer secret-2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Name: "shipping", Method: MethodToken}, p)
+
+	_, err = a.Authenticate("Bearer secret-3", nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	_, err = a.Authenticate("Basic YTpi", nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	_, err = a.Authenticate("", nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	now := time.Now()
+	claims := jwt.Claims{
+		Subject:  "analytics",
+		Issuer:   "https://issuer",
+		Audience: jwt.Audience{"iris"},
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+
+	p, err = a.Authenticate("Bearer "+signJWT(t, key, claims), nil)
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Name: "analytics", Method: MethodJWT}, p)
+
+	expired := claims
+	expired.Expiry = jwt.NewNumericDate(now.Add(-time.Hour))
+	_, err = a.Authenticate("Bearer "+signJWT(t, key, expired), nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	wrongAudience := claims
+	wrongAudience.Audience = jwt.Audience{"other"}
+	_, err = a.Authenticate("Bearer "+signJWT(t, key, wrongAudience), nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = a.Authenticate("Bearer "+signJWT(t, other, claims), nil)
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "orders-service"}}
+	state := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	p, err = a.Authenticate("", state)
+	require.NoError(t, err)
+	assert.Equal(t, Principal{Name: "orders-service", Method: MethodCertificate}, p)
+
+	// Unverified certificates are not trusted.
+	_, err = a.Authenticate("", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	assert.ErrorIs(t, err, Unauthenticated)
+
+	// Tokens take precedence over certificates.
+	p, err = a.Authenticate("Bearer secret-1", state)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", p.Name)
+}
+
+func TestAuthenticatorAnonymous(t *testing.T) {
+	a, err := NewAuthenticator(log.NewNopLogger(), nil, Options{AllowAnonymous: true})
+	require.NoError(t, err)
+
+	p, err := a.Authenticate("", nil)
+	require.NoError(t, err)
+	assert.True(t, p.Anonymous())
+
+	// Bad credentials are rejected even though anonymous clients are let in.
+	_, err = a.Authenticate("Bearer unknown", nil)
+	assert.True(t, errors.Is(err, Unauthenticated))
+
+	ctx := NewContext(context.Background(), p)
+	got, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, p, got)
+
+	_, ok = FromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestAuthorizer(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auth_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	registry := prometheus.NewRegistry()
+
+	a, err := OpenAuthorizer(log.NewNopLogger(), registry, dir, 32*1024*4, []string{"root"})
+	require.NoError(t, err)
+
+	billing := Principal{Name: "billing", Method: MethodToken}
+	anonymous := Principal{Name: AnonymousName, Method: MethodAnonymous}
+
+	assert.NoError(t, a.Authorize(Principal{Name: "root", Method: MethodToken}, OperationAdmin, ResourceCluster, ""))
+
+	err = a.Authorize(billing, OperationProduce, ResourceTopic, "invoices")
+	assert.ErrorIs(t, err, PermissionDenied)
+
+	require.NoError(t, a.Grant(ACL{Principal: "billing", ResourceType: ResourceTopic, PatternType: PatternPrefix, Name: "billing.", Operation: OperationProduce}))
+	require.NoError(t, a.Grant(ACL{Principal: "billing", ResourceType: ResourceGroup, PatternType: PatternLiteral, Name: "invoicing", Operation: OperationAdmin}))
+	require.NoError(t, a.Grant(ACL{Principal: WildcardPrincipal, ResourceType: ResourceTopic, PatternType: PatternLiteral, Name: "public", Operation: OperationConsume}))
+
+	// Granting twice has no effect.
+	require.NoError(t, a.Grant(ACL{Principal: WildcardPrincipal, ResourceType: ResourceTopic, PatternType: PatternLiteral, Name: "public", Operation: OperationConsume}))
+
+	err = a.Grant(ACL{Principal: "billing", ResourceType: ResourceCluster, PatternType: PatternLiteral, Name: "x", Operation: OperationAdmin})
+	assert.ErrorIs(t, err, InvalidACL)
+
+	err = a.Grant(ACL{Principal: "billing", ResourceType: ResourceTopic, PatternType: PatternLiteral, Name: "x", Operation: "delete"})
+	assert.ErrorIs(t, err, InvalidACL)
+
+	assert.NoError(t, a.Authorize(billing, OperationProduce, ResourceTopic, "billing.invoices"))
+	assert.ErrorIs(t, a.Authorize(billing, OperationConsume, ResourceTopic, "billing.invoices"), PermissionDenied)
+	assert.ErrorIs(t, a.Authorize(billing, OperationProduce, ResourceTopic, "shipping"), PermissionDenied)
+
+	// Admin implies the other operations.
+	assert.NoError(t, a.Authorize(billing, OperationConsume, ResourceGroup, "invoicing"))
+	assert.ErrorIs(t, a.Authorize(billing, OperationConsume, ResourceGroup, "invoicing-2"), PermissionDenied)
+
+	assert.NoError(t, a.Authorize(anonymous, OperationConsume, ResourceTopic, "public"))
+	assert.ErrorIs(t, a.Authorize(anonymous, OperationProduce, ResourceTopic, "public"), PermissionDenied)
+
+	// Super users must be authenticated.
+	assert.ErrorIs(t, a.Authorize(Principal{Name: "root", Method: MethodAnonymous}, OperationAdmin, ResourceCluster, ""), PermissionDenied)
+
+	denied, err := registry.Gather()
+	require.NoError(t, err)
+
+	var total float64
+
+	for _, family := range denied {
+		if family.GetName() == "auth_authorization_denied_total" {
+			for _, m := range family.GetMetric() {
+				total += m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	assert.Equal(t, float64(6), total)
+
+	found, err := a.Revoke(ACL{Principal: "billing", ResourceType: ResourceGroup, PatternType: PatternLiteral, Name: "invoicing", Operation: OperationAdmin})
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	require.NoError(t, a.Stop())
+
+	// ACLs are kept across restarts.
+	a, err = OpenAuthorizer(log.NewNopLogger(), nil, dir, 32*1024*4, nil)
+	require.NoError(t, err)
+	defer a.Stop()
+
+	assert.Len(t, a.ACLs(), 2)
+	assert.NoError(t, a.Authorize(billing, OperationProduce, ResourceTopic, "billing.invoices"))
+	assert.ErrorIs(t, a.Authorize(billing, OperationConsume, ResourceGroup, "invoicing"), PermissionDenied)
+}
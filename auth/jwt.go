@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPrincipalClaim = "sub"
+	defaultJWTLeeway      = time.Minute
+)
+
+var jwtAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+type JWTOptions struct {
+	// JWKSFile holds the public keys tokens are signed with as a JSON web key set.
+	JWKSFile string
+	// Issuer and Audience are checked if they are set.
+	Issuer   string
+	Audience string
+	// PrincipalClaim names the string claim holding the principal, sub by default.
+	PrincipalClaim string
+	// Leeway allows for clock skew when checking the expiry of tokens.
+	Leeway time.Duration
+}
+
+// JWTVerifier verifies signed JWTs with keys of a local JWKS file.
+type JWTVerifier struct {
+	options JWTOptions
+	keys    *jose.JSONWebKeySet
+}
+
+func NewJWTVerifier(options JWTOptions) (*JWTVerifier, error) {
+	data, err := os.ReadFile(options.JWKSFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var keys jose.JSONWebKeySet
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, errors.Wrapf(err, "invalid JWKS %s", options.JWKSFile)
+	}
+
+	for _, key := range keys.Keys {
+		if !key.IsPublic() {
+			return nil, errors.Errorf("JWKS %s holds the private key %q", options.JWKSFile, key.KeyID)
+		}
+	}
+
+	if options.PrincipalClaim == "" {
+		options.PrincipalClaim = defaultPrincipalClaim
+	}
+
+	if options.Leeway == 0 {
+		options.Leeway = defaultJWTLeeway
+	}
+
+	return &JWTVerifier{options: options, keys: &keys}, nil
+}
+
+// Verify checks the signature and the claims of the token and returns its principal.
+func (v *JWTVerifier) Verify(token string) (string, error) {
+	parsed, err := jwt.ParseSigned(token, jwtAlgorithms)
+
+	if err != nil {
+		return "", errors.Wrap(InvalidToken, err.Error())
+	}
+
+	var claims jwt.Claims
+	var custom map[string]interface{}
+
+	if err := parsed.Claims(v.keys, &claims, &custom); err != nil {
+		return "", errors.Wrap(InvalidToken, err.Error())
+	}
+
+	expected := jwt.Expected{Issuer: v.options.Issuer, Time: time.Now()}
+
+	if v.options.Audience != "" {
+		expected.AnyAudience = jwt.Audience{v.options.Audience}
+	}
+
+	if err := claims.ValidateWithLeeway(expected, v.options.Leeway); err != nil {
+		return "", errors.Wrap(InvalidToken, err.Error())
+	}
+
+	// Tokens without an expiry would be valid forever.
+	if claims.Expiry == nil {
+		return "", errors.Wrap(InvalidToken, "no expiry")
+	}
+
+	name, _ := custom[v.options.PrincipalClaim].(string)
+
+	if name == "" {
+		return "", errors.Wrapf(InvalidToken, "no %s claim", v.options.PrincipalClaim)
+	}
+
+	return name, nil
+}
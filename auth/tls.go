@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Client certificates are not asked for.
+	ClientAuthNone = "none"
+	// Client certificates are verified if clients send one.
+	ClientAuthVerify = "verify"
+	// Clients must send a certificate which can be verified.
+	ClientAuthRequire = "require"
+)
+
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the certificates client certificates are verified with.
+	ClientCAFile string
+	ClientAuth   string
+}
+
+// Enabled reports whether TLS is configured.
+func (o TLSOptions) Enabled() bool {
+	return o.CertFile != ""
+}
+
+// ServerConfig returns the TLS config of the listeners.
+func (o TLSOptions) ServerConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load server certificate")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	switch o.ClientAuth {
+	case "", ClientAuthNone:
+		return config, nil
+	case ClientAuthVerify:
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, errors.Errorf("unknown client auth %q", o.ClientAuth)
+	}
+
+	if o.ClientCAFile == "" {
+		return nil, errors.Errorf("client auth %s needs a client CA file", o.ClientAuth)
+	}
+
+	pem, err := os.ReadFile(o.ClientCAFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.ClientCAs = x509.NewCertPool()
+
+	if !config.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates in %s", o.ClientCAFile)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Tokens maps static bearer tokens to principals. Only digests of the tokens are kept.
+type Tokens struct {
+	digests map[[sha256.Size]byte]string
+}
+
+// LoadTokens reads a file with one principal and its token per line separated by
+// white space. Empty lines and lines starting with # are skipped.
+//
+//	# principal token
+//	billing  c2VjcmV0LXRva2Vu
+func LoadTokens(file string) (*Tokens, error) {
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	t := &Tokens{digests: make(map[[sha256.Size]byte]string)}
+	scanner := bufio.NewScanner(f)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+
+		if len(fields) != 2 {
+			return nil, errors.Errorf("%s:%d: expected a principal and a token", file, line)
+		}
+
+		digest := sha256.Sum256([]byte(fields[1]))
+
+		if _, ok := t.digests[digest]; ok {
+			return nil, errors.Errorf("%s:%d: duplicate token", file, line)
+		}
+
+		t.digests[digest] = fields[0]
+	}
+
+	return t, scanner.Err()
+}
+
+// Lookup returns the principal the token belongs to.
+func (t *Tokens) Lookup(token string) (string, bool) {
+	// Tokens are looked up by their digest, so lookups don't leak how much of a token matches.
+	name, ok := t.digests[sha256.Sum256([]byte(token))]
+
+	return name, ok
+}
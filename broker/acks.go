@@ -0,0 +1,85 @@
+package broker
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Acks is the durability producers ask for.
+type Acks int
+
+const (
+	// AcksLeader returns once the leader has appended the messages.
+	AcksLeader Acks = iota
+	// AcksNone is for producers which don't wait for a response. The broker appends
+	// the messages like AcksLeader, it is up to the protocol to not respond.
+	AcksNone
+	// AcksAll returns once all in-sync replicas have the messages. The messages are
+	// rejected if the partition has less than the min in-sync replicas of the topic.
+	AcksAll
+)
+
+var (
+	InvalidAcks       = errors.New("Invalid acks")
+	NotEnoughReplicas = errors.New("Not enough in-sync replicas")
+	// The messages have been appended, but the in-sync replicas shrank below the minimum meanwhile.
+	NotEnoughReplicasAfterAppend = errors.New("Not enough in-sync replicas after append")
+)
+
+// ParseAcks parses 0, leader (or 1) and all (or -1), an empty string is AcksLeader.
+func ParseAcks(s string) (Acks, error) {
+	switch s {
+	case "", "leader", "1":
+		return AcksLeader, nil
+	case "0":
+		return AcksNone, nil
+	case "all", "-1":
+		return AcksAll, nil
+	default:
+		return 0, errors.Wrapf(InvalidAcks, "%q", s)
+	}
+}
+
+func (a Acks) String() string {
+	switch a {
+	case AcksNone:
+		return "0"
+	case AcksAll:
+		return "all"
+	default:
+		return "leader"
+	}
+}
+
+// enoughReplicas returns the error if the partition has less in-sync replicas
+// than the topic requires, partitions without replicas have one.
+func (p *Partition) enoughReplicas(config TopicConfig, err error) error {
+	isr := 1
+
+	if p.replication != nil {
+		isr = len(p.ISR())
+	}
+
+	if isr < config.minInSyncReplicas() {
+		return errors.Wrapf(err, "%s has %d in-sync replicas, %d required", p, isr, config.minInSyncReplicas())
+	}
+
+	return nil
+}
+
+// waitReplicated waits until the high watermark of the partition has reached the end offset.
+func (b *Broker) waitReplicated(ctx context.Context, p *Partition, config TopicConfig, end uint64) error {
+	for p.HighWatermark() < end {
+		select {
+		case <-p.highWatermarkAdvanced(end - 1):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return BrokerClosed
+		}
+	}
+
+	// The high watermark also advances when replicas leave the in-sync replicas.
+	return p.enoughReplicas(config, NotEnoughReplicasAfterAppend)
+}
@@ -0,0 +1,793 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"iris/schema"
+	"iris/storage"
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	topicsTableName = internalTopicPrefix + "topics"
+	groupsTableName = internalTopicPrefix + "groups"
+	schemasDirName  = internalTopicPrefix + "schemas"
+
+	DefaultMaxFetchMessages = 500
+
+	// A fetch skips at most this many expired or filtered messages, so a fetch
+	// over a long expired prefix or which matches nothing does not scan the whole
+	// partition. The client continues from the returned next offset.
+	maxSkippedScan = 100 * DefaultMaxFetchMessages
+)
+
+var (
+	UnknownTopic       = errors.New("Unknown topic")
+	UnknownPartition   = errors.New("Unknown partition")
+	TopicExists        = errors.New("Topic already exists")
+	InvalidName        = errors.New("Invalid name")
+	InvalidPartitions  = errors.New("Invalid partition count")
+	InvalidTopicConfig = errors.New("Invalid topic config")
+	NoMessages         = errors.New("No messages to produce")
+	BrokerClosed       = errors.New("Broker closed")
+)
+
+type Options struct {
+	Dir         string
+	SegmentSize int
+	// Journal are the settings of the partition journals.
+	Journal storage.JournalOptions
+	// SyncInterval is how often the partitions are flushed to disk, zero leaves it to the
+	// journal settings and the operating system.
+	SyncInterval time.Duration
+
+	// AutoCreateTopics creates unknown topics with DefaultPartitions on produce.
+	AutoCreateTopics  bool
+	DefaultPartitions int
+
+	// Delayed messages are kept in buckets of this width until they are due,
+	// the scheduler looks for due messages every SchedulerInterval.
+	DelayBucketWidth  time.Duration
+	SchedulerInterval time.Duration
+
+	// RetentionInterval is how often segments are checked for removal.
+	RetentionInterval time.Duration
+
+	// NodeID identifies the broker among the replicas of partitions.
+	NodeID int32
+	// ReplicaClient fetches the partitions led by other brokers,
+	// the broker doesn't follow them without it.
+	ReplicaClient ReplicaClient
+	// ReplicaLagTime is how long a follower may not catch up with the
+	// leader before it is removed from the in-sync replicas.
+	ReplicaLagTime time.Duration
+	// ReplicaCopyRate limits the bytes per second new replicas copy the sealed
+	// segments of their leader with, zero doesn't limit it.
+	ReplicaCopyRate int
+}
+
+func DefaultOptions(dir string) Options {
+	return Options{
+		Dir:               dir,
+		SegmentSize:       wal.DefaultSegmentSize,
+		Journal:           storage.DefaultJournalOptions(),
+		AutoCreateTopics:  true,
+		DefaultPartitions: 1,
+		DelayBucketWidth:  time.Minute,
+		SchedulerInterval: 100 * time.Millisecond,
+		RetentionInterval: time.Minute,
+		ReplicaLagTime:    DefaultReplicaLagTime,
+		ReplicaCopyRate:   DefaultReplicaCopyRate,
+	}
+}
+
+// DynamicOptions are the options which change while the broker runs, see Reconfigure.
+type DynamicOptions struct {
+	Journal           storage.JournalOptions
+	SyncInterval      time.Duration
+	RetentionInterval time.Duration
+}
+
+// Broker owns the topics stored in a data directory and the state of the consumer groups reading them.
+type Broker struct {
+	logger     log.Logger
+	registerer prometheus.Registerer
+	options    Options
+	metrics    *BrokerMetrics
+
+	mutex   sync.RWMutex
+	closed  bool
+	topics  map[string]*Topic
+	table   *storage.Table
+	groups  *Groups
+	schemas *schema.Registry
+
+	delays    *storage.DelayStore
+	scheduler *scheduler
+	// Shared by the segment copies of all partitions.
+	copyThrottle *throttle
+
+	// Closed and replaced on every Reconfigure.
+	reconfigured chan struct{}
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+type BrokerMetrics struct {
+	producedMessages     prometheus.Counter
+	fetchedMessages      prometheus.Counter
+	nacks                prometheus.Counter
+	deadLetteredMessages prometheus.Counter
+	delayedScheduled     prometheus.Counter
+	delayedDelivered     prometheus.Counter
+	expiredSkipped       prometheus.Counter
+	expiredSegments      prometheus.Counter
+	retentionSegments    prometheus.Counter
+	filteredMessages     prometheus.Counter
+	queueReceived        prometheus.Counter
+	queueRedelivered     prometheus.Counter
+	queueAcked           prometheus.Counter
+	queueReleased        prometheus.Counter
+	replicatedMessages   prometheus.Counter
+	notEnoughReplicas    prometheus.Counter
+	isrShrinks           prometheus.Counter
+	isrExpands           prometheus.Counter
+	underReplicated      prometheus.Gauge
+	copiedBytes          prometheus.Counter
+	reassignments        prometheus.Counter
+	truncations          prometheus.Counter
+	deletedSegments      prometheus.Counter
+}
+
+func NewBroker(logger log.Logger, registerer prometheus.Registerer, options Options) (*Broker, error) {
+	if err := os.MkdirAll(options.Dir, 0o777); err != nil {
+		return nil, err
+	}
+
+	b := &Broker{
+		logger:     logger,
+		registerer: registerer,
+		options:    options,
+		topics:     make(map[string]*Topic),
+		done:       make(chan struct{}),
+		metrics:    NewBrokerMetrics(prometheus.WrapRegistererWithPrefix("broker_", registerer)),
+	}
+
+	b.copyThrottle = &throttle{rate: options.ReplicaCopyRate}
+	b.reconfigured = make(chan struct{})
+
+	table, err := storage.OpenTable(logger, partitionRegisterer(registerer, topicsTableName, 0), filepath.Join(options.Dir, topicsTableName), options.SegmentSize)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open topics table")
+	}
+
+	b.table = table
+
+	groups, err := openGroups(logger, partitionRegisterer(registerer, groupsTableName, 0), filepath.Join(options.Dir, groupsTableName), options.SegmentSize)
+
+	if err != nil {
+		b.Stop()
+		return nil, errors.Wrap(err, "unable to open groups table")
+	}
+
+	b.groups = groups
+
+	schemas, err := schema.OpenRegistry(logger, registerer, filepath.Join(options.Dir, schemasDirName), options.SegmentSize)
+
+	if err != nil {
+		b.Stop()
+		return nil, errors.Wrap(err, "unable to open schema registry")
+	}
+
+	b.schemas = schemas
+
+	var loadErr error
+
+	table.Range("", func(name string, value []byte) bool {
+		var config TopicConfig
+
+		if loadErr = json.Unmarshal(value, &config); loadErr != nil {
+			loadErr = errors.Wrapf(loadErr, "invalid config of topic %s", name)
+			return false
+		}
+
+		topic, err := openTopic(logger, registerer, options, name, config)
+
+		if err != nil {
+			loadErr = err
+			return false
+		}
+
+		b.topics[name] = topic
+
+		return true
+	})
+
+	if loadErr != nil {
+		b.Stop()
+		return nil, loadErr
+	}
+
+	if err := b.startScheduler(); err != nil {
+		b.Stop()
+		return nil, err
+	}
+
+	b.wg.Add(3)
+	go b.runRetention()
+	go b.runSync()
+	go b.runReplicaLagCheck()
+
+	for _, t := range b.topics {
+		b.startFollowing(t)
+	}
+
+	level.Info(logger).Log("msg", "broker started", "dir", options.Dir, "topics", len(b.topics))
+
+	return b, nil
+}
+
+func NewBrokerMetrics(registerer prometheus.Registerer) *BrokerMetrics {
+	m := &BrokerMetrics{}
+
+	m.producedMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "produced_messages_total",
+		Help: "Total number of messages produced.",
+	})
+
+	m.fetchedMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "fetched_messages_total",
+		Help: "Total number of messages fetched by consumers.",
+	})
+
+	m.nacks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "nacks_total",
+		Help: "Total number of failed deliveries reported by consumers.",
+	})
+
+	m.deadLetteredMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "dead_lettered_messages_total",
+		Help: "Total number of messages moved to a dead-letter topic.",
+	})
+
+	m.delayedScheduled = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "delayed_messages_scheduled_total",
+		Help: "Total number of messages stored for delayed delivery.",
+	})
+
+	m.delayedDelivered = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "delayed_messages_delivered_total",
+		Help: "Total number of delayed messages moved into their partition.",
+	})
+
+	m.expiredSkipped = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "expired_messages_skipped_total",
+		Help: "Total number of expired messages skipped on fetch.",
+	})
+
+	m.expiredSegments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "expired_segments_dropped_total",
+		Help: "Total number of segments dropped by retention because all of their messages expired.",
+	})
+
+	m.retentionSegments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "retention_segments_dropped_total",
+		Help: "Total number of segments dropped because they exceeded the retention time or size.",
+	})
+
+	m.filteredMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "filtered_messages_total",
+		Help: "Total number of messages skipped on fetch because they did not match the filter of the consumer.",
+	})
+
+	m.queueReceived = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "queue_received_messages_total",
+		Help: "Total number of messages leased to queue consumers, including redeliveries.",
+	})
+
+	m.queueRedelivered = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "queue_redelivered_messages_total",
+		Help: "Total number of queue messages delivered again after their lease ended.",
+	})
+
+	m.queueAcked = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "queue_acked_messages_total",
+		Help: "Total number of queue messages acked by consumers.",
+	})
+
+	m.queueReleased = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "queue_released_messages_total",
+		Help: "Total number of queue messages released by consumers.",
+	})
+
+	m.replicatedMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "replicated_messages_total",
+		Help: "Total number of messages fetched from the leaders of followed partitions.",
+	})
+
+	m.notEnoughReplicas = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "not_enough_replicas_total",
+		Help: "Total number of produce requests with acks=all rejected for too few in-sync replicas.",
+	})
+
+	m.isrShrinks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "isr_shrinks_total",
+		Help: "Total number of followers removed from the in-sync replicas of led partitions.",
+	})
+
+	m.isrExpands = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "isr_expands_total",
+		Help: "Total number of followers added to the in-sync replicas of led partitions.",
+	})
+
+	m.underReplicated = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "under_replicated_partitions",
+		Help: "Number of led partitions with followers which are not in sync.",
+	})
+
+	m.copiedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "copied_segment_bytes_total",
+		Help: "Total number of segment bytes copied from the leaders of newly assigned partitions.",
+	})
+
+	m.reassignments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "reassigned_partitions_total",
+		Help: "Total number of partitions whose replicas were moved by this broker.",
+	})
+
+	m.truncations = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "replica_truncations_total",
+		Help: "Total number of times a follower truncated messages which diverged from the leader.",
+	})
+
+	m.deletedSegments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "deleted_segments_total",
+		Help: "Total number of segments dropped because they are before the log start offset.",
+	})
+
+	registerer.MustRegister(m.producedMessages, m.fetchedMessages, m.nacks, m.deadLetteredMessages, m.delayedScheduled, m.delayedDelivered,
+		m.expiredSkipped, m.expiredSegments, m.retentionSegments, m.filteredMessages, m.queueReceived, m.queueRedelivered, m.queueAcked, m.queueReleased,
+		m.replicatedMessages, m.notEnoughReplicas, m.isrShrinks, m.isrExpands, m.underReplicated, m.copiedBytes, m.reassignments,
+		m.truncations, m.deletedSegments)
+
+	return m
+}
+
+func (b *Broker) startScheduler() error {
+	delays, err := storage.OpenDelayStore(b.logger, filepath.Join(b.options.Dir, delayedStoreName), b.options.SegmentSize, b.options.DelayBucketWidth)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to open delay store")
+	}
+
+	b.delays = delays
+
+	table, err := storage.OpenTable(b.logger, partitionRegisterer(b.registerer, deliveredName, 0), filepath.Join(b.options.Dir, deliveredName), b.options.SegmentSize)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to open delivered table")
+	}
+
+	s, err := newScheduler(log.With(b.logger, "component", "scheduler"), b, delays, table, b.options.SchedulerInterval)
+
+	if err != nil {
+		table.Stop()
+		return err
+	}
+
+	b.scheduler = s
+
+	go s.run()
+
+	return nil
+}
+
+// CreateTopic creates a topic with the given config, the config is stored
+// before the partitions are opened so the topic is known after a restart.
+func (b *Broker) CreateTopic(name string, config TopicConfig) (*Topic, error) {
+	if err := validateTopicName(name); err != nil {
+		return nil, err
+	}
+
+	return b.createTopic(name, config)
+}
+
+func (b *Broker) createTopic(name string, config TopicConfig) (*Topic, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil, BrokerClosed
+	}
+
+	if _, ok := b.topics[name]; ok {
+		return nil, errors.Wrapf(TopicExists, "%s", name)
+	}
+
+	value, err := json.Marshal(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.table.Put(name, value); err != nil {
+		return nil, err
+	}
+
+	topic, err := openTopic(b.logger, b.registerer, b.options, name, config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.topics[name] = topic
+	b.startFollowing(topic)
+
+	level.Info(b.logger).Log("msg", "topic created", "topic", name, "partitions", config.Partitions)
+
+	return topic, nil
+}
+
+// Topic returns the topic with the given name.
+func (b *Broker) Topic(name string) (*Topic, error) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	topic, ok := b.topics[name]
+
+	if !ok {
+		return nil, errors.Wrapf(UnknownTopic, "%s", name)
+	}
+
+	return topic, nil
+}
+
+// Topics returns all topics of the broker.
+func (b *Broker) Topics() []*Topic {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	topics := make([]*Topic, 0, len(b.topics))
+
+	for _, t := range b.topics {
+		topics = append(topics, t)
+	}
+
+	return topics
+}
+
+// AutoCreateTopic returns the topic, it is created with the default config
+// if it is unknown and topics are created on produce.
+func (b *Broker) AutoCreateTopic(name string) (*Topic, error) {
+	if err := validateTopicName(name); err != nil {
+		return nil, err
+	}
+
+	return b.topicOrCreate(name, b.options.AutoCreateTopics)
+}
+
+// topicOrCreate returns the topic and creates it with the default config if it is unknown.
+func (b *Broker) topicOrCreate(name string, create bool) (*Topic, error) {
+	topic, err := b.Topic(name)
+
+	if !create || !errors.Is(err, UnknownTopic) {
+		return topic, err
+	}
+
+	topic, err = b.createTopic(name, TopicConfig{Partitions: b.options.DefaultPartitions})
+
+	if errors.Is(err, TopicExists) {
+		return b.Topic(name)
+	}
+
+	return topic, err
+}
+
+func (b *Broker) Partition(topic string, partition int) (*Partition, error) {
+	t, err := b.Topic(topic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Partition(partition)
+}
+
+type ProduceRequest struct {
+	Topic string
+	// Partition is chosen by the key of the first message if it is negative.
+	Partition int
+	Messages  []*storage.Message
+
+	// DeliverAt delays the messages until the given time, consumers don't see
+	// them before. A zero or past time delivers them at once.
+	DeliverAt time.Time
+
+	Acks Acks
+}
+
+type ProduceResult struct {
+	Partition  int
+	BaseOffset uint64
+	// Delayed messages have no offset until they are delivered.
+	Delayed bool
+}
+
+// Produce appends the messages to a partition led by the broker. With AcksAll it waits
+// until the in-sync replicas have the messages or the context is done.
+func (b *Broker) Produce(ctx context.Context, req ProduceRequest) (ProduceResult, error) {
+	if len(req.Messages) == 0 {
+		return ProduceResult{}, NoMessages
+	}
+
+	if err := validateTopicName(req.Topic); err != nil {
+		return ProduceResult{}, err
+	}
+
+	if err := b.validateSchemas(req.Topic, req.Messages); err != nil {
+		return ProduceResult{}, err
+	}
+
+	return b.produce(ctx, req, b.options.AutoCreateTopics)
+}
+
+func (b *Broker) produce(ctx context.Context, req ProduceRequest, create bool) (ProduceResult, error) {
+	topic, err := b.topicOrCreate(req.Topic, create)
+
+	if err != nil {
+		return ProduceResult{}, err
+	}
+
+	var p *Partition
+
+	if req.Partition < 0 {
+		p = topic.choosePartition(req.Messages[0].Key)
+	} else if p, err = topic.Partition(req.Partition); err != nil {
+		return ProduceResult{}, err
+	}
+
+	if err := p.leading(); err != nil {
+		return ProduceResult{}, err
+	}
+
+	if req.Acks == AcksAll {
+		if err := p.enoughReplicas(topic.Config, NotEnoughReplicas); err != nil {
+			b.metrics.notEnoughReplicas.Inc()
+			return ProduceResult{}, err
+		}
+	}
+
+	if req.DeliverAt.After(time.Now()) {
+		return b.schedule(p, req)
+	}
+
+	base, err := p.journal.Append(req.Messages...)
+
+	if err != nil {
+		return ProduceResult{}, errors.Wrapf(err, "unable to append to %s", p)
+	}
+
+	if p.replication != nil {
+		p.replication.appended(p.journal.NextOffset())
+	}
+
+	b.metrics.producedMessages.Add(float64(len(req.Messages)))
+
+	res := ProduceResult{Partition: p.ID, BaseOffset: base}
+
+	if req.Acks == AcksAll {
+		if err := b.waitReplicated(ctx, p, topic.Config, base+uint64(len(req.Messages))); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+type FetchRequest struct {
+	Topic       string
+	Partition   int
+	Offset      uint64
+	MaxMessages int
+	// Filter skips the messages it does not match if it is set.
+	Filter Filter
+}
+
+type FetchResult struct {
+	// Messages holds the messages which have not expired.
+	Messages []*storage.Message
+	// NextOffset is the offset to continue fetching from, expired and filtered
+	// messages are skipped so it may be past the last returned message.
+	NextOffset uint64
+	// HighWatermark is the offset of the next message produced to the partition.
+	HighWatermark uint64
+}
+
+func (b *Broker) Fetch(req FetchRequest) (FetchResult, error) {
+	p, err := b.Partition(req.Topic, req.Partition)
+
+	if err != nil {
+		return FetchResult{}, err
+	}
+
+	if err := p.leading(); err != nil {
+		return FetchResult{}, err
+	}
+
+	max := req.MaxMessages
+
+	if max <= 0 || max > DefaultMaxFetchMessages {
+		max = DefaultMaxFetchMessages
+	}
+
+	now := time.Now()
+	hw := p.HighWatermark()
+	msgs := make([]*storage.Message, 0)
+	next := req.Offset
+	expired := 0
+	filtered := 0
+
+	if req.Offset > hw {
+		return FetchResult{}, errors.Wrapf(storage.OffsetOutOfRange, "offset %d is after the high watermark %d of %s", req.Offset, hw, p)
+	}
+
+	err = p.journal.Scan(req.Offset, func(msg *storage.Message) bool {
+		// Messages after the high watermark are not replicated yet.
+		if msg.Offset >= hw {
+			return false
+		}
+
+		next = msg.Offset + 1
+
+		if msg.Expired(now) {
+			expired++
+			return expired+filtered < maxSkippedScan
+		}
+
+		if req.Filter != nil && !req.Filter.Match(msg) {
+			filtered++
+			return expired+filtered < maxSkippedScan
+		}
+
+		msgs = append(msgs, msg)
+
+		return len(msgs) < max
+	})
+
+	if err != nil {
+		return FetchResult{}, err
+	}
+
+	b.metrics.fetchedMessages.Add(float64(len(msgs)))
+	b.metrics.expiredSkipped.Add(float64(expired))
+	b.metrics.filteredMessages.Add(float64(filtered))
+
+	return FetchResult{Messages: msgs, NextOffset: next, HighWatermark: hw}, nil
+}
+
+// OffsetForTime returns the offset of the first message of the partition whose timestamp
+// is not before t, it is the high watermark if there is none. Partitions have no time
+// index, so the partition is scanned from its start.
+func (b *Broker) OffsetForTime(topic string, partition int, t time.Time) (uint64, error) {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := p.leading(); err != nil {
+		return 0, err
+	}
+
+	offset := p.HighWatermark()
+	start, err := p.journal.StartOffset()
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = p.journal.Scan(start, func(msg *storage.Message) bool {
+		if msg.Offset >= offset {
+			return false
+		}
+
+		if msg.Timestamp.Before(t) {
+			return true
+		}
+
+		offset = msg.Offset
+
+		return false
+	})
+
+	return offset, err
+}
+
+// Wait blocks until the message at the offset is below the high watermark of the partition,
+// the context is done or the broker is stopped.
+func (b *Broker) Wait(ctx context.Context, topic string, partition int, offset uint64) error {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return err
+	}
+
+	for offset >= p.HighWatermark() {
+		select {
+		case <-p.highWatermarkAdvanced(offset):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return BrokerClosed
+		}
+	}
+
+	return nil
+}
+
+// Stop closes all journals of the broker.
+func (b *Broker) Stop() error {
+	b.mutex.Lock()
+
+	if b.closed {
+		b.mutex.Unlock()
+		return BrokerClosed
+	}
+
+	b.closed = true
+	b.mutex.Unlock()
+
+	// Background loops look up topics, so they are stopped before the lock is taken again.
+	close(b.done)
+	b.wg.Wait()
+
+	if b.scheduler != nil {
+		b.scheduler.stop()
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.scheduler != nil {
+		if err := b.scheduler.table.Stop(); err != nil {
+			level.Error(b.logger).Log("msg", "error stopping delivered table", "err", err)
+		}
+	}
+
+	if b.delays != nil {
+		b.delays.Stop()
+	}
+
+	for _, t := range b.topics {
+		t.stop()
+	}
+
+	if b.groups != nil {
+		if err := b.groups.stop(); err != nil {
+			level.Error(b.logger).Log("msg", "error stopping groups table", "err", err)
+		}
+	}
+
+	if b.schemas != nil {
+		if err := b.schemas.Stop(); err != nil {
+			level.Error(b.logger).Log("msg", "error stopping schema registry", "err", err)
+		}
+	}
+
+	return b.table.Stop()
+}
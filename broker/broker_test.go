@@ -0,0 +1,1220 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBroker(t *testing.T, dir string) *Broker {
+	options := DefaultOptions(dir)
+	options.SegmentSize = 32 * 1024 * 4
+
+	b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+	require.NoError(t, err)
+
+	return b
+}
+
+func TestBrokerProduceFetch(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+
+	_, err = b.CreateTopic("orders", TopicConfig{Partitions: 3})
+	require.NoError(t, err)
+
+	_, err = b.CreateTopic("orders", TopicConfig{Partitions: 3})
+	assert.ErrorIs(t, err, TopicExists)
+
+	_, err = b.CreateTopic("__orders", TopicConfig{Partitions: 1})
+	assert.ErrorIs(t, err, InvalidName)
+
+	res, err := b.Produce(context.Background(), ProduceRequest{
+		Topic:     "orders",
+		Partition: -1,
+		Messages:  []*storage.Message{{Key: []byte("k"), Value: []byte("v1")}, {Key: []byte("k"), Value: []byte("v2")}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), res.BaseOffset)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "orders", Partition: res.Partition, Offset: 1})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 1)
+	assert.Equal(t, []byte("v2"), fetched.Messages[0].Value)
+	assert.Equal(t, uint64(2), fetched.HighWatermark)
+
+	require.NoError(t, b.Commit("billing", "orders", res.Partition, 2))
+	require.NoError(t, b.Stop())
+
+	// Topics and commits survive a restart.
+	b = newTestBroker(t, dir)
+	defer b.Stop()
+
+	topic, err := b.Topic("orders")
+	require.NoError(t, err)
+	assert.Len(t, topic.Partitions(), 3)
+
+	offset, ok, err := b.Committed("billing", "orders", res.Partition)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), offset)
+
+	_, err = b.Fetch(FetchRequest{Topic: "payments"})
+	assert.ErrorIs(t, err, UnknownTopic)
+}
+
+func TestBrokerDeadLetter(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	_, err = b.Produce(context.Background(), ProduceRequest{
+		Topic:    "orders",
+		Messages: []*storage.Message{{Value: []byte("ok")}, {Value: []byte("poison"), Headers: []storage.Header{{Key: "type", Value: []byte("order")}}}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.SetDeadLetterPolicy("billing", DeadLetterPolicy{MaxAttempts: 3}))
+	require.NoError(t, b.Commit("billing", "orders", 0, 1))
+
+	for i := 1; i < 3; i++ {
+		res, err := b.Nack("billing", "orders", 0, 1, "handler failed")
+		require.NoError(t, err)
+		assert.Equal(t, i, res.Attempts)
+		assert.False(t, res.DeadLettered)
+	}
+
+	res, err := b.Nack("billing", "orders", 0, 1, "handler failed")
+	require.NoError(t, err)
+	assert.True(t, res.DeadLettered)
+	assert.Equal(t, 3, res.Attempts)
+
+	offset, _, err := b.Committed("billing", "orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), offset)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: DeadLetterTopic("orders"), Partition: 0})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 1)
+
+	dead := fetched.Messages[0]
+	assert.Equal(t, []byte("poison"), dead.Value)
+
+	for key, expected := range map[string]string{
+		"type":                    "order",
+		DeadLetterReasonHeader:    "handler failed",
+		DeadLetterGroupHeader:     "billing",
+		DeadLetterTopicHeader:     "orders",
+		DeadLetterPartitionHeader: "0",
+		DeadLetterOffsetHeader:    "1",
+		DeadLetterAttemptsHeader:  "3",
+	} {
+		value, ok := dead.Header(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, expected, string(value), key)
+	}
+
+	// Attempts start over for a group without a policy.
+	res, err = b.Nack("shipping", "orders", 0, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Attempts)
+	assert.False(t, res.DeadLettered)
+
+	// A message after the one the group is at is dead-lettered without skipping the ones before it.
+	require.NoError(t, b.SetDeadLetterPolicy("audit", DeadLetterPolicy{MaxAttempts: 1}))
+
+	res, err = b.Nack("audit", "orders", 0, 1, "")
+	require.NoError(t, err)
+	assert.True(t, res.DeadLettered)
+
+	_, found, err := b.Committed("audit", "orders", 0)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	res, err = b.Nack("audit", "orders", 0, 0, "")
+	require.NoError(t, err)
+	assert.True(t, res.DeadLettered)
+
+	offset, _, err = b.Committed("audit", "orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), offset)
+}
+
+func TestBrokerDelayedDelivery(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+
+	res, err := b.Produce(context.Background(), ProduceRequest{
+		Topic:     "reminders",
+		Messages:  []*storage.Message{{Value: []byte("later")}},
+		DeliverAt: time.Now().Add(300 * time.Millisecond),
+	})
+	require.NoError(t, err)
+	assert.True(t, res.Delayed)
+
+	_, err = b.Produce(context.Background(), ProduceRequest{
+		Topic:     "reminders",
+		Messages:  []*storage.Message{{Value: []byte("sooner")}},
+		DeliverAt: time.Now().Add(100 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "reminders"})
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Messages)
+
+	require.Eventually(t, func() bool {
+		fetched, err = b.Fetch(FetchRequest{Topic: "reminders"})
+		return err == nil && len(fetched.Messages) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+
+	assert.Equal(t, []byte("sooner"), fetched.Messages[0].Value)
+	assert.Equal(t, []byte("later"), fetched.Messages[1].Value)
+
+	_, ok := fetched.Messages[0].Header(DelayIDHeader)
+	assert.True(t, ok)
+	_, ok = fetched.Messages[0].Header(DelayTopicHeader)
+	assert.False(t, ok)
+
+	require.NoError(t, b.Stop())
+}
+
+func TestBrokerDelayedDeliveryAcrossRestart(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+
+	for _, value := range []string{"first", "second"} {
+		_, err = b.Produce(context.Background(), ProduceRequest{
+			Topic:     "reminders",
+			Messages:  []*storage.Message{{Value: []byte(value)}},
+			DeliverAt: time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	// Interrupt the delivery of the first message right after the append,
+	// before it is recorded as delivered.
+	b.scheduler.stop()
+
+	buckets, err := b.delays.Buckets()
+	require.NoError(t, err)
+	require.Len(t, buckets, 1)
+
+	j, err := b.delays.Bucket(buckets[0])
+	require.NoError(t, err)
+	msgs, err := j.Read(0, 1)
+	require.NoError(t, err)
+
+	p, err := b.Partition("reminders", 0)
+	require.NoError(t, err)
+
+	require.NoError(t, b.scheduler.table.Put(inflightKey, []byte(`{"bucket":`+strconv.FormatInt(buckets[0], 10)+`,"offset":0,"topic":"reminders","partition":0,"from":0}`)))
+	_, err = p.journal.Append(&storage.Message{Value: msgs[0].Value, Headers: []storage.Header{{Key: DelayIDHeader, Value: []byte(delayID(buckets[0], 0))}}})
+	require.NoError(t, err)
+
+	go b.scheduler.run()
+	require.NoError(t, b.Stop())
+
+	b = newTestBroker(t, dir)
+	defer b.Stop()
+
+	b.scheduler.stop()
+	require.NoError(t, b.scheduler.deliverDue(time.Now().Add(2*time.Hour)))
+	require.NoError(t, b.scheduler.deliverDue(time.Now().Add(2*time.Hour)))
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "reminders"})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 2)
+	assert.Equal(t, []byte("first"), fetched.Messages[0].Value)
+	assert.Equal(t, []byte("second"), fetched.Messages[1].Value)
+
+	// Delivered buckets are removed once they are over.
+	buckets, err = b.delays.Buckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+
+	go b.scheduler.run()
+}
+
+func TestBrokerDeleteRecords(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		_, err := b.Produce(context.Background(), ProduceRequest{Topic: "orders", Messages: []*storage.Message{{Value: bytes.Repeat([]byte("x"), 8*1024)}}})
+		require.NoError(t, err)
+	}
+
+	_, err = b.DeleteRecords("orders", 0, 41)
+	assert.ErrorIs(t, err, storage.OffsetOutOfRange)
+
+	start, err := b.DeleteRecords("orders", 0, 30)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), start)
+
+	_, err = b.Fetch(FetchRequest{Topic: "orders", Offset: 29})
+	assert.ErrorIs(t, err, storage.OffsetOutOfRange)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "orders", Offset: 30})
+	require.NoError(t, err)
+	assert.Len(t, fetched.Messages, 10)
+
+	// Retention drops the segments before the log start offset.
+	b.applyRetention(time.Now())
+	assert.Positive(t, testutil.ToFloat64(b.metrics.deletedSegments))
+}
+
+func TestBrokerRetentionBytes(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		_, err := b.Produce(context.Background(), ProduceRequest{Topic: "orders", Messages: []*storage.Message{{Value: bytes.Repeat([]byte("x"), 8*1024)}}})
+		require.NoError(t, err)
+	}
+
+	// The retention options apply to the partitions which are open already.
+	options := storage.DefaultJournalOptions()
+	options.RetentionBytes = 32 * 1024 * 4
+	b.Reconfigure(DynamicOptions{Journal: options})
+
+	b.applyRetention(time.Now())
+	assert.Positive(t, testutil.ToFloat64(b.metrics.retentionSegments))
+
+	_, err = b.Fetch(FetchRequest{Topic: "orders", Offset: 0})
+	assert.ErrorIs(t, err, storage.OffsetOutOfRange)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "orders", Offset: 39})
+	require.NoError(t, err)
+	assert.Len(t, fetched.Messages, 1)
+}
+
+func TestBrokerReconfigure(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		_, err := b.Produce(context.Background(), ProduceRequest{Topic: "orders", Messages: []*storage.Message{{Value: bytes.Repeat([]byte("x"), 8*1024)}}})
+		require.NoError(t, err)
+	}
+
+	_, err = b.DeleteRecords("orders", 0, 30)
+	require.NoError(t, err)
+
+	// The retention loop picks up the shorter interval without waiting for the old one.
+	b.Reconfigure(DynamicOptions{
+		Journal:           storage.JournalOptions{SyncEveryAppend: true},
+		SyncInterval:      10 * time.Millisecond,
+		RetentionInterval: 10 * time.Millisecond,
+	})
+
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(b.metrics.deletedSegments) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Partitions created afterwards get the new options too.
+	_, err = b.CreateTopic("payments", TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	_, err = b.Produce(context.Background(), ProduceRequest{Topic: "payments", Messages: []*storage.Message{{Value: []byte("paid")}}})
+	require.NoError(t, err)
+
+	b.mutex.RLock()
+	assert.Equal(t, 10*time.Millisecond, b.options.RetentionInterval)
+	b.mutex.RUnlock()
+}
+
+func TestBrokerFetchSkipsExpired(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	_, err = b.Produce(context.Background(), ProduceRequest{
+		Topic: "sessions",
+		Messages: []*storage.Message{
+			{Value: []byte("expired"), ExpiresAt: time.Now().Add(-time.Second)},
+			{Value: []byte("alive"), ExpiresAt: time.Now().Add(time.Hour)},
+			{Value: []byte("expired"), ExpiresAt: time.Now().Add(-time.Second)},
+		},
+	})
+	require.NoError(t, err)
+
+	fetched, err := b.Fetch(FetchRequest{Topic: "sessions"})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 1)
+	assert.Equal(t, []byte("alive"), fetched.Messages[0].Value)
+	assert.Equal(t, uint64(3), fetched.NextOffset)
+
+	fetched, err = b.Fetch(FetchRequest{Topic: "sessions", Offset: 2})
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Messages)
+	assert.Equal(t, uint64(3), fetched.NextOffset)
+}
+
+func TestBrokerFetchBoundsExpiredScan(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	expired := make([]*storage.Message, 0, maxSkippedScan)
+
+	for i := 0; i < maxSkippedScan+10; i++ {
+		expired = append(expired, &storage.Message{Value: []byte("expired"), ExpiresAt: time.Now().Add(-time.Second)})
+	}
+
+	_, err = b.Produce(context.Background(), ProduceRequest{
+		Topic:    "sessions",
+		Messages: append(expired, &storage.Message{Value: []byte("alive")}),
+	})
+	require.NoError(t, err)
+
+	// The first fetch stops after skipping the bound and returns where to continue.
+	fetched, err := b.Fetch(FetchRequest{Topic: "sessions"})
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Messages)
+	assert.Equal(t, uint64(maxSkippedScan), fetched.NextOffset)
+
+	fetched, err = b.Fetch(FetchRequest{Topic: "sessions", Offset: fetched.NextOffset})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 1)
+	assert.Equal(t, []byte("alive"), fetched.Messages[0].Value)
+	assert.Equal(t, uint64(maxSkippedScan+11), fetched.NextOffset)
+}
+
+func TestBrokerQueue(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+
+	_, err = b.CreateTopic("jobs", TopicConfig{Partitions: 2, Mode: QueueMode, VisibilityTimeout: time.Minute, MaxDeliveries: 2})
+	require.NoError(t, err)
+
+	_, err = b.CreateTopic("stream", TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	_, err = b.Receive(ReceiveRequest{Topic: "stream"})
+	assert.ErrorIs(t, err, NotQueue)
+
+	for i := 0; i < 4; i++ {
+		_, err := b.Produce(context.Background(), ProduceRequest{Topic: "jobs", Partition: i % 2, Messages: []*storage.Message{{Value: []byte(strconv.Itoa(i))}}})
+		require.NoError(t, err)
+	}
+
+	leases, err := b.Receive(ReceiveRequest{Topic: "jobs", MaxMessages: 3})
+	require.NoError(t, err)
+	require.Len(t, leases, 3)
+
+	for _, l := range leases {
+		assert.Equal(t, 1, l.Deliveries)
+	}
+
+	// Leased messages are hidden from other consumers.
+	rest, err := b.Receive(ReceiveRequest{Topic: "jobs", MaxMessages: 10})
+	require.NoError(t, err)
+	require.Len(t, rest, 1)
+
+	empty, err := b.Receive(ReceiveRequest{Topic: "jobs", MaxMessages: 10})
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	require.NoError(t, b.Ack("jobs", leases[0].Partition, leases[0].Message.Offset))
+	assert.ErrorIs(t, b.Ack("jobs", leases[0].Partition, leases[0].Message.Offset), NotLeased)
+
+	res, err := b.Release("jobs", leases[1].Partition, leases[1].Message.Offset, "failed")
+	require.NoError(t, err)
+	assert.Equal(t, ReleaseResult{Deliveries: 1}, res)
+
+	// Released messages are delivered again at once, the lease state survives a restart.
+	require.NoError(t, b.Stop())
+	b = newTestBroker(t, dir)
+	defer b.Stop()
+
+	again, err := b.Receive(ReceiveRequest{Topic: "jobs", MaxMessages: 10})
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, leases[1].Message.Value, again[0].Message.Value)
+	assert.Equal(t, 2, again[0].Deliveries)
+
+	res, err = b.Release("jobs", again[0].Partition, again[0].Message.Offset, "failed again")
+	require.NoError(t, err)
+	assert.Equal(t, ReleaseResult{Deliveries: 2, DeadLettered: true}, res)
+
+	dead, err := b.Fetch(FetchRequest{Topic: DeadLetterTopic("jobs")})
+	require.NoError(t, err)
+	require.Len(t, dead.Messages, 1)
+	assert.Equal(t, leases[1].Message.Value, dead.Messages[0].Value)
+
+	// Leases which are neither acked nor released end with their visibility timeout.
+	_, err = b.CreateTopic("tasks", TopicConfig{Partitions: 1, Mode: QueueMode, VisibilityTimeout: 50 * time.Millisecond, MaxDeliveries: 2})
+	require.NoError(t, err)
+
+	_, err = b.Produce(context.Background(), ProduceRequest{Topic: "tasks", Partition: 0, Messages: []*storage.Message{{Value: []byte("task")}}})
+	require.NoError(t, err)
+
+	first, err := b.Receive(ReceiveRequest{Topic: "tasks"})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+
+	require.Eventually(t, func() bool {
+		redelivered, err := b.Receive(ReceiveRequest{Topic: "tasks"})
+		return err == nil && len(redelivered) == 1 && redelivered[0].Deliveries == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The message is dead-lettered instead of delivered a third time.
+	require.Eventually(t, func() bool {
+		leases, err := b.Receive(ReceiveRequest{Topic: "tasks"})
+		require.NoError(t, err)
+		require.Empty(t, leases)
+
+		dead, err := b.Fetch(FetchRequest{Topic: DeadLetterTopic("tasks")})
+		return err == nil && len(dead.Messages) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestParseFilter(t *testing.T) {
+	msg := &storage.Message{
+		Key:     []byte("order-1"),
+		Headers: []storage.Header{{Key: "region", Value: []byte("eu")}, {Key: "x-type", Value: []byte("it's")}},
+	}
+
+	for expr, match := range map[string]bool{
+		`key = 'order-1'`:                                         true,
+		`key = "order-2"`:                                         false,
+		`key prefix 'order-'`:                                     true,
+		`KEY PREFIX 'item-'`:                                      false,
+		`header.region in ('us', 'eu')`:                           true,
+		`header.region in ('us')`:                                 false,
+		`header.missing = 'eu'`:                                   false,
+		`header.missing != 'eu'`:                                  true,
+		`header.x-type = 'it\'s'`:                                 true,
+		`key prefix 'item-' or header.region = 'eu'`:              true,
+		`key prefix 'order-' and header.region = 'us'`:            false,
+		`key = 'a' or key = 'b' and key = 'order-1'`:              false,
+		`(key = 'a' or key = 'order-1') and header.region = 'eu'`: true,
+	} {
+		f, err := ParseFilter(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, match, f.Match(msg), expr)
+	}
+
+	for _, expr := range []string{
+		``,
+		`key`,
+		`key = `,
+		`key = 'a`,
+		`value = 'a'`,
+		`header. = 'a'`,
+		`key in 'a'`,
+		`key in ('a' 'b')`,
+		`(key = 'a'`,
+		`key = 'a' and`,
+		`key = 'a' key = 'b'`,
+		`key ~ 'a'`,
+	} {
+		_, err := ParseFilter(expr)
+		assert.ErrorIs(t, err, InvalidFilter, expr)
+	}
+}
+
+func TestBrokerFetchFiltered(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := newTestBroker(t, dir)
+	defer b.Stop()
+
+	for i := 0; i < 100; i++ {
+		msg := &storage.Message{Value: []byte(strconv.Itoa(i))}
+
+		if i%10 == 0 {
+			msg.SetHeader("type", []byte("rare"))
+		}
+
+		_, err := b.Produce(context.Background(), ProduceRequest{Topic: "events", Partition: 0, Messages: []*storage.Message{msg}})
+		require.NoError(t, err)
+	}
+
+	filter, err := ParseFilter(`header.type = 'rare'`)
+	require.NoError(t, err)
+
+	res, err := b.Fetch(FetchRequest{Topic: "events", Offset: 1, MaxMessages: 3, Filter: filter})
+	require.NoError(t, err)
+	require.Len(t, res.Messages, 3)
+	assert.Equal(t, []byte("30"), res.Messages[2].Value)
+	assert.Equal(t, uint64(31), res.NextOffset)
+
+	res, err = b.Fetch(FetchRequest{Topic: "events", Offset: 91, Filter: filter})
+	require.NoError(t, err)
+	assert.Empty(t, res.Messages)
+	assert.Equal(t, uint64(100), res.NextOffset)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, b.Wait(ctx, "events", 0, 100), context.DeadlineExceeded)
+	require.NoError(t, b.Wait(context.Background(), "events", 0, 99))
+}
+
+// localReplicas lets brokers of a test fetch from each other without a network.
+type localReplicas map[int32]*Broker
+
+func (l localReplicas) ReplicaFetch(ctx context.Context, leader int32, req ReplicaFetchRequest) (ReplicaFetchResult, error) {
+	return l[leader].ReplicaFetch(ctx, req)
+}
+
+func (l localReplicas) SegmentFiles(ctx context.Context, leader int32, topic string, partition int) ([]storage.SegmentFile, error) {
+	return l[leader].SegmentFiles(topic, partition)
+}
+
+func (l localReplicas) ReadSegmentFile(ctx context.Context, leader int32, req SegmentReadRequest) ([]byte, error) {
+	return l[leader].ReadSegmentFile(req)
+}
+
+func (l localReplicas) SetReplicas(ctx context.Context, id int32, topic string, partition int, replicas []int32) error {
+	return l[id].SetReplicas(topic, partition, replicas)
+}
+
+func (l localReplicas) SetLeader(ctx context.Context, id int32, topic string, partition int, leader int32, epoch int32) error {
+	return l[id].SetLeader(topic, partition, leader, epoch)
+}
+
+// partitionedReplicas fails the fetches of isolated brokers.
+type partitionedReplicas struct {
+	localReplicas
+
+	isolated sync.Map
+}
+
+func (p *partitionedReplicas) ReplicaFetch(ctx context.Context, leader int32, req ReplicaFetchRequest) (ReplicaFetchResult, error) {
+	if _, ok := p.isolated.Load(req.ReplicaID); ok {
+		return ReplicaFetchResult{}, errors.New("isolated")
+	}
+
+	return p.localReplicas.ReplicaFetch(ctx, leader, req)
+}
+
+func TestReplication(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := make(localReplicas)
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+		options.ReplicaLagTime = 500 * time.Millisecond
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		// Followers start fetching once the topic is created.
+		replicas[id] = b
+
+		_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}})
+		require.NoError(t, err)
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	p, err := leader.Partition("orders", 0)
+	require.NoError(t, err)
+
+	_, err = leader.Produce(context.Background(), ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("1")}, {Value: []byte("2")}}})
+	require.NoError(t, err)
+
+	// Nothing is visible before the follower has fetched the messages.
+	fetched, err := leader.Fetch(FetchRequest{Topic: "orders", Partition: 0})
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Messages)
+	assert.Equal(t, uint64(0), fetched.HighWatermark)
+
+	follower := start(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, leader.Wait(ctx, "orders", 0, 1))
+	assert.Equal(t, []int32{1, 2}, p.ISR())
+
+	fetched, err = leader.Fetch(FetchRequest{Topic: "orders", Partition: 0})
+	require.NoError(t, err)
+	assert.Len(t, fetched.Messages, 2)
+
+	_, err = follower.Produce(context.Background(), ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("3")}}})
+	assert.ErrorIs(t, err, NotLeader)
+
+	_, err = follower.Fetch(FetchRequest{Topic: "orders", Partition: 0})
+	assert.ErrorIs(t, err, NotLeader)
+
+	// The follower has the messages with the offsets of the leader.
+	fp, err := follower.Partition("orders", 0)
+	require.NoError(t, err)
+
+	msgs, err := fp.Journal().Read(0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, uint64(1), msgs[1].Offset)
+	assert.Equal(t, []byte("2"), msgs[1].Value)
+
+	// The leadership moves to the follower, which the former leader fetches from then.
+	require.NoError(t, follower.SetLeader("orders", 0, 2, 1))
+	require.NoError(t, leader.SetLeader("orders", 0, 2, 1))
+	assert.ErrorIs(t, leader.SetLeader("orders", 0, 3, 2), UnknownReplica)
+
+	_, err = follower.Produce(context.Background(), ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("3")}}})
+	require.NoError(t, err)
+
+	require.NoError(t, follower.Wait(ctx, "orders", 0, 2))
+	assert.Equal(t, []int32{2, 1}, fp.ISR())
+	assert.Equal(t, uint64(3), p.NextOffset())
+
+	require.NoError(t, leader.SetLeader("orders", 0, 1, 2))
+	require.NoError(t, follower.SetLeader("orders", 0, 1, 2))
+
+	// A follower which stops fetching leaves the in-sync replicas after the lag time.
+	require.NoError(t, follower.Stop())
+
+	_, err = leader.Produce(context.Background(), ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("4")}}})
+	require.NoError(t, err)
+
+	require.NoError(t, leader.Wait(ctx, "orders", 0, 3))
+	assert.Equal(t, []int32{1}, p.ISR())
+	assert.Equal(t, uint64(4), p.HighWatermark())
+}
+
+func TestReplicationDivergence(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := &partitionedReplicas{localReplicas: make(localReplicas)}
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		replicas.localReplicas[id] = b
+
+		_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}})
+		require.NoError(t, err)
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	follower := start(2)
+	defer follower.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = leader.Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("1")}, {Value: []byte("2")}}})
+	require.NoError(t, err)
+	require.NoError(t, leader.Wait(ctx, "orders", 0, 1))
+
+	// The follower loses contact and takes over while the former leader still takes messages.
+	replicas.isolated.Store(int32(2), true)
+
+	_, err = leader.Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("3")}, {Value: []byte("4")}}})
+	require.NoError(t, err)
+
+	require.NoError(t, follower.SetLeader("orders", 0, 2, 1))
+
+	_, err = follower.Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("x")}}})
+	require.NoError(t, err)
+
+	replicas.isolated.Delete(int32(2))
+
+	// The former leader truncates the messages the new leader never got and fetches the new ones.
+	require.NoError(t, leader.SetLeader("orders", 0, 2, 1))
+	assert.ErrorIs(t, leader.SetLeader("orders", 0, 1, 0), StaleLeaderEpoch)
+	require.NoError(t, follower.Wait(ctx, "orders", 0, 2))
+
+	p, err := leader.Partition("orders", 0)
+	require.NoError(t, err)
+
+	msgs, err := p.Journal().Read(0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, []byte("x"), msgs[2].Value)
+	assert.Equal(t, int32(1), p.LeaderEpoch())
+	assert.Equal(t, []storage.Epoch{{Epoch: 0, StartOffset: 0}, {Epoch: 1, StartOffset: 2}}, p.Journal().Epochs().Entries(0))
+	assert.Equal(t, 1.0, testutil.ToFloat64(leader.metrics.truncations))
+}
+
+func TestReplicationAfterDeletedRecords(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := &partitionedReplicas{localReplicas: make(localReplicas)}
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+		options.ReplicaLagTime = 200 * time.Millisecond
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		replicas.localReplicas[id] = b
+
+		_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}})
+		require.NoError(t, err)
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	follower := start(2)
+	defer follower.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	produce := func(n int) {
+		for i := 0; i < n; i++ {
+			_, err := leader.Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: bytes.Repeat([]byte("x"), 8*1024)}}})
+			require.NoError(t, err)
+		}
+	}
+
+	produce(10)
+	require.NoError(t, leader.Wait(ctx, "orders", 0, 9))
+
+	p, err := leader.Partition("orders", 0)
+	require.NoError(t, err)
+
+	// The records the stopped follower is missing are deleted on the leader.
+	replicas.isolated.Store(int32(2), true)
+
+	require.Eventually(t, func() bool {
+		return slices.Equal([]int32{1}, p.ISR())
+	}, 5*time.Second, 20*time.Millisecond)
+
+	produce(30)
+
+	_, err = leader.DeleteRecords("orders", 0, 30)
+	require.NoError(t, err)
+	leader.applyRetention(time.Now())
+
+	replicas.isolated.Delete(int32(2))
+
+	// The follower continues at the log start offset of the leader and catches up.
+	require.Eventually(t, func() bool {
+		return slices.Equal([]int32{1, 2}, p.ISR())
+	}, 5*time.Second, 20*time.Millisecond)
+
+	fp, err := follower.Partition("orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(40), fp.NextOffset())
+
+	logStart, err := fp.Journal().StartOffset()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(30), logStart)
+
+	msgs, err := fp.Journal().Read(30, 100)
+	require.NoError(t, err)
+	require.Len(t, msgs, 10)
+	assert.Equal(t, uint64(30), msgs[0].Offset)
+}
+
+func TestReplicatedDelayedDelivery(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := make(localReplicas)
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		replicas[id] = b
+
+		_, err = b.CreateTopic("reminders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}})
+		require.NoError(t, err)
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	follower := start(2)
+	defer follower.Stop()
+
+	leader.scheduler.stop()
+
+	_, err = leader.Produce(context.Background(), ProduceRequest{
+		Topic:     "reminders",
+		Partition: 0,
+		Messages:  []*storage.Message{{Value: []byte("later")}},
+		DeliverAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	// The message stays scheduled while another broker leads the partition.
+	require.NoError(t, follower.SetLeader("reminders", 0, 2, 1))
+	require.NoError(t, leader.SetLeader("reminders", 0, 2, 1))
+	require.NoError(t, leader.scheduler.deliverDue(time.Now().Add(2*time.Hour)))
+
+	p, err := leader.Partition("reminders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), p.NextOffset())
+
+	buckets, err := leader.delays.Buckets()
+	require.NoError(t, err)
+	assert.Len(t, buckets, 1)
+
+	// It is delivered and replicated once the leadership comes back.
+	require.NoError(t, leader.SetLeader("reminders", 0, 1, 2))
+	require.NoError(t, follower.SetLeader("reminders", 0, 1, 2))
+	require.NoError(t, leader.scheduler.deliverDue(time.Now().Add(2*time.Hour)))
+
+	go leader.scheduler.run()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, leader.Wait(ctx, "reminders", 0, 0))
+
+	fetched, err := leader.Fetch(FetchRequest{Topic: "reminders", Partition: 0})
+	require.NoError(t, err)
+	require.Len(t, fetched.Messages, 1)
+	assert.Equal(t, []byte("later"), fetched.Messages[0].Value)
+
+	fp, err := follower.Partition("reminders", 0)
+	require.NoError(t, err)
+
+	msgs, err := fp.Journal().Read(0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, []byte("later"), msgs[0].Value)
+
+	buckets, err = leader.delays.Buckets()
+	require.NoError(t, err)
+	assert.Empty(t, buckets)
+}
+
+func TestUncleanLeaderElection(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := &partitionedReplicas{localReplicas: make(localReplicas)}
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+		options.ReplicaLagTime = 200 * time.Millisecond
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		replicas.localReplicas[id] = b
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	lagging := start(2)
+	defer lagging.Stop()
+
+	follower := start(3)
+	defer follower.Stop()
+
+	// The topics are created once all brokers are known, the followers start fetching then.
+	for _, b := range []*Broker{leader, lagging, follower} {
+		_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2, 3}}})
+		require.NoError(t, err)
+
+		_, err = b.CreateTopic("metrics", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2, 3}}, UncleanLeaderElection: true})
+		require.NoError(t, err)
+	}
+
+	// The lagging replica stops fetching and drops out of the in-sync replicas.
+	replicas.isolated.Store(int32(2), true)
+
+	for _, topic := range []string{"orders", "metrics"} {
+		p, err := leader.Partition(topic, 0)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return slices.Equal(p.ISR(), []int32{1, 3}) }, 5*time.Second, 10*time.Millisecond)
+
+		// The follower learns it with the next fetch.
+		fp, err := follower.Partition(topic, 0)
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool { return !fp.replication.inSync(2) }, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Neither the leader nor the follower accept a leader which may miss acknowledged messages.
+	assert.ErrorIs(t, leader.SetLeader("orders", 0, 2, 1), UncleanLeaderElection)
+	assert.ErrorIs(t, follower.SetLeader("orders", 0, 2, 1), UncleanLeaderElection)
+	require.NoError(t, follower.SetLeader("orders", 0, 3, 1))
+
+	// Topics which allow unclean elections prefer availability.
+	require.NoError(t, follower.SetLeader("metrics", 0, 2, 1))
+	require.NoError(t, leader.SetLeader("metrics", 0, 2, 1))
+
+	p, err := leader.Partition("metrics", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), p.Leader())
+}
+
+func TestAcksAll(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := make(localReplicas)
+	config := TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}, MinInSyncReplicas: 2}
+
+	start := func(id int32) *Broker {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+		options.ReplicaLagTime = 500 * time.Millisecond
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+
+		replicas[id] = b
+
+		_, err = b.CreateTopic("orders", config)
+		require.NoError(t, err)
+
+		return b
+	}
+
+	leader := start(1)
+	defer leader.Stop()
+
+	_, err = leader.CreateTopic("payments", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}, MinInSyncReplicas: 3})
+	assert.ErrorIs(t, err, InvalidTopicConfig)
+
+	produce := func(ctx context.Context, acks Acks) (ProduceResult, error) {
+		return leader.Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("1")}}, Acks: acks})
+	}
+
+	// The messages are appended, but the follower never gets them.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = produce(ctx, AcksAll)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	follower := start(2)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := produce(ctx, AcksAll)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), res.BaseOffset)
+
+	fp, err := follower.Partition("orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), fp.NextOffset())
+
+	// Without the follower the partition has too few in-sync replicas for acks=all.
+	require.NoError(t, follower.Stop())
+
+	p, err := leader.Partition("orders", 0)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(p.ISR()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	_, err = produce(ctx, AcksAll)
+	assert.ErrorIs(t, err, NotEnoughReplicas)
+
+	res, err = produce(ctx, AcksLeader)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), res.BaseOffset)
+}
+
+func TestReassignPartition(t *testing.T) {
+	dir, err := os.MkdirTemp("", "broker_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	replicas := make(localReplicas)
+	brokers := make(map[int32]*Broker)
+
+	for _, id := range []int32{1, 2, 3} {
+		options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+
+		b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+		defer b.Stop()
+
+		replicas[id] = b
+		brokers[id] = b
+	}
+
+	for _, b := range brokers {
+		_, err = b.CreateTopic("orders", TopicConfig{Partitions: 1, Replicas: [][]int32{{1, 2}}})
+		require.NoError(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Enough messages to seal a few segments.
+	for i := 0; i < 50; i++ {
+		_, err := brokers[1].Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: bytes.Repeat([]byte("x"), 8*1024)}}, Acks: AcksAll})
+		require.NoError(t, err)
+	}
+
+	assert.ErrorIs(t, brokers[2].ReassignPartition(ctx, "orders", 0, []int32{3, 2}), NotLeader)
+	assert.ErrorIs(t, brokers[1].ReassignPartition(ctx, "orders", 0, []int32{3, 3}), InvalidTopicConfig)
+
+	// The leadership moves to the new replica, the former leader deletes its copy.
+	require.NoError(t, brokers[1].ReassignPartition(ctx, "orders", 0, []int32{3, 2}))
+
+	p3, err := brokers[3].Partition("orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), p3.Leader())
+	assert.Equal(t, uint64(50), p3.NextOffset())
+	assert.Greater(t, testutil.ToFloat64(brokers[3].metrics.copiedBytes), float64(0))
+
+	files, err := p3.Journal().SegmentFiles()
+	require.NoError(t, err)
+	assert.NotEmpty(t, files)
+
+	p1, err := brokers[1].Partition("orders", 0)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return p1.NextOffset() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	_, err = brokers[1].Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("y")}}})
+	assert.ErrorIs(t, err, NotLeader)
+
+	res, err := brokers[3].Produce(ctx, ProduceRequest{Topic: "orders", Partition: 0, Messages: []*storage.Message{{Value: []byte("y")}}, Acks: AcksAll})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(50), res.BaseOffset)
+
+	msgs, err := p3.Journal().Read(0, 100)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 51)
+
+	// The new replicas are kept in the topic config.
+	require.NoError(t, brokers[3].Stop())
+
+	options := DefaultOptions(filepath.Join(dir, "3"))
+	options.SegmentSize = 32 * 1024 * 4
+	options.NodeID = 3
+
+	b, err := NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+	require.NoError(t, err)
+	defer b.Stop()
+
+	p, err := b.Partition("orders", 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), p.Leader())
+	assert.Equal(t, uint64(51), p.NextOffset())
+}
+
+func TestThrottle(t *testing.T) {
+	th := &throttle{rate: 1000}
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, th.wait(context.Background(), 100))
+	}
+
+	// The first chunk passes at once, the following ones wait for the ones before.
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, th.wait(ctx, 100), context.Canceled)
+}
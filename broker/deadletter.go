@@ -0,0 +1,188 @@
+package broker
+
+import (
+	"context"
+	"strconv"
+
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	DeadLetterSuffix = ".dlq"
+
+	// Headers added to a message when it is moved to a dead-letter topic.
+	DeadLetterReasonHeader    = "iris-dlq-reason"
+	DeadLetterGroupHeader     = "iris-dlq-group"
+	DeadLetterTopicHeader     = "iris-dlq-topic"
+	DeadLetterPartitionHeader = "iris-dlq-partition"
+	DeadLetterOffsetHeader    = "iris-dlq-offset"
+	DeadLetterAttemptsHeader  = "iris-dlq-attempts"
+)
+
+var InvalidPolicy = errors.New("Invalid dead-letter policy")
+
+type DeadLetterPolicy struct {
+	// MaxAttempts is the number of failed deliveries after which a message is
+	// moved to the dead-letter topic, zero disables dead-lettering.
+	MaxAttempts int `json:"maxAttempts"`
+}
+
+type NackResult struct {
+	Attempts     int
+	DeadLettered bool
+}
+
+// DeadLetterTopic returns the name of the dead-letter topic of the given topic.
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+func (b *Broker) SetDeadLetterPolicy(group string, policy DeadLetterPolicy) error {
+	if err := validateName(group); err != nil {
+		return err
+	}
+
+	if policy.MaxAttempts < 0 {
+		return errors.Wrapf(InvalidPolicy, "max attempts %d", policy.MaxAttempts)
+	}
+
+	return b.groups.setDeadLetterPolicy(group, policy)
+}
+
+func (b *Broker) DeadLetterPolicy(group string) DeadLetterPolicy {
+	return b.groups.deadLetterPolicy(group)
+}
+
+// Nack records a failed delivery of the message to the group. Once the dead-letter
+// policy of the group is exhausted the message is copied to the dead-letter topic
+// and the group is moved past it.
+func (b *Broker) Nack(group string, topic string, partition int, offset uint64, reason string) (NackResult, error) {
+	if err := validateName(group); err != nil {
+		return NackResult{}, err
+	}
+
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return NackResult{}, err
+	}
+
+	if end := p.HighWatermark(); offset >= end {
+		return NackResult{}, errors.Wrapf(storage.OffsetOutOfRange, "offset %d is not in %s", offset, p)
+	}
+
+	b.groups.mutex.Lock()
+	defer b.groups.mutex.Unlock()
+
+	b.metrics.nacks.Inc()
+
+	attempts, err := b.groups.attempts.Failed(group, topic, partition, offset)
+
+	if err != nil {
+		return NackResult{}, err
+	}
+
+	policy := b.groups.deadLetterPolicy(group)
+
+	if policy.MaxAttempts == 0 || attempts < policy.MaxAttempts {
+		return NackResult{Attempts: attempts}, nil
+	}
+
+	if err := b.deadLetter(group, p, offset, attempts, reason); err != nil {
+		return NackResult{}, errors.Wrapf(err, "unable to dead-letter offset %d of %s", offset, p)
+	}
+
+	return NackResult{Attempts: attempts, DeadLettered: true}, nil
+}
+
+func (b *Broker) deadLetter(group string, p *Partition, offset uint64, attempts int, reason string) error {
+	msg, err := readMessage(p, offset)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := b.copyToDeadLetter(p, msg, group, attempts, reason)
+
+	if err != nil {
+		return err
+	}
+
+	committed, ok := b.groups.committed(group, p.Topic, p.ID)
+
+	if !ok {
+		if committed, err = p.journal.StartOffset(); err != nil {
+			return err
+		}
+	}
+
+	// The group only moves past the message it is at. Committing past a later message
+	// would skip the messages before it, which the group hasn't processed yet.
+	if committed == offset {
+		if err := b.groups.commit(group, p.Topic, p.ID, offset+1); err != nil {
+			return err
+		}
+	}
+
+	if err := b.groups.attempts.Clear(group, p.Topic, p.ID, offset); err != nil {
+		return err
+	}
+
+	level.Info(b.logger).Log("msg", "message moved to dead-letter topic", "group", group, "source", p, "offset", offset, "attempts", attempts, "dlqOffset", res.BaseOffset)
+
+	return nil
+}
+
+// copyToDeadLetter appends the message to the dead-letter topic of its partition,
+// the group is left out of the headers for messages of queues.
+func (b *Broker) copyToDeadLetter(p *Partition, msg *storage.Message, group string, attempts int, reason string) (ProduceResult, error) {
+	dead := &storage.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: append([]storage.Header(nil), msg.Headers...),
+	}
+
+	dead.SetHeader(DeadLetterReasonHeader, []byte(reason))
+
+	if group != "" {
+		dead.SetHeader(DeadLetterGroupHeader, []byte(group))
+	}
+
+	dead.SetHeader(DeadLetterTopicHeader, []byte(p.Topic))
+	dead.SetHeader(DeadLetterPartitionHeader, []byte(strconv.Itoa(p.ID)))
+	dead.SetHeader(DeadLetterOffsetHeader, []byte(strconv.FormatUint(msg.Offset, 10)))
+	dead.SetHeader(DeadLetterAttemptsHeader, []byte(strconv.Itoa(attempts)))
+
+	// Dead-letter topics are created even if topics are not created on produce.
+	res, err := b.produce(context.Background(), ProduceRequest{
+		Topic:     DeadLetterTopic(p.Topic),
+		Partition: 0,
+		Messages:  []*storage.Message{dead},
+	}, true)
+
+	if err != nil {
+		return ProduceResult{}, err
+	}
+
+	b.metrics.deadLetteredMessages.Inc()
+
+	return res, nil
+}
+
+// readMessage reads the message at the offset of the partition.
+func readMessage(p *Partition, offset uint64) (*storage.Message, error) {
+	msgs, err := p.journal.Read(offset, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msgs) == 0 || msgs[0].Offset != offset {
+		return nil, errors.Wrapf(storage.OffsetOutOfRange, "offset %d", offset)
+	}
+
+	return msgs[0], nil
+}
@@ -0,0 +1,461 @@
+package broker
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"iris/storage"
+
+	"github.com/pkg/errors"
+)
+
+var InvalidFilter = errors.New("Invalid filter")
+
+// Filter selects the messages a consumer is interested in.
+type Filter interface {
+	Match(msg *storage.Message) bool
+	String() string
+}
+
+// ParseFilter parses a filter expression on the key and the headers of messages:
+//
+//	key = 'order-1'
+//	key prefix 'order-' and header.region in ('eu', 'us')
+//	header.type != 'test' or (header.priority = 'high' and header.retry prefix '')
+//
+// Conditions compare the key or a header with = (or its negation !=), prefix
+// and in, they are combined with and, which binds stronger than or, and
+// grouped with parentheses. A missing header only matches !=. Keywords are case
+// insensitive, strings are quoted with ' or " and backslash escapes the next character.
+func ParseFilter(expr string) (Filter, error) {
+	p := &filterParser{lexer: filterLexer{input: expr}}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	f, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.token)
+	}
+
+	return f, nil
+}
+
+type filterOp int
+
+const (
+	opEqual filterOp = iota
+	opNotEqual
+	opPrefix
+	opIn
+)
+
+// condition matches the key or a header of a message against values.
+type condition struct {
+	// header is empty for conditions on the key.
+	header string
+	op     filterOp
+	values [][]byte
+}
+
+func (c *condition) Match(msg *storage.Message) bool {
+	value, ok := msg.Key, true
+
+	if c.header != "" {
+		value, ok = msg.Header(c.header)
+	}
+
+	if !ok {
+		return c.op == opNotEqual
+	}
+
+	switch c.op {
+	case opEqual:
+		return bytes.Equal(value, c.values[0])
+	case opNotEqual:
+		return !bytes.Equal(value, c.values[0])
+	case opPrefix:
+		return bytes.HasPrefix(value, c.values[0])
+	case opIn:
+		for _, v := range c.values {
+			if bytes.Equal(value, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *condition) String() string {
+	field := "key"
+
+	if c.header != "" {
+		field = "header." + c.header
+	}
+
+	quoted := make([]string, 0, len(c.values))
+
+	for _, v := range c.values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+
+	switch c.op {
+	case opEqual:
+		return field + " = " + quoted[0]
+	case opNotEqual:
+		return field + " != " + quoted[0]
+	case opPrefix:
+		return field + " prefix " + quoted[0]
+	default:
+		return field + " in (" + strings.Join(quoted, ", ") + ")"
+	}
+}
+
+type andFilter []Filter
+
+func (f andFilter) Match(msg *storage.Message) bool {
+	for _, c := range f {
+		if !c.Match(msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f andFilter) String() string {
+	return joinFilters(f, " and ")
+}
+
+type orFilter []Filter
+
+func (f orFilter) Match(msg *storage.Message) bool {
+	for _, c := range f {
+		if c.Match(msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f orFilter) String() string {
+	return joinFilters(f, " or ")
+}
+
+func joinFilters(filters []Filter, sep string) string {
+	parts := make([]string, 0, len(filters))
+
+	for _, f := range filters {
+		parts = append(parts, "("+f.String()+")")
+	}
+
+	return strings.Join(parts, sep)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenEqual
+	tokenNotEqual
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// is reports whether the token is the given keyword.
+func (t token) is(keyword string) bool {
+	return t.kind == tokenIdent && strings.EqualFold(t.value, keyword)
+}
+
+type filterLexer struct {
+	input string
+	pos   int
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+func (l *filterLexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+
+	start := l.pos
+
+	if l.pos == len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	switch c := l.input[l.pos]; {
+	case c == '(':
+		l.pos++
+		return token{kind: tokenLParen, value: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokenRParen, value: ")", pos: start}, nil
+	case c == ',':
+		l.pos++
+		return token{kind: tokenComma, value: ",", pos: start}, nil
+	case c == '=':
+		l.pos++
+		return token{kind: tokenEqual, value: "=", pos: start}, nil
+	case c == '!' && strings.HasPrefix(l.input[l.pos:], "!="):
+		l.pos += 2
+		return token{kind: tokenNotEqual, value: "!=", pos: start}, nil
+	case c == '\'' || c == '"':
+		return l.quoted(c)
+	}
+
+	for _, r := range l.input[l.pos:] {
+		if !isIdentRune(r) {
+			break
+		}
+
+		l.pos += len(string(r))
+	}
+
+	if l.pos == start {
+		return token{}, errors.Wrapf(InvalidFilter, "unexpected %q at %d", l.input[start], start)
+	}
+
+	return token{kind: tokenIdent, value: l.input[start:l.pos], pos: start}, nil
+}
+
+func (l *filterLexer) quoted(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var b strings.Builder
+
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		l.pos++
+
+		switch {
+		case c == quote:
+			return token{kind: tokenString, value: b.String(), pos: start}, nil
+		case c == '\\' && l.pos < len(l.input):
+			b.WriteByte(l.input[l.pos])
+			l.pos++
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return token{}, errors.Wrapf(InvalidFilter, "unterminated string at %d", start)
+}
+
+type filterParser struct {
+	lexer filterLexer
+	token token
+}
+
+func (p *filterParser) advance() error {
+	t, err := p.lexer.next()
+
+	if err != nil {
+		return err
+	}
+
+	p.token = t
+
+	return nil
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return errors.Wrapf(InvalidFilter, "%s at %d", fmt.Sprintf(format, args...), p.token.pos)
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	f, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	filters := orFilter{f}
+
+	for p.token.is("or") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		f, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, f)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return filters, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	f, err := p.parseTerm()
+
+	if err != nil {
+		return nil, err
+	}
+
+	filters := andFilter{f}
+
+	for p.token.is("and") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		f, err := p.parseTerm()
+
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, f)
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return filters, nil
+}
+
+func (p *filterParser) parseTerm() (Filter, error) {
+	if p.token.kind == tokenLParen {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		f, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.token.kind != tokenRParen {
+			return nil, p.errorf("expected ) instead of %s", p.token)
+		}
+
+		return f, p.advance()
+	}
+
+	return p.parseCondition()
+}
+
+func (p *filterParser) parseCondition() (Filter, error) {
+	c := &condition{}
+
+	switch {
+	case p.token.is("key"):
+	case p.token.kind == tokenIdent && strings.HasPrefix(strings.ToLower(p.token.value), "header.") && len(p.token.value) > len("header."):
+		c.header = p.token.value[len("header."):]
+	default:
+		return nil, p.errorf("expected key or header.<name> instead of %s", p.token)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.token.kind == tokenEqual:
+		c.op = opEqual
+	case p.token.kind == tokenNotEqual:
+		c.op = opNotEqual
+	case p.token.is("prefix"):
+		c.op = opPrefix
+	case p.token.is("in"):
+		c.op = opIn
+	default:
+		return nil, p.errorf("expected =, !=, prefix or in instead of %s", p.token)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if c.op != opIn {
+		value, err := p.parseString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.values = [][]byte{value}
+
+		return c, nil
+	}
+
+	if p.token.kind != tokenLParen {
+		return nil, p.errorf("expected ( instead of %s", p.token)
+	}
+
+	for {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseString()
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.values = append(c.values, value)
+
+		if p.token.kind == tokenRParen {
+			return c, p.advance()
+		}
+
+		if p.token.kind != tokenComma {
+			return nil, p.errorf("expected , or ) instead of %s", p.token)
+		}
+	}
+}
+
+func (p *filterParser) parseString() ([]byte, error) {
+	if p.token.kind != tokenString {
+		return nil, p.errorf("expected a string instead of %s", p.token)
+	}
+
+	value := []byte(p.token.value)
+
+	return value, p.advance()
+}
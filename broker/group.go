@@ -0,0 +1,123 @@
+package broker
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Groups keeps the committed offsets, delivery attempts and policies of the consumer groups.
+type Groups struct {
+	table    *storage.Table
+	attempts *storage.DeliveryAttempts
+
+	// Serializes nacks, so a message is dead-lettered only once.
+	mutex sync.Mutex
+}
+
+func openGroups(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int) (*Groups, error) {
+	table, err := storage.OpenTable(logger, registerer, dir, segmentSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Groups{
+		table:    table,
+		attempts: storage.NewDeliveryAttempts(table),
+	}, nil
+}
+
+func offsetKey(group string, topic string, partition int) string {
+	return fmt.Sprintf("offsets/%s/%s/%d", group, topic, partition)
+}
+
+func policyKey(group string) string {
+	return "policies/" + group
+}
+
+func (g *Groups) commit(group string, topic string, partition int, offset uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, offset)
+
+	if err := g.table.Put(offsetKey(group, topic, partition), value); err != nil {
+		return err
+	}
+
+	return g.attempts.ClearBelow(group, topic, partition, offset)
+}
+
+func (g *Groups) committed(group string, topic string, partition int) (uint64, bool) {
+	value, ok := g.table.Get(offsetKey(group, topic, partition))
+
+	if !ok || len(value) != 8 {
+		return 0, false
+	}
+
+	return binary.BigEndian.Uint64(value), true
+}
+
+func (g *Groups) setDeadLetterPolicy(group string, policy DeadLetterPolicy) error {
+	value, err := json.Marshal(policy)
+
+	if err != nil {
+		return err
+	}
+
+	return g.table.Put(policyKey(group), value)
+}
+
+func (g *Groups) deadLetterPolicy(group string) DeadLetterPolicy {
+	var policy DeadLetterPolicy
+
+	if value, ok := g.table.Get(policyKey(group)); ok {
+		json.Unmarshal(value, &policy)
+	}
+
+	return policy
+}
+
+func (g *Groups) stop() error {
+	return g.table.Stop()
+}
+
+// Commit stores the offset of the next message the group is going to consume from the partition.
+func (b *Broker) Commit(group string, topic string, partition int, offset uint64) error {
+	if err := validateName(group); err != nil {
+		return err
+	}
+
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return err
+	}
+
+	if end := p.HighWatermark(); offset > end {
+		return errors.Wrapf(storage.OffsetOutOfRange, "offset %d is after the end of %s", offset, p)
+	}
+
+	return b.groups.commit(group, topic, partition, offset)
+}
+
+// Committed returns the committed offset of the group, false is returned if the group has not committed yet.
+func (b *Broker) Committed(group string, topic string, partition int) (uint64, bool, error) {
+	if err := validateName(group); err != nil {
+		return 0, false, err
+	}
+
+	if _, err := b.Partition(topic, partition); err != nil {
+		return 0, false, err
+	}
+
+	offset, ok := b.groups.committed(group, topic, partition)
+
+	return offset, ok, nil
+}
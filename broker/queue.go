@@ -0,0 +1,280 @@
+package broker
+
+import (
+	"sync"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	ackLogDirName = "acks"
+
+	DefaultVisibilityTimeout = 30 * time.Second
+
+	// Reason given to the dead-letter topic for messages of queues which were received too often.
+	maxDeliveriesReason = "max deliveries exceeded"
+)
+
+var (
+	NotQueue  = errors.New("Topic is not a queue")
+	NotLeased = errors.New("Message is not leased")
+)
+
+// queue holds the delivery state of a partition of a queue topic.
+type queue struct {
+	// Serializes receives and acks, the ack log is not safe for concurrent use.
+	mutex sync.Mutex
+	acks  *storage.AckLog
+}
+
+type ReceiveRequest struct {
+	Topic       string
+	MaxMessages int
+	// VisibilityTimeout overrides the visibility timeout of the topic if it is set.
+	VisibilityTimeout time.Duration
+}
+
+// Lease is a message handed out to a consumer of a queue. It is hidden from
+// other consumers until the deadline and delivered again unless it is acked.
+type Lease struct {
+	Partition int
+	Message   *storage.Message
+	// Deliveries counts the deliveries of the message including this one.
+	Deliveries int
+	Deadline   time.Time
+}
+
+type ReleaseResult struct {
+	Deliveries   int
+	DeadLettered bool
+}
+
+func (b *Broker) queuePartition(topic string, partition int) (*Partition, error) {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.queue == nil {
+		return nil, errors.Wrapf(NotQueue, "%s", topic)
+	}
+
+	return p, nil
+}
+
+// Receive leases up to MaxMessages messages of the queue. Messages whose lease
+// has ended are delivered again before new ones, partitions are visited in turns.
+func (b *Broker) Receive(req ReceiveRequest) ([]Lease, error) {
+	topic, err := b.Topic(req.Topic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !topic.Config.Queue() {
+		return nil, errors.Wrapf(NotQueue, "%s", req.Topic)
+	}
+
+	max := req.MaxMessages
+
+	if max <= 0 || max > DefaultMaxFetchMessages {
+		max = DefaultMaxFetchMessages
+	}
+
+	timeout := req.VisibilityTimeout
+
+	if timeout <= 0 {
+		timeout = topic.Config.VisibilityTimeout
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultVisibilityTimeout
+	}
+
+	partitions := topic.Partitions()
+	first := int(topic.next.Add(1) % uint64(len(partitions)))
+	leases := make([]Lease, 0)
+
+	for i := 0; i < len(partitions) && len(leases) < max; i++ {
+		p := partitions[(first+i)%len(partitions)]
+
+		leased, err := b.receive(topic, p, max-len(leases), timeout)
+
+		if err != nil {
+			return leases, errors.Wrapf(err, "unable to receive from %s", p)
+		}
+
+		leases = append(leases, leased...)
+	}
+
+	return leases, nil
+}
+
+func (b *Broker) receive(topic *Topic, p *Partition, max int, timeout time.Duration) ([]Lease, error) {
+	p.queue.mutex.Lock()
+	defer p.queue.mutex.Unlock()
+
+	acks := p.queue.acks
+	now := time.Now()
+	deadline := now.Add(timeout)
+	leases := make([]Lease, 0)
+
+	lease := func(msg *storage.Message) error {
+		if msg.Expired(now) {
+			b.metrics.expiredSkipped.Inc()
+			return acks.Ack(msg.Offset)
+		}
+
+		deliveries, err := acks.Lease(msg.Offset, deadline)
+
+		if err != nil {
+			return err
+		}
+
+		leases = append(leases, Lease{Partition: p.ID, Message: msg, Deliveries: deliveries, Deadline: deadline})
+
+		return nil
+	}
+
+	for _, offset := range acks.Expired(now, max) {
+		msg, err := readMessage(p, offset)
+
+		// The message is gone with its segment, there is nothing left to deliver.
+		if errors.Is(err, storage.OffsetOutOfRange) {
+			if err := acks.Ack(offset); err != nil {
+				return leases, err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return leases, err
+		}
+
+		if n := topic.Config.MaxDeliveries; n > 0 && acks.State(offset).Deliveries >= n {
+			if err := b.deadLetterQueued(p, msg, maxDeliveriesReason); err != nil {
+				return leases, err
+			}
+
+			continue
+		}
+
+		if err := lease(msg); err != nil {
+			return leases, err
+		}
+
+		b.metrics.queueRedelivered.Inc()
+	}
+
+	if len(leases) < max && acks.Next() < p.NextOffset() {
+		msgs := make([]*storage.Message, 0)
+
+		err := p.journal.Scan(acks.Next(), func(msg *storage.Message) bool {
+			msgs = append(msgs, msg)
+			return len(msgs) < max-len(leases)
+		})
+
+		if err != nil {
+			return leases, err
+		}
+
+		for _, msg := range msgs {
+			if err := lease(msg); err != nil {
+				return leases, err
+			}
+		}
+	}
+
+	b.metrics.queueReceived.Add(float64(len(leases)))
+
+	return leases, nil
+}
+
+// deadLetterQueued moves the message of a queue to the dead-letter topic and acks it.
+func (b *Broker) deadLetterQueued(p *Partition, msg *storage.Message, reason string) error {
+	deliveries := p.queue.acks.State(msg.Offset).Deliveries
+
+	res, err := b.copyToDeadLetter(p, msg, "", deliveries, reason)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to dead-letter offset %d of %s", msg.Offset, p)
+	}
+
+	level.Info(b.logger).Log("msg", "message moved to dead-letter topic", "source", p, "offset", msg.Offset, "deliveries", deliveries, "dlqOffset", res.BaseOffset)
+
+	return p.queue.acks.Ack(msg.Offset)
+}
+
+// Ack marks the leased message as processed, it is not delivered again.
+func (b *Broker) Ack(topic string, partition int, offset uint64) error {
+	p, err := b.queuePartition(topic, partition)
+
+	if err != nil {
+		return err
+	}
+
+	p.queue.mutex.Lock()
+	defer p.queue.mutex.Unlock()
+
+	if s := p.queue.acks.State(offset); s.Acked || s.Deliveries == 0 {
+		return errors.Wrapf(NotLeased, "offset %d of %s", offset, p)
+	}
+
+	if err := p.queue.acks.Ack(offset); err != nil {
+		return err
+	}
+
+	b.metrics.queueAcked.Inc()
+
+	return nil
+}
+
+// Release ends the lease of a message which could not be processed, so it is
+// delivered again at once. Once the message has been delivered MaxDeliveries
+// times it is moved to the dead-letter topic instead.
+func (b *Broker) Release(topic string, partition int, offset uint64, reason string) (ReleaseResult, error) {
+	p, err := b.queuePartition(topic, partition)
+
+	if err != nil {
+		return ReleaseResult{}, err
+	}
+
+	t, err := b.Topic(topic)
+
+	if err != nil {
+		return ReleaseResult{}, err
+	}
+
+	p.queue.mutex.Lock()
+	defer p.queue.mutex.Unlock()
+
+	s := p.queue.acks.State(offset)
+
+	if s.Acked || s.Deliveries == 0 {
+		return ReleaseResult{}, errors.Wrapf(NotLeased, "offset %d of %s", offset, p)
+	}
+
+	b.metrics.queueReleased.Inc()
+
+	if n := t.Config.MaxDeliveries; n == 0 || s.Deliveries < n {
+		return ReleaseResult{Deliveries: s.Deliveries}, p.queue.acks.Release(offset)
+	}
+
+	msg, err := readMessage(p, offset)
+
+	if err != nil {
+		return ReleaseResult{}, err
+	}
+
+	if err := b.deadLetterQueued(p, msg, reason); err != nil {
+		return ReleaseResult{}, err
+	}
+
+	return ReleaseResult{Deliveries: s.Deliveries, DeadLettered: true}, nil
+}
@@ -0,0 +1,466 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultReplicaCopyRate = 50 * 1024 * 1024
+
+	// Segment files are copied in chunks of at most this many bytes.
+	segmentCopyChunk = 1024 * 1024
+	// Copied segments are collected in this directory of the partition until they are imported.
+	importDirName = "import"
+	// A reassignment checks this often whether the new replicas have caught up.
+	reassignmentCheckInterval = 100 * time.Millisecond
+)
+
+type SegmentReadRequest struct {
+	Topic     string
+	Partition int
+	// Name is a file name listed by SegmentFiles.
+	Name     string
+	Position int64
+	MaxBytes int
+}
+
+// throttle spaces out copies to a rate in bytes per second.
+type throttle struct {
+	rate int
+
+	mutex sync.Mutex
+	// next is when the next chunk may be copied.
+	next time.Time
+}
+
+// wait blocks until n more bytes may be copied without exceeding the rate.
+func (t *throttle) wait(ctx context.Context, n int) error {
+	if t.rate <= 0 {
+		return nil
+	}
+
+	t.mutex.Lock()
+	now := time.Now()
+	start := t.next
+
+	if start.Before(now) {
+		start = now
+	}
+
+	t.next = start.Add(time.Duration(n) * time.Second / time.Duration(t.rate))
+	t.mutex.Unlock()
+
+	if !start.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SegmentFiles lists the files of the sealed segments of a partition, which new replicas copy.
+func (b *Broker) SegmentFiles(topic string, partition int) ([]storage.SegmentFile, error) {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p.journal.SegmentFiles()
+}
+
+// ReadSegmentFile reads a chunk of a file listed by SegmentFiles.
+func (b *Broker) ReadSegmentFile(req SegmentReadRequest) ([]byte, error) {
+	p, err := b.Partition(req.Topic, req.Partition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	maxBytes := req.MaxBytes
+
+	if maxBytes <= 0 || maxBytes > segmentCopyChunk {
+		maxBytes = segmentCopyChunk
+	}
+
+	return p.journal.ReadSegmentFile(req.Name, req.Position, maxBytes)
+}
+
+// copySegments copies the sealed segments of the leader into the empty journal of a new replica,
+// which fetches the rest of the partition from the leader afterwards.
+func (b *Broker) copySegments(ctx context.Context, p *Partition, leader int32) error {
+	files, err := b.options.ReplicaClient.SegmentFiles(ctx, leader, p.Topic, p.ID)
+
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	dir := filepath.Join(partitionDir(b.options.Dir, p.Topic, p.ID), importDirName)
+
+	// Files of an interrupted copy are copied again.
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	size := int64(0)
+
+	for _, file := range files {
+		if err := b.copySegmentFile(ctx, p, leader, dir, file); err != nil {
+			return errors.Wrapf(err, "unable to copy %s", file.Name)
+		}
+
+		size += file.Size
+	}
+
+	if err := p.journal.Import(dir); err != nil {
+		return err
+	}
+
+	level.Info(b.logger).Log("msg", "copied segments from leader", "partition", p, "leader", leader, "files", len(files), "bytes", size, "next", p.journal.NextOffset())
+
+	return nil
+}
+
+func (b *Broker) copySegmentFile(ctx context.Context, p *Partition, leader int32, dir string, file storage.SegmentFile) error {
+	if filepath.Base(file.Name) != file.Name {
+		return errors.Wrapf(storage.UnknownSegmentFile, "%q", file.Name)
+	}
+
+	f, err := os.Create(filepath.Join(dir, file.Name))
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	for position := int64(0); position < file.Size; {
+		n := int(min(segmentCopyChunk, file.Size-position))
+
+		if err := b.copyThrottle.wait(ctx, n); err != nil {
+			return err
+		}
+
+		data, err := b.options.ReplicaClient.ReadSegmentFile(ctx, leader, SegmentReadRequest{
+			Topic:     p.Topic,
+			Partition: p.ID,
+			Name:      file.Name,
+			Position:  position,
+			MaxBytes:  n,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if len(data) == 0 {
+			return errors.Errorf("file ends at %d instead of %d", position, file.Size)
+		}
+
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+
+		position += int64(len(data))
+		b.metrics.copiedBytes.Add(float64(len(data)))
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// deleteReplica removes the messages of a partition the broker is not a replica of anymore.
+func (b *Broker) deleteReplica(p *Partition) {
+	if err := p.journal.Clear(); err != nil {
+		level.Error(b.logger).Log("msg", "unable to delete replica of partition", "partition", p, "err", err)
+		return
+	}
+
+	level.Info(b.logger).Log("msg", "deleted replica of partition", "partition", p)
+}
+
+// SetReplicas changes the replicas of a partition, the leader must remain one of them.
+// Added replicas copy the partition from the leader and follow it, a broker which
+// is not a replica anymore deletes its copy.
+func (b *Broker) SetReplicas(topic string, partition int, replicas []int32) error {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return err
+	}
+
+	r := p.replication
+
+	if r == nil {
+		return errors.Wrapf(InvalidTopicConfig, "%s has no replicas", p)
+	}
+
+	if leader := r.leader.Load(); !slices.Contains(replicas, leader) {
+		return errors.Wrapf(UnknownReplica, "leader %d is not one of the replicas %v of %s", leader, replicas, p)
+	}
+
+	replicas = slices.Clone(replicas)
+
+	if err := b.storeReplicas(topic, partition, replicas); err != nil {
+		return err
+	}
+
+	level.Info(b.logger).Log("msg", "partition replicas changed", "partition", p, "replicas", fmt.Sprint(replicas))
+
+	if r.setReplicas(replicas, p.journal.NextOffset(), time.Now()) {
+		b.deleteReplica(p)
+	}
+
+	if b.options.ReplicaClient != nil {
+		b.startFollower(p)
+	}
+
+	return nil
+}
+
+// reassignedConfig returns the stored config of a topic with other replicas for a partition.
+func (b *Broker) reassignedConfig(topic string, partition int, replicas []int32) (TopicConfig, error) {
+	value, ok := b.table.Get(topic)
+
+	if !ok {
+		return TopicConfig{}, errors.Wrapf(UnknownTopic, "%s", topic)
+	}
+
+	var config TopicConfig
+
+	if err := json.Unmarshal(value, &config); err != nil {
+		return TopicConfig{}, errors.Wrapf(err, "invalid config of topic %s", topic)
+	}
+
+	if partition < 0 || partition >= len(config.Replicas) {
+		return TopicConfig{}, errors.Wrapf(UnknownPartition, "partition %d of topic %s", partition, topic)
+	}
+
+	config.Replicas[partition] = replicas
+
+	return config, config.Validate()
+}
+
+// storeReplicas keeps the replicas of a partition in the topic config, so they are known after a restart.
+func (b *Broker) storeReplicas(topic string, partition int, replicas []int32) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return BrokerClosed
+	}
+
+	config, err := b.reassignedConfig(topic, partition, replicas)
+
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(config)
+
+	if err != nil {
+		return err
+	}
+
+	return b.table.Put(topic, value)
+}
+
+// ReassignPartition moves a partition led by the broker to the given replicas. The added replicas
+// copy the sealed segments in bulk and fetch the rest like any follower. Once they are in sync,
+// the leadership moves to the first of them unless the leader remains a replica, and the former
+// replicas delete their copy.
+func (b *Broker) ReassignPartition(ctx context.Context, topic string, partition int, replicas []int32) error {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return err
+	}
+
+	r := p.replication
+
+	if r == nil {
+		return errors.Wrapf(InvalidTopicConfig, "%s has no replicas", p)
+	}
+
+	if err := p.leading(); err != nil {
+		return err
+	}
+
+	b.mutex.RLock()
+	_, err = b.reassignedConfig(topic, partition, replicas)
+	b.mutex.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	current := slices.Clone(r.replicas)
+	r.mutex.Unlock()
+
+	all := slices.Clone(current)
+
+	for _, id := range replicas {
+		if !slices.Contains(all, id) {
+			all = append(all, id)
+		}
+	}
+
+	level.Info(b.logger).Log("msg", "partition reassignment started", "partition", p, "from", fmt.Sprint(current), "to", fmt.Sprint(replicas))
+
+	if err := b.setReplicasOn(ctx, all, topic, partition, all); err != nil {
+		return err
+	}
+
+	if err := b.waitReassigned(ctx, p, replicas); err != nil {
+		return err
+	}
+
+	if leader := r.leader.Load(); !slices.Contains(replicas, leader) {
+		// The former leader is told last, it takes messages until then.
+		order := []int32{replicas[0]}
+
+		for _, id := range all {
+			if id != replicas[0] && id != leader {
+				order = append(order, id)
+			}
+		}
+
+		epoch := r.epoch.Load() + 1
+
+		for _, id := range append(order, leader) {
+			if err := b.setLeaderOn(ctx, id, topic, partition, replicas[0], epoch); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := b.setReplicasOn(ctx, all, topic, partition, replicas); err != nil {
+		return err
+	}
+
+	level.Info(b.logger).Log("msg", "partition reassigned", "partition", p, "replicas", fmt.Sprint(replicas))
+	b.metrics.reassignments.Inc()
+
+	return nil
+}
+
+// waitReassigned waits until the replicas are in sync. The first one must also have all messages
+// if it takes over the leadership.
+func (b *Broker) waitReassigned(ctx context.Context, p *Partition, replicas []int32) error {
+	ticker := time.NewTicker(reassignmentCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.leading(); err != nil {
+			return err
+		}
+
+		if reassigned(p, replicas) {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.done:
+			return BrokerClosed
+		}
+	}
+}
+
+func reassigned(p *Partition, replicas []int32) bool {
+	states := p.Replicas()
+	leader := states[0].ID
+	end := p.NextOffset()
+
+	// Replicas only accept an in-sync leader, so all must know the in-sync replicas before it moves.
+	if !slices.Contains(replicas, leader) {
+		for _, s := range states[1:] {
+			if !s.ISRKnown {
+				return false
+			}
+		}
+	}
+
+	for _, id := range replicas {
+		if id == leader {
+			continue
+		}
+
+		i := slices.IndexFunc(states, func(s ReplicaState) bool { return s.ID == id })
+
+		if i < 0 || !states[i].InSync {
+			return false
+		}
+
+		if id == replicas[0] && !slices.Contains(replicas, leader) && states[i].Offset < end {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b *Broker) setReplicasOn(ctx context.Context, ids []int32, topic string, partition int, replicas []int32) error {
+	for _, id := range ids {
+		var err error
+
+		if id == b.options.NodeID {
+			err = b.SetReplicas(topic, partition, replicas)
+		} else if b.options.ReplicaClient == nil {
+			err = errors.Wrapf(UnknownReplica, "%d can't be reached without a replica client", id)
+		} else {
+			err = b.options.ReplicaClient.SetReplicas(ctx, id, topic, partition, replicas)
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "unable to set replicas on %d", id)
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker) setLeaderOn(ctx context.Context, id int32, topic string, partition int, leader int32, epoch int32) error {
+	var err error
+
+	if id == b.options.NodeID {
+		err = b.SetLeader(topic, partition, leader, epoch)
+	} else if b.options.ReplicaClient == nil {
+		err = errors.Wrapf(UnknownReplica, "%d can't be reached without a replica client", id)
+	} else {
+		err = b.options.ReplicaClient.SetLeader(ctx, id, topic, partition, leader, epoch)
+	}
+
+	return errors.Wrapf(err, "unable to set leader on %d", id)
+}
@@ -0,0 +1,884 @@
+package broker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultReplicaLagTime = 10 * time.Second
+
+	// Followers wait this long before fetching again after a failed fetch.
+	replicaFetchBackoff = time.Second
+	// Followers ask for at most this many bytes and wait at most this long for new messages.
+	replicaFetchMaxBytes = 1024 * 1024
+	replicaFetchMaxWait  = 5 * time.Second
+)
+
+var (
+	NotLeader             = errors.New("Broker is not the leader of the partition")
+	UnknownReplica        = errors.New("Unknown replica")
+	StaleLeaderEpoch      = errors.New("Stale leader epoch")
+	UncleanLeaderElection = errors.New("Unclean leader election")
+)
+
+// ReplicaClient reaches the other replicas of partitions. Followers fetch messages and copy
+// sealed segments from the leader, a reassignment changes the replicas on every broker.
+type ReplicaClient interface {
+	ReplicaFetch(ctx context.Context, leader int32, req ReplicaFetchRequest) (ReplicaFetchResult, error)
+	SegmentFiles(ctx context.Context, leader int32, topic string, partition int) ([]storage.SegmentFile, error)
+	ReadSegmentFile(ctx context.Context, leader int32, req SegmentReadRequest) ([]byte, error)
+	SetReplicas(ctx context.Context, id int32, topic string, partition int, replicas []int32) error
+	SetLeader(ctx context.Context, id int32, topic string, partition int, leader int32, epoch int32) error
+}
+
+type ReplicaFetchRequest struct {
+	// ReplicaID is the node id of the fetching follower.
+	ReplicaID int32
+	Topic     string
+	Partition int
+	// Offset is the next offset of the follower, it reports that the
+	// follower has replicated all messages before it.
+	Offset uint64
+	// LastEpoch is the leader epoch of the last message of the follower,
+	// the leader checks that its journal has the same messages up to the offset.
+	LastEpoch int32
+	MaxBytes  int
+	// MaxWait is how long the leader waits for new messages if the follower has caught up.
+	MaxWait time.Duration
+}
+
+type ReplicaFetchResult struct {
+	// Messages holds all messages from the offset on, including expired ones.
+	Messages      []*storage.Message
+	HighWatermark uint64
+	// LogStartOffset is the offset before which the messages were deleted on the leader.
+	LogStartOffset uint64
+	// Epochs holds the leader epochs of the messages.
+	Epochs []storage.Epoch
+	// Diverging is set without messages if the journal of the follower diverges from the leader's.
+	Diverging *EpochEnd
+	// ISR holds the in-sync replicas of the partition.
+	ISR []int32
+}
+
+// EpochEnd is the last leader epoch the journals of a follower and the leader may have in common
+// and the offset it ends at on the leader.
+type EpochEnd struct {
+	Epoch     int32
+	EndOffset uint64
+}
+
+type ReplicaState struct {
+	ID int32
+	// Offset is the next offset of the replica.
+	Offset  uint64
+	InSync  bool
+	Contact time.Time
+	// ISRKnown is set for followers which know the current in-sync replicas.
+	ISRKnown bool
+}
+
+// replication is the replica state of a partition with assigned replicas.
+// The leader tracks the position of every follower, followers which have fetched the end
+// of its journal within the lag time are in sync. The high watermark is the offset all
+// in-sync replicas have reached, consumers only see the messages before it.
+type replication struct {
+	nodeID int32
+	leader atomic.Int32
+	// epoch is incremented whenever the leadership moves.
+	epoch atomic.Int32
+
+	mutex sync.Mutex
+	// replicas changes when the partition is reassigned.
+	replicas  []int32
+	followers map[int32]*follower
+	hw        uint64
+	// isr is the in-sync replicas a follower was last told by the leader.
+	isr []int32
+	// Closed and replaced whenever the high watermark advances.
+	advanced chan struct{}
+	// following is set while the partition is fetched from the leader.
+	following bool
+	// Closed and replaced whenever the leader, the replicas or the in-sync replicas change.
+	changed chan struct{}
+}
+
+type follower struct {
+	offset uint64
+	inSync bool
+	// isrSent is set once a fetch response told the follower the current in-sync replicas,
+	// isrKnown once the follower fetched again after it, so it has taken them over.
+	isrSent  bool
+	isrKnown bool
+	// caughtUp is when the follower last fetched from the end of the leader's journal.
+	caughtUp time.Time
+	contact  time.Time
+}
+
+// newReplication starts with all followers in sync, so messages only become visible
+// once the followers have fetched them or they fell behind for the lag time.
+func newReplication(nodeID int32, replicas []int32, epoch int32, now time.Time) *replication {
+	r := &replication{
+		nodeID:    nodeID,
+		replicas:  replicas,
+		followers: make(map[int32]*follower),
+		isr:       slices.Clone(replicas),
+		advanced:  make(chan struct{}),
+		changed:   make(chan struct{}),
+	}
+
+	r.leader.Store(replicas[0])
+	r.epoch.Store(epoch)
+	r.resetFollowers(now)
+
+	return r
+}
+
+func (r *replication) resetFollowers(now time.Time) {
+	clear(r.followers)
+
+	for _, id := range r.replicas {
+		if id != r.leader.Load() {
+			r.followers[id] = &follower{inSync: true, caughtUp: now}
+		}
+	}
+}
+
+func (r *replication) isLeader() bool {
+	return r.leader.Load() == r.nodeID
+}
+
+// inSync reports whether a replica is in sync. The leader knows it from the positions of
+// its followers, the other replicas from the in-sync replicas the leader told them last.
+func (r *replication) inSync(id int32) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if id == r.leader.Load() {
+		return true
+	}
+
+	if r.isLeader() {
+		f, ok := r.followers[id]
+		return ok && f.inSync
+	}
+
+	return slices.Contains(r.isr, id)
+}
+
+// sendISR returns the in-sync replicas for a fetch response to the follower.
+func (r *replication) sendISR(id int32) []int32 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	isr := []int32{r.leader.Load()}
+
+	for _, replica := range r.replicas {
+		if f, ok := r.followers[replica]; ok && f.inSync {
+			isr = append(isr, replica)
+		}
+	}
+
+	if f, ok := r.followers[id]; ok {
+		f.isrSent = true
+	}
+
+	return isr
+}
+
+// isrChanged makes the followers learn the in-sync replicas again and wakes their waiting fetches.
+func (r *replication) isrChanged() {
+	for _, f := range r.followers {
+		f.isrSent = false
+		f.isrKnown = false
+	}
+
+	r.change()
+}
+
+// setLeader moves the leadership to another replica, a new leader starts with all followers in sync.
+func (r *replication) setLeader(leader int32, epoch int32, now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.leader.Store(leader)
+	r.epoch.Store(epoch)
+	r.resetFollowers(now)
+	r.change()
+}
+
+func (r *replication) change() {
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// changes returns a channel which is closed by the next change of the leader or the replicas.
+func (r *replication) changes() <-chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.changed
+}
+
+// startFollowing reports whether a fetcher has to be started for the partition.
+func (r *replication) startFollowing() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.following || r.isLeader() || !slices.Contains(r.replicas, r.nodeID) {
+		return false
+	}
+
+	r.following = true
+
+	return true
+}
+
+// stopFollowing reports whether the fetcher has to stop because the node leads the partition
+// now or is not one of its replicas anymore.
+func (r *replication) stopFollowing() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.following = !r.isLeader() && slices.Contains(r.replicas, r.nodeID)
+
+	return !r.following
+}
+
+// setReplicas replaces the replicas, added followers are not in sync before they caught up.
+// It reports whether the node has to delete its copy of the partition, which a running
+// fetcher does once it stopped instead.
+func (r *replication) setReplicas(replicas []int32, end uint64, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := slices.Contains(r.replicas, r.nodeID) && !slices.Contains(replicas, r.nodeID)
+	r.replicas = replicas
+	r.isr = slices.DeleteFunc(r.isr, func(id int32) bool { return !slices.Contains(replicas, id) })
+
+	for id := range r.followers {
+		if !slices.Contains(replicas, id) {
+			delete(r.followers, id)
+		}
+	}
+
+	for _, id := range replicas {
+		if _, ok := r.followers[id]; !ok && id != r.leader.Load() {
+			r.followers[id] = &follower{caughtUp: now}
+		}
+	}
+
+	if r.isLeader() {
+		r.updateHighWatermark(end)
+	}
+
+	r.isrChanged()
+
+	return removed && !r.following
+}
+
+func (r *replication) isReplica(id int32) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return slices.Contains(r.replicas, id)
+}
+
+func (r *replication) replicaCount() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.replicas)
+}
+
+// updateHighWatermark moves the high watermark to the smallest offset of the in-sync replicas,
+// it never moves back. end is the next offset of the leader.
+func (r *replication) updateHighWatermark(end uint64) {
+	hw := end
+
+	for _, f := range r.followers {
+		if f.inSync {
+			hw = min(hw, f.offset)
+		}
+	}
+
+	r.advance(hw)
+}
+
+func (r *replication) advance(hw uint64) {
+	if hw <= r.hw {
+		return
+	}
+
+	r.hw = hw
+
+	close(r.advanced)
+	r.advanced = make(chan struct{})
+}
+
+// fetched records the position reported by a follower, it returns whether the in-sync replicas changed.
+func (r *replication) fetched(id int32, offset uint64, end uint64, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	f, ok := r.followers[id]
+
+	// The leadership moved since the fetch was checked.
+	if !ok {
+		return false
+	}
+
+	f.offset = offset
+	f.contact = now
+	f.isrKnown = f.isrSent
+
+	if offset >= end {
+		f.caughtUp = now
+	}
+
+	expanded := !f.inSync && offset >= r.hw
+
+	if expanded {
+		f.inSync = true
+		r.isrChanged()
+	}
+
+	r.updateHighWatermark(end)
+
+	return expanded
+}
+
+// shrink removes the followers which have not caught up within the lag time from
+// the in-sync replicas and returns how many were removed.
+func (r *replication) shrink(end uint64, lagTime time.Duration, now time.Time) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := 0
+
+	for _, f := range r.followers {
+		if f.inSync && now.Sub(f.caughtUp) > lagTime {
+			f.inSync = false
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		r.isrChanged()
+	}
+
+	r.updateHighWatermark(end)
+
+	return removed
+}
+
+func (r *replication) appended(end uint64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.updateHighWatermark(end)
+}
+
+func (r *replication) highWatermark() uint64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.hw
+}
+
+func (r *replication) highWatermarkAdvanced(offset uint64) <-chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if offset < r.hw {
+		done := make(chan struct{})
+		close(done)
+
+		return done
+	}
+
+	return r.advanced
+}
+
+// Leader returns the node id of the leader of the partition, which is the only replica
+// producers and consumers use. It is -1 for partitions without assigned replicas.
+func (p *Partition) Leader() int32 {
+	if p.replication == nil {
+		return -1
+	}
+
+	return p.replication.leader.Load()
+}
+
+// LeaderEpoch returns the epoch of the current leader of the partition, -1 for partitions
+// without assigned replicas.
+func (p *Partition) LeaderEpoch() int32 {
+	if p.replication == nil {
+		return storage.NoEpoch
+	}
+
+	return p.replication.epoch.Load()
+}
+
+// Replicas returns the state of the replicas of the partition, the leader comes first.
+// Followers only know their own state.
+func (p *Partition) Replicas() []ReplicaState {
+	if p.replication == nil {
+		return nil
+	}
+
+	r := p.replication
+	states := []ReplicaState{{ID: r.leader.Load(), Offset: p.journal.NextOffset(), InSync: true}}
+
+	if !r.isLeader() {
+		return states
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, id := range r.replicas {
+		if f, ok := r.followers[id]; ok {
+			states = append(states, ReplicaState{ID: id, Offset: f.offset, InSync: f.inSync, Contact: f.contact, ISRKnown: f.isrKnown})
+		}
+	}
+
+	return states
+}
+
+// ISR returns the node ids of the in-sync replicas of the partition.
+func (p *Partition) ISR() []int32 {
+	isr := make([]int32, 0)
+
+	for _, r := range p.Replicas() {
+		if r.InSync {
+			isr = append(isr, r.ID)
+		}
+	}
+
+	return isr
+}
+
+// HighWatermark returns the offset up to which consumers see the messages of the partition.
+func (p *Partition) HighWatermark() uint64 {
+	if p.replication == nil {
+		return p.journal.NextOffset()
+	}
+
+	return p.replication.highWatermark()
+}
+
+// highWatermarkAdvanced returns a channel which is closed once the high watermark has passed the offset.
+func (p *Partition) highWatermarkAdvanced(offset uint64) <-chan struct{} {
+	if p.replication == nil {
+		return p.journal.Appended(offset)
+	}
+
+	return p.replication.highWatermarkAdvanced(offset)
+}
+
+// leading returns NotLeader if producers and consumers must use another broker for the partition.
+func (p *Partition) leading() error {
+	if p.replication != nil && !p.replication.isLeader() {
+		return errors.Wrapf(NotLeader, "%s is led by %d", p, p.replication.leader.Load())
+	}
+
+	return nil
+}
+
+// ReplicaFetch returns the messages of a partition from the offset of a follower on and
+// records the position of the follower. If the follower has caught up it waits up to
+// the max wait for new messages.
+func (b *Broker) ReplicaFetch(ctx context.Context, req ReplicaFetchRequest) (ReplicaFetchResult, error) {
+	p, err := b.Partition(req.Topic, req.Partition)
+
+	if err != nil {
+		return ReplicaFetchResult{}, err
+	}
+
+	r := p.replication
+
+	if r == nil || !r.isLeader() {
+		return ReplicaFetchResult{}, errors.Wrapf(NotLeader, "%s", p)
+	}
+
+	if req.ReplicaID == r.nodeID || !r.isReplica(req.ReplicaID) {
+		return ReplicaFetchResult{}, errors.Wrapf(UnknownReplica, "%d is not a follower of %s", req.ReplicaID, p)
+	}
+
+	start, err := p.journal.StartOffset()
+
+	if err != nil {
+		return ReplicaFetchResult{}, err
+	}
+
+	// A follower behind the log start offset is told where to continue, the messages it
+	// is missing were deleted.
+	if req.Offset < start {
+		return ReplicaFetchResult{HighWatermark: r.highWatermark(), LogStartOffset: start}, nil
+	}
+
+	end := p.journal.NextOffset()
+
+	if div := diverging(p.journal.Epochs(), req.LastEpoch, req.Offset, end); div != nil {
+		return ReplicaFetchResult{HighWatermark: r.highWatermark(), Diverging: div}, nil
+	}
+
+	// A change of the in-sync replicas ends the wait, so the followers learn it at once.
+	changed := r.changes()
+
+	if r.fetched(req.ReplicaID, req.Offset, end, time.Now()) {
+		level.Info(b.logger).Log("msg", "replica joined the in-sync replicas", "partition", p, "replica", req.ReplicaID)
+		b.metrics.isrExpands.Inc()
+	}
+
+	// Followers are told the high watermark if nothing is appended in time.
+	if req.Offset == end && req.MaxWait > 0 {
+		timer := time.NewTimer(req.MaxWait)
+
+		select {
+		case <-p.journal.Appended(req.Offset):
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ReplicaFetchResult{}, ctx.Err()
+		case <-b.done:
+			timer.Stop()
+			return ReplicaFetchResult{}, BrokerClosed
+		}
+
+		timer.Stop()
+	}
+
+	msgs := make([]*storage.Message, 0)
+	size := 0
+
+	err = p.journal.Scan(req.Offset, func(msg *storage.Message) bool {
+		msgs = append(msgs, msg)
+		size += len(msg.Key) + len(msg.Value)
+
+		return req.MaxBytes <= 0 || size < req.MaxBytes
+	})
+
+	if err != nil {
+		return ReplicaFetchResult{}, err
+	}
+
+	return ReplicaFetchResult{
+		Messages:       msgs,
+		HighWatermark:  r.highWatermark(),
+		LogStartOffset: start,
+		Epochs:         p.journal.Epochs().Entries(req.Offset),
+		ISR:            r.sendISR(req.ReplicaID),
+	}, nil
+}
+
+// diverging returns where the journal of a follower must be truncated to if its last epoch ends
+// before its offset on the leader, or if it has messages the leader doesn't have at all.
+func diverging(epochs *storage.Epochs, lastEpoch int32, offset uint64, end uint64) *EpochEnd {
+	if lastEpoch == storage.NoEpoch {
+		if offset > end {
+			return &EpochEnd{Epoch: storage.NoEpoch, EndOffset: end}
+		}
+
+		return nil
+	}
+
+	epoch, epochEnd := epochs.EndOffset(lastEpoch, end)
+
+	if epoch != lastEpoch || epochEnd < offset {
+		return &EpochEnd{Epoch: epoch, EndOffset: epochEnd}
+	}
+
+	return nil
+}
+
+// follow replicates a partition from its leader until the broker stops or the node becomes its leader.
+func (b *Broker) follow(p *Partition) {
+	defer b.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-b.done
+		cancel()
+	}()
+
+	r := p.replication
+	copied := false
+
+	for !r.stopFollowing() {
+		leader := r.leader.Load()
+
+		// A new replica starts with a bulk copy of the sealed segments of the leader.
+		if !copied && p.journal.NextOffset() == 0 {
+			if err := b.copySegments(ctx, p, leader); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				level.Warn(b.logger).Log("msg", "error copying segments from leader", "partition", p, "leader", leader, "err", err)
+
+				select {
+				case <-time.After(replicaFetchBackoff):
+				case <-b.done:
+					return
+				}
+
+				continue
+			}
+
+			copied = true
+		}
+
+		res, err := b.fetchFromLeader(ctx, p, leader)
+
+		if err == nil && res.Diverging != nil {
+			err = b.truncateDiverged(p, leader, *res.Diverging)
+
+			if err == nil {
+				continue
+			}
+		}
+
+		if err == nil && p.journal.NextOffset() < res.LogStartOffset {
+			err = b.skipDeleted(p, leader, res.LogStartOffset)
+
+			if err == nil {
+				continue
+			}
+		}
+
+		if err == nil {
+			err = b.replicate(p, res)
+		}
+
+		if err == nil {
+			b.metrics.replicatedMessages.Add(float64(len(res.Messages)))
+
+			r.mutex.Lock()
+			r.advance(min(res.HighWatermark, p.journal.NextOffset()))
+			r.isr = res.ISR
+			r.mutex.Unlock()
+
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The fetch was canceled, the partition may not be followed anymore.
+		if leader != r.leader.Load() || !r.isReplica(r.nodeID) {
+			continue
+		}
+
+		level.Warn(b.logger).Log("msg", "error fetching from leader", "partition", p, "leader", leader, "err", err)
+
+		select {
+		case <-time.After(replicaFetchBackoff):
+		case <-b.done:
+			return
+		}
+	}
+
+	if !r.isReplica(r.nodeID) {
+		b.deleteReplica(p)
+	}
+}
+
+// truncateDiverged removes the messages of a follower after the last offset its journal
+// has in common with the leader's, these were appended by a former leader.
+func (b *Broker) truncateDiverged(p *Partition, leader int32, div EpochEnd) error {
+	next := p.journal.NextOffset()
+	_, end := p.journal.Epochs().EndOffset(div.Epoch, next)
+	offset := min(div.EndOffset, end, next)
+
+	level.Warn(b.logger).Log("msg", "truncating journal diverged from leader", "partition", p, "leader", leader, "epoch", div.Epoch, "offset", offset, "removed", next-offset)
+	b.metrics.truncations.Inc()
+
+	return p.journal.TruncateTo(offset)
+}
+
+// skipDeleted empties the journal of a follower which is behind the log start offset of the
+// leader, the messages it is missing were deleted there and it continues at the offset.
+func (b *Broker) skipDeleted(p *Partition, leader int32, start uint64) error {
+	level.Warn(b.logger).Log("msg", "follower is behind the log start offset of the leader", "partition", p, "leader", leader, "next", p.journal.NextOffset(), "start", start)
+
+	return p.journal.Reset(start)
+}
+
+// replicate appends the fetched messages and records the leader epochs they belong to.
+func (b *Broker) replicate(p *Partition, res ReplicaFetchResult) error {
+	end := p.journal.NextOffset() + uint64(len(res.Messages))
+
+	for _, e := range res.Epochs {
+		if e.StartOffset >= end {
+			break
+		}
+
+		if err := p.journal.Epochs().Assign(e.Epoch, max(e.StartOffset, p.journal.NextOffset())); err != nil {
+			return err
+		}
+	}
+
+	if err := p.journal.Replicate(res.Messages...); err != nil {
+		return err
+	}
+
+	return p.journal.AdvanceStartOffset(min(res.LogStartOffset, p.journal.NextOffset()))
+}
+
+// fetchFromLeader fetches the messages after the end of the journal, a change of
+// the leader or the replicas cancels a fetch waiting for new messages.
+func (b *Broker) fetchFromLeader(ctx context.Context, p *Partition, leader int32) (ReplicaFetchResult, error) {
+	r := p.replication
+	changed := r.changes()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-changed:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return b.options.ReplicaClient.ReplicaFetch(ctx, leader, ReplicaFetchRequest{
+		ReplicaID: r.nodeID,
+		Topic:     p.Topic,
+		Partition: p.ID,
+		Offset:    p.journal.NextOffset(),
+		LastEpoch: p.journal.Epochs().Latest(),
+		MaxBytes:  replicaFetchMaxBytes,
+		MaxWait:   replicaFetchMaxWait,
+	})
+}
+
+// startFollowing starts replicating the partitions of the topic which are led by other brokers.
+func (b *Broker) startFollowing(t *Topic) {
+	if b.options.ReplicaClient == nil {
+		return
+	}
+
+	for _, p := range t.partitions {
+		b.startFollower(p)
+	}
+}
+
+func (b *Broker) startFollower(p *Partition) {
+	if r := p.replication; r != nil && r.startFollowing() {
+		b.wg.Add(1)
+		go b.follow(p)
+	}
+}
+
+// SetLeader moves the leadership of a replicated partition to one of its replicas in a new
+// leader epoch. A former leader which holds messages the new leader never got truncates
+// them once it fetches from the new leader. Only an in-sync replica may take over unless
+// the topic allows unclean leader elections, another replica may miss acknowledged messages.
+func (b *Broker) SetLeader(topic string, partition int, leader int32, epoch int32) error {
+	t, err := b.Topic(topic)
+
+	if err != nil {
+		return err
+	}
+
+	p, err := t.Partition(partition)
+
+	if err != nil {
+		return err
+	}
+
+	r := p.replication
+
+	if r == nil || !r.isReplica(leader) {
+		return errors.Wrapf(UnknownReplica, "%d is not a replica of %s", leader, p)
+	}
+
+	if current := r.epoch.Load(); epoch < current {
+		return errors.Wrapf(StaleLeaderEpoch, "epoch %d of %s is older than %d", epoch, p, current)
+	}
+
+	if r.leader.Load() == leader && r.epoch.Load() == epoch {
+		return nil
+	}
+
+	if !r.inSync(leader) && !t.Config.UncleanLeaderElection {
+		return errors.Wrapf(UncleanLeaderElection, "leader %d of %s is not in sync", leader, p)
+	}
+
+	level.Info(b.logger).Log("msg", "partition leader changed", "partition", p, "leader", leader, "epoch", epoch)
+
+	r.setLeader(leader, epoch, time.Now())
+
+	if r.isLeader() {
+		if err := p.journal.StartEpoch(epoch); err != nil {
+			return err
+		}
+
+		r.appended(p.journal.NextOffset())
+		return nil
+	}
+
+	if b.options.ReplicaClient != nil {
+		b.startFollower(p)
+	}
+
+	return nil
+}
+
+func (b *Broker) runReplicaLagCheck() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.options.ReplicaLagTime / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			b.checkReplicaLag(now)
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// checkReplicaLag removes the followers which fell behind from the in-sync replicas.
+func (b *Broker) checkReplicaLag(now time.Time) {
+	underReplicated := 0
+
+	for _, t := range b.Topics() {
+		for _, p := range t.Partitions() {
+			r := p.replication
+
+			if r == nil || !r.isLeader() {
+				continue
+			}
+
+			if removed := r.shrink(p.journal.NextOffset(), b.options.ReplicaLagTime, now); removed > 0 {
+				level.Warn(b.logger).Log("msg", "replicas left the in-sync replicas", "partition", p, "removed", removed)
+				b.metrics.isrShrinks.Add(float64(removed))
+			}
+
+			if len(p.ISR()) < r.replicaCount() {
+				underReplicated++
+			}
+		}
+	}
+
+	b.metrics.underReplicated.Set(float64(underReplicated))
+}
@@ -0,0 +1,168 @@
+package broker
+
+import (
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+func (b *Broker) runRetention() {
+	defer b.wg.Done()
+
+	b.runEvery(func(o Options) time.Duration { return o.RetentionInterval }, b.applyRetention)
+}
+
+// applyRetention drops the segments of all partitions which are no longer needed.
+func (b *Broker) applyRetention(now time.Time) {
+	for _, t := range b.Topics() {
+		for _, p := range t.Partitions() {
+			dropped, err := p.journal.DropExpired(now)
+
+			if err != nil {
+				level.Error(b.logger).Log("msg", "error dropping expired segments", "partition", p, "err", err)
+			}
+
+			b.metrics.expiredSegments.Add(float64(dropped))
+
+			dropped, err = p.journal.DropOverRetention(now)
+
+			if err != nil {
+				level.Error(b.logger).Log("msg", "error dropping segments over retention", "partition", p, "err", err)
+			}
+
+			b.metrics.retentionSegments.Add(float64(dropped))
+
+			if err := b.dropDeleted(p); err != nil {
+				level.Error(b.logger).Log("msg", "error dropping deleted segments", "partition", p, "err", err)
+			}
+		}
+	}
+}
+
+func (b *Broker) runSync() {
+	defer b.wg.Done()
+
+	b.runEvery(func(o Options) time.Duration { return o.SyncInterval }, func(time.Time) { b.syncPartitions() })
+}
+
+// runEvery calls fn every interval of the options until the broker stops, the interval is
+// read again when the broker is reconfigured. A zero interval doesn't call fn at all.
+func (b *Broker) runEvery(interval func(Options) time.Duration, fn func(now time.Time)) {
+	for {
+		b.mutex.RLock()
+		d, reconfigured := interval(b.options), b.reconfigured
+		b.mutex.RUnlock()
+
+		if !b.tick(d, reconfigured, fn) {
+			return
+		}
+	}
+}
+
+// tick calls fn every d until the broker is reconfigured or stopped, it returns false once stopped.
+func (b *Broker) tick(d time.Duration, reconfigured <-chan struct{}, fn func(now time.Time)) bool {
+	var ticks <-chan time.Time
+
+	if d > 0 {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case now := <-ticks:
+			fn(now)
+		case <-reconfigured:
+			return true
+		case <-b.done:
+			return false
+		}
+	}
+}
+
+// Reconfigure applies the options to the journals of all partitions and to the partitions
+// opened later. The sync and retention loops switch to the new intervals right away.
+func (b *Broker) Reconfigure(options DynamicOptions) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.options.Journal = options.Journal
+	b.options.SyncInterval = options.SyncInterval
+	b.options.RetentionInterval = options.RetentionInterval
+
+	for _, t := range b.topics {
+		for _, p := range t.Partitions() {
+			p.journal.SetOptions(options.Journal)
+		}
+	}
+
+	close(b.reconfigured)
+	b.reconfigured = make(chan struct{})
+
+	level.Info(b.logger).Log("msg", "broker reconfigured", "syncInterval", options.SyncInterval,
+		"syncEveryAppend", options.Journal.SyncEveryAppend, "retentionInterval", options.RetentionInterval,
+		"retentionTime", options.Journal.RetentionTime, "retentionBytes", options.Journal.RetentionBytes)
+}
+
+// syncPartitions flushes the messages appended to all partitions since the last sync to disk.
+func (b *Broker) syncPartitions() {
+	for _, t := range b.Topics() {
+		for _, p := range t.Partitions() {
+			if err := p.journal.Sync(); err != nil {
+				level.Error(b.logger).Log("msg", "error syncing partition", "partition", p, "err", err)
+			}
+		}
+	}
+}
+
+// dropDeleted removes the segments which only hold messages before the log start offset.
+func (b *Broker) dropDeleted(p *Partition) error {
+	start, err := p.journal.StartOffset()
+
+	if err != nil {
+		return err
+	}
+
+	dropped, err := p.journal.DropBefore(start)
+	b.metrics.deletedSegments.Add(float64(dropped))
+
+	return err
+}
+
+// DeleteRecords deletes the messages of a partition before the offset, which becomes the log start
+// offset. Fetches before it fail and retention drops the segments before it. Followers advance their
+// log start offset when they fetch, the ones behind it start over there. The new log start offset
+// is returned.
+func (b *Broker) DeleteRecords(topic string, partition int, offset uint64) (uint64, error) {
+	p, err := b.Partition(topic, partition)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if err := p.leading(); err != nil {
+		return 0, err
+	}
+
+	// Queues track acks from the first message on, they lose messages by acks and retention only.
+	if p.queue != nil {
+		return 0, errors.Wrapf(InvalidTopicConfig, "records of queue %s can't be deleted", p)
+	}
+
+	if hw := p.HighWatermark(); offset > hw {
+		return 0, errors.Wrapf(storage.OffsetOutOfRange, "offset %d is after the high watermark %d of %s", offset, hw, p)
+	}
+
+	if err := p.journal.AdvanceStartOffset(offset); err != nil {
+		return 0, err
+	}
+
+	level.Info(b.logger).Log("msg", "deleted records", "partition", p, "before", offset)
+
+	return p.journal.StartOffset()
+}
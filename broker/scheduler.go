@@ -0,0 +1,370 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	delayedStoreName = internalTopicPrefix + "delayed"
+	deliveredName    = internalTopicPrefix + "delivered"
+
+	// Headers of a delayed message which tell where it is going to be delivered.
+	DelayTopicHeader     = "iris-delay-topic"
+	DelayPartitionHeader = "iris-delay-partition"
+
+	// DelayIDHeader identifies a delivered delayed message, the scheduler uses it
+	// to find out whether a delivery was interrupted before or after the append.
+	DelayIDHeader = "iris-delay-id"
+
+	inflightKey = "inflight"
+)
+
+// inflight is stored before a delayed message is appended to its partition.
+type inflight struct {
+	Bucket    int64  `json:"bucket"`
+	Offset    uint64 `json:"offset"`
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	From      uint64 `json:"from"`
+}
+
+type pendingBucket struct {
+	loaded uint64
+	due    []*storage.Message
+}
+
+// scheduler moves delayed messages into their partitions once they are due.
+// Every delivered message is recorded in a table, so it is moved exactly once.
+type scheduler struct {
+	logger   log.Logger
+	broker   *Broker
+	store    *storage.DelayStore
+	table    *storage.Table
+	interval time.Duration
+	buckets  map[int64]*pendingBucket
+
+	stopc chan chan struct{}
+}
+
+func deliveredPrefix(bucket int64) string {
+	return fmt.Sprintf("delivered/%d/", bucket)
+}
+
+func deliveredKey(bucket int64, offset uint64) string {
+	return deliveredPrefix(bucket) + strconv.FormatUint(offset, 10)
+}
+
+func delayID(bucket int64, offset uint64) string {
+	return fmt.Sprintf("%d/%d", bucket, offset)
+}
+
+func newScheduler(logger log.Logger, b *Broker, store *storage.DelayStore, table *storage.Table, interval time.Duration) (*scheduler, error) {
+	s := &scheduler{
+		logger:   logger,
+		broker:   b,
+		store:    store,
+		table:    table,
+		interval: interval,
+		buckets:  make(map[int64]*pendingBucket),
+		stopc:    make(chan chan struct{}),
+	}
+
+	if err := s.recoverInflight(); err != nil {
+		return nil, errors.Wrap(err, "unable to recover inflight delivery")
+	}
+
+	return s, nil
+}
+
+// recoverInflight finds out whether the delivery which was going on when
+// the broker stopped has reached the partition.
+func (s *scheduler) recoverInflight() error {
+	value, ok := s.table.Get(inflightKey)
+
+	if !ok {
+		return nil
+	}
+
+	var in inflight
+
+	if err := json.Unmarshal(value, &in); err != nil {
+		return err
+	}
+
+	if _, ok := s.table.Get(deliveredKey(in.Bucket, in.Offset)); ok {
+		return nil
+	}
+
+	p, err := s.broker.Partition(in.Topic, in.Partition)
+
+	if err != nil {
+		return err
+	}
+
+	id := []byte(delayID(in.Bucket, in.Offset))
+	found := false
+
+	err = p.journal.Scan(in.From, func(msg *storage.Message) bool {
+		value, ok := msg.Header(DelayIDHeader)
+		found = ok && string(value) == string(id)
+
+		return !found
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return nil
+	}
+
+	level.Info(s.logger).Log("msg", "delayed message was delivered before stop", "id", id, "partition", p)
+
+	return s.table.Put(deliveredKey(in.Bucket, in.Offset), nil)
+}
+
+func (s *scheduler) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.deliverDue(time.Now()); err != nil {
+				level.Error(s.logger).Log("msg", "error delivering delayed messages", "err", err)
+			}
+		case donec := <-s.stopc:
+			close(donec)
+			return
+		}
+	}
+}
+
+func (s *scheduler) stop() {
+	donec := make(chan struct{})
+	s.stopc <- donec
+	<-donec
+}
+
+// deliverDue delivers the due messages of all buckets which have started
+// and drops the buckets which are over and fully delivered.
+func (s *scheduler) deliverDue(now time.Time) error {
+	buckets, err := s.store.Buckets()
+
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		if time.UnixMilli(bucket).After(now) {
+			break
+		}
+
+		if err := s.deliverBucket(bucket, now); err != nil {
+			return errors.Wrapf(err, "bucket %d", bucket)
+		}
+	}
+
+	return nil
+}
+
+func (s *scheduler) deliverBucket(bucket int64, now time.Time) error {
+	pending, err := s.load(bucket)
+
+	if err != nil {
+		return err
+	}
+
+	// Messages of partitions led by another broker wait in the bucket until
+	// the leadership comes back, the others are delivered meanwhile.
+	waiting := make([]*storage.Message, 0)
+
+	for len(pending.due) > 0 {
+		msg := pending.due[0]
+
+		if deliverAt, _ := storage.DeliverAt(msg); deliverAt.After(now) {
+			break
+		}
+
+		err := s.deliver(bucket, msg)
+
+		if errors.Is(err, NotLeader) {
+			waiting = append(waiting, msg)
+		} else if err != nil {
+			pending.due = append(waiting, pending.due...)
+			return err
+		}
+
+		pending.due = pending.due[1:]
+	}
+
+	pending.due = append(waiting, pending.due...)
+
+	if len(pending.due) > 0 || s.store.BucketEnd(bucket).After(now) {
+		return nil
+	}
+
+	dropped, err := s.store.Drop(bucket, pending.loaded)
+
+	if err != nil || !dropped {
+		return err
+	}
+
+	delete(s.buckets, bucket)
+
+	keys := make([]string, 0)
+
+	s.table.Range(deliveredPrefix(bucket), func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	for _, key := range keys {
+		if err := s.table.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// load reads the messages added to the bucket since the last call and
+// keeps the ones which are not delivered yet ordered by their due time.
+func (s *scheduler) load(bucket int64) (*pendingBucket, error) {
+	pending, ok := s.buckets[bucket]
+
+	if !ok {
+		pending = &pendingBucket{}
+		s.buckets[bucket] = pending
+	}
+
+	j, err := s.store.Bucket(bucket)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if j.NextOffset() == pending.loaded {
+		return pending, nil
+	}
+
+	err = j.Scan(pending.loaded, func(msg *storage.Message) bool {
+		pending.loaded = msg.Offset + 1
+
+		if _, ok := s.table.Get(deliveredKey(bucket, msg.Offset)); !ok {
+			pending.due = append(pending.due, msg)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pending.due, func(i, j int) bool {
+		a, _ := storage.DeliverAt(pending.due[i])
+		b, _ := storage.DeliverAt(pending.due[j])
+
+		return a.Before(b)
+	})
+
+	return pending, nil
+}
+
+func (s *scheduler) deliver(bucket int64, msg *storage.Message) error {
+	topic, _ := msg.Header(DelayTopicHeader)
+	value, _ := msg.Header(DelayPartitionHeader)
+	partition, err := strconv.Atoi(string(value))
+
+	if err != nil {
+		return errors.Wrapf(err, "invalid partition of delayed message %d", msg.Offset)
+	}
+
+	p, err := s.broker.Partition(string(topic), partition)
+
+	if err != nil {
+		return err
+	}
+
+	// Only the leader appends, a follower would diverge from it.
+	if err := p.leading(); err != nil {
+		return err
+	}
+
+	in, err := json.Marshal(inflight{
+		Bucket:    bucket,
+		Offset:    msg.Offset,
+		Topic:     p.Topic,
+		Partition: p.ID,
+		From:      p.NextOffset(),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.table.Put(inflightKey, in); err != nil {
+		return err
+	}
+
+	out := &storage.Message{Key: msg.Key, Value: msg.Value, ExpiresAt: msg.ExpiresAt}
+
+	for _, h := range msg.Headers {
+		switch h.Key {
+		case DelayTopicHeader, DelayPartitionHeader, storage.DeliverAtHeader:
+		default:
+			out.Headers = append(out.Headers, h)
+		}
+	}
+
+	out.SetHeader(DelayIDHeader, []byte(delayID(bucket, msg.Offset)))
+
+	if _, err := p.journal.Append(out); err != nil {
+		return err
+	}
+
+	if p.replication != nil {
+		p.replication.appended(p.journal.NextOffset())
+	}
+
+	s.broker.metrics.producedMessages.Inc()
+	s.broker.metrics.delayedDelivered.Inc()
+
+	return s.table.Put(deliveredKey(bucket, msg.Offset), nil)
+}
+
+// schedule stores the messages of the request in the delay store, the partition
+// is chosen now so the producer knows where they are going to show up.
+func (b *Broker) schedule(p *Partition, req ProduceRequest) (ProduceResult, error) {
+	for _, msg := range req.Messages {
+		dm := &storage.Message{
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Headers:   append([]storage.Header(nil), msg.Headers...),
+			ExpiresAt: msg.ExpiresAt,
+		}
+
+		dm.SetHeader(DelayTopicHeader, []byte(p.Topic))
+		dm.SetHeader(DelayPartitionHeader, []byte(strconv.Itoa(p.ID)))
+
+		if _, _, err := b.delays.Add(req.DeliverAt, dm); err != nil {
+			return ProduceResult{}, errors.Wrapf(err, "unable to delay message for %s", p)
+		}
+	}
+
+	b.metrics.delayedScheduled.Add(float64(len(req.Messages)))
+
+	return ProduceResult{Partition: p.ID, Delayed: true}, nil
+}
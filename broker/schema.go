@@ -0,0 +1,38 @@
+package broker
+
+import (
+	"strconv"
+
+	"iris/schema"
+	"iris/storage"
+
+	"github.com/pkg/errors"
+)
+
+// Schemas returns the schema registry, the subject of the values of a topic is named like the topic.
+func (b *Broker) Schemas() *schema.Registry {
+	return b.schemas
+}
+
+// validateSchemas checks the values of the messages which name their schema in a header.
+func (b *Broker) validateSchemas(topic string, msgs []*storage.Message) error {
+	for i, msg := range msgs {
+		value, ok := msg.Header(schema.IDHeader)
+
+		if !ok {
+			continue
+		}
+
+		id, err := strconv.ParseInt(string(value), 10, 64)
+
+		if err != nil {
+			return errors.Wrapf(schema.InvalidPayload, "message %d: invalid schema ID %q", i, value)
+		}
+
+		if err := b.schemas.Validate(topic, id, msg.Value); err != nil {
+			return errors.Wrapf(err, "message %d", i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package broker
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Topics starting with this prefix are reserved for the broker itself.
+	internalTopicPrefix = "__"
+)
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,249}$`)
+
+type TopicMode string
+
+const (
+	// Stream topics are read in order by offset, consumer groups commit their position.
+	StreamMode TopicMode = "stream"
+	// Queue topics hand out single messages to competing consumers, which ack them one by one.
+	QueueMode TopicMode = "queue"
+)
+
+type TopicConfig struct {
+	Partitions int       `json:"partitions"`
+	Mode       TopicMode `json:"mode,omitempty"`
+
+	// VisibilityTimeout is how long a received message of a queue is hidden
+	// from other consumers before it is delivered again unless it is acked.
+	VisibilityTimeout time.Duration `json:"visibilityTimeout,omitempty"`
+	// MaxDeliveries is the number of deliveries after which a message of a queue
+	// is moved to the dead-letter topic, zero delivers it until it is acked.
+	MaxDeliveries int `json:"maxDeliveries,omitempty"`
+
+	// Replicas holds the node ids of the brokers keeping a replica of each partition,
+	// the first one leads the partition. Partitions are only kept by the broker
+	// they are created on if it is empty.
+	Replicas [][]int32 `json:"replicas,omitempty"`
+	// MinInSyncReplicas is the number of in-sync replicas a partition needs
+	// to accept messages produced with AcksAll, zero is treated as one.
+	MinInSyncReplicas int `json:"minInSyncReplicas,omitempty"`
+	// UncleanLeaderElection allows replicas which are not in sync to become the leader
+	// of a partition, which loses the messages they are missing.
+	UncleanLeaderElection bool `json:"uncleanLeaderElection,omitempty"`
+}
+
+// Validate checks the config of a new topic.
+func (c TopicConfig) Validate() error {
+	if c.Partitions <= 0 {
+		return errors.Wrapf(InvalidPartitions, "%d", c.Partitions)
+	}
+
+	switch c.Mode {
+	case "", StreamMode, QueueMode:
+	default:
+		return errors.Wrapf(InvalidTopicConfig, "unknown mode %q", c.Mode)
+	}
+
+	if c.VisibilityTimeout < 0 {
+		return errors.Wrapf(InvalidTopicConfig, "visibility timeout %s", c.VisibilityTimeout)
+	}
+
+	if c.MaxDeliveries < 0 {
+		return errors.Wrapf(InvalidTopicConfig, "max deliveries %d", c.MaxDeliveries)
+	}
+
+	if c.MinInSyncReplicas < 0 {
+		return errors.Wrapf(InvalidTopicConfig, "min in-sync replicas %d", c.MinInSyncReplicas)
+	}
+
+	return c.validateReplicas()
+}
+
+func (c TopicConfig) validateReplicas() error {
+	if len(c.Replicas) == 0 {
+		if c.MinInSyncReplicas > 1 {
+			return errors.Wrapf(InvalidTopicConfig, "min in-sync replicas %d without replicas", c.MinInSyncReplicas)
+		}
+
+		return nil
+	}
+
+	// The ack logs of queues are local to a broker.
+	if c.Queue() {
+		return errors.Wrap(InvalidTopicConfig, "queues can't be replicated")
+	}
+
+	if len(c.Replicas) != c.Partitions {
+		return errors.Wrapf(InvalidTopicConfig, "replicas of %d partitions for %d partitions", len(c.Replicas), c.Partitions)
+	}
+
+	for i, replicas := range c.Replicas {
+		if len(replicas) == 0 {
+			return errors.Wrapf(InvalidTopicConfig, "partition %d has no replicas", i)
+		}
+
+		for j, id := range replicas {
+			if id < 0 || slices.Contains(replicas[:j], id) {
+				return errors.Wrapf(InvalidTopicConfig, "invalid replicas %v of partition %d", replicas, i)
+			}
+		}
+
+		// Messages produced with AcksAll could never be accepted.
+		if c.MinInSyncReplicas > len(replicas) {
+			return errors.Wrapf(InvalidTopicConfig, "min in-sync replicas %d for %d replicas of partition %d", c.MinInSyncReplicas, len(replicas), i)
+		}
+	}
+
+	return nil
+}
+
+// minInSyncReplicas returns the number of in-sync replicas a partition needs for AcksAll.
+func (c TopicConfig) minInSyncReplicas() int {
+	return max(c.MinInSyncReplicas, 1)
+}
+
+// Queue reports whether the topic is consumed as a queue.
+func (c TopicConfig) Queue() bool {
+	return c.Mode == QueueMode
+}
+
+type Topic struct {
+	Name   string
+	Config TopicConfig
+
+	partitions []*Partition
+	next       atomic.Uint64
+}
+
+type Partition struct {
+	Topic   string
+	ID      int
+	journal *storage.Journal
+
+	// Only set for partitions of queues.
+	queue *queue
+	// Only set for partitions with assigned replicas.
+	replication *replication
+}
+
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return errors.Wrapf(InvalidName, "%q", name)
+	}
+
+	return nil
+}
+
+func validateTopicName(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(name, internalTopicPrefix) {
+		return errors.Wrapf(InvalidName, "%q uses the reserved prefix %s", name, internalTopicPrefix)
+	}
+
+	return nil
+}
+
+func partitionDir(dir string, topic string, partition int) string {
+	return filepath.Join(dir, topic, strconv.Itoa(partition))
+}
+
+func partitionRegisterer(registerer prometheus.Registerer, topic string, partition int) prometheus.Registerer {
+	return prometheus.WrapRegistererWith(prometheus.Labels{
+		"topic":     topic,
+		"partition": strconv.Itoa(partition),
+	}, registerer)
+}
+
+func openTopic(logger log.Logger, registerer prometheus.Registerer, options Options, name string, config TopicConfig) (*Topic, error) {
+	t := &Topic{
+		Name:       name,
+		Config:     config,
+		partitions: make([]*Partition, 0, config.Partitions),
+	}
+
+	for i := 0; i < config.Partitions; i++ {
+		journal, err := storage.NewJournal(
+			log.With(logger, "topic", name, "partition", i),
+			partitionRegisterer(registerer, name, i),
+			partitionDir(options.Dir, name, i),
+			options.SegmentSize,
+		)
+
+		if err != nil {
+			t.stop()
+			return nil, errors.Wrapf(err, "unable to open partition %d of topic %s", i, name)
+		}
+
+		journal.SetOptions(options.Journal)
+
+		p := &Partition{Topic: name, ID: i, journal: journal}
+		t.partitions = append(t.partitions, p)
+
+		if len(config.Replicas) > 0 {
+			epoch := max(journal.Epochs().Latest(), 0)
+			p.replication = newReplication(options.NodeID, config.Replicas[i], epoch, time.Now())
+
+			if p.replication.isLeader() {
+				if err := journal.StartEpoch(epoch); err != nil {
+					t.stop()
+					return nil, errors.Wrapf(err, "unable to start leader epoch of partition %d of topic %s", i, name)
+				}
+
+				p.replication.appended(journal.NextOffset())
+			}
+		}
+
+		if !config.Queue() {
+			continue
+		}
+
+		acks, err := storage.OpenAckLog(
+			log.With(logger, "topic", name, "partition", i),
+			partitionRegisterer(registerer, name, i),
+			filepath.Join(partitionDir(options.Dir, name, i), ackLogDirName),
+		)
+
+		if err != nil {
+			t.stop()
+			return nil, errors.Wrapf(err, "unable to open ack log of partition %d of topic %s", i, name)
+		}
+
+		p.queue = &queue{acks: acks}
+	}
+
+	return t, nil
+}
+
+func (t *Topic) Partition(id int) (*Partition, error) {
+	if id < 0 || id >= len(t.partitions) {
+		return nil, errors.Wrapf(UnknownPartition, "partition %d of topic %s", id, t.Name)
+	}
+
+	return t.partitions[id], nil
+}
+
+func (t *Topic) Partitions() []*Partition {
+	return t.partitions
+}
+
+// choosePartition hashes the key to a partition, messages without a key
+// are spread over the partitions in turns.
+func (t *Topic) choosePartition(key []byte) *Partition {
+	n := uint64(len(t.partitions))
+
+	if len(key) == 0 {
+		return t.partitions[t.next.Add(1)%n]
+	}
+
+	h := fnv.New32a()
+	h.Write(key)
+
+	return t.partitions[uint64(h.Sum32())%n]
+}
+
+func (t *Topic) stop() {
+	for _, p := range t.partitions {
+		p.journal.Stop()
+
+		if p.queue != nil {
+			p.queue.acks.Stop()
+		}
+	}
+}
+
+func (p *Partition) String() string {
+	return fmt.Sprintf("%s/%d", p.Topic, p.ID)
+}
+
+// NextOffset returns the offset of the next message produced to the partition.
+func (p *Partition) NextOffset() uint64 {
+	return p.journal.NextOffset()
+}
+
+func (p *Partition) Journal() *storage.Journal {
+	return p.journal
+}
@@ -0,0 +1,371 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"iris/storage"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	// Attributes are stored in message headers with this prefix, as in the Kafka protocol binding.
+	HeaderPrefix = "ce_"
+	// ContentTypeHeader holds the datacontenttype attribute of stored events.
+	ContentTypeHeader = "content-type"
+	// PartitionKey is the extension stored as the key of messages.
+	PartitionKey = "partitionkey"
+
+	// Media types of structured and batched events.
+	StructuredContentType = "application/cloudevents+json"
+	BatchContentType      = "application/cloudevents-batch+json"
+
+	maxExtensionNameLength = 20
+)
+
+var InvalidEvent = errors.New("Invalid CloudEvent")
+
+// Event is a CloudEvent. Optional attributes are empty if they are not set and
+// data is nil if the event has none. Extension values are kept in their canonical
+// string encoding, so they survive the binary modes which only know strings.
+type Event struct {
+	ID          string
+	Source      string
+	SpecVersion string
+	Type        string
+
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	// Time is kept as it was given, so formatting it again does not change it.
+	Time string
+
+	Extensions map[string]string
+	Data       []byte
+}
+
+var contextAttributes = map[string]bool{
+	"id": true, "source": true, "specversion": true, "type": true,
+	"datacontenttype": true, "dataschema": true, "subject": true, "time": true,
+	"data": true, "data_base64": true,
+}
+
+// Validate checks the required attributes and the names of extensions.
+func (e *Event) Validate() error {
+	if e.SpecVersion != SpecVersion {
+		return errors.Wrapf(InvalidEvent, "unsupported specversion %q", e.SpecVersion)
+	}
+
+	for name, value := range map[string]string{"id": e.ID, "source": e.Source, "type": e.Type} {
+		if value == "" {
+			return errors.Wrapf(InvalidEvent, "no %s", name)
+		}
+	}
+
+	if e.Time != "" {
+		if _, err := time.Parse(time.RFC3339Nano, e.Time); err != nil {
+			return errors.Wrapf(InvalidEvent, "invalid time %q", e.Time)
+		}
+	}
+
+	for name := range e.Extensions {
+		if !validExtensionName(name) {
+			return errors.Wrapf(InvalidEvent, "invalid extension name %q", name)
+		}
+	}
+
+	return nil
+}
+
+func validExtensionName(name string) bool {
+	if name == "" || len(name) > maxExtensionNameLength || contextAttributes[name] {
+		return false
+	}
+
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// attributes returns the attributes of the event which are set, except data.
+func (e *Event) attributes() map[string]string {
+	attrs := map[string]string{
+		"id":          e.ID,
+		"source":      e.Source,
+		"specversion": e.SpecVersion,
+		"type":        e.Type,
+	}
+
+	for name, value := range map[string]string{
+		"datacontenttype": e.DataContentType,
+		"dataschema":      e.DataSchema,
+		"subject":         e.Subject,
+		"time":            e.Time,
+	} {
+		if value != "" {
+			attrs[name] = value
+		}
+	}
+
+	for name, value := range e.Extensions {
+		attrs[name] = value
+	}
+
+	return attrs
+}
+
+// setAttribute sets a context attribute or an extension of the event.
+func (e *Event) setAttribute(name string, value string) {
+	switch name {
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "specversion":
+		e.SpecVersion = value
+	case "type":
+		e.Type = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "subject":
+		e.Subject = value
+	case "time":
+		e.Time = value
+	default:
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+
+		e.Extensions[name] = value
+	}
+}
+
+// IsEvent reports whether the message holds an event in binary mode.
+func IsEvent(msg *storage.Message) bool {
+	_, ok := msg.Header(HeaderPrefix + "specversion")
+	return ok
+}
+
+// ToMessage stores the event in binary mode, the attributes become headers,
+// the partition key the key and the data the value of the message.
+func (e *Event) ToMessage() *storage.Message {
+	msg := &storage.Message{Value: e.Data}
+	attrs := e.attributes()
+
+	if key, ok := attrs[PartitionKey]; ok {
+		msg.Key = []byte(key)
+		delete(attrs, PartitionKey)
+	}
+
+	names := make([]string, 0, len(attrs))
+
+	for name := range attrs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		header := HeaderPrefix + name
+
+		if name == "datacontenttype" {
+			header = ContentTypeHeader
+		}
+
+		msg.Headers = append(msg.Headers, storage.Header{Key: header, Value: []byte(attrs[name])})
+	}
+
+	return msg
+}
+
+// FromMessage reads an event stored in binary mode, headers which are not
+// attributes are ignored.
+func FromMessage(msg *storage.Message) (Event, error) {
+	if !IsEvent(msg) {
+		return Event{}, errors.Wrap(InvalidEvent, "message is not a CloudEvent")
+	}
+
+	var e Event
+
+	for _, h := range msg.Headers {
+		switch {
+		case h.Key == ContentTypeHeader:
+			e.DataContentType = string(h.Value)
+		case strings.HasPrefix(h.Key, HeaderPrefix):
+			e.setAttribute(h.Key[len(HeaderPrefix):], string(h.Value))
+		}
+	}
+
+	if msg.Key != nil {
+		e.setAttribute(PartitionKey, string(msg.Key))
+	}
+
+	if len(msg.Value) > 0 {
+		e.Data = msg.Value
+	}
+
+	return e, e.Validate()
+}
+
+// isJSON reports whether data of the content type is JSON, which it is if no content type is set.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	t, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	return t == "application/json" || t == "text/json" || strings.HasSuffix(t, "+json")
+}
+
+func isText(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+
+	return err == nil && (strings.HasPrefix(t, "text/") || t == "application/xml" || strings.HasSuffix(t, "+xml"))
+}
+
+// MarshalJSON encodes the event in structured mode. JSON data is embedded as is,
+// text data as a string and any other data in base64.
+func (e Event) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{})
+
+	for name, value := range e.attributes() {
+		fields[name] = value
+	}
+
+	if e.Data != nil {
+		switch {
+		case isJSON(e.DataContentType) && json.Valid(e.Data):
+			fields["data"] = json.RawMessage(e.Data)
+		case isText(e.DataContentType) && utf8.Valid(e.Data):
+			fields["data"] = string(e.Data)
+		default:
+			fields["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON decodes an event in structured mode. Extension values which are
+// numbers or booleans are kept in their string encoding.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	if err := d.Decode(&fields); err != nil {
+		return errors.Wrapf(InvalidEvent, "invalid structured event: %s", err)
+	}
+
+	*e = Event{}
+
+	var encoded *string
+
+	for name, raw := range fields {
+		switch name {
+		case "data":
+			if string(raw) != "null" {
+				e.Data = raw
+			}
+		case "data_base64":
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return errors.Wrap(InvalidEvent, "data_base64 must be a string")
+			}
+		default:
+			var value interface{}
+
+			dec := json.NewDecoder(bytes.NewReader(raw))
+			dec.UseNumber()
+
+			if err := dec.Decode(&value); err != nil {
+				return errors.Wrapf(InvalidEvent, "invalid attribute %s: %s", name, err)
+			}
+
+			switch v := value.(type) {
+			case nil:
+			case string:
+				e.setAttribute(name, v)
+			case json.Number:
+				e.setAttribute(name, v.String())
+			case bool:
+				e.setAttribute(name, string(raw))
+			default:
+				return errors.Wrapf(InvalidEvent, "attribute %s must be a string, a number or a boolean", name)
+			}
+		}
+	}
+
+	if encoded != nil {
+		if e.Data != nil {
+			return errors.Wrap(InvalidEvent, "data and data_base64 are both set")
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(*encoded)
+
+		if err != nil {
+			return errors.Wrapf(InvalidEvent, "invalid data_base64: %s", err)
+		}
+
+		e.Data = decoded
+	} else if e.Data != nil && !isJSON(e.DataContentType) {
+		// Data of other content types is given as a JSON string.
+		var text string
+
+		if err := json.Unmarshal(e.Data, &text); err == nil {
+			e.Data = []byte(text)
+		}
+	}
+
+	return e.Validate()
+}
+
+// Parse decodes an event in structured mode.
+func Parse(data []byte) (Event, error) {
+	var e Event
+
+	if err := json.Unmarshal(data, &e); err != nil {
+		if errors.Is(err, InvalidEvent) {
+			return Event{}, err
+		}
+
+		return Event{}, errors.Wrapf(InvalidEvent, "invalid structured event: %s", err)
+	}
+
+	return e, nil
+}
+
+// ParseBatch decodes a batch of events in structured mode.
+func ParseBatch(data []byte) ([]Event, error) {
+	var events []Event
+
+	if err := json.Unmarshal(data, &events); err != nil {
+		if errors.Is(err, InvalidEvent) {
+			return nil, err
+		}
+
+		return nil, errors.Wrapf(InvalidEvent, "invalid batch: %s", err)
+	}
+
+	return events, nil
+}
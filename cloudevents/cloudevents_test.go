@@ -0,0 +1,88 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"iris/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStructuredRoundTrip(t *testing.T) {
+	for _, structured := range []string{
+		`{"specversion": "1.0", "id": "1", "source": "/orders", "type": "order.created", "time": "2024-01-02T03:04:05.123456789+01:00", "data": {"id": 1}}`,
+		`{"specversion": "1.0", "id": "2", "source": "/orders", "type": "order.created", "datacontenttype": "text/plain", "data": "hello", "partitionkey": "order-2"}`,
+		`{"specversion": "1.0", "id": "3", "source": "/orders", "type": "order.created", "datacontenttype": "application/octet-stream", "data_base64": "AAEC"}`,
+		`{"specversion": "1.0", "id": "4", "source": "/orders", "type": "order.created", "subject": "4", "dataschema": "https://example.com/order", "region": "eu"}`,
+	} {
+		e, err := Parse([]byte(structured))
+		require.NoError(t, err, structured)
+
+		// Storing the event in binary mode and reading it back keeps all of it.
+		read, err := FromMessage(e.ToMessage())
+		require.NoError(t, err)
+		assert.Equal(t, e, read)
+
+		data, err := json.Marshal(read)
+		require.NoError(t, err)
+		assert.JSONEq(t, structured, string(data))
+	}
+
+	e, err := Parse([]byte(`{"specversion": "1.0", "id": "1", "source": "/", "type": "t", "count": 3, "valid": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"count": "3", "valid": "true"}, e.Extensions)
+
+	msg := e.ToMessage()
+	v, ok := msg.Header("ce_count")
+	require.True(t, ok)
+	assert.Equal(t, []byte("3"), v)
+}
+
+func TestInvalidEvents(t *testing.T) {
+	for _, structured := range []string{
+		`{"specversion": "0.3", "id": "1", "source": "/", "type": "t"}`,
+		`{"specversion": "1.0", "source": "/", "type": "t"}`,
+		`{"specversion": "1.0", "id": "1", "source": "/", "type": "t", "time": "yesterday"}`,
+		`{"specversion": "1.0", "id": "1", "source": "/", "type": "t", "Region": "eu"}`,
+		`{"specversion": "1.0", "id": "1", "source": "/", "type": "t", "ext": {}}`,
+		`{"specversion": "1.0", "id": "1", "source": "/", "type": "t", "data": 1, "data_base64": "AA=="}`,
+		`[]`,
+		`not json`,
+	} {
+		_, err := Parse([]byte(structured))
+		assert.ErrorIs(t, err, InvalidEvent, structured)
+	}
+
+	_, err := FromMessage(&storage.Message{Value: []byte("plain")})
+	assert.ErrorIs(t, err, InvalidEvent)
+
+	_, err = ParseBatch([]byte(`[{"specversion": "1.0", "id": "1", "source": "/", "type": "t"}, {}]`))
+	assert.ErrorIs(t, err, InvalidEvent)
+}
+
+func TestHTTPBinaryMode(t *testing.T) {
+	e := Event{
+		ID:              "1",
+		Source:          "/orders",
+		SpecVersion:     SpecVersion,
+		Type:            "order.created",
+		DataContentType: "application/json",
+		Subject:         "a \"quoted\" 100% ünicode subject",
+		Data:            []byte(`{"id": 1}`),
+	}
+
+	header := make(http.Header)
+	e.WriteHTTPHeaders(header)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "a%20%22quoted%22%20100%25%20%C3%BCnicode%20subject", header.Get("ce-subject"))
+
+	read, err := FromHTTP(header, e.Data)
+	require.NoError(t, err)
+	assert.Equal(t, e, read)
+
+	_, err = FromHTTP(http.Header{"Ce-Id": {"1"}}, nil)
+	assert.ErrorIs(t, err, InvalidEvent)
+}
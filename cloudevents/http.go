@@ -0,0 +1,71 @@
+package cloudevents
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Attributes are sent in HTTP headers with this prefix in binary mode,
+// except datacontenttype which is the Content-Type of the body.
+const HTTPHeaderPrefix = "ce-"
+
+// FromHTTP reads an event in binary mode from the headers and the body of a request or a response.
+func FromHTTP(header http.Header, body []byte) (Event, error) {
+	var e Event
+
+	for name, values := range header {
+		name = strings.ToLower(name)
+
+		if !strings.HasPrefix(name, HTTPHeaderPrefix) || len(values) == 0 {
+			continue
+		}
+
+		value, err := url.PathUnescape(values[0])
+
+		if err != nil {
+			return Event{}, errors.Wrapf(InvalidEvent, "invalid header %s: %s", name, err)
+		}
+
+		e.setAttribute(name[len(HTTPHeaderPrefix):], value)
+	}
+
+	e.DataContentType = header.Get("Content-Type")
+
+	if len(body) > 0 {
+		e.Data = body
+	}
+
+	return e, e.Validate()
+}
+
+// WriteHTTPHeaders sets the headers of the event in binary mode, the data is the body.
+func (e *Event) WriteHTTPHeaders(header http.Header) {
+	for name, value := range e.attributes() {
+		if name == "datacontenttype" {
+			header.Set("Content-Type", value)
+			continue
+		}
+
+		header.Set(HTTPHeaderPrefix+name, escapeHeader(value))
+	}
+}
+
+// escapeHeader percent-encodes the characters which may not be sent in header values.
+func escapeHeader(value string) string {
+	var b strings.Builder
+
+	for _, c := range []byte(value) {
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
@@ -1,12 +1,603 @@
 package config
 
-import "time"
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
+	"iris/quota"
+	"iris/storage"
+	"iris/storage/wal"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables overriding the config file. The rest of the
+// name is the yaml path in upper snake case, IRIS_STORAGE_SEGMENT_SIZE sets storage.segmentSize.
+const EnvPrefix = "IRIS"
+
+var (
+	InvalidConfig = errors.New("Invalid config")
+)
+
+// FsyncPolicy is when appended messages are flushed to disk.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways flushes every append before it is acknowledged.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval flushes all partitions every fsync interval.
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever leaves flushing to the operating system.
+	FsyncNever FsyncPolicy = "never"
+)
+
+// Config is the configuration of a broker, see Load.
 type Config struct {
+	NodeID int32 `yaml:"nodeId"`
+	// DataDir holds all partitions of the broker, they are not spread over several
+	// directories. Several disks are put together below it, e.g. with RAID or LVM.
+	DataDir string `yaml:"dataDir"`
+	// Peers maps the node ids of the other brokers to their gRPC addresses.
+	Peers     map[int32]string `yaml:"peers"`
+	Listeners Listeners        `yaml:"listeners"`
+	TLS       TLSOptions       `yaml:"tls"`
+	Storage   StorageOptions   `yaml:"storage"`
+	Auth      AuthOptions      `yaml:"auth"`
+	Quotas    QuotaOptions     `yaml:"quotas"`
+	// Controller runs a member of the raft controller in the broker.
+	Controller ControllerOptions `yaml:"controller"`
+	// LogLevel is one of debug, info, warn or error.
+	LogLevel string `yaml:"logLevel"`
+	// ShutdownTimeout is how long in-flight requests are drained on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+// Listeners are the addresses the broker serves on, an empty address disables the listener.
+type Listeners struct {
+	GRPC    string `yaml:"grpc"`
+	HTTP    string `yaml:"http"`
+	Metrics string `yaml:"metrics"`
+	Kafka   string `yaml:"kafka"`
+}
+
+// TLSOptions enable TLS on the gRPC, HTTP and Kafka listeners when a certificate is set.
+type TLSOptions struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// ClientCAFile verifies the certificates of clients, which then must present one.
+	ClientCAFile string `yaml:"clientCAFile"`
+}
+
+type StorageOptions struct {
+	// SegmentSize is the size of the journal segments, a multiple of the wal page size.
+	SegmentSize int          `yaml:"segmentSize"`
+	Fsync       FsyncOptions `yaml:"fsync"`
+	// RetentionInterval is how often segments are checked for removal.
+	RetentionInterval time.Duration `yaml:"retentionInterval"`
+	// Retention is the retention of the partitions of every topic.
+	Retention RetentionOptions `yaml:"retention"`
+	Index     IndexOptions     `yaml:"index"`
+}
+
+// RetentionOptions limit how long and how much of a partition is kept, zero doesn't limit it.
+// Sealed segments are dropped oldest first, so partitions keep their active segment in any case.
+type RetentionOptions struct {
+	// Time is how long segments are kept after they were last written.
+	Time time.Duration `yaml:"time"`
+	// Bytes is the size the segments of a partition are kept below.
+	Bytes int64 `yaml:"bytes"`
+}
+
+type FsyncOptions struct {
+	Policy FsyncPolicy `yaml:"policy"`
+	// Interval is how often partitions are flushed with the interval policy.
+	Interval time.Duration `yaml:"interval"`
 }
 
 type IndexOptions struct {
-	WriteInterval         time.Duration
-	SegmentSize           int
-	MaxMemoryIndexRecords int
+	// IntervalBytes is how many bytes of messages are appended between two offset index entries.
+	IntervalBytes int `yaml:"intervalBytes"`
+}
+
+type AuthOptions struct {
+	// Authorize enforces the ACLs stored in the data directory.
+	Authorize bool `yaml:"authorize"`
+	// SuperUsers are principals which bypass the ACLs.
+	SuperUsers []string `yaml:"superUsers"`
+	// TokensFile holds static bearer tokens, it enables authentication.
+	TokensFile string `yaml:"tokensFile"`
+	// AllowAnonymous lets clients without credentials in when authentication is enabled.
+	AllowAnonymous bool `yaml:"allowAnonymous"`
+}
+
+type QuotaOptions struct {
+	// Default limits apply to every client without limits of its own.
+	Default quota.Limits `yaml:"default"`
+	// Clients maps principals or client IDs to their limits.
+	Clients     map[string]quota.Limits `yaml:"clients"`
+	Burst       time.Duration           `yaml:"burst"`
+	MaxThrottle time.Duration           `yaml:"maxThrottle"`
+}
+
+// ControllerOptions configure the raft controller keeping the cluster metadata,
+// it is disabled without members.
+type ControllerOptions struct {
+	// Members are the node ids of the controllers, the broker itself must be one of them
+	// and the others must be peers.
+	Members []int32 `yaml:"members"`
+}
+
+// Default returns the config used for the settings missing from the file and the environment.
+func Default() Config {
+	return Config{
+		DataDir: "data",
+		Listeners: Listeners{
+			GRPC:    ":9090",
+			HTTP:    ":8080",
+			Metrics: ":9100",
+		},
+		Storage: StorageOptions{
+			SegmentSize: wal.DefaultSegmentSize,
+			Fsync: FsyncOptions{
+				Policy:   FsyncNever,
+				Interval: time.Second,
+			},
+			RetentionInterval: time.Minute,
+			Index: IndexOptions{
+				IntervalBytes: storage.DefaultIndexIntervalBytes,
+			},
+		},
+		Quotas: QuotaOptions{
+			Burst:       quota.DefaultBurst,
+			MaxThrottle: quota.DefaultMaxThrottle,
+		},
+		LogLevel:        "info",
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Load reads the config file at path over the defaults, applies the IRIS_ environment overrides
+// and validates the result. An empty path only applies the environment to the defaults.
+func Load(path string) (Config, error) {
+	c := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return Config{}, errors.Wrap(err, "unable to read config")
+		}
+
+		if err := Parse(data, &c); err != nil {
+			return Config{}, errors.Wrapf(err, "unable to parse %s", path)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(&c).Elem(), EnvPrefix, os.LookupEnv); err != nil {
+		return Config{}, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return c, nil
+}
+
+// Parse decodes yaml into the config, settings missing from data are left as they are.
+// Unknown settings are rejected, they are most likely typos.
+func Parse(data []byte, c *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	// An empty file decodes to io.EOF, it leaves the config as it is.
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrapf(InvalidConfig, "%v", err)
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting of the config at once.
+func (c Config) Validate() error {
+	var problems []string
+
+	invalid := func(field string, format string, args ...interface{}) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.NodeID < 0 {
+		invalid("nodeId", "must not be negative, got %d", c.NodeID)
+	}
+
+	if c.DataDir == "" {
+		invalid("dataDir", "must be set")
+	}
+
+	for id, addr := range c.Peers {
+		if id == c.NodeID {
+			invalid("peers", "node %d is the broker itself", id)
+		}
+
+		if addr == "" {
+			invalid("peers", "node %d has no address", id)
+		}
+	}
+
+	if c.Listeners.GRPC == "" {
+		invalid("listeners.grpc", "must be set, brokers replicate through it")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		invalid("tls", "certFile and keyFile must be set together")
+	}
+
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		invalid("tls.clientCAFile", "requires certFile and keyFile")
+	}
+
+	if size := c.Storage.SegmentSize; size <= 0 || size%wal.PageSize != 0 {
+		invalid("storage.segmentSize", "must be a positive multiple of the %d bytes page size, got %d", wal.PageSize, size)
+	}
+
+	switch c.Storage.Fsync.Policy {
+	case FsyncAlways, FsyncNever:
+	case FsyncInterval:
+		if c.Storage.Fsync.Interval <= 0 {
+			invalid("storage.fsync.interval", "must be positive with the interval policy, got %s", c.Storage.Fsync.Interval)
+		}
+	default:
+		invalid("storage.fsync.policy", "must be one of always, interval or never, got %q", c.Storage.Fsync.Policy)
+	}
+
+	if c.Storage.RetentionInterval <= 0 {
+		invalid("storage.retentionInterval", "must be positive, got %s", c.Storage.RetentionInterval)
+	}
+
+	if c.Storage.Retention.Time < 0 {
+		invalid("storage.retention.time", "must not be negative, got %s", c.Storage.Retention.Time)
+	}
+
+	if size := c.Storage.Retention.Bytes; size < 0 || size > 0 && size < int64(c.Storage.SegmentSize) {
+		invalid("storage.retention.bytes", "must be zero or at least the segment size %d, got %d", c.Storage.SegmentSize, size)
+	}
+
+	if c.Storage.Index.IntervalBytes <= 0 {
+		invalid("storage.index.intervalBytes", "must be positive, got %d", c.Storage.Index.IntervalBytes)
+	}
+
+	if c.Auth.AllowAnonymous && c.Auth.TokensFile == "" {
+		invalid("auth.allowAnonymous", "requires tokensFile")
+	}
+
+	validateLimits := func(field string, limits quota.Limits) {
+		if limits.ProduceBytesPerSecond < 0 || limits.FetchBytesPerSecond < 0 || limits.RequestsPerSecond < 0 {
+			invalid(field, "rates must not be negative")
+		}
+	}
+
+	validateLimits("quotas.default", c.Quotas.Default)
+
+	for client, limits := range c.Quotas.Clients {
+		validateLimits("quotas.clients."+client, limits)
+	}
+
+	if c.Quotas.Burst < 0 || c.Quotas.MaxThrottle < 0 {
+		invalid("quotas", "burst and maxThrottle must not be negative")
+	}
+
+	members := make(map[int32]bool)
+
+	for _, id := range c.Controller.Members {
+		if members[id] {
+			invalid("controller.members", "node %d is listed twice", id)
+		}
+
+		if _, ok := c.Peers[id]; !ok && id != c.NodeID {
+			invalid("controller.members", "node %d is not a peer", id)
+		}
+
+		members[id] = true
+	}
+
+	if len(members) > 0 && !members[c.NodeID] {
+		invalid("controller.members", "must include the broker itself, node %d", c.NodeID)
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("logLevel", "must be one of debug, info, warn or error, got %q", c.LogLevel)
+	}
+
+	if c.ShutdownTimeout < 0 {
+		invalid("shutdownTimeout", "must not be negative, got %s", c.ShutdownTimeout)
+	}
+
+	if len(problems) > 0 {
+		return errors.Wrapf(InvalidConfig, "%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// TLSConfig returns the server TLS config of the certificate, nil when TLS is disabled.
+func (o TLSOptions) TLSConfig() (*tls.Config, error) {
+	if o.CertFile == "" {
+		return nil, nil
+	}
+
+	config, pool, err := o.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if pool != nil {
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// ClientTLSConfig returns the TLS config brokers dial their peers with, nil when TLS is disabled.
+// Brokers present their own certificate and verify the peers with the client CA when it is set.
+func (o TLSOptions) ClientTLSConfig() (*tls.Config, error) {
+	if o.CertFile == "" {
+		return nil, nil
+	}
+
+	config, pool, err := o.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.RootCAs = pool
+
+	return config, nil
+}
+
+func (o TLSOptions) load() (*tls.Config, *x509.CertPool, error) {
+	cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to load tls certificate")
+	}
+
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if o.ClientCAFile == "" {
+		return config, nil, nil
+	}
+
+	pem, err := os.ReadFile(o.ClientCAFile)
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to read client CA")
+	}
+
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, nil, errors.Errorf("no certificates in client CA %s", o.ClientCAFile)
+	}
+
+	return config, pool, nil
+}
+
+// applyEnv sets the fields of v from the environment variables named after their yaml path.
+// Maps aren't overridden, lists are comma separated.
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+
+		if tag == "" || tag == "-" || v.Field(i).Kind() == reflect.Map {
+			continue
+		}
+
+		name := prefix + "_" + envName(tag)
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name, lookup); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		value, ok := lookup(name)
+
+		if !ok {
+			continue
+		}
+
+		if err := setField(field, value); err != nil {
+			return errors.Wrapf(InvalidConfig, "%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// envName turns a yaml name like segmentSize or clientCAFile into SEGMENT_SIZE or CLIENT_CA_FILE.
+func envName(tag string) string {
+	var b strings.Builder
+	runes := []rune(tag)
+
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			b.WriteByte('_')
+		}
+
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return b.String()
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Slice:
+		items := reflect.Zero(field.Type())
+
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+
+			v := reflect.New(field.Type().Elem()).Elem()
+
+			if err := setField(v, item); err != nil {
+				return err
+			}
+
+			items = reflect.Append(items, v)
+		}
+
+		field.Set(items)
+	default:
+		return errors.Errorf("can't be set from the environment")
+	}
+
+	return nil
+}
+
+// dynamicFields are the yaml paths of the settings which change without restarting the broker.
+var dynamicFields = []string{
+	"storage.fsync",
+	"storage.retentionInterval",
+	"storage.retention",
+	"storage.index",
+	"quotas",
+	"logLevel",
+}
+
+// Changes are the settings which differ between two configs, by yaml path.
+type Changes struct {
+	// Dynamic settings are applied to the running broker.
+	Dynamic []string
+	// Restart settings only apply when the broker is restarted.
+	Restart []string
+}
+
+func (c Changes) Empty() bool {
+	return len(c.Dynamic) == 0 && len(c.Restart) == 0
+}
+
+// IsDynamic reports whether the setting at the yaml path changes without restarting the broker.
+func IsDynamic(path string) bool {
+	for _, field := range dynamicFields {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Diff returns the settings of next which differ from c.
+func (c Config) Diff(next Config) Changes {
+	var changes Changes
+
+	diffFields(reflect.ValueOf(c), reflect.ValueOf(next), "", func(path string, _, _ reflect.Value) {
+		if IsDynamic(path) {
+			changes.Dynamic = append(changes.Dynamic, path)
+		} else {
+			changes.Restart = append(changes.Restart, path)
+		}
+	})
+
+	return changes
+}
+
+// WithDynamic returns c with the dynamic settings of next, what a running broker
+// uses after reloading next.
+func (c Config) WithDynamic(next Config) Config {
+	diffFields(reflect.ValueOf(&c).Elem(), reflect.ValueOf(next), "", func(path string, field, value reflect.Value) {
+		if IsDynamic(path) {
+			field.Set(value)
+		}
+	})
+
+	return c
+}
+
+// diffFields calls changed with the yaml path of every field of a which differs in b,
+// structs are compared field by field and everything else as a whole.
+func diffFields(a, b reflect.Value, prefix string, changed func(path string, a, b reflect.Value)) {
+	t := a.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		path := tag
+
+		if prefix != "" {
+			path = prefix + "." + tag
+		}
+
+		fa, fb := a.Field(i), b.Field(i)
+
+		if fa.Kind() == reflect.Struct {
+			diffFields(fa, fb, path, changed)
+		} else if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			changed(path, fa, fb)
+		}
+	}
 }
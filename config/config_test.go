@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iris/storage/wal"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	dir, err := os.MkdirTemp("", "config")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "iris.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+nodeId: 2
+dataDir: /var/lib/iris
+peers:
+  1: broker-1:9090
+  3: broker-3:9090
+listeners:
+  kafka: :9092
+  metrics: ""
+storage:
+  segmentSize: 65536
+  fsync:
+    policy: interval
+    interval: 200ms
+  retention:
+    time: 168h
+    bytes: 1073741824
+  index:
+    intervalBytes: 1024
+auth:
+  superUsers: [admin]
+quotas:
+  default:
+    produceBytesPerSecond: 1048576
+`)
+
+	c, err := Load(path)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(2), c.NodeID)
+	assert.Equal(t, "/var/lib/iris", c.DataDir)
+	assert.Equal(t, map[int32]string{1: "broker-1:9090", 3: "broker-3:9090"}, c.Peers)
+	assert.Equal(t, Listeners{GRPC: ":9090", HTTP: ":8080", Kafka: ":9092"}, c.Listeners)
+	assert.Equal(t, 65536, c.Storage.SegmentSize)
+	assert.Equal(t, FsyncOptions{Policy: FsyncInterval, Interval: 200 * time.Millisecond}, c.Storage.Fsync)
+	assert.Equal(t, time.Minute, c.Storage.RetentionInterval)
+	assert.Equal(t, RetentionOptions{Time: 168 * time.Hour, Bytes: 1 << 30}, c.Storage.Retention)
+	assert.Equal(t, 1024, c.Storage.Index.IntervalBytes)
+	assert.Equal(t, []string{"admin"}, c.Auth.SuperUsers)
+	assert.Equal(t, float64(1048576), c.Quotas.Default.ProduceBytesPerSecond)
+	assert.Equal(t, "info", c.LogLevel)
+
+	c, err = Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), c)
+
+	_, err = Load(writeConfig(t, "storage:\n  segmentSzie: 65536\n"))
+	assert.True(t, errors.Is(err, InvalidConfig))
+	assert.Contains(t, err.Error(), "segmentSzie")
+}
+
+func TestLoadEnv(t *testing.T) {
+	path := writeConfig(t, "nodeId: 1\nstorage:\n  segmentSize: 65536\n")
+
+	t.Setenv("IRIS_NODE_ID", "4")
+	t.Setenv("IRIS_STORAGE_SEGMENT_SIZE", "131072")
+	t.Setenv("IRIS_STORAGE_FSYNC_POLICY", "always")
+	t.Setenv("IRIS_STORAGE_RETENTION_INTERVAL", "5m")
+	t.Setenv("IRIS_STORAGE_RETENTION_TIME", "24h")
+	t.Setenv("IRIS_AUTH_SUPER_USERS", "admin, ops")
+	t.Setenv("IRIS_QUOTAS_DEFAULT_REQUESTS_PER_SECOND", "100")
+	t.Setenv("IRIS_CONTROLLER_MEMBERS", "4")
+
+	c, err := Load(path)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int32(4), c.NodeID)
+	assert.Equal(t, 131072, c.Storage.SegmentSize)
+	assert.Equal(t, FsyncAlways, c.Storage.Fsync.Policy)
+	assert.Equal(t, 5*time.Minute, c.Storage.RetentionInterval)
+	assert.Equal(t, 24*time.Hour, c.Storage.Retention.Time)
+	assert.Equal(t, []string{"admin", "ops"}, c.Auth.SuperUsers)
+	assert.Equal(t, float64(100), c.Quotas.Default.RequestsPerSecond)
+	assert.Equal(t, []int32{4}, c.Controller.Members)
+
+	t.Setenv("IRIS_STORAGE_RETENTION_INTERVAL", "soon")
+
+	_, err = Load(path)
+	assert.True(t, errors.Is(err, InvalidConfig))
+	assert.Contains(t, err.Error(), "IRIS_STORAGE_RETENTION_INTERVAL")
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Default().Validate())
+
+	for field, update := range map[string]func(c *Config){
+		"dataDir":                     func(c *Config) { c.DataDir = "" },
+		"peers":                       func(c *Config) { c.Peers = map[int32]string{0: "localhost:9090"} },
+		"listeners.grpc":              func(c *Config) { c.Listeners.GRPC = "" },
+		"tls":                         func(c *Config) { c.TLS.CertFile = "iris.crt" },
+		"storage.segmentSize":         func(c *Config) { c.Storage.SegmentSize = wal.PageSize + 1 },
+		"storage.fsync.policy":        func(c *Config) { c.Storage.Fsync.Policy = "sometimes" },
+		"storage.fsync.interval":      func(c *Config) { c.Storage.Fsync = FsyncOptions{Policy: FsyncInterval} },
+		"storage.retentionInterval":   func(c *Config) { c.Storage.RetentionInterval = 0 },
+		"storage.retention.time":      func(c *Config) { c.Storage.Retention.Time = -time.Hour },
+		"storage.retention.bytes":     func(c *Config) { c.Storage.Retention.Bytes = wal.PageSize },
+		"storage.index.intervalBytes": func(c *Config) { c.Storage.Index.IntervalBytes = -1 },
+		"auth.allowAnonymous":         func(c *Config) { c.Auth.AllowAnonymous = true },
+		"quotas.default":              func(c *Config) { c.Quotas.Default.FetchBytesPerSecond = -1 },
+		"controller.members":          func(c *Config) { c.Controller.Members = []int32{0, 1} },
+		"logLevel":                    func(c *Config) { c.LogLevel = "verbose" },
+	} {
+		c := Default()
+		update(&c)
+
+		err := c.Validate()
+		assert.True(t, errors.Is(err, InvalidConfig), field)
+		assert.Contains(t, err.Error(), field+":")
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "SEGMENT_SIZE", envName("segmentSize"))
+	assert.Equal(t, "CLIENT_CA_FILE", envName("clientCAFile"))
+	assert.Equal(t, "GRPC", envName("grpc"))
+}
+
+func TestDiff(t *testing.T) {
+	c := Default()
+	assert.True(t, c.Diff(c).Empty())
+
+	next := Default()
+	next.DataDir = "/var/lib/iris"
+	next.Peers = map[int32]string{1: "broker-1:9090"}
+	next.Storage.SegmentSize *= 2
+	next.Storage.Fsync.Policy = FsyncInterval
+	next.Storage.RetentionInterval = time.Hour
+	next.Storage.Retention.Bytes = 1 << 30
+	next.Quotas.Default.RequestsPerSecond = 10
+	next.LogLevel = "debug"
+
+	changes := c.Diff(next)
+	assert.Equal(t, []string{"storage.fsync.policy", "storage.retentionInterval", "storage.retention.bytes", "quotas.default.requestsPerSecond", "logLevel"}, changes.Dynamic)
+	assert.Equal(t, []string{"dataDir", "peers", "storage.segmentSize"}, changes.Restart)
+
+	// Only the dynamic settings are taken over, the others keep running with their old values.
+	applied := c.WithDynamic(next)
+	assert.Equal(t, "data", applied.DataDir)
+	assert.Nil(t, applied.Peers)
+	assert.Equal(t, c.Storage.SegmentSize, applied.Storage.SegmentSize)
+	assert.Equal(t, next.Storage.Fsync, applied.Storage.Fsync)
+	assert.Equal(t, next.Quotas, applied.Quotas)
+	assert.Equal(t, "debug", applied.LogLevel)
+	assert.Equal(t, changes.Restart, applied.Diff(next).Restart)
+	assert.Empty(t, applied.Diff(next).Dynamic)
+}
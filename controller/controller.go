@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"iris/broker"
+	"iris/raft"
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const logDirName = "__controller"
+
+// DefaultReplicationFactor is the number of replicas of the partitions of topics created without replicas.
+const DefaultReplicationFactor = 3
+
+var NoBrokers = errors.New("No brokers registered")
+
+type Options struct {
+	// Dir holds the raft log and the snapshots of the metadata.
+	Dir         string
+	SegmentSize int
+	Raft        raft.Config
+}
+
+func DefaultOptions(dir string, id int32, members []int32) Options {
+	return Options{
+		Dir:         dir,
+		SegmentSize: wal.DefaultSegmentSize,
+		Raft:        raft.DefaultConfig(id, members),
+	}
+}
+
+// Controller keeps the cluster metadata consistent among its members. Changes are proposed
+// to the leader of the controllers and applied once a majority has written them to its log.
+type Controller struct {
+	logger log.Logger
+	node   *raft.Node
+	store  *store
+}
+
+func NewController(logger log.Logger, registerer prometheus.Registerer, options Options, transport raft.Transport) (*Controller, error) {
+	l, err := raft.OpenLog(logger, prometheus.WrapRegistererWith(prometheus.Labels{"topic": logDirName, "partition": "0"}, registerer), options.Dir, options.SegmentSize)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open controller log")
+	}
+
+	s := newStore()
+	node, err := raft.NewNode(logger, registerer, options.Raft, l, s, transport)
+
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return &Controller{logger: logger, node: node, store: s}, nil
+}
+
+// Node returns the raft node, which handles the requests of the other controllers.
+func (c *Controller) Node() *raft.Node {
+	return c.node
+}
+
+// Leader returns the id of the leading controller, changes must be proposed to it.
+func (c *Controller) Leader() int32 {
+	return c.node.Leader()
+}
+
+func (c *Controller) propose(ctx context.Context, cmd Command) error {
+	data, err := json.Marshal(cmd)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.node.Propose(ctx, data)
+
+	return err
+}
+
+func (c *Controller) RegisterBroker(ctx context.Context, info BrokerInfo) error {
+	return c.propose(ctx, Command{Type: RegisterBrokerCommand, Broker: &info})
+}
+
+// CreateTopic adds a topic to the cluster. Without replicas in the config, the partitions
+// are spread over the registered brokers with up to DefaultReplicationFactor replicas each.
+func (c *Controller) CreateTopic(ctx context.Context, name string, config broker.TopicConfig) error {
+	if len(config.Replicas) == 0 && config.Partitions > 0 {
+		metadata, _ := c.store.get()
+		ids := slices.Sorted(maps.Keys(metadata.Brokers))
+
+		if len(ids) == 0 {
+			return NoBrokers
+		}
+
+		factor := min(DefaultReplicationFactor, len(ids))
+
+		for i := 0; i < config.Partitions; i++ {
+			replicas := make([]int32, 0, factor)
+
+			for j := 0; j < factor; j++ {
+				replicas = append(replicas, ids[(i+j)%len(ids)])
+			}
+
+			config.Replicas = append(config.Replicas, replicas)
+		}
+	}
+
+	return c.propose(ctx, Command{Type: CreateTopicCommand, Topic: name, TopicConfig: &config})
+}
+
+// UpdatePartition sets the leader and the in-sync replicas of a partition.
+func (c *Controller) UpdatePartition(ctx context.Context, topic string, partition int, leader int32, isr []int32) error {
+	return c.propose(ctx, Command{Type: UpdatePartitionCommand, Topic: topic, Partition: partition, Leader: leader, ISR: isr})
+}
+
+// SetConfig sets a cluster config, an empty value removes it.
+func (c *Controller) SetConfig(ctx context.Context, key string, value string) error {
+	return c.propose(ctx, Command{Type: SetConfigCommand, Key: key, Value: value})
+}
+
+// Metadata returns the metadata applied by this controller and the index of its last change,
+// it may lag behind the leader.
+func (c *Controller) Metadata() (Metadata, uint64) {
+	return c.store.get()
+}
+
+// Watch calls fn with every change after the index, starting with the whole metadata if the
+// changes are not retained anymore. It returns once the context is done or fn fails.
+func (c *Controller) Watch(ctx context.Context, after uint64, fn func(Change) error) error {
+	return c.store.watch(ctx, after, fn)
+}
+
+// Sync creates the topics of the metadata on the broker and moves the leadership of their
+// partitions as the metadata changes, until the context is done.
+func (c *Controller) Sync(ctx context.Context, b *broker.Broker) error {
+	return c.Watch(ctx, 0, func(change Change) error {
+		if change.Metadata != nil {
+			for name, t := range change.Metadata.Topics {
+				c.syncTopic(b, name, t)
+			}
+
+			return nil
+		}
+
+		cmd := change.Command
+		metadata, _ := c.store.get()
+
+		switch cmd.Type {
+		case CreateTopicCommand, UpdatePartitionCommand:
+			if t, ok := metadata.Topics[cmd.Topic]; ok {
+				c.syncTopic(b, cmd.Topic, t)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Controller) syncTopic(b *broker.Broker, name string, t TopicMetadata) {
+	config := t.Config
+	config.Replicas = make([][]int32, 0, len(t.Partitions))
+
+	for _, p := range t.Partitions {
+		config.Replicas = append(config.Replicas, p.Replicas)
+	}
+
+	if _, err := b.CreateTopic(name, config); err != nil && !errors.Is(err, broker.TopicExists) {
+		level.Error(c.logger).Log("msg", "unable to create topic of the metadata", "topic", name, "err", err)
+		return
+	}
+
+	for i, p := range t.Partitions {
+		if err := b.SetLeader(name, i, p.Leader, p.LeaderEpoch); err != nil {
+			level.Error(c.logger).Log("msg", "unable to set partition leader", "topic", name, "partition", i, "err", err)
+		}
+	}
+}
+
+// Stop stops the raft node and closes the log.
+func (c *Controller) Stop() error {
+	return c.node.Stop()
+}
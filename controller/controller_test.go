@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"iris/broker"
+	"iris/raft"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startController(t *testing.T, dir string, id int32, transport *raft.LocalTransport) *Controller {
+	options := DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))), id, []int32{1, 2, 3})
+	options.SegmentSize = 32 * 1024 * 4
+	options.Raft.ElectionTimeout = 100 * time.Millisecond
+	options.Raft.HeartbeatInterval = 20 * time.Millisecond
+
+	c, err := NewController(log.NewNopLogger(), prometheus.NewRegistry(), options, transport)
+	require.NoError(t, err)
+
+	transport.Add(c.Node())
+
+	return c
+}
+
+func TestController(t *testing.T) {
+	dir, err := os.MkdirTemp("", "controller_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	transport := raft.NewLocalTransport()
+	controllers := make(map[int32]*Controller)
+
+	for _, id := range []int32{1, 2, 3} {
+		controllers[id] = startController(t, dir, id, transport)
+	}
+
+	defer func() {
+		for _, c := range controllers {
+			c.Stop()
+		}
+	}()
+
+	var leader *Controller
+
+	require.Eventually(t, func() bool {
+		for id, c := range controllers {
+			if c.Leader() == id {
+				leader = c
+			}
+		}
+
+		return leader != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assert.ErrorIs(t, leader.CreateTopic(ctx, "orders", broker.TopicConfig{Partitions: 2}), NoBrokers)
+
+	for _, id := range []int32{1, 2, 3} {
+		require.NoError(t, leader.RegisterBroker(ctx, BrokerInfo{ID: id, Address: "broker-" + strconv.Itoa(int(id))}))
+	}
+
+	// Brokers follow the metadata of their controller.
+	brokers := make(map[int32]*broker.Broker)
+
+	for id, c := range controllers {
+		options := broker.DefaultOptions(filepath.Join(dir, "broker-"+strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+
+		b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+		defer b.Stop()
+
+		brokers[id] = b
+
+		go c.Sync(ctx, b)
+	}
+
+	require.NoError(t, leader.CreateTopic(ctx, "orders", broker.TopicConfig{Partitions: 2}))
+	assert.ErrorIs(t, leader.CreateTopic(ctx, "orders", broker.TopicConfig{Partitions: 1}), broker.TopicExists)
+
+	for id, c := range controllers {
+		if c != leader {
+			assert.ErrorIs(t, c.SetConfig(ctx, "retention.ms", "1000"), raft.NotLeader)
+		}
+
+		require.Eventually(t, func() bool {
+			metadata, _ := c.Metadata()
+			return len(metadata.Topics) == 1
+		}, 5*time.Second, 10*time.Millisecond, "controller %d", id)
+
+		metadata, _ := c.Metadata()
+		assert.Len(t, metadata.Brokers, 3)
+		assert.Equal(t, []int32{1, 2, 3}, metadata.Topics["orders"].Partitions[0].Replicas)
+		assert.Equal(t, []int32{2, 3, 1}, metadata.Topics["orders"].Partitions[1].Replicas)
+	}
+
+	for id, b := range brokers {
+		require.Eventually(t, func() bool {
+			_, err := b.Partition("orders", 1)
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond, "broker %d", id)
+	}
+
+	// Watchers see the changes after the index they know.
+	_, index := leader.Metadata()
+	changes := make(chan Change, 1)
+
+	go leader.Watch(ctx, index, func(c Change) error {
+		changes <- c
+		return nil
+	})
+
+	require.NoError(t, leader.UpdatePartition(ctx, "orders", 1, 3, []int32{3, 1}))
+	assert.ErrorIs(t, leader.UpdatePartition(ctx, "orders", 1, 4, []int32{4}), broker.UnknownReplica)
+	// Only an in-sync replica may take over the leadership unless unclean elections are enabled.
+	assert.ErrorIs(t, leader.UpdatePartition(ctx, "orders", 1, 2, []int32{2}), broker.UncleanLeaderElection)
+
+	select {
+	case c := <-changes:
+		require.NotNil(t, c.Command)
+		assert.Equal(t, UpdatePartitionCommand, c.Command.Type)
+		assert.Greater(t, c.Index, index)
+	case <-ctx.Done():
+		t.Fatal("no change")
+	}
+
+	for id, b := range brokers {
+		require.Eventually(t, func() bool {
+			p, err := b.Partition("orders", 1)
+			return err == nil && p.Leader() == 3
+		}, 5*time.Second, 10*time.Millisecond, "broker %d", id)
+	}
+
+	// A restarted controller restores the metadata from its log.
+	var restarted int32
+
+	for id, c := range controllers {
+		if c != leader {
+			restarted = id
+		}
+	}
+
+	require.NoError(t, controllers[restarted].Stop())
+	controllers[restarted] = startController(t, dir, restarted, transport)
+
+	require.Eventually(t, func() bool {
+		metadata, _ := controllers[restarted].Metadata()
+		topic, ok := metadata.Topics["orders"]
+		return ok && topic.Partitions[1].Leader == 3 && topic.Partitions[1].LeaderEpoch == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
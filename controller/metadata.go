@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+
+	"iris/broker"
+
+	"github.com/pkg/errors"
+)
+
+// Watchers further behind than this many changes get the whole metadata instead.
+const maxRetainedChanges = 1024
+
+var InvalidCommand = errors.New("Invalid controller command")
+
+type CommandType string
+
+const (
+	RegisterBrokerCommand  CommandType = "registerBroker"
+	CreateTopicCommand     CommandType = "createTopic"
+	UpdatePartitionCommand CommandType = "updatePartition"
+	SetConfigCommand       CommandType = "setConfig"
+)
+
+// Command is a change of the metadata written to the raft log.
+type Command struct {
+	Type CommandType `json:"type"`
+
+	Broker *BrokerInfo `json:"broker,omitempty"`
+
+	Topic       string              `json:"topic,omitempty"`
+	TopicConfig *broker.TopicConfig `json:"topicConfig,omitempty"`
+	Partition   int                 `json:"partition,omitempty"`
+	Leader      int32               `json:"leader,omitempty"`
+	ISR         []int32             `json:"isr,omitempty"`
+
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type BrokerInfo struct {
+	ID int32 `json:"id"`
+	// Address is the gRPC address of the broker.
+	Address string `json:"address"`
+}
+
+type PartitionMetadata struct {
+	Replicas []int32 `json:"replicas"`
+	Leader   int32   `json:"leader"`
+	// LeaderEpoch is incremented whenever the leader changes.
+	LeaderEpoch int32   `json:"leaderEpoch"`
+	ISR         []int32 `json:"isr"`
+}
+
+type TopicMetadata struct {
+	Config     broker.TopicConfig  `json:"config"`
+	Partitions []PartitionMetadata `json:"partitions"`
+}
+
+// Metadata is the state of the cluster replicated by the controllers.
+type Metadata struct {
+	Brokers map[int32]BrokerInfo     `json:"brokers"`
+	Topics  map[string]TopicMetadata `json:"topics"`
+	Configs map[string]string        `json:"configs"`
+}
+
+func newMetadata() Metadata {
+	return Metadata{
+		Brokers: make(map[int32]BrokerInfo),
+		Topics:  make(map[string]TopicMetadata),
+		Configs: make(map[string]string),
+	}
+}
+
+func (m Metadata) clone() Metadata {
+	c := Metadata{
+		Brokers: maps.Clone(m.Brokers),
+		Topics:  make(map[string]TopicMetadata, len(m.Topics)),
+		Configs: maps.Clone(m.Configs),
+	}
+
+	for name, t := range m.Topics {
+		t.Partitions = slices.Clone(t.Partitions)
+
+		for i, p := range t.Partitions {
+			t.Partitions[i].Replicas = slices.Clone(p.Replicas)
+			t.Partitions[i].ISR = slices.Clone(p.ISR)
+		}
+
+		c.Topics[name] = t
+	}
+
+	return c
+}
+
+// Change is a change of the metadata at an index of the raft log. Watchers which missed
+// changes get a change with the whole metadata instead of a command.
+type Change struct {
+	Index    uint64
+	Command  *Command
+	Metadata *Metadata
+}
+
+// store is the state machine of the controller.
+type store struct {
+	mutex    sync.Mutex
+	metadata Metadata
+	index    uint64
+	// changes holds the latest changes, all changes after floor are retained.
+	changes []Change
+	floor   uint64
+	// Closed and replaced whenever a change is applied.
+	updated chan struct{}
+}
+
+func newStore() *store {
+	return &store{metadata: newMetadata(), updated: make(chan struct{})}
+}
+
+func (s *store) Apply(index uint64, data []byte) error {
+	var cmd Command
+
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return errors.Wrap(InvalidCommand, err.Error())
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.metadata.apply(cmd); err != nil {
+		return err
+	}
+
+	s.index = index
+	s.changes = append(s.changes, Change{Index: index, Command: &cmd})
+
+	if len(s.changes) > maxRetainedChanges {
+		s.floor = s.changes[0].Index
+		s.changes = slices.Delete(s.changes, 0, 1)
+	}
+
+	s.notify()
+
+	return nil
+}
+
+func (s *store) notify() {
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+func (s *store) Snapshot() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return json.Marshal(s.metadata)
+}
+
+func (s *store) Restore(index uint64, data []byte) error {
+	metadata := newMetadata()
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return errors.Wrap(err, "invalid metadata snapshot")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.metadata = metadata
+	s.index = index
+	s.changes = nil
+	s.floor = index
+	s.notify()
+
+	return nil
+}
+
+func (s *store) get() (Metadata, uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.metadata.clone(), s.index
+}
+
+// watch calls fn with the changes after the index until the context is done or fn fails.
+func (s *store) watch(ctx context.Context, after uint64, fn func(Change) error) error {
+	for {
+		s.mutex.Lock()
+
+		var changes []Change
+
+		switch {
+		case after >= s.index:
+		case after < s.floor:
+			metadata := s.metadata.clone()
+			changes = []Change{{Index: s.index, Metadata: &metadata}}
+		default:
+			i, _ := slices.BinarySearchFunc(s.changes, after+1, func(c Change, index uint64) int {
+				return cmp.Compare(c.Index, index)
+			})
+			changes = slices.Clone(s.changes[i:])
+		}
+
+		updated := s.updated
+		s.mutex.Unlock()
+
+		for _, c := range changes {
+			if err := fn(c); err != nil {
+				return err
+			}
+
+			after = c.Index
+		}
+
+		if len(changes) > 0 {
+			continue
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// apply changes the metadata by a command, it fails without changes if the command is invalid.
+func (m Metadata) apply(cmd Command) error {
+	switch cmd.Type {
+	case RegisterBrokerCommand:
+		if cmd.Broker == nil || cmd.Broker.ID < 0 {
+			return errors.Wrap(InvalidCommand, "invalid broker")
+		}
+
+		m.Brokers[cmd.Broker.ID] = *cmd.Broker
+	case CreateTopicCommand:
+		return m.createTopic(cmd.Topic, cmd.TopicConfig)
+	case UpdatePartitionCommand:
+		return m.updatePartition(cmd.Topic, cmd.Partition, cmd.Leader, cmd.ISR)
+	case SetConfigCommand:
+		if cmd.Key == "" {
+			return errors.Wrap(InvalidCommand, "empty config key")
+		}
+
+		if cmd.Value == "" {
+			delete(m.Configs, cmd.Key)
+		} else {
+			m.Configs[cmd.Key] = cmd.Value
+		}
+	default:
+		return errors.Wrapf(InvalidCommand, "unknown type %q", cmd.Type)
+	}
+
+	return nil
+}
+
+func (m Metadata) createTopic(name string, config *broker.TopicConfig) error {
+	if config == nil {
+		return errors.Wrap(InvalidCommand, "missing topic config")
+	}
+
+	if _, ok := m.Topics[name]; ok {
+		return errors.Wrapf(broker.TopicExists, "%s", name)
+	}
+
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	if len(config.Replicas) == 0 {
+		return errors.Wrapf(broker.InvalidTopicConfig, "topic %s has no replicas", name)
+	}
+
+	t := TopicMetadata{Config: *config}
+
+	for _, replicas := range config.Replicas {
+		t.Partitions = append(t.Partitions, PartitionMetadata{
+			Replicas: slices.Clone(replicas),
+			Leader:   replicas[0],
+			ISR:      slices.Clone(replicas),
+		})
+	}
+
+	m.Topics[name] = t
+
+	return nil
+}
+
+func (m Metadata) updatePartition(topic string, partition int, leader int32, isr []int32) error {
+	t, ok := m.Topics[topic]
+
+	if !ok {
+		return errors.Wrapf(broker.UnknownTopic, "%s", topic)
+	}
+
+	if partition < 0 || partition >= len(t.Partitions) {
+		return errors.Wrapf(broker.UnknownPartition, "partition %d of topic %s", partition, topic)
+	}
+
+	p := &t.Partitions[partition]
+
+	if !slices.Contains(p.Replicas, leader) || !slices.Contains(isr, leader) {
+		return errors.Wrapf(broker.UnknownReplica, "leader %d of %s/%d", leader, topic, partition)
+	}
+
+	for _, id := range isr {
+		if !slices.Contains(p.Replicas, id) {
+			return errors.Wrapf(broker.UnknownReplica, "in-sync replica %d of %s/%d", id, topic, partition)
+		}
+	}
+
+	if p.Leader != leader {
+		if !slices.Contains(p.ISR, leader) && !t.Config.UncleanLeaderElection {
+			return errors.Wrapf(broker.UncleanLeaderElection, "leader %d of %s/%d is not in sync", leader, topic, partition)
+		}
+
+		p.Leader = leader
+		p.LeaderEpoch++
+	}
+
+	p.ISR = slices.Clone(isr)
+
+	return nil
+}
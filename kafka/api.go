@@ -0,0 +1,690 @@
+package kafka
+
+// apiKey identifies the type of a request.
+type apiKey int16
+
+const (
+	apiProduce         apiKey = 0
+	apiFetch           apiKey = 1
+	apiListOffsets     apiKey = 2
+	apiMetadata        apiKey = 3
+	apiOffsetCommit    apiKey = 8
+	apiOffsetFetch     apiKey = 9
+	apiFindCoordinator apiKey = 10
+	apiVersions        apiKey = 18
+)
+
+type versionRange struct {
+	min int16
+	max int16
+}
+
+// supportedVersions are the versions of the supported APIs. Flexible versions,
+// which use compact encodings and tagged fields, are not supported.
+var supportedVersions = map[apiKey]versionRange{
+	apiProduce:         {3, 8},
+	apiFetch:           {4, 11},
+	apiListOffsets:     {1, 5},
+	apiMetadata:        {1, 8},
+	apiOffsetCommit:    {2, 7},
+	apiOffsetFetch:     {1, 5},
+	apiFindCoordinator: {0, 2},
+	apiVersions:        {0, 2},
+}
+
+var apiNames = map[apiKey]string{
+	apiProduce:         "produce",
+	apiFetch:           "fetch",
+	apiListOffsets:     "list_offsets",
+	apiMetadata:        "metadata",
+	apiOffsetCommit:    "offset_commit",
+	apiOffsetFetch:     "offset_fetch",
+	apiFindCoordinator: "find_coordinator",
+	apiVersions:        "api_versions",
+}
+
+func (k apiKey) String() string {
+	if name, ok := apiNames[k]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+func (k apiKey) supports(version int16) bool {
+	r, ok := supportedVersions[k]
+	return ok && version >= r.min && version <= r.max
+}
+
+// Error codes of the protocol.
+const (
+	errNone                         int16 = 0
+	errUnknownServerError           int16 = -1
+	errOffsetOutOfRange             int16 = 1
+	errUnknownTopicOrPartition      int16 = 3
+	errNotLeaderOrFollower          int16 = 6
+	errRequestTimedOut              int16 = 7
+	errCoordinatorNotAvailable      int16 = 15
+	errInvalidTopic                 int16 = 17
+	errNotEnoughReplicas            int16 = 19
+	errNotEnoughReplicasAfterAppend int16 = 20
+	errTopicAuthorizationFailed     int16 = 29
+	errGroupAuthorizationFailed     int16 = 30
+	errClusterAuthorizationFailed   int16 = 31
+	errUnsupportedVersion           int16 = 35
+	errInvalidPartitions            int16 = 37
+	errInvalidRequest               int16 = 42
+	errUnsupportedCompressionType   int16 = 76
+	errInvalidRecord                int16 = 87
+)
+
+// Special timestamps of list offsets requests.
+const (
+	latestTimestamp   = -1
+	earliestTimestamp = -2
+)
+
+type produceRequest struct {
+	acks      int16
+	timeoutMs int32
+	topics    []produceTopic
+}
+
+type produceTopic struct {
+	name       string
+	partitions []producePartition
+}
+
+type producePartition struct {
+	index   int32
+	records []byte
+}
+
+func decodeProduceRequest(d *decoder, version int16) produceRequest {
+	var req produceRequest
+
+	d.nullableString() // transactional id
+	req.acks = d.int16()
+	req.timeoutMs = d.int32()
+
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		t := produceTopic{name: d.string()}
+
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			t.partitions = append(t.partitions, producePartition{index: d.int32(), records: d.bytes()})
+		}
+
+		req.topics = append(req.topics, t)
+	}
+
+	return req
+}
+
+type produceResponse struct {
+	topics []produceTopicResponse
+}
+
+type produceTopicResponse struct {
+	name       string
+	partitions []producePartitionResponse
+}
+
+type producePartitionResponse struct {
+	index          int32
+	errorCode      int16
+	errorMessage   *string
+	baseOffset     int64
+	logStartOffset int64
+}
+
+func (r *produceResponse) encode(e *encoder, version int16) {
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(p.errorCode)
+			e.int64(p.baseOffset)
+			e.int64(-1) // log append time
+
+			if version >= 5 {
+				e.int64(p.logStartOffset)
+			}
+
+			if version >= 8 {
+				e.arrayLen(0) // record errors
+				e.nullableString(p.errorMessage)
+			}
+		}
+	}
+
+	e.int32(0) // throttle time
+}
+
+type fetchRequest struct {
+	maxWaitMs int32
+	minBytes  int32
+	maxBytes  int32
+	topics    []fetchTopic
+}
+
+type fetchTopic struct {
+	name       string
+	partitions []fetchPartition
+}
+
+type fetchPartition struct {
+	index             int32
+	fetchOffset       int64
+	partitionMaxBytes int32
+}
+
+func decodeFetchRequest(d *decoder, version int16) fetchRequest {
+	var req fetchRequest
+
+	d.int32() // replica id
+	req.maxWaitMs = d.int32()
+	req.minBytes = d.int32()
+	req.maxBytes = d.int32()
+	d.int8() // isolation level
+
+	if version >= 7 {
+		d.int32() // session id
+		d.int32() // session epoch
+	}
+
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		t := fetchTopic{name: d.string()}
+
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			p := fetchPartition{index: d.int32()}
+
+			if version >= 9 {
+				d.int32() // current leader epoch
+			}
+
+			p.fetchOffset = d.int64()
+
+			if version >= 5 {
+				d.int64() // log start offset
+			}
+
+			p.partitionMaxBytes = d.int32()
+			t.partitions = append(t.partitions, p)
+		}
+
+		req.topics = append(req.topics, t)
+	}
+
+	if version >= 7 {
+		for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+			d.string()
+			d.int32Array()
+		}
+	}
+
+	if version >= 11 {
+		d.string() // rack id
+	}
+
+	return req
+}
+
+type fetchResponse struct {
+	topics []fetchTopicResponse
+}
+
+type fetchTopicResponse struct {
+	name       string
+	partitions []fetchPartitionResponse
+}
+
+type fetchPartitionResponse struct {
+	index          int32
+	errorCode      int16
+	highWatermark  int64
+	logStartOffset int64
+	records        []byte
+}
+
+// failed reports whether a partition has an error, which is returned without waiting.
+func (r *fetchResponse) failed() bool {
+	for _, t := range r.topics {
+		for _, p := range t.partitions {
+			if p.errorCode != errNone {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (r *fetchResponse) encode(e *encoder, version int16) {
+	e.int32(0) // throttle time
+
+	if version >= 7 {
+		e.int16(errNone)
+		e.int32(0) // session id, fetch sessions are not supported
+	}
+
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(p.errorCode)
+			e.int64(p.highWatermark)
+			e.int64(p.highWatermark) // last stable offset
+
+			if version >= 5 {
+				e.int64(p.logStartOffset)
+			}
+
+			e.arrayLen(-1) // aborted transactions
+
+			if version >= 11 {
+				e.int32(-1) // preferred read replica
+			}
+
+			e.bytes(p.records)
+		}
+	}
+}
+
+type listOffsetsRequest struct {
+	topics []listOffsetsTopic
+}
+
+type listOffsetsTopic struct {
+	name       string
+	partitions []listOffsetsPartition
+}
+
+type listOffsetsPartition struct {
+	index     int32
+	timestamp int64
+}
+
+func decodeListOffsetsRequest(d *decoder, version int16) listOffsetsRequest {
+	var req listOffsetsRequest
+
+	d.int32() // replica id
+
+	if version >= 2 {
+		d.int8() // isolation level
+	}
+
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		t := listOffsetsTopic{name: d.string()}
+
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			p := listOffsetsPartition{index: d.int32()}
+
+			if version >= 4 {
+				d.int32() // current leader epoch
+			}
+
+			p.timestamp = d.int64()
+			t.partitions = append(t.partitions, p)
+		}
+
+		req.topics = append(req.topics, t)
+	}
+
+	return req
+}
+
+type listOffsetsResponse struct {
+	topics []listOffsetsTopicResponse
+}
+
+type listOffsetsTopicResponse struct {
+	name       string
+	partitions []listOffsetsPartitionResponse
+}
+
+type listOffsetsPartitionResponse struct {
+	index     int32
+	errorCode int16
+	timestamp int64
+	offset    int64
+}
+
+func (r *listOffsetsResponse) encode(e *encoder, version int16) {
+	if version >= 2 {
+		e.int32(0) // throttle time
+	}
+
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(p.errorCode)
+			e.int64(p.timestamp)
+			e.int64(p.offset)
+
+			if version >= 4 {
+				e.int32(0) // leader epoch
+			}
+		}
+	}
+}
+
+type metadataRequest struct {
+	// All topics are described if topics is nil.
+	topics            []string
+	allowAutoCreation bool
+}
+
+func decodeMetadataRequest(d *decoder, version int16) metadataRequest {
+	req := metadataRequest{allowAutoCreation: true}
+	n := d.arrayLen()
+
+	if n >= 0 {
+		req.topics = make([]string, 0, n)
+	}
+
+	for i := 0; i < n && d.err == nil; i++ {
+		req.topics = append(req.topics, d.string())
+	}
+
+	if version >= 4 {
+		req.allowAutoCreation = d.bool()
+	}
+
+	if version >= 8 {
+		d.bool() // include cluster authorized operations
+		d.bool() // include topic authorized operations
+	}
+
+	return req
+}
+
+type metadataResponse struct {
+	nodeID    int32
+	host      string
+	port      int32
+	clusterID string
+	topics    []metadataTopic
+}
+
+type metadataTopic struct {
+	errorCode  int16
+	name       string
+	internal   bool
+	partitions []int32
+}
+
+func (r *metadataResponse) encode(e *encoder, version int16) {
+	if version >= 3 {
+		e.int32(0) // throttle time
+	}
+
+	// The broker is the only one of the cluster and leads every partition.
+	e.arrayLen(1)
+	e.int32(r.nodeID)
+	e.string(r.host)
+	e.int32(r.port)
+	e.nullableString(nil) // rack
+
+	if version >= 2 {
+		e.nullableString(&r.clusterID)
+	}
+
+	e.int32(r.nodeID) // controller id
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.int16(t.errorCode)
+		e.string(t.name)
+		e.bool(t.internal)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int16(errNone)
+			e.int32(p)
+			e.int32(r.nodeID)
+
+			if version >= 7 {
+				e.int32(0) // leader epoch
+			}
+
+			e.int32Array([]int32{r.nodeID}) // replicas
+			e.int32Array([]int32{r.nodeID}) // in-sync replicas
+
+			if version >= 5 {
+				e.int32Array(nil) // offline replicas
+			}
+		}
+
+		if version >= 8 {
+			e.int32(-2147483648) // topic authorized operations are not reported
+		}
+	}
+
+	if version >= 8 {
+		e.int32(-2147483648) // cluster authorized operations
+	}
+}
+
+type offsetCommitRequest struct {
+	groupID string
+	topics  []offsetCommitTopic
+}
+
+type offsetCommitTopic struct {
+	name       string
+	partitions []offsetCommitPartition
+}
+
+type offsetCommitPartition struct {
+	index  int32
+	offset int64
+}
+
+func decodeOffsetCommitRequest(d *decoder, version int16) offsetCommitRequest {
+	req := offsetCommitRequest{groupID: d.string()}
+
+	d.int32()  // generation id
+	d.string() // member id
+
+	if version <= 4 {
+		d.int64() // retention time
+	}
+
+	if version >= 7 {
+		d.nullableString() // group instance id
+	}
+
+	for i, n := 0, d.arrayLen(); i < n && d.err == nil; i++ {
+		t := offsetCommitTopic{name: d.string()}
+
+		for j, m := 0, d.arrayLen(); j < m && d.err == nil; j++ {
+			p := offsetCommitPartition{index: d.int32(), offset: d.int64()}
+
+			if version >= 6 {
+				d.int32() // committed leader epoch
+			}
+
+			d.nullableString() // metadata, which is not stored
+			t.partitions = append(t.partitions, p)
+		}
+
+		req.topics = append(req.topics, t)
+	}
+
+	return req
+}
+
+type offsetCommitResponse struct {
+	topics []offsetCommitTopicResponse
+}
+
+type offsetCommitTopicResponse struct {
+	name       string
+	partitions []offsetCommitPartitionResponse
+}
+
+type offsetCommitPartitionResponse struct {
+	index     int32
+	errorCode int16
+}
+
+func (r *offsetCommitResponse) encode(e *encoder, version int16) {
+	if version >= 3 {
+		e.int32(0) // throttle time
+	}
+
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int16(p.errorCode)
+		}
+	}
+}
+
+type offsetFetchRequest struct {
+	groupID string
+	// The offsets of all partitions the group committed to are fetched if topics is nil.
+	topics []offsetFetchTopic
+}
+
+type offsetFetchTopic struct {
+	name       string
+	partitions []int32
+}
+
+func decodeOffsetFetchRequest(d *decoder, version int16) offsetFetchRequest {
+	req := offsetFetchRequest{groupID: d.string()}
+	n := d.arrayLen()
+
+	if n >= 0 {
+		req.topics = make([]offsetFetchTopic, 0, n)
+	}
+
+	for i := 0; i < n && d.err == nil; i++ {
+		req.topics = append(req.topics, offsetFetchTopic{name: d.string(), partitions: d.int32Array()})
+	}
+
+	return req
+}
+
+type offsetFetchResponse struct {
+	errorCode int16
+	topics    []offsetFetchTopicResponse
+}
+
+type offsetFetchTopicResponse struct {
+	name       string
+	partitions []offsetFetchPartitionResponse
+}
+
+type offsetFetchPartitionResponse struct {
+	index     int32
+	offset    int64
+	errorCode int16
+}
+
+func (r *offsetFetchResponse) encode(e *encoder, version int16) {
+	if version >= 3 {
+		e.int32(0) // throttle time
+	}
+
+	e.arrayLen(len(r.topics))
+
+	for _, t := range r.topics {
+		e.string(t.name)
+		e.arrayLen(len(t.partitions))
+
+		for _, p := range t.partitions {
+			e.int32(p.index)
+			e.int64(p.offset)
+
+			if version >= 5 {
+				e.int32(-1) // committed leader epoch
+			}
+
+			e.string("") // metadata
+			e.int16(p.errorCode)
+		}
+	}
+
+	if version >= 2 {
+		e.int16(r.errorCode)
+	}
+}
+
+type findCoordinatorRequest struct {
+	key     string
+	keyType int8
+}
+
+func decodeFindCoordinatorRequest(d *decoder, version int16) findCoordinatorRequest {
+	req := findCoordinatorRequest{key: d.string()}
+
+	if version >= 1 {
+		req.keyType = d.int8()
+	}
+
+	return req
+}
+
+type findCoordinatorResponse struct {
+	errorCode int16
+	nodeID    int32
+	host      string
+	port      int32
+}
+
+func (r *findCoordinatorResponse) encode(e *encoder, version int16) {
+	if version >= 1 {
+		e.int32(0) // throttle time
+	}
+
+	e.int16(r.errorCode)
+
+	if version >= 1 {
+		e.nullableString(nil) // error message
+	}
+
+	e.int32(r.nodeID)
+	e.string(r.host)
+	e.int32(r.port)
+}
+
+type apiVersionsResponse struct {
+	errorCode int16
+}
+
+func (r *apiVersionsResponse) encode(e *encoder, version int16) {
+	e.int16(r.errorCode)
+
+	keys := []apiKey{apiProduce, apiFetch, apiListOffsets, apiMetadata, apiOffsetCommit, apiOffsetFetch, apiFindCoordinator, apiVersions}
+	e.arrayLen(len(keys))
+
+	for _, k := range keys {
+		e.int16(int16(k))
+		e.int16(supportedVersions[k].min)
+		e.int16(supportedVersions[k].max)
+	}
+
+	if version >= 1 {
+		e.int32(0) // throttle time
+	}
+}
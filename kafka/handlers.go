@@ -0,0 +1,502 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"iris/auth"
+	"iris/broker"
+	"iris/schema"
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+// errorCode maps broker and storage errors to error codes of the protocol.
+func (s *Server) errorCode(api apiKey, err error) int16 {
+	var code int16
+
+	switch {
+	case err == nil:
+		return errNone
+	case errors.Is(err, broker.UnknownTopic), errors.Is(err, broker.UnknownPartition):
+		code = errUnknownTopicOrPartition
+	case errors.Is(err, broker.InvalidName):
+		code = errInvalidTopic
+	case errors.Is(err, broker.InvalidPartitions):
+		code = errInvalidPartitions
+	case errors.Is(err, storage.OffsetOutOfRange):
+		code = errOffsetOutOfRange
+	case errors.Is(err, broker.NotLeader):
+		code = errNotLeaderOrFollower
+	case errors.Is(err, broker.NotEnoughReplicas):
+		code = errNotEnoughReplicas
+	case errors.Is(err, broker.NotEnoughReplicasAfterAppend):
+		code = errNotEnoughReplicasAfterAppend
+	case errors.Is(err, context.DeadlineExceeded):
+		code = errRequestTimedOut
+	case errors.Is(err, UnsupportedCompression):
+		code = errUnsupportedCompressionType
+	case errors.Is(err, InvalidRecord), errors.Is(err, schema.InvalidPayload), errors.Is(err, schema.UnknownSchema):
+		code = errInvalidRecord
+	case errors.Is(err, InvalidRequest), errors.Is(err, broker.NoMessages):
+		code = errInvalidRequest
+	default:
+		code = errUnknownServerError
+		level.Error(s.logger).Log("msg", "error handling request", "api", api, "err", err)
+	}
+
+	s.metrics.requestErrors.WithLabelValues(api.String(), strconv.Itoa(int(code))).Inc()
+
+	return code
+}
+
+func internalTopic(name string) bool {
+	return strings.HasPrefix(name, "__")
+}
+
+func (s *Server) metadata(c *conn, req metadataRequest) metadataResponse {
+	host, port := s.address(c)
+	res := metadataResponse{nodeID: s.options.NodeID, host: host, port: port, clusterID: s.options.ClusterID}
+
+	describe := func(name string) int16 {
+		if code := s.authorize(c, auth.OperationConsume, auth.ResourceTopic, name); code == errNone {
+			return code
+		}
+
+		return s.authorize(c, auth.OperationProduce, auth.ResourceTopic, name)
+	}
+
+	add := func(t *broker.Topic) {
+		mt := metadataTopic{name: t.Name, internal: internalTopic(t.Name)}
+
+		for _, p := range t.Partitions() {
+			mt.partitions = append(mt.partitions, int32(p.ID))
+		}
+
+		res.topics = append(res.topics, mt)
+	}
+
+	// Listing all topics leaves out those the client may not use.
+	if req.topics == nil {
+		topics := s.broker.Topics()
+		sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+
+		for _, t := range topics {
+			if describe(t.Name) == errNone {
+				add(t)
+			}
+		}
+
+		return res
+	}
+
+	for _, name := range req.topics {
+		if code := describe(name); code != errNone {
+			res.topics = append(res.topics, metadataTopic{errorCode: code, name: name})
+			continue
+		}
+
+		var t *broker.Topic
+		var err error
+
+		if req.allowAutoCreation && s.authorize(c, auth.OperationProduce, auth.ResourceTopic, name) == errNone {
+			t, err = s.broker.AutoCreateTopic(name)
+		} else {
+			t, err = s.broker.Topic(name)
+		}
+
+		if err != nil {
+			res.topics = append(res.topics, metadataTopic{errorCode: s.errorCode(apiMetadata, err), name: name})
+			continue
+		}
+
+		add(t)
+	}
+
+	return res
+}
+
+func (s *Server) produce(c *conn, req produceRequest) produceResponse {
+	var res produceResponse
+
+	acks := broker.AcksLeader
+
+	switch req.acks {
+	case 0:
+		acks = broker.AcksNone
+	case -1:
+		acks = broker.AcksAll
+	}
+
+	// With acks=all the partitions wait for their replicas up to the timeout of the request.
+	ctx, cancel := context.WithTimeout(c.ctx, time.Duration(req.timeoutMs)*time.Millisecond)
+	defer cancel()
+
+	for _, t := range req.topics {
+		tr := produceTopicResponse{name: t.name}
+		denied := s.authorize(c, auth.OperationProduce, auth.ResourceTopic, t.name)
+
+		for _, p := range t.partitions {
+			pr := producePartitionResponse{index: p.index, baseOffset: -1, logStartOffset: -1}
+
+			if denied != errNone {
+				pr.errorCode = denied
+				tr.partitions = append(tr.partitions, pr)
+
+				continue
+			}
+
+			offset, err := s.producePartition(ctx, t.name, acks, p)
+
+			if err != nil {
+				pr.errorCode = s.errorCode(apiProduce, err)
+				msg := err.Error()
+				pr.errorMessage = &msg
+			} else {
+				pr.baseOffset = int64(offset)
+			}
+
+			tr.partitions = append(tr.partitions, pr)
+		}
+
+		res.topics = append(res.topics, tr)
+	}
+
+	return res
+}
+
+func (s *Server) producePartition(ctx context.Context, topic string, acks broker.Acks, p producePartition) (uint64, error) {
+	msgs, err := decodeRecordBatches(p.records)
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := s.broker.Produce(ctx, broker.ProduceRequest{
+		Topic:     topic,
+		Partition: int(p.index),
+		Messages:  msgs,
+		Acks:      acks,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.BaseOffset, nil
+}
+
+// fetch reads the partitions of the request and waits up to the max wait time
+// for new messages if less than the minimum bytes are available.
+func (s *Server) fetch(c *conn, req fetchRequest) fetchResponse {
+	maxWait := min(time.Duration(req.maxWaitMs)*time.Millisecond, s.options.MaxWait)
+	ctx, cancel := context.WithTimeout(c.ctx, maxWait)
+	defer cancel()
+
+	for {
+		res, size := s.fetchOnce(c, req)
+
+		if size >= int(req.minBytes) || res.failed() || ctx.Err() != nil {
+			return res
+		}
+
+		s.waitAny(ctx, req)
+	}
+}
+
+func (s *Server) fetchOnce(c *conn, req fetchRequest) (fetchResponse, int) {
+	var res fetchResponse
+
+	total := 0
+	maxBytes := int(req.maxBytes)
+
+	if maxBytes <= 0 {
+		maxBytes = s.options.MaxRequestSize
+	}
+
+	for _, t := range req.topics {
+		tr := fetchTopicResponse{name: t.name}
+		denied := s.authorize(c, auth.OperationConsume, auth.ResourceTopic, t.name)
+
+		for _, p := range t.partitions {
+			pr := fetchPartitionResponse{index: p.index, highWatermark: -1, logStartOffset: -1}
+
+			if denied != errNone {
+				pr.errorCode = denied
+				tr.partitions = append(tr.partitions, pr)
+
+				continue
+			}
+
+			// The first message is always returned, even if it exceeds the limits.
+			limit := min(int(p.partitionMaxBytes), maxBytes-total)
+
+			if total == 0 {
+				limit = max(limit, 1)
+			}
+
+			records, size, err := s.fetchPartition(t.name, p, limit, &pr)
+
+			if err != nil {
+				pr.errorCode = s.errorCode(apiFetch, err)
+			}
+
+			pr.records = records
+			total += size
+			tr.partitions = append(tr.partitions, pr)
+		}
+
+		res.topics = append(res.topics, tr)
+	}
+
+	return res, total
+}
+
+func (s *Server) fetchPartition(topic string, p fetchPartition, limit int, pr *fetchPartitionResponse) ([]byte, int, error) {
+	part, err := s.broker.Partition(topic, int(p.index))
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start, err := part.Journal().StartOffset()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pr.logStartOffset = int64(start)
+	pr.highWatermark = int64(part.HighWatermark())
+
+	if p.fetchOffset < 0 || uint64(p.fetchOffset) < start {
+		return nil, 0, errors.Wrapf(storage.OffsetOutOfRange, "offset %d is before the start %d of %s", p.fetchOffset, start, part)
+	}
+
+	if limit <= 0 {
+		return nil, 0, nil
+	}
+
+	res, err := s.broker.Fetch(broker.FetchRequest{
+		Topic:     topic,
+		Partition: int(p.index),
+		Offset:    uint64(p.fetchOffset),
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pr.highWatermark = int64(res.HighWatermark)
+	msgs := res.Messages
+	size := 0
+
+	for i, msg := range msgs {
+		size += len(msg.Key) + len(msg.Value)
+
+		for _, h := range msg.Headers {
+			size += len(h.Key) + len(h.Value)
+		}
+
+		if size > limit && i > 0 {
+			msgs = msgs[:i]
+			break
+		}
+	}
+
+	return encodeRecordBatch(msgs, 0), size, nil
+}
+
+// waitAny blocks until a message is appended to one of the partitions of the request or the context is done.
+func (s *Server) waitAny(ctx context.Context, req fetchRequest) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	appended := make(chan struct{}, 1)
+	waiting := 0
+
+	for _, t := range req.topics {
+		for _, p := range t.partitions {
+			if p.fetchOffset < 0 {
+				continue
+			}
+
+			waiting++
+
+			go func(topic string, partition int, offset uint64) {
+				if err := s.broker.Wait(ctx, topic, partition, offset); err == nil {
+					select {
+					case appended <- struct{}{}:
+					default:
+					}
+				}
+			}(t.name, int(p.index), uint64(p.fetchOffset))
+		}
+	}
+
+	if waiting == 0 {
+		<-ctx.Done()
+		return
+	}
+
+	select {
+	case <-appended:
+	case <-ctx.Done():
+	}
+}
+
+func (s *Server) listOffsets(c *conn, req listOffsetsRequest) listOffsetsResponse {
+	var res listOffsetsResponse
+
+	for _, t := range req.topics {
+		tr := listOffsetsTopicResponse{name: t.name}
+		denied := s.authorize(c, auth.OperationConsume, auth.ResourceTopic, t.name)
+
+		for _, p := range t.partitions {
+			pr := listOffsetsPartitionResponse{index: p.index, timestamp: -1, offset: -1}
+
+			if denied != errNone {
+				pr.errorCode = denied
+			} else if offset, err := s.offsetFor(t.name, int(p.index), p.timestamp); err != nil {
+				pr.errorCode = s.errorCode(apiListOffsets, err)
+			} else {
+				pr.offset = int64(offset)
+			}
+
+			tr.partitions = append(tr.partitions, pr)
+		}
+
+		res.topics = append(res.topics, tr)
+	}
+
+	return res
+}
+
+func (s *Server) offsetFor(topic string, partition int, timestamp int64) (uint64, error) {
+	p, err := s.broker.Partition(topic, partition)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch timestamp {
+	case latestTimestamp:
+		return p.HighWatermark(), nil
+	case earliestTimestamp:
+		return p.Journal().StartOffset()
+	default:
+		return s.broker.OffsetForTime(topic, partition, time.UnixMilli(timestamp))
+	}
+}
+
+func (s *Server) findCoordinator(c *conn, req findCoordinatorRequest) findCoordinatorResponse {
+	host, port := s.address(c)
+
+	// Transactions have no coordinator.
+	if req.keyType != 0 {
+		return findCoordinatorResponse{errorCode: errCoordinatorNotAvailable, nodeID: -1, port: -1}
+	}
+
+	if code := s.authorize(c, auth.OperationConsume, auth.ResourceGroup, req.key); code != errNone {
+		return findCoordinatorResponse{errorCode: code, nodeID: -1, port: -1}
+	}
+
+	return findCoordinatorResponse{nodeID: s.options.NodeID, host: host, port: port}
+}
+
+func (s *Server) offsetCommit(c *conn, req offsetCommitRequest) offsetCommitResponse {
+	var res offsetCommitResponse
+
+	groupDenied := s.authorize(c, auth.OperationConsume, auth.ResourceGroup, req.groupID)
+
+	for _, t := range req.topics {
+		tr := offsetCommitTopicResponse{name: t.name}
+		denied := groupDenied
+
+		if denied == errNone {
+			denied = s.authorize(c, auth.OperationConsume, auth.ResourceTopic, t.name)
+		}
+
+		for _, p := range t.partitions {
+			pr := offsetCommitPartitionResponse{index: p.index, errorCode: denied}
+
+			if denied == errNone {
+				if p.offset < 0 {
+					pr.errorCode = s.errorCode(apiOffsetCommit, errors.Wrapf(storage.OffsetOutOfRange, "offset %d", p.offset))
+				} else {
+					pr.errorCode = s.errorCode(apiOffsetCommit, s.broker.Commit(req.groupID, t.name, int(p.index), uint64(p.offset)))
+				}
+			}
+
+			tr.partitions = append(tr.partitions, pr)
+		}
+
+		res.topics = append(res.topics, tr)
+	}
+
+	return res
+}
+
+func (s *Server) offsetFetch(c *conn, req offsetFetchRequest) offsetFetchResponse {
+	var res offsetFetchResponse
+
+	if code := s.authorize(c, auth.OperationConsume, auth.ResourceGroup, req.groupID); code != errNone {
+		res.errorCode = code
+		return res
+	}
+
+	topics := req.topics
+
+	// Without topics the offsets of every partition the group has committed to are returned.
+	all := topics == nil
+
+	if all {
+		for _, t := range s.broker.Topics() {
+			ft := offsetFetchTopic{name: t.Name}
+
+			for _, p := range t.Partitions() {
+				ft.partitions = append(ft.partitions, int32(p.ID))
+			}
+
+			topics = append(topics, ft)
+		}
+
+		sort.Slice(topics, func(i, j int) bool { return topics[i].name < topics[j].name })
+	}
+
+	for _, t := range topics {
+		tr := offsetFetchTopicResponse{name: t.name}
+		denied := s.authorize(c, auth.OperationConsume, auth.ResourceTopic, t.name)
+
+		for _, index := range t.partitions {
+			pr := offsetFetchPartitionResponse{index: index, offset: -1, errorCode: denied}
+
+			if denied == errNone {
+				offset, ok, err := s.broker.Committed(req.groupID, t.name, int(index))
+
+				switch {
+				case err != nil:
+					pr.errorCode = s.errorCode(apiOffsetFetch, err)
+				case ok:
+					pr.offset = int64(offset)
+				case all:
+					continue
+				}
+			} else if all {
+				continue
+			}
+
+			tr.partitions = append(tr.partitions, pr)
+		}
+
+		if !all || len(tr.partitions) > 0 {
+			res.topics = append(res.topics, tr)
+		}
+	}
+
+	return res
+}
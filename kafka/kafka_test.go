@@ -0,0 +1,227 @@
+package kafka
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"iris/broker"
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, dir string) (*broker.Broker, net.Addr, func()) {
+	options := broker.DefaultOptions(dir)
+	options.SegmentSize = 32 * 1024 * 4
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := NewServer(log.NewNopLogger(), prometheus.NewRegistry(), b, nil, nil, DefaultOptions())
+
+	go s.Serve(lis)
+
+	return b, lis.Addr(), func() {
+		s.Stop()
+		b.Stop()
+	}
+}
+
+func newMessages(values ...string) []*storage.Message {
+	msgs := make([]*storage.Message, 0, len(values))
+
+	for _, v := range values {
+		msgs = append(msgs, &storage.Message{Value: []byte(v)})
+	}
+
+	return msgs
+}
+
+func TestRecordBatch(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	msgs := []*storage.Message{
+		{Offset: 5, Timestamp: now, Key: []byte("a"), Value: []byte("1"), Headers: []storage.Header{{Key: "h", Value: []byte("v")}}},
+		{Offset: 7, Timestamp: now.Add(time.Second), Value: []byte("2")},
+	}
+
+	decoded, err := decodeRecordBatches(encodeRecordBatch(msgs, 0))
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+
+	for i, msg := range decoded {
+		assert.Equal(t, msgs[i].Timestamp, msg.Timestamp)
+		assert.Equal(t, msgs[i].Key, msg.Key)
+		assert.Equal(t, msgs[i].Value, msg.Value)
+		assert.Equal(t, msgs[i].Headers, msg.Headers)
+	}
+
+	batch := encodeRecordBatch(msgs, 0)
+	batch[len(batch)-1] ^= 0xff
+
+	_, err = decodeRecordBatches(batch)
+	assert.ErrorIs(t, err, InvalidRecord)
+
+	_, err = decodeRecordBatches(batch[:20])
+	assert.ErrorIs(t, err, InvalidRecord)
+}
+
+func TestKafkaClient(t *testing.T) {
+	dir, err := os.MkdirTemp("", "kafka_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, addr, stop := newTestServer(t, dir)
+	defer stop()
+
+	_, err = b.CreateTopic("orders", broker.TopicConfig{Partitions: 2})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := &kafkago.Client{Addr: addr, Timeout: 10 * time.Second}
+
+	versions, err := client.ApiVersions(ctx, &kafkago.ApiVersionsRequest{Addr: addr})
+	require.NoError(t, err)
+	assert.Len(t, versions.ApiKeys, len(supportedVersions))
+
+	w := &kafkago.Writer{
+		Addr:         addr,
+		Topic:        "orders",
+		Balancer:     &kafkago.Hash{},
+		RequiredAcks: kafkago.RequireAll,
+		BatchTimeout: 10 * time.Millisecond,
+	}
+
+	err = w.WriteMessages(ctx,
+		kafkago.Message{Key: []byte("a"), Value: []byte("1"), Headers: []kafkago.Header{{Key: "h", Value: []byte("v")}}},
+		kafkago.Message{Key: []byte("a"), Value: []byte("2")},
+		kafkago.Message{Key: []byte("a"), Value: []byte("3")},
+	)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// All messages have the same key and end up in one partition.
+	offsets, err := client.ListOffsets(ctx, &kafkago.ListOffsetsRequest{
+		Addr: addr,
+		Topics: map[string][]kafkago.OffsetRequest{
+			"orders": {kafkago.FirstOffsetOf(0), kafkago.LastOffsetOf(0), kafkago.FirstOffsetOf(1), kafkago.LastOffsetOf(1)},
+		},
+	})
+	require.NoError(t, err)
+
+	partition := -1
+
+	for _, p := range offsets.Topics["orders"] {
+		require.NoError(t, p.Error)
+
+		if p.LastOffset == 3 {
+			partition = p.Partition
+			assert.Equal(t, int64(0), p.FirstOffset)
+		}
+	}
+
+	require.NotEqual(t, -1, partition)
+
+	r := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:   []string{addr.String()},
+		Topic:     "orders",
+		Partition: partition,
+		MaxWait:   100 * time.Millisecond,
+	})
+	defer r.Close()
+
+	require.NoError(t, r.SetOffset(1))
+
+	msg, err := r.ReadMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), msg.Offset)
+	assert.Equal(t, []byte("a"), msg.Key)
+	assert.Equal(t, []byte("2"), msg.Value)
+
+	msg, err = r.ReadMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("3"), msg.Value)
+
+	// The reader waits for messages produced later.
+	go func() {
+		_, err := b.Produce(context.Background(), broker.ProduceRequest{Topic: "orders", Partition: partition, Messages: newMessages("4")})
+		assert.NoError(t, err)
+	}()
+
+	msg, err = r.ReadMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), msg.Offset)
+	assert.Equal(t, []byte("4"), msg.Value)
+
+	fetched, err := client.Fetch(ctx, &kafkago.FetchRequest{Addr: addr, Topic: "orders", Partition: partition, Offset: 0, MaxBytes: 1024})
+	require.NoError(t, err)
+	require.NoError(t, fetched.Error)
+
+	first, err := fetched.Records.ReadRecord()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), first.Offset)
+	require.Len(t, first.Headers, 1)
+	assert.Equal(t, "h", first.Headers[0].Key)
+
+	coordinator, err := client.FindCoordinator(ctx, &kafkago.FindCoordinatorRequest{Addr: addr, Key: "billing"})
+	require.NoError(t, err)
+	require.NoError(t, coordinator.Error)
+	assert.Equal(t, addr.(*net.TCPAddr).Port, coordinator.Coordinator.Port)
+
+	committed, err := client.OffsetCommit(ctx, &kafkago.OffsetCommitRequest{
+		Addr:    addr,
+		GroupID: "billing",
+		Topics:  map[string][]kafkago.OffsetCommit{"orders": {{Partition: partition, Offset: 2}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, committed.Topics["orders"], 1)
+	require.NoError(t, committed.Topics["orders"][0].Error)
+
+	committedOffset, ok, err := b.Committed("billing", "orders", partition)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), committedOffset)
+
+	fetchedOffsets, err := client.OffsetFetch(ctx, &kafkago.OffsetFetchRequest{
+		Addr:    addr,
+		GroupID: "billing",
+		Topics:  map[string][]int{"orders": {0, 1}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, fetchedOffsets.Error)
+
+	byPartition := make(map[int]int64)
+
+	for _, p := range fetchedOffsets.Topics["orders"] {
+		require.NoError(t, p.Error)
+		byPartition[p.Partition] = p.CommittedOffset
+	}
+
+	assert.Equal(t, int64(2), byPartition[partition])
+	assert.Equal(t, int64(-1), byPartition[1-partition])
+
+	// Fetching past the end of the partition fails.
+	fetched, err = client.Fetch(ctx, &kafkago.FetchRequest{Addr: addr, Topic: "orders", Partition: partition, Offset: 10})
+	require.NoError(t, err)
+	assert.ErrorIs(t, fetched.Error, kafkago.OffsetOutOfRange)
+
+	// Unknown topics are created on produce like with the other APIs.
+	w = &kafkago.Writer{Addr: addr, Topic: "shipping", AllowAutoTopicCreation: true, BatchTimeout: 10 * time.Millisecond, RequiredAcks: kafkago.RequireOne}
+	require.NoError(t, w.WriteMessages(ctx, kafkago.Message{Value: []byte("x")}))
+	require.NoError(t, w.Close())
+
+	topic, err := b.Topic("shipping")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), topic.Partitions()[0].NextOffset())
+}
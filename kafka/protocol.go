@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	InvalidRequest     = errors.New("Invalid request")
+	UnsupportedVersion = errors.New("Unsupported version")
+)
+
+// decoder reads the primitive types of the Kafka protocol from a request. The first
+// error is kept and every following read returns a zero value, so callers check
+// the error once after decoding a request.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = errors.Wrapf(InvalidRequest, format, args...)
+	}
+}
+
+func (d *decoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(d.buf) {
+		d.fail("need %d bytes, %d left", n, len(d.buf))
+		return nil
+	}
+
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+
+	return b
+}
+
+func (d *decoder) int8() int8 {
+	b := d.take(1)
+
+	if b == nil {
+		return 0
+	}
+
+	return int8(b[0])
+}
+
+func (d *decoder) bool() bool {
+	return d.int8() != 0
+}
+
+func (d *decoder) int16() int16 {
+	b := d.take(2)
+
+	if b == nil {
+		return 0
+	}
+
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *decoder) int32() int32 {
+	b := d.take(4)
+
+	if b == nil {
+		return 0
+	}
+
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *decoder) int64() int64 {
+	b := d.take(8)
+
+	if b == nil {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.buf)
+
+	if n <= 0 {
+		d.fail("invalid varint")
+		return 0
+	}
+
+	d.buf = d.buf[n:]
+
+	return v
+}
+
+func (d *decoder) string() string {
+	n := d.int16()
+
+	if n < 0 {
+		d.fail("null string")
+		return ""
+	}
+
+	return string(d.take(int(n)))
+}
+
+func (d *decoder) nullableString() *string {
+	n := d.int16()
+
+	if n < 0 {
+		return nil
+	}
+
+	s := string(d.take(int(n)))
+
+	return &s
+}
+
+// bytes returns nil for null bytes.
+func (d *decoder) bytes() []byte {
+	n := d.int32()
+
+	if n < 0 {
+		return nil
+	}
+
+	return d.take(int(n))
+}
+
+// arrayLen returns the length of an array, -1 for a null array.
+func (d *decoder) arrayLen() int {
+	n := int(d.int32())
+
+	// Every element takes at least one byte.
+	if n > len(d.buf) {
+		d.fail("array of %d elements with %d bytes left", n, len(d.buf))
+		return 0
+	}
+
+	return n
+}
+
+func (d *decoder) int32Array() []int32 {
+	n := d.arrayLen()
+
+	if n < 0 {
+		return nil
+	}
+
+	res := make([]int32, 0, n)
+
+	for i := 0; i < n && d.err == nil; i++ {
+		res = append(res, d.int32())
+	}
+
+	return res
+}
+
+// encoder appends the primitive types of the Kafka protocol to a response.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) int8(v int8) {
+	e.buf = append(e.buf, byte(v))
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.int8(1)
+	} else {
+		e.int8(0)
+	}
+}
+
+func (e *encoder) int16(v int16) {
+	e.buf = binary.BigEndian.AppendUint16(e.buf, uint16(v))
+}
+
+func (e *encoder) int32(v int32) {
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(v))
+}
+
+func (e *encoder) int64(v int64) {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, uint64(v))
+}
+
+func (e *encoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.int16(int16(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) nullableString(s *string) {
+	if s == nil {
+		e.int16(-1)
+		return
+	}
+
+	e.string(*s)
+}
+
+func (e *encoder) bytes(b []byte) {
+	if b == nil {
+		e.int32(-1)
+		return
+	}
+
+	e.int32(int32(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) arrayLen(n int) {
+	e.int32(int32(n))
+}
+
+func (e *encoder) int32Array(vs []int32) {
+	e.arrayLen(len(vs))
+
+	for _, v := range vs {
+		e.int32(v)
+	}
+}
@@ -0,0 +1,215 @@
+package kafka
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"iris/storage"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	recordBatchMagic = 2
+	// Size of the batch header up to the crc, which covers everything after it.
+	recordBatchPrefixSize = 8 + 4 + 4 + 1 + 4
+	recordBatchHeaderSize = recordBatchPrefixSize + 2 + 4 + 8 + 8 + 8 + 2 + 4 + 4
+
+	compressionMask = 0x07
+	compressionNone = 0
+	compressionGzip = 1
+
+	attributeTransactional = 0x10
+	attributeControl       = 0x20
+)
+
+var (
+	InvalidRecord          = errors.New("Invalid record")
+	UnsupportedCompression = errors.New("Unsupported compression")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// decodeRecordBatches reads the messages of the v2 record batches produced to a partition.
+// Only uncompressed and gzip compressed batches are supported, transactions are not.
+func decodeRecordBatches(data []byte) ([]*storage.Message, error) {
+	var msgs []*storage.Message
+
+	for len(data) > 0 {
+		if len(data) < recordBatchHeaderSize {
+			return nil, errors.Wrap(InvalidRecord, "truncated record batch")
+		}
+
+		length := int(int32(binary.BigEndian.Uint32(data[8:12])))
+
+		if length < recordBatchHeaderSize-12 || 12+length > len(data) {
+			return nil, errors.Wrapf(InvalidRecord, "invalid record batch length %d", length)
+		}
+
+		batch := data[:12+length]
+		data = data[12+length:]
+
+		if magic := batch[16]; magic != recordBatchMagic {
+			return nil, errors.Wrapf(InvalidRecord, "unsupported magic %d", magic)
+		}
+
+		if crc := binary.BigEndian.Uint32(batch[17:21]); crc != crc32.Checksum(batch[21:], castagnoli) {
+			return nil, errors.Wrap(InvalidRecord, "record batch crc mismatch")
+		}
+
+		d := decoder{buf: batch[21:]}
+		attributes := d.int16()
+		d.int32() // last offset delta
+		baseTimestamp := d.int64()
+		d.int64() // max timestamp
+		d.int64() // producer id
+		d.int16() // producer epoch
+		d.int32() // base sequence
+		count := int(d.int32())
+
+		if attributes&(attributeTransactional|attributeControl) != 0 {
+			return nil, errors.Wrap(InvalidRecord, "transactions are not supported")
+		}
+
+		records := d.buf
+
+		switch attributes & compressionMask {
+		case compressionNone:
+		case compressionGzip:
+			r, err := gzip.NewReader(bytes.NewReader(records))
+
+			if err != nil {
+				return nil, errors.Wrapf(InvalidRecord, "gzip: %s", err)
+			}
+
+			if records, err = io.ReadAll(r); err != nil {
+				return nil, errors.Wrapf(InvalidRecord, "gzip: %s", err)
+			}
+		default:
+			return nil, errors.Wrapf(UnsupportedCompression, "codec %d", attributes&compressionMask)
+		}
+
+		d = decoder{buf: records}
+
+		for i := 0; i < count && d.err == nil; i++ {
+			msgs = append(msgs, decodeRecord(&d, baseTimestamp))
+		}
+
+		if d.err != nil {
+			return nil, errors.Wrapf(InvalidRecord, "%s", d.err)
+		}
+	}
+
+	return msgs, nil
+}
+
+func decodeRecord(d *decoder, baseTimestamp int64) *storage.Message {
+	length := d.varint()
+	rd := decoder{buf: d.take(int(length))}
+
+	rd.int8() // attributes
+	timestamp := baseTimestamp + rd.varint()
+	rd.varint() // offset delta
+
+	msg := &storage.Message{
+		Key:   varintBytes(&rd),
+		Value: varintBytes(&rd),
+	}
+
+	if timestamp >= 0 {
+		msg.Timestamp = time.UnixMilli(timestamp)
+	}
+
+	headers := int(rd.varint())
+
+	for i := 0; i < headers && rd.err == nil; i++ {
+		key := varintBytes(&rd)
+		msg.Headers = append(msg.Headers, storage.Header{Key: string(key), Value: varintBytes(&rd)})
+	}
+
+	if rd.err != nil && d.err == nil {
+		d.err = rd.err
+	}
+
+	return msg
+}
+
+func varintBytes(d *decoder) []byte {
+	n := d.varint()
+
+	if n < 0 {
+		return nil
+	}
+
+	return d.take(int(n))
+}
+
+// encodeRecordBatch writes fetched messages as one uncompressed v2 record batch,
+// offsets may have gaps where messages expired.
+func encodeRecordBatch(msgs []*storage.Message, leaderEpoch int32) []byte {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	base := msgs[0].Offset
+	baseTimestamp := msgs[0].Timestamp.UnixMilli()
+	maxTimestamp := baseTimestamp
+
+	var records encoder
+
+	for _, msg := range msgs {
+		timestamp := msg.Timestamp.UnixMilli()
+		maxTimestamp = max(maxTimestamp, timestamp)
+
+		var r encoder
+		r.int8(0)
+		r.varint(timestamp - baseTimestamp)
+		r.varint(int64(msg.Offset - base))
+		appendVarintBytes(&r, msg.Key)
+		appendVarintBytes(&r, msg.Value)
+		r.varint(int64(len(msg.Headers)))
+
+		for _, h := range msg.Headers {
+			appendVarintBytes(&r, []byte(h.Key))
+			appendVarintBytes(&r, h.Value)
+		}
+
+		records.varint(int64(len(r.buf)))
+		records.buf = append(records.buf, r.buf...)
+	}
+
+	var body encoder
+	body.int16(compressionNone)
+	body.int32(int32(msgs[len(msgs)-1].Offset - base))
+	body.int64(baseTimestamp)
+	body.int64(maxTimestamp)
+	body.int64(-1) // producer id
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(msgs)))
+	body.buf = append(body.buf, records.buf...)
+
+	var e encoder
+	e.int64(int64(base))
+	e.int32(int32(4 + 1 + 4 + len(body.buf)))
+	e.int32(leaderEpoch)
+	e.int8(recordBatchMagic)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, crc32.Checksum(body.buf, castagnoli))
+	e.buf = append(e.buf, body.buf...)
+
+	return e.buf
+}
+
+func appendVarintBytes(e *encoder, b []byte) {
+	if b == nil {
+		e.varint(-1)
+		return
+	}
+
+	e.varint(int64(len(b)))
+	e.buf = append(e.buf, b...)
+}
@@ -0,0 +1,497 @@
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"iris/auth"
+	"iris/broker"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultMaxRequestSize = 100 * 1024 * 1024
+	// DefaultMaxWait bounds the time fetch requests wait for new messages.
+	DefaultMaxWait = 30 * time.Second
+)
+
+var ServerClosed = errors.New("Server closed")
+
+type Options struct {
+	// NodeID is the id of the broker in metadata responses, it is the only broker
+	// of the cluster and coordinator of every group.
+	NodeID    int32
+	ClusterID string
+	// AdvertisedAddress is the host:port clients connect to, it is the local
+	// address of their connection if it is empty.
+	AdvertisedAddress string
+
+	MaxRequestSize int
+	MaxWait        time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ClusterID:      "iris",
+		MaxRequestSize: DefaultMaxRequestSize,
+		MaxWait:        DefaultMaxWait,
+	}
+}
+
+// Server speaks the subset of the Kafka protocol needed by clients to produce, fetch and
+// commit offsets. Topics and partitions are the ones of the broker, consumer group
+// membership is not supported, so groups only commit and fetch offsets.
+type Server struct {
+	logger  log.Logger
+	broker  *broker.Broker
+	options Options
+	// Connections are not authenticated without an authenticator and not authorized without an authorizer.
+	auth    *auth.Authenticator
+	authz   *auth.Authorizer
+	metrics *ServerMetrics
+
+	mutex     sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+type ServerMetrics struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	requestErrors   *prometheus.CounterVec
+	connections     prometheus.Gauge
+}
+
+func NewServer(logger log.Logger, registerer prometheus.Registerer, b *broker.Broker, authenticator *auth.Authenticator, authorizer *auth.Authorizer, options Options) *Server {
+	return &Server{
+		logger:    logger,
+		broker:    b,
+		options:   options,
+		auth:      authenticator,
+		authz:     authorizer,
+		metrics:   NewServerMetrics(registerer),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func NewServerMetrics(registerer prometheus.Registerer) *ServerMetrics {
+	m := &ServerMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "requests_total",
+			Help: "Total number of Kafka protocol requests.",
+		}, []string{"api"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "request_duration_seconds",
+			Help:    "Duration of Kafka protocol requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"api"}),
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "request_errors_total",
+			Help: "Total number of errors returned in Kafka protocol responses.",
+		}, []string{"api", "code"}),
+		connections: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "connections",
+			Help: "Number of open Kafka protocol connections.",
+		}),
+	}
+
+	if registerer != nil {
+		prometheus.WrapRegistererWithPrefix("kafka_", registerer).MustRegister(
+			m.requests,
+			m.requestDuration,
+			m.requestErrors,
+			m.connections,
+		)
+	}
+
+	return m
+}
+
+// Serve accepts connections on the listener until the server is stopped, it always returns an error.
+func (s *Server) Serve(lis net.Listener) error {
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		lis.Close()
+
+		return ServerClosed
+	}
+
+	s.listeners[lis] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.listeners, lis)
+		s.mutex.Unlock()
+	}()
+
+	for {
+		conn, err := lis.Accept()
+
+		if err != nil {
+			select {
+			case <-s.done:
+				return ServerClosed
+			default:
+			}
+
+			var ne net.Error
+
+			if errors.As(err, &ne) && ne.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+
+			return err
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return ServerClosed
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	s.metrics.connections.Inc()
+
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mutex.Lock()
+	delete(s.conns, conn)
+	s.mutex.Unlock()
+
+	s.metrics.connections.Dec()
+	s.wg.Done()
+}
+
+// Stop closes the listeners and all connections and waits for their requests to finish.
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+
+	if s.closed {
+		s.mutex.Unlock()
+		return ServerClosed
+	}
+
+	s.closed = true
+	close(s.done)
+
+	for lis := range s.listeners {
+		lis.Close()
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.mutex.Unlock()
+	s.wg.Wait()
+
+	return nil
+}
+
+// principal authenticates the connection with its client certificate.
+func (s *Server) principal(conn net.Conn) (auth.Principal, error) {
+	anonymous := auth.Principal{Name: auth.AnonymousName, Method: auth.MethodAnonymous}
+
+	if s.auth == nil {
+		return anonymous, nil
+	}
+
+	var state *tls.ConnectionState
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			return anonymous, err
+		}
+
+		cs := tc.ConnectionState()
+		state = &cs
+	}
+
+	return s.auth.Authenticate("", state)
+}
+
+// conn is the state of a client connection, requests are handled one after another
+// so responses are sent in the order of the requests.
+type conn struct {
+	net.Conn
+
+	principal auth.Principal
+	ctx       context.Context
+}
+
+func (s *Server) serveConn(nc net.Conn) {
+	defer s.untrack(nc)
+	defer nc.Close()
+
+	p, err := s.principal(nc)
+
+	if err != nil {
+		level.Debug(s.logger).Log("msg", "authentication failed", "remote", nc.RemoteAddr(), "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	c := &conn{Conn: nc, principal: p, ctx: ctx}
+	r := bufio.NewReader(nc)
+
+	for {
+		req, err := s.readRequest(r)
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				level.Debug(s.logger).Log("msg", "closing connection", "remote", nc.RemoteAddr(), "err", err)
+			}
+
+			return
+		}
+
+		res, err := s.handle(c, req)
+
+		if err != nil {
+			level.Debug(s.logger).Log("msg", "closing connection", "remote", nc.RemoteAddr(), "api", req.key, "version", req.version, "err", err)
+			return
+		}
+
+		if res == nil {
+			continue
+		}
+
+		if _, err := nc.Write(res); err != nil {
+			return
+		}
+	}
+}
+
+type request struct {
+	key           apiKey
+	version       int16
+	correlationID int32
+	body          decoder
+}
+
+func (s *Server) readRequest(r io.Reader) (request, error) {
+	var size [4]byte
+
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return request{}, err
+	}
+
+	n := int(int32(binary.BigEndian.Uint32(size[:])))
+
+	if n < 8 || n > s.options.MaxRequestSize {
+		return request{}, errors.Wrapf(InvalidRequest, "request size %d", n)
+	}
+
+	buf := make([]byte, n)
+
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return request{}, err
+	}
+
+	d := decoder{buf: buf}
+	req := request{
+		key:           apiKey(d.int16()),
+		version:       d.int16(),
+		correlationID: d.int32(),
+	}
+
+	d.nullableString() // client id
+	req.body = d
+
+	return req, d.err
+}
+
+// handle returns the framed response to the request, or nil if it has none.
+// An error closes the connection.
+func (s *Server) handle(c *conn, req request) ([]byte, error) {
+	start := time.Now()
+
+	var e encoder
+	e.int32(0) // size
+	e.int32(req.correlationID)
+
+	if !req.key.supports(req.version) {
+		// Clients ask for the supported versions with the newest version they know,
+		// the answer is in the oldest format, which every client can read.
+		if req.key != apiVersions {
+			return nil, errors.Wrapf(UnsupportedVersion, "%s version %d", req.key, req.version)
+		}
+
+		res := apiVersionsResponse{errorCode: errUnsupportedVersion}
+		res.encode(&e, 0)
+
+		return frame(e.buf), nil
+	}
+
+	s.metrics.requests.WithLabelValues(req.key.String()).Inc()
+	defer func() {
+		s.metrics.requestDuration.WithLabelValues(req.key.String()).Observe(time.Since(start).Seconds())
+	}()
+
+	d := &req.body
+
+	switch req.key {
+	case apiVersions:
+		res := apiVersionsResponse{}
+		res.encode(&e, req.version)
+	case apiMetadata:
+		r := decodeMetadataRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.metadata(c, r)
+		res.encode(&e, req.version)
+	case apiProduce:
+		r := decodeProduceRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.produce(c, r)
+
+		if r.acks == 0 {
+			return nil, nil
+		}
+
+		res.encode(&e, req.version)
+	case apiFetch:
+		r := decodeFetchRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.fetch(c, r)
+		res.encode(&e, req.version)
+	case apiListOffsets:
+		r := decodeListOffsetsRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.listOffsets(c, r)
+		res.encode(&e, req.version)
+	case apiFindCoordinator:
+		r := decodeFindCoordinatorRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.findCoordinator(c, r)
+		res.encode(&e, req.version)
+	case apiOffsetCommit:
+		r := decodeOffsetCommitRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.offsetCommit(c, r)
+		res.encode(&e, req.version)
+	case apiOffsetFetch:
+		r := decodeOffsetFetchRequest(d, req.version)
+
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		res := s.offsetFetch(c, r)
+		res.encode(&e, req.version)
+	}
+
+	return frame(e.buf), nil
+}
+
+// frame sets the size of a response, which is written in front of it.
+func frame(buf []byte) []byte {
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-4))
+	return buf
+}
+
+// address returns the host and port clients of the connection reach the broker at.
+func (s *Server) address(c *conn) (string, int32) {
+	addr := s.options.AdvertisedAddress
+
+	if addr == "" {
+		addr = c.LocalAddr().String()
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		return addr, 0
+	}
+
+	p, _ := strconv.Atoi(port)
+
+	return host, int32(p)
+}
+
+// authorize returns the error code of a denied operation, or errNone.
+func (s *Server) authorize(c *conn, op auth.Operation, resourceType auth.ResourceType, name string) int16 {
+	if s.authz == nil {
+		return errNone
+	}
+
+	if err := s.authz.Authorize(c.principal, op, resourceType, name); err != nil {
+		switch resourceType {
+		case auth.ResourceGroup:
+			return errGroupAuthorizationFailed
+		case auth.ResourceCluster:
+			return errClusterAuthorizationFailed
+		default:
+			return errTopicAuthorizationFailed
+		}
+	}
+
+	return errNone
+}
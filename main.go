@@ -1,74 +1,45 @@
 package main
 
 import (
-	"iris/storage/wal"
+	"fmt"
 	"os"
-	"os/signal"
-	"sync"
-	"syscall"
-	"time"
 
-	"github.com/go-faker/faker/v4"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/prometheus/client_golang/prometheus"
 )
 
-func main() {
-	logger := log.NewLogfmtLogger(os.Stdout)
-	registerer := prometheus.NewRegistry()
+const usage = `Usage: iris <command> [flags]
 
-	os.MkdirAll("data", 0777)
-	//j, err := journal.NewJournal(logger, registerer, "data", wlog.DefaultSegmentSize)
+Commands:
+  serve    run the broker
+  wal      inspect the segment files of a stopped broker
+`
 
-	w, err := wal.NewWal(logger, registerer, "data", wal.DefaultSegmentSize, "ext")
+func main() {
+	logger := log.With(log.NewLogfmtLogger(log.NewSyncWriter(os.Stdout)), "ts", log.DefaultTimestampUTC)
 
-	if err != nil {
-		level.Error(logger).Log("err", err)
-		return
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
 
-	done := false
-	wg := sync.WaitGroup{}
-
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-
-		offset := 0
-
-		now := time.Now()
-
-        sentence := faker.Sentence()
-        bytesOfSentence := []byte(sentence)
-
-		for !done {
-
-			if err := w.Log(uint64(offset), bytesOfSentence); err != nil {
-				level.Error(logger).Log("err", err)
-			}
+	var err error
 
-			offset++
-		}
-
-		logger.Log("now", time.Now(), "since", time.Since(now), "offset", offset, "msg", "offsets has been written")
-	}()
-
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	logger.Log("msg", "app started...")
-	<-sigs
-
-	done = true
-	wg.Wait()
-
-	logger.Log("msg", "exiting...")
-}
+	switch os.Args[1] {
+	case "serve":
+		err = serve(logger, os.Args[2:])
+	case "wal":
+		err = walCommand(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
 
-func maybePanic(err error) {
-    if err != nil {
-        panic(err)
-    }
+	if err != nil {
+		level.Error(logger).Log("msg", "command failed", "command", os.Args[1], "err", err)
+		os.Exit(1)
+	}
 }
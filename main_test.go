@@ -0,0 +1,468 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"iris/api/irispb"
+	"iris/storage"
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var update = flag.Bool("update", false, "write the wal fixtures and golden files in testdata")
+
+func writeTestConfig(t *testing.T, dir string, data string) string {
+	path := filepath.Join(dir, "iris.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	return path
+}
+
+// testServe is iris serve running until a signal is sent.
+type testServe struct {
+	addr    string
+	signals chan os.Signal
+	done    chan error
+
+	mutex sync.Mutex
+	lines []map[string]string
+}
+
+// startServe runs iris serve with the config and waits for its gRPC listener.
+func startServe(t *testing.T, path string) *testServe {
+	s := &testServe{signals: make(chan os.Signal, 1), done: make(chan error, 1)}
+	addrs := make(chan string, 1)
+
+	logger := log.LoggerFunc(func(keyvals ...interface{}) error {
+		line := make(map[string]string)
+
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			line[fmt.Sprint(keyvals[i])] = fmt.Sprint(keyvals[i+1])
+		}
+
+		if line["msg"] == "listening" && line["server"] == "grpc" {
+			addrs <- line["addr"]
+		}
+
+		s.mutex.Lock()
+		s.lines = append(s.lines, line)
+		s.mutex.Unlock()
+
+		return nil
+	})
+
+	go func() {
+		s.done <- serveUntil(logger, []string{"-config", path}, s.signals)
+	}()
+
+	select {
+	case s.addr = <-addrs:
+	case err := <-s.done:
+		require.FailNow(t, "serve returned before listening", "%v", err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "serve didn't start listening")
+	}
+
+	return s
+}
+
+// logged returns the lines logged with the message so far.
+func (s *testServe) logged(msg string) []map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var lines []map[string]string
+
+	for _, line := range s.lines {
+		if line["msg"] == msg {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func (s *testServe) stop(t *testing.T) {
+	s.signals <- syscall.SIGTERM
+
+	select {
+	case err := <-s.done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "serve didn't stop")
+	}
+}
+
+func dialTest(t *testing.T, addr string) *grpc.ClientConn {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestServe(t *testing.T) {
+	dir, err := os.MkdirTemp("", "serve")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := writeTestConfig(t, dir, fmt.Sprintf(`
+dataDir: %s
+listeners:
+  grpc: 127.0.0.1:0
+  http: 127.0.0.1:0
+  metrics: ""
+storage:
+  segmentSize: 131072
+controller:
+  members: [0]
+shutdownTimeout: 5s
+`, filepath.Join(dir, "data")))
+
+	s := startServe(t, path)
+	client := irispb.NewIrisClient(dialTest(t, s.addr))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = client.CreateTopic(ctx, &irispb.CreateTopicRequest{Topic: "orders", Partitions: 1})
+	require.NoError(t, err)
+
+	_, err = client.Produce(ctx, &irispb.ProduceRequest{Topic: "orders", Messages: []*irispb.Message{{Value: []byte("v")}}})
+	require.NoError(t, err)
+
+	// The only controller elects itself.
+	assert.Eventually(t, func() bool { return len(s.logged("elected raft leader")) > 0 }, 10*time.Second, 10*time.Millisecond)
+
+	s.stop(t)
+	assert.DirExists(t, filepath.Join(dir, "data", controllerDirName))
+
+	// The messages are flushed on shutdown and served again after a restart.
+	s = startServe(t, path)
+	client = irispb.NewIrisClient(dialTest(t, s.addr))
+
+	fetched, err := client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders"})
+	require.NoError(t, err)
+	require.Len(t, fetched.GetMessages(), 1)
+	assert.Equal(t, []byte("v"), fetched.GetMessages()[0].GetValue())
+
+	s.stop(t)
+}
+
+func TestServeListenError(t *testing.T) {
+	dir, err := os.MkdirTemp("", "serve")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	path := writeTestConfig(t, dir, fmt.Sprintf(`
+dataDir: %s
+listeners:
+  grpc: 127.0.0.1:0
+  http: %s
+  metrics: ""
+`, filepath.Join(dir, "data"), lis.Addr()))
+
+	// The listeners opened before are closed and the broker is stopped, it opens again.
+	for i := 0; i < 2; i++ {
+		err = serveUntil(log.NewNopLogger(), []string{"-config", path}, make(chan os.Signal))
+		assert.ErrorContains(t, err, "unable to listen on "+lis.Addr().String()+" for http")
+	}
+}
+
+func TestServeReload(t *testing.T) {
+	dir, err := os.MkdirTemp("", "serve")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	base := fmt.Sprintf(`
+dataDir: %s
+listeners:
+  grpc: 127.0.0.1:0
+  http: ""
+  metrics: ""
+shutdownTimeout: 5s
+`, filepath.Join(dir, "data"))
+
+	path := writeTestConfig(t, dir, base)
+	s := startServe(t, path)
+	defer s.stop(t)
+
+	admin := irispb.NewIrisAdminClient(dialTest(t, s.addr))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	writeTestConfig(t, dir, base+`
+logLevel: debug
+storage:
+  segmentSize: 262144
+  retention:
+    time: 1h
+`)
+
+	reloaded, err := admin.ReloadConfig(ctx, &irispb.ReloadConfigRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"storage.retention.time", "logLevel"}, reloaded.GetApplied())
+	assert.Equal(t, []string{"storage.segmentSize"}, reloaded.GetRestartRequired())
+	assert.Len(t, s.logged("config change requires a restart"), 1)
+
+	// The broker runs with the retention of the file.
+	reconfigured := s.logged("broker reconfigured")
+	require.Len(t, reconfigured, 1)
+	assert.Equal(t, "1h0m0s", reconfigured[0]["retentionTime"])
+
+	// The pending restart is reported by every reload but logged once.
+	reloaded, err = admin.ReloadConfig(ctx, &irispb.ReloadConfigRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.GetApplied())
+	assert.Equal(t, []string{"storage.segmentSize"}, reloaded.GetRestartRequired())
+	assert.Len(t, s.logged("config change requires a restart"), 1)
+
+	// SIGHUP applies the retention bytes, the segment size changed back needs no restart.
+	writeTestConfig(t, dir, base+`
+logLevel: debug
+storage:
+  retention:
+    time: 1h
+    bytes: 2147483648
+`)
+
+	s.signals <- syscall.SIGHUP
+
+	require.Eventually(t, func() bool { return len(s.logged("broker reconfigured")) == 2 }, 10*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "2147483648", s.logged("broker reconfigured")[1]["retentionBytes"])
+	assert.Equal(t, "storage.retention.bytes", s.logged("config reloaded")[1]["applied"])
+	assert.Len(t, s.logged("config change requires a restart"), 1)
+
+	// SIGHUP reports a restart-only change just like the admin API.
+	writeTestConfig(t, dir, base+`
+logLevel: debug
+nodeId: 3
+storage:
+  retention:
+    time: 1h
+    bytes: 2147483648
+`)
+
+	s.signals <- syscall.SIGHUP
+
+	require.Eventually(t, func() bool { return len(s.logged("config reloaded without dynamic changes")) == 2 }, 10*time.Second, 10*time.Millisecond)
+
+	restarts := s.logged("config change requires a restart")
+	require.Len(t, restarts, 2)
+	assert.Equal(t, "nodeId", restarts[1]["field"])
+
+	reloaded, err = admin.ReloadConfig(ctx, &irispb.ReloadConfigRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.GetApplied())
+	assert.Equal(t, []string{"nodeId"}, reloaded.GetRestartRequired())
+	assert.Len(t, s.logged("config change requires a restart"), 2)
+
+	// A config which doesn't validate isn't applied at all.
+	writeTestConfig(t, dir, base+"logLevel: verbose\n")
+
+	_, err = admin.ReloadConfig(ctx, &irispb.ReloadConfigRequest{})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// dumpFixtureDir holds a journal segment with a few messages and the golden output of dump.
+var dumpFixtureDir = filepath.Join("testdata", "dump")
+
+// writeDumpFixture writes the journal segment the dump tests print.
+func writeDumpFixture(t *testing.T) {
+	require.NoError(t, os.RemoveAll(dumpFixtureDir))
+	require.NoError(t, os.MkdirAll(dumpFixtureDir, 0o777))
+
+	w, err := wal.NewWal(log.NewNopLogger(), prometheus.NewRegistry(), dumpFixtureDir, wal.PageSize, storage.JournalSegmentExt)
+	require.NoError(t, err)
+
+	timestamp := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, msg := range []*storage.Message{
+		{Offset: 0, Timestamp: timestamp, Key: []byte("order-1"), Value: []byte("paid"), Headers: []storage.Header{{Key: "region", Value: []byte("eu")}}},
+		{Offset: 1, Timestamp: timestamp.Add(time.Second), Value: []byte("shipped"), ExpiresAt: timestamp.Add(time.Hour)},
+		{Offset: 2, Timestamp: timestamp.Add(2 * time.Second), Key: []byte("order-2"), Value: []byte{0xff, 'o', 'k'}},
+	} {
+		require.NoError(t, w.Log(msg.Offset, storage.EncodeMessage(msg)))
+	}
+
+	require.NoError(t, w.Stop())
+}
+
+func TestWalDump(t *testing.T) {
+	if *update {
+		writeDumpFixture(t)
+	}
+
+	segment := wal.ToSegmentName(dumpFixtureDir, 0, storage.JournalSegmentExt)
+
+	for name, args := range map[string][]string{
+		"hex":           nil,
+		"utf8":          {"-payload", "utf8"},
+		"envelope":      {"-payload", "envelope"},
+		"none":          {"-payload", "none"},
+		"json-hex":      {"-json"},
+		"json-envelope": {"-json", "-payload", "envelope"},
+		"json-none":     {"-json", "-payload", "none"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, dump(&out, append(args, segment)))
+
+			golden := filepath.Join(dumpFixtureDir, name+".golden")
+
+			if *update {
+				require.NoError(t, os.WriteFile(golden, out.Bytes(), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), out.String())
+		})
+	}
+
+	var out bytes.Buffer
+	assert.ErrorContains(t, dump(&out, []string{"-payload", "base64", segment}), `unknown payload format "base64"`)
+}
+
+// corruptRecord flips a byte in the payload of the record at the position of a segment.
+func corruptRecord(t *testing.T, path string, position int64) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	b := make([]byte, 1)
+	_, err = f.ReadAt(b, position+16)
+	require.NoError(t, err)
+
+	_, err = f.WriteAt([]byte{^b[0]}, position+16)
+	require.NoError(t, err)
+}
+
+// recordPositions returns the positions of the records of a segment.
+func recordPositions(t *testing.T, path string) []int64 {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var positions []int64
+	r := wal.NewReader(f)
+
+	for r.Next() {
+		positions = append(positions, r.Position())
+	}
+
+	require.NoError(t, r.Err())
+
+	return positions
+}
+
+func TestWalVerify(t *testing.T) {
+	dir, err := os.MkdirTemp("", "verify")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := storage.NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, 32*1024*4)
+	require.NoError(t, err)
+
+	for i := 0; i < 40; i++ {
+		_, err := j.Append(&storage.Message{Value: bytes.Repeat([]byte("x"), 8*1024)})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, j.Stop())
+
+	refs, err := wal.SegmentsOf(dir, storage.JournalSegmentExt)
+	require.NoError(t, err)
+	require.Greater(t, len(refs), 2)
+
+	var out bytes.Buffer
+	require.NoError(t, verify(&out, []string{dir}))
+	assert.Equal(t, fmt.Sprintf("checked %d segments, 0 problems, 0 repaired\n", len(refs)), out.String())
+
+	// The last segment is corrupted in its second record.
+	last := refs[len(refs)-1]
+	lastAt := recordPositions(t, last.Name())[1]
+
+	corruptRecord(t, last.Name(), lastAt)
+
+	// The corruption is located at the start of the invalid record,
+	// the offset index points at a record which can't be read anymore.
+	out.Reset()
+	assert.ErrorContains(t, verify(&out, []string{dir}), "2 problems are not repaired")
+	assert.Contains(t, out.String(), fmt.Sprintf("corruption in segment %s at %d: ", last.Name(), lastAt))
+	assert.Contains(t, out.String(), fmt.Sprintf("record 1 of offset %d points at %d, where no message starts\n", last.Index()+1, lastAt))
+	assert.Contains(t, out.String(), fmt.Sprintf("checked %d segments, 2 problems, 0 repaired\n", len(refs)))
+
+	// The segment is cut off before the invalid record together with its offset index.
+	out.Reset()
+	require.NoError(t, verify(&out, []string{"-repair", dir}))
+	assert.Contains(t, out.String(), fmt.Sprintf("truncated %s at %d\n", last.Name(), lastAt))
+	assert.Contains(t, out.String(), fmt.Sprintf("truncated %s to 1 records\n", wal.ToSegmentName(dir, last.Index(), storage.OffsetIndexSegmentExt)))
+	assert.Contains(t, out.String(), fmt.Sprintf("checked %d segments, 2 problems, 2 repaired\n", len(refs)))
+
+	info, err := os.Stat(last.Name())
+	require.NoError(t, err)
+	assert.Equal(t, lastAt, info.Size())
+
+	// A sealed segment is corrupted in its third record.
+	sealed := refs[0]
+	sealedAt := recordPositions(t, sealed.Name())[2]
+
+	corruptRecord(t, sealed.Name(), sealedAt)
+
+	// It is cut off as well and the segments after it are moved aside with their sidecars,
+	// so no offsets are missing in the middle of the journal.
+	out.Reset()
+	require.NoError(t, verify(&out, []string{"-repair", dir}))
+	assert.Contains(t, out.String(), fmt.Sprintf("corruption in segment %s at %d: ", sealed.Name(), sealedAt))
+	assert.Contains(t, out.String(), fmt.Sprintf("truncated %s at %d\n", sealed.Name(), sealedAt))
+
+	for _, ref := range refs[1:] {
+		assert.Contains(t, out.String(), fmt.Sprintf("moved %s to %s\n", ref.Name(), filepath.Join(dir, wal.CorruptDirName)))
+		assert.NoFileExists(t, ref.Name())
+		assert.NoFileExists(t, wal.ToSegmentName(dir, ref.Index(), storage.OffsetIndexSegmentExt))
+		assert.FileExists(t, wal.ToSegmentName(filepath.Join(dir, wal.CorruptDirName), ref.Index(), storage.JournalSegmentExt))
+		assert.FileExists(t, wal.ToSegmentName(filepath.Join(dir, wal.CorruptDirName), ref.Index(), storage.OffsetIndexSegmentExt))
+	}
+
+	assert.Contains(t, out.String(), fmt.Sprintf("truncated %s to 2 records\n", wal.ToSegmentName(dir, sealed.Index(), storage.OffsetIndexSegmentExt)))
+	assert.Contains(t, out.String(), "checked 1 segments, 2 problems, 2 repaired\n")
+
+	info, err = os.Stat(sealed.Name())
+	require.NoError(t, err)
+	assert.Equal(t, sealedAt, info.Size())
+
+	out.Reset()
+	require.NoError(t, verify(&out, []string{dir}))
+	assert.Equal(t, "checked 1 segments, 0 problems, 0 repaired\n", out.String())
+
+	// The journal continues after the last message before the corruption.
+	j, err = storage.NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, 32*1024*4)
+	require.NoError(t, err)
+	defer j.Stop()
+
+	assert.Equal(t, uint64(2), j.NextOffset())
+}
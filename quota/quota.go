@@ -0,0 +1,220 @@
+package quota
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Kind string
+
+const (
+	KindProduce Kind = "produce"
+	KindFetch   Kind = "fetch"
+)
+
+const (
+	// DefaultBurst is how long clients may use their rate at once after being idle.
+	DefaultBurst = time.Second
+	// DefaultMaxThrottle caps the throttle time of a single response.
+	DefaultMaxThrottle = 30 * time.Second
+
+	// Buckets of clients idle for this long are full again and are dropped.
+	idleTimeout = time.Minute
+)
+
+// Limits are rates per second, a zero rate is unlimited.
+type Limits struct {
+	ProduceBytesPerSecond float64 `json:"produceBytesPerSecond,omitempty" yaml:"produceBytesPerSecond"`
+	FetchBytesPerSecond   float64 `json:"fetchBytesPerSecond,omitempty" yaml:"fetchBytesPerSecond"`
+	RequestsPerSecond     float64 `json:"requestsPerSecond,omitempty" yaml:"requestsPerSecond"`
+}
+
+type Options struct {
+	// Default limits apply to every client without limits of its own.
+	Default Limits
+	// Clients maps principals or client IDs to their limits.
+	Clients     map[string]Limits
+	Burst       time.Duration
+	MaxThrottle time.Duration
+}
+
+// Manager tracks the usage of clients in token buckets. Clients over their quota
+// are not failed, they are told for how long their responses are throttled.
+type Manager struct {
+	logger  log.Logger
+	metrics *ManagerMetrics
+	options Options
+
+	mutex   sync.Mutex
+	clients map[string]*clientBuckets
+	swept   time.Time
+}
+
+type ManagerMetrics struct {
+	throttled    *prometheus.CounterVec
+	throttleTime *prometheus.CounterVec
+}
+
+type clientBuckets struct {
+	requests bucket
+	produce  bucket
+	fetch    bucket
+	last     time.Time
+}
+
+// bucket is a token bucket which may go into debt, so a request larger than
+// the burst is served and the client throttled until the debt is paid back.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *bucket) take(rate float64, burst time.Duration, n float64, now time.Time) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+
+	capacity := rate * burst.Seconds()
+
+	if b.last.IsZero() {
+		b.tokens = capacity
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(capacity, b.tokens+rate*elapsed)
+	}
+
+	b.last = now
+	b.tokens -= n
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+func NewManager(logger log.Logger, registerer prometheus.Registerer, options Options) *Manager {
+	return &Manager{
+		logger:  logger,
+		metrics: NewManagerMetrics(prometheus.WrapRegistererWithPrefix("quota_", registerer)),
+		options: withDefaults(options),
+		clients: make(map[string]*clientBuckets),
+	}
+}
+
+func withDefaults(options Options) Options {
+	if options.Burst <= 0 {
+		options.Burst = DefaultBurst
+	}
+
+	if options.MaxThrottle <= 0 {
+		options.MaxThrottle = DefaultMaxThrottle
+	}
+
+	return options
+}
+
+func NewManagerMetrics(registerer prometheus.Registerer) *ManagerMetrics {
+	m := &ManagerMetrics{}
+
+	m.throttled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "throttled_requests_total",
+		Help: "Total number of requests throttled for exceeding a quota.",
+	}, []string{"kind"})
+	m.throttleTime = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "throttle_seconds_total",
+		Help: "Total time responses have been throttled for exceeding a quota.",
+	}, []string{"kind"})
+
+	if registerer != nil {
+		registerer.MustRegister(m.throttled, m.throttleTime)
+	}
+
+	return m
+}
+
+// SetOptions replaces the limits of all clients, the buckets of clients keep their tokens.
+func (m *Manager) SetOptions(options Options) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.options = withDefaults(options)
+}
+
+// Limits returns the limits of the client.
+func (m *Manager) Limits(client string) Limits {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.limits(client)
+}
+
+// limits returns the limits of the client, must be called with the mutex held.
+func (m *Manager) limits(client string) Limits {
+	if limits, ok := m.options.Clients[client]; ok {
+		return limits
+	}
+
+	return m.options.Default
+}
+
+// Record accounts a request of the client which moved the given number of bytes,
+// it returns how long the response should be throttled.
+func (m *Manager) Record(client string, kind Kind, bytes int, now time.Time) time.Duration {
+	m.mutex.Lock()
+
+	m.sweep(now)
+
+	limits := m.limits(client)
+	maxThrottle := m.options.MaxThrottle
+
+	c, ok := m.clients[client]
+
+	if !ok {
+		c = &clientBuckets{}
+		m.clients[client] = c
+	}
+
+	c.last = now
+
+	throttle := c.requests.take(limits.RequestsPerSecond, m.options.Burst, 1, now)
+
+	switch kind {
+	case KindProduce:
+		throttle = max(throttle, c.produce.take(limits.ProduceBytesPerSecond, m.options.Burst, float64(bytes), now))
+	case KindFetch:
+		throttle = max(throttle, c.fetch.take(limits.FetchBytesPerSecond, m.options.Burst, float64(bytes), now))
+	}
+
+	m.mutex.Unlock()
+
+	if throttle <= 0 {
+		return 0
+	}
+
+	throttle = min(throttle, maxThrottle)
+
+	m.metrics.throttled.WithLabelValues(string(kind)).Inc()
+	m.metrics.throttleTime.WithLabelValues(string(kind)).Add(throttle.Seconds())
+	level.Debug(m.logger).Log("msg", "client throttled", "client", client, "kind", kind, "throttle", throttle)
+
+	return throttle
+}
+
+// sweep drops the buckets of idle clients, must be called with the mutex held.
+func (m *Manager) sweep(now time.Time) {
+	if now.Sub(m.swept) < idleTimeout {
+		return
+	}
+
+	m.swept = now
+
+	for client, c := range m.clients {
+		if now.Sub(c.last) >= idleTimeout {
+			delete(m.clients, client)
+		}
+	}
+}
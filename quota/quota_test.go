@@ -0,0 +1,69 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	m := NewManager(log.NewNopLogger(), registry, Options{
+		Default: Limits{ProduceBytesPerSecond: 1000},
+		Clients: map[string]Limits{
+			"billing": {RequestsPerSecond: 2},
+			"batch":   {FetchBytesPerSecond: 100},
+		},
+		MaxThrottle: 5 * time.Second,
+	})
+
+	now := time.Now()
+
+	// The burst allows a second of usage at once.
+	assert.Zero(t, m.Record("orders", KindProduce, 1000, now))
+	assert.Equal(t, 500*time.Millisecond, m.Record("orders", KindProduce, 500, now))
+
+	// Clients are tracked separately.
+	assert.Zero(t, m.Record("shipping", KindProduce, 1000, now))
+
+	// The debt is paid back over time.
+	assert.Equal(t, 100*time.Millisecond, m.Record("orders", KindProduce, 100, now.Add(500*time.Millisecond)))
+	assert.Zero(t, m.Record("orders", KindProduce, 100, now.Add(800*time.Millisecond)))
+
+	// Fetches have no limit by default.
+	assert.Zero(t, m.Record("orders", KindFetch, 1<<20, now))
+
+	assert.Zero(t, m.Record("billing", KindProduce, 1<<20, now))
+	assert.Zero(t, m.Record("billing", KindFetch, 0, now))
+	assert.Equal(t, 500*time.Millisecond, m.Record("billing", KindFetch, 0, now))
+
+	// Throttling is capped.
+	assert.Equal(t, 5*time.Second, m.Record("batch", KindFetch, 10000, now))
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.metrics.throttled.WithLabelValues(string(KindProduce))))
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.metrics.throttled.WithLabelValues(string(KindFetch))))
+	assert.InDelta(t, 5.5, testutil.ToFloat64(m.metrics.throttleTime.WithLabelValues(string(KindFetch))), 1e-9)
+
+	// Idle clients are forgotten.
+	m.Record("orders", KindProduce, 0, now.Add(2*idleTimeout))
+	assert.Len(t, m.clients, 1)
+}
+
+func TestManagerSetOptions(t *testing.T) {
+	m := NewManager(log.NewNopLogger(), prometheus.NewRegistry(), Options{Default: Limits{ProduceBytesPerSecond: 1000}})
+
+	now := time.Now()
+
+	assert.Zero(t, m.Record("orders", KindProduce, 1000, now))
+	assert.Equal(t, time.Second, m.Record("orders", KindProduce, 1000, now))
+
+	// New limits apply right away, the debt of the client is kept.
+	m.SetOptions(Options{Default: Limits{ProduceBytesPerSecond: 2000}, MaxThrottle: 2 * time.Second})
+	assert.Equal(t, Limits{ProduceBytesPerSecond: 2000}, m.Limits("orders"))
+	assert.Equal(t, 2*time.Second, m.Record("orders", KindProduce, 3000, now))
+}
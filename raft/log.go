@@ -0,0 +1,545 @@
+package raft
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	LogSegmentExt = "raft"
+	SnapshotExt   = "snapshot"
+
+	stateFileName   = "state"
+	walDirName      = "wal"
+	snapshotDirName = "snapshots"
+	tmpExt          = "tmp"
+)
+
+const (
+	recordEntry    byte = 1
+	recordTruncate byte = 2
+)
+
+var (
+	CorruptLog     = errors.New("Corrupt raft log")
+	InvalidEntries = errors.New("Entries do not continue the log")
+)
+
+// Entry is a record of the replicated log.
+type Entry struct {
+	Index uint64
+	Term  uint64
+	// Entries without data are written by new leaders to commit the entries of earlier terms.
+	Data []byte
+}
+
+// Snapshot holds the state machine with all entries up to its index applied.
+type Snapshot struct {
+	Index uint64
+	Term  uint64
+	Data  []byte
+}
+
+type hardState struct {
+	Term     uint64 `json:"term"`
+	VotedFor int32  `json:"votedFor"`
+}
+
+// segment is a wal segment of the log, last is the highest index of an entry written to it.
+type segment struct {
+	index uint64
+	last  uint64
+}
+
+// Log is the persistent state of a raft node. Entries are appended to wal segments, entries
+// replaced by a new leader are removed by a truncate record, so the log is only ever appended.
+// The entries after the last snapshot are kept in memory, the segments before it are removed.
+type Log struct {
+	logger log.Logger
+	dir    string
+	// The segments and snapshots are kept in subdirectories, apart from the other files.
+	walDir      string
+	snapshotDir string
+	wal         *wal.Wal
+	// records is the number of records written to the wal, segments are named after it.
+	records  uint64
+	segments []segment
+
+	snapIndex uint64
+	snapTerm  uint64
+	entries   []Entry
+
+	state hardState
+}
+
+// OpenLog opens the log stored in the directory, it is created if it does not exist.
+func OpenLog(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int) (*Log, error) {
+	l := &Log{
+		logger:      logger,
+		dir:         dir,
+		walDir:      filepath.Join(dir, walDirName),
+		snapshotDir: filepath.Join(dir, snapshotDirName),
+		state:       hardState{VotedFor: None},
+	}
+
+	for _, d := range []string{l.walDir, l.snapshotDir} {
+		if err := os.MkdirAll(d, 0o777); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.loadState(); err != nil {
+		return nil, err
+	}
+
+	if err := l.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	if err := l.replay(); err != nil {
+		return nil, err
+	}
+
+	w, err := wal.NewWal(logger, registerer, l.walDir, segmentSize, LogSegmentExt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l.wal = w
+
+	if len(l.segments) == 0 {
+		l.segments = append(l.segments, segment{index: w.ActiveSegmentRef().Index()})
+	}
+
+	return l, nil
+}
+
+func (l *Log) loadState() error {
+	bytes, err := os.ReadFile(filepath.Join(l.dir, stateFileName))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(json.Unmarshal(bytes, &l.state), "invalid raft state")
+}
+
+func (l *Log) loadSnapshot() error {
+	ref, err := wal.LastSegmentOf(l.snapshotDir, SnapshotExt)
+
+	if err != nil || ref == nil {
+		return err
+	}
+
+	s, err := readSnapshot(ref.Name())
+
+	if err != nil {
+		return err
+	}
+
+	l.snapIndex = s.Index
+	l.snapTerm = s.Term
+
+	return nil
+}
+
+// replay reads the entries after the snapshot from the wal segments. A torn record at the
+// end of the last segment is cut off, so that new records are not appended behind it.
+func (l *Log) replay() error {
+	refs, err := wal.SegmentsOf(l.walDir, LogSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	for i, ref := range refs {
+		f, err := os.OpenFile(ref.Name(), os.O_RDWR, 0o666)
+
+		if err != nil {
+			return err
+		}
+
+		seg := segment{index: ref.Index()}
+		r := wal.NewReader(f)
+		n := uint64(0)
+
+		for r.Next() {
+			n++
+
+			if err := l.replayRecord(r.Record(), &seg); err != nil {
+				f.Close()
+				return errors.Wrapf(err, "segment %s", ref.Name())
+			}
+		}
+
+		if err := r.Err(); err != nil {
+			if i < len(refs)-1 {
+				f.Close()
+				return errors.Wrapf(CorruptLog, "segment %s: %s", ref.Name(), err)
+			}
+
+			level.Warn(l.logger).Log("msg", "truncating torn tail of raft log", "segment", ref.Name(), "position", r.Position(), "err", err)
+
+			if err := f.Truncate(r.Position()); err != nil {
+				f.Close()
+				return err
+			}
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		l.segments = append(l.segments, seg)
+		l.records = ref.Index() + n
+	}
+
+	return nil
+}
+
+func (l *Log) replayRecord(rec []byte, seg *segment) error {
+	if len(rec) < 9 {
+		return errors.Wrapf(CorruptLog, "record of %d bytes", len(rec))
+	}
+
+	index := binary.BigEndian.Uint64(rec[1:])
+
+	switch rec[0] {
+	case recordTruncate:
+		l.truncate(index)
+	case recordEntry:
+		if len(rec) < 17 {
+			return errors.Wrapf(CorruptLog, "entry of %d bytes", len(rec))
+		}
+
+		seg.last = max(seg.last, index)
+
+		// Entries before the snapshot are already applied.
+		if index <= l.snapIndex {
+			return nil
+		}
+
+		l.truncate(index)
+
+		if index != l.LastIndex()+1 {
+			return errors.Wrapf(CorruptLog, "entry %d after entry %d", index, l.LastIndex())
+		}
+
+		l.entries = append(l.entries, Entry{
+			Index: index,
+			Term:  binary.BigEndian.Uint64(rec[9:]),
+			Data:  append([]byte(nil), rec[17:]...),
+		})
+	default:
+		return errors.Wrapf(CorruptLog, "unknown record type %d", rec[0])
+	}
+
+	return nil
+}
+
+// truncate removes the entries from the index on from memory.
+func (l *Log) truncate(index uint64) {
+	if index <= l.snapIndex {
+		l.entries = l.entries[:0]
+		return
+	}
+
+	if index <= l.LastIndex() {
+		l.entries = l.entries[:index-l.snapIndex-1]
+	}
+}
+
+func encodeEntry(e Entry) []byte {
+	rec := make([]byte, 17+len(e.Data))
+	rec[0] = recordEntry
+	binary.BigEndian.PutUint64(rec[1:], e.Index)
+	binary.BigEndian.PutUint64(rec[9:], e.Term)
+	copy(rec[17:], e.Data)
+
+	return rec
+}
+
+func encodeTruncate(index uint64) []byte {
+	rec := make([]byte, 9)
+	rec[0] = recordTruncate
+	binary.BigEndian.PutUint64(rec[1:], index)
+
+	return rec
+}
+
+// HardState returns the current term and the node voted for in it, None if it has not voted.
+func (l *Log) HardState() (uint64, int32) {
+	return l.state.Term, l.state.VotedFor
+}
+
+// SetHardState persists the current term and vote.
+func (l *Log) SetHardState(term uint64, votedFor int32) error {
+	state := hardState{Term: term, VotedFor: votedFor}
+	bytes, err := json.Marshal(state)
+
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(l.dir, stateFileName)
+
+	if err := writeFile(name, name+"."+tmpExt, bytes); err != nil {
+		return err
+	}
+
+	l.state = state
+
+	return nil
+}
+
+// FirstIndex returns the index of the first entry kept in the log.
+func (l *Log) FirstIndex() uint64 {
+	return l.snapIndex + 1
+}
+
+func (l *Log) LastIndex() uint64 {
+	return l.snapIndex + uint64(len(l.entries))
+}
+
+func (l *Log) LastTerm() uint64 {
+	if len(l.entries) == 0 {
+		return l.snapTerm
+	}
+
+	return l.entries[len(l.entries)-1].Term
+}
+
+// SnapshotIndex returns the index of the last entry in the snapshot, 0 without a snapshot.
+func (l *Log) SnapshotIndex() uint64 {
+	return l.snapIndex
+}
+
+// Term returns the term of the entry at the index, false is returned
+// if the entry is not in the log or has been replaced by the snapshot.
+func (l *Log) Term(index uint64) (uint64, bool) {
+	switch {
+	case index == l.snapIndex:
+		return l.snapTerm, true
+	case index < l.snapIndex || index > l.LastIndex():
+		return 0, false
+	default:
+		return l.entries[index-l.snapIndex-1].Term, true
+	}
+}
+
+// Entries returns up to max entries starting at the index, which must be after the snapshot.
+func (l *Log) Entries(index uint64, max int) []Entry {
+	if index <= l.snapIndex || index > l.LastIndex() {
+		return nil
+	}
+
+	entries := l.entries[index-l.snapIndex-1:]
+
+	if len(entries) > max {
+		entries = entries[:max]
+	}
+
+	return append([]Entry(nil), entries...)
+}
+
+// Append writes the entries and syncs them to disk. The entries must be consecutive
+// and may start before the end of the log, the entries they replace are removed.
+func (l *Log) Append(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	first := entries[0].Index
+
+	if first <= l.snapIndex || first > l.LastIndex()+1 {
+		return errors.Wrapf(InvalidEntries, "entry %d for a log from %d to %d", first, l.FirstIndex(), l.LastIndex())
+	}
+
+	for i, e := range entries {
+		if e.Index != first+uint64(i) {
+			return errors.Wrapf(InvalidEntries, "entry %d after entry %d", e.Index, first+uint64(i)-1)
+		}
+	}
+
+	recs := make([][]byte, 0, len(entries)+1)
+
+	if first <= l.LastIndex() {
+		recs = append(recs, encodeTruncate(first))
+	}
+
+	for _, e := range entries {
+		recs = append(recs, encodeEntry(e))
+	}
+
+	if err := l.write(recs, entries[len(entries)-1].Index); err != nil {
+		return err
+	}
+
+	l.truncate(first)
+	l.entries = append(l.entries, entries...)
+
+	return nil
+}
+
+// write appends records to the wal and syncs them, last is the highest entry index written.
+func (l *Log) write(recs [][]byte, last uint64) error {
+	refs, err := l.wal.Append(l.records, recs...)
+
+	if err != nil {
+		return err
+	}
+
+	l.records += uint64(len(recs))
+
+	for _, ref := range refs {
+		if ref.Segment != l.segments[len(l.segments)-1].index {
+			l.segments = append(l.segments, segment{index: ref.Segment})
+		}
+	}
+
+	seg := &l.segments[len(l.segments)-1]
+	seg.last = max(seg.last, last)
+
+	return l.wal.Sync()
+}
+
+// Snapshot reads the last snapshot, false is returned if there is none.
+func (l *Log) Snapshot() (Snapshot, bool, error) {
+	if l.snapIndex == 0 {
+		return Snapshot{}, false, nil
+	}
+
+	s, err := readSnapshot(wal.ToSegmentName(l.snapshotDir, l.snapIndex, SnapshotExt))
+
+	return s, err == nil, err
+}
+
+// SaveSnapshot stores a snapshot and removes the entries it replaces. Entries after the
+// snapshot are kept if the log has the last entry of the snapshot, otherwise they are removed.
+func (l *Log) SaveSnapshot(s Snapshot) error {
+	if s.Index <= l.snapIndex {
+		return nil
+	}
+
+	bytes := make([]byte, 16+len(s.Data))
+	binary.BigEndian.PutUint64(bytes, s.Index)
+	binary.BigEndian.PutUint64(bytes[8:], s.Term)
+	copy(bytes[16:], s.Data)
+
+	name := wal.ToSegmentName(l.snapshotDir, s.Index, SnapshotExt)
+
+	if err := writeFile(name, wal.ToSegmentName(l.snapshotDir, s.Index, tmpExt), bytes); err != nil {
+		return err
+	}
+
+	if term, ok := l.Term(s.Index); ok && term == s.Term {
+		l.entries = append([]Entry(nil), l.entries[s.Index-l.snapIndex:]...)
+	} else {
+		// The replay must not pick up the entries which don't belong to the snapshot.
+		if len(l.entries) > 0 {
+			if err := l.write([][]byte{encodeTruncate(s.Index + 1)}, 0); err != nil {
+				return err
+			}
+		}
+
+		l.entries = nil
+	}
+
+	l.snapIndex = s.Index
+	l.snapTerm = s.Term
+
+	return l.compact()
+}
+
+// compact removes the older snapshots and the sealed segments which only hold entries of the snapshot.
+func (l *Log) compact() error {
+	refs, err := wal.SegmentsOf(l.snapshotDir, SnapshotExt)
+
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.Index() < l.snapIndex {
+			if err := os.Remove(ref.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for len(l.segments) > 1 && l.segments[0].last <= l.snapIndex {
+		name := wal.ToSegmentName(l.walDir, l.segments[0].index, LogSegmentExt)
+
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		level.Debug(l.logger).Log("msg", "removed raft log segment", "segment", name, "snapshot", l.snapIndex)
+
+		l.segments = l.segments[1:]
+	}
+
+	return nil
+}
+
+// Close syncs and closes the log.
+func (l *Log) Close() error {
+	return l.wal.Stop()
+}
+
+func readSnapshot(name string) (Snapshot, error) {
+	bytes, err := os.ReadFile(name)
+
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	if len(bytes) < 16 {
+		return Snapshot{}, errors.Wrapf(CorruptLog, "snapshot %s of %d bytes", name, len(bytes))
+	}
+
+	return Snapshot{
+		Index: binary.BigEndian.Uint64(bytes),
+		Term:  binary.BigEndian.Uint64(bytes[8:]),
+		Data:  bytes[16:],
+	}, nil
+}
+
+// writeFile replaces a file atomically with the given content, which is written to tmp first.
+func writeFile(name string, tmp string, bytes []byte) error {
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(bytes); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,960 @@
+package raft
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// None is the id of no node, e.g. as the leader while there is an election.
+const None int32 = -1
+
+var (
+	NotLeader   = errors.New("Node is not the leader")
+	NodeStopped = errors.New("Node stopped")
+)
+
+type State int
+
+const (
+	Follower State = iota
+	Candidate
+	Leader
+)
+
+func (s State) String() string {
+	switch s {
+	case Candidate:
+		return "candidate"
+	case Leader:
+		return "leader"
+	default:
+		return "follower"
+	}
+}
+
+// StateMachine is the state replicated by the log. All methods are called one at a time.
+type StateMachine interface {
+	// Apply is called with the data of each committed entry in log order, the
+	// returned error is passed to the proposer. Apply must be deterministic.
+	Apply(index uint64, data []byte) error
+	// Snapshot returns the state with all applied entries.
+	Snapshot() ([]byte, error)
+	// Restore replaces the state with a snapshot of all entries up to the index.
+	Restore(index uint64, data []byte) error
+}
+
+// Transport sends the messages of a node to its peers.
+type Transport interface {
+	RequestVote(ctx context.Context, to int32, req VoteRequest) (VoteResponse, error)
+	AppendEntries(ctx context.Context, to int32, req AppendRequest) (AppendResponse, error)
+	InstallSnapshot(ctx context.Context, to int32, req SnapshotRequest) (SnapshotResponse, error)
+}
+
+type VoteRequest struct {
+	Term      uint64
+	Candidate int32
+	LastIndex uint64
+	LastTerm  uint64
+}
+
+type VoteResponse struct {
+	Term    uint64
+	Granted bool
+}
+
+type AppendRequest struct {
+	Term      uint64
+	Leader    int32
+	PrevIndex uint64
+	PrevTerm  uint64
+	Entries   []Entry
+	Commit    uint64
+}
+
+// AppendResponse reports the last index of the follower, which the leader continues
+// from when the request did not match the log of the follower.
+type AppendResponse struct {
+	Term      uint64
+	Success   bool
+	LastIndex uint64
+}
+
+type SnapshotRequest struct {
+	Term     uint64
+	Leader   int32
+	Snapshot Snapshot
+}
+
+type SnapshotResponse struct {
+	Term uint64
+}
+
+type Config struct {
+	ID int32
+	// Members holds the ids of all nodes of the cluster, including this one.
+	Members []int32
+	// ElectionTimeout is the minimum time without a leader after which a follower
+	// starts an election, the actual timeout is randomized up to twice as long.
+	ElectionTimeout   time.Duration
+	HeartbeatInterval time.Duration
+	// SnapshotEntries is the number of applied entries after which a snapshot is taken.
+	SnapshotEntries uint64
+	// MaxAppendEntries limits the number of entries sent to a follower at once.
+	MaxAppendEntries int
+}
+
+func DefaultConfig(id int32, members []int32) Config {
+	return Config{
+		ID:                id,
+		Members:           members,
+		ElectionTimeout:   time.Second,
+		HeartbeatInterval: 100 * time.Millisecond,
+		SnapshotEntries:   4096,
+		MaxAppendEntries:  256,
+	}
+}
+
+type NodeMetrics struct {
+	term          prometheus.Gauge
+	leader        prometheus.Gauge
+	commitIndex   prometheus.Gauge
+	leaderChanges prometheus.Counter
+	elections     prometheus.Counter
+	snapshots     prometheus.Counter
+}
+
+func NewNodeMetrics(registerer prometheus.Registerer) *NodeMetrics {
+	m := &NodeMetrics{}
+
+	m.term = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "term",
+		Help: "Current term of the raft node.",
+	})
+
+	m.leader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "leader",
+		Help: "Whether the raft node is the leader.",
+	})
+
+	m.commitIndex = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "commit_index",
+		Help: "Index of the last committed entry of the raft log.",
+	})
+
+	m.leaderChanges = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "leader_changes_total",
+		Help: "Total number of leader changes seen by the raft node.",
+	})
+
+	m.elections = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "elections_total",
+		Help: "Total number of elections started by the raft node.",
+	})
+
+	m.snapshots = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "snapshots_total",
+		Help: "Total number of snapshots taken or installed by the raft node.",
+	})
+
+	registerer.MustRegister(m.term, m.leader, m.commitIndex, m.leaderChanges, m.elections, m.snapshots)
+
+	return m
+}
+
+type waiter struct {
+	term uint64
+	done chan error
+}
+
+// Node is a member of a raft cluster. Entries proposed to the leader are replicated
+// to the logs of the members and applied to the state machine once a majority has them.
+type Node struct {
+	logger    log.Logger
+	config    Config
+	log       *Log
+	sm        StateMachine
+	transport Transport
+	metrics   *NodeMetrics
+
+	mutex    sync.Mutex
+	state    State
+	leader   int32
+	commit   uint64
+	applied  uint64
+	deadline time.Time
+	// Only used while leading.
+	next        map[int32]uint64
+	match       map[int32]uint64
+	replicating map[int32]bool
+	waiters     map[uint64]waiter
+
+	// applyMutex is held while the state machine is changed.
+	applyMutex sync.Mutex
+	applyc     chan struct{}
+	done       chan struct{}
+	wg         sync.WaitGroup
+	stopped    bool
+}
+
+// NewNode restores the state machine from the last snapshot of the log and starts the node.
+// The log is closed when the node is stopped.
+func NewNode(logger log.Logger, registerer prometheus.Registerer, config Config, l *Log, sm StateMachine, transport Transport) (*Node, error) {
+	if config.ElectionTimeout <= config.HeartbeatInterval {
+		return nil, errors.Errorf("election timeout %s must exceed the heartbeat interval %s", config.ElectionTimeout, config.HeartbeatInterval)
+	}
+
+	n := &Node{
+		logger:      logger,
+		config:      config,
+		log:         l,
+		sm:          sm,
+		transport:   transport,
+		metrics:     NewNodeMetrics(prometheus.WrapRegistererWithPrefix("raft_", registerer)),
+		leader:      None,
+		next:        make(map[int32]uint64),
+		match:       make(map[int32]uint64),
+		replicating: make(map[int32]bool),
+		waiters:     make(map[uint64]waiter),
+		applyc:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	s, ok, err := l.Snapshot()
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read snapshot")
+	}
+
+	if ok {
+		if err := sm.Restore(s.Index, s.Data); err != nil {
+			return nil, errors.Wrap(err, "unable to restore snapshot")
+		}
+
+		n.commit = s.Index
+		n.applied = s.Index
+	}
+
+	term, _ := l.HardState()
+	n.metrics.term.Set(float64(term))
+	n.resetDeadline()
+
+	n.wg.Add(2)
+	go n.run()
+	go n.applyCommitted()
+
+	return n, nil
+}
+
+func (n *Node) ID() int32 {
+	return n.config.ID
+}
+
+// Leader returns the id of the current leader, None if it is not known.
+func (n *Node) Leader() int32 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.leader
+}
+
+func (n *Node) State() State {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.state
+}
+
+func (n *Node) Term() uint64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	term, _ := n.log.HardState()
+
+	return term
+}
+
+// Applied returns the index of the last entry applied to the state machine.
+func (n *Node) Applied() uint64 {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.applied
+}
+
+func (n *Node) peers() []int32 {
+	peers := make([]int32, 0, len(n.config.Members))
+
+	for _, id := range n.config.Members {
+		if id != n.config.ID {
+			peers = append(peers, id)
+		}
+	}
+
+	return peers
+}
+
+func (n *Node) quorum() int {
+	return len(n.config.Members)/2 + 1
+}
+
+func (n *Node) resetDeadline() {
+	timeout := n.config.ElectionTimeout + rand.N(n.config.ElectionTimeout)
+	n.deadline = time.Now().Add(timeout)
+}
+
+func (n *Node) setHardState(term uint64, votedFor int32) {
+	if err := n.log.SetHardState(term, votedFor); err != nil {
+		// Voting twice in a term could elect two leaders.
+		level.Error(n.logger).Log("msg", "unable to persist raft state", "err", err)
+		panic(err)
+	}
+
+	n.metrics.term.Set(float64(term))
+}
+
+func (n *Node) setLeader(leader int32) {
+	if n.leader == leader {
+		return
+	}
+
+	n.leader = leader
+
+	if leader != None {
+		n.metrics.leaderChanges.Inc()
+		level.Info(n.logger).Log("msg", "raft leader changed", "leader", leader)
+	}
+}
+
+func (n *Node) run() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(n.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n.tick()
+		case <-n.done:
+			return
+		}
+	}
+}
+
+func (n *Node) tick() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopped {
+		return
+	}
+
+	if n.state == Leader {
+		n.replicateAll()
+		return
+	}
+
+	if time.Now().After(n.deadline) {
+		n.startElection()
+	}
+}
+
+// stepDown follows the leader of a newer term, or of the current term as a candidate.
+func (n *Node) stepDown(term uint64) {
+	current, _ := n.log.HardState()
+
+	if term > current {
+		n.setHardState(term, None)
+		n.setLeader(None)
+	}
+
+	if n.state == Leader {
+		level.Info(n.logger).Log("msg", "stepping down as raft leader", "term", term)
+	}
+
+	n.state = Follower
+	n.metrics.leader.Set(0)
+}
+
+func (n *Node) startElection() {
+	term, _ := n.log.HardState()
+	term++
+
+	n.state = Candidate
+	n.setLeader(None)
+	n.setHardState(term, n.config.ID)
+	n.resetDeadline()
+	n.metrics.elections.Inc()
+
+	level.Debug(n.logger).Log("msg", "starting raft election", "term", term)
+
+	votes := 1
+
+	if votes >= n.quorum() {
+		n.becomeLeader()
+		return
+	}
+
+	req := VoteRequest{
+		Term:      term,
+		Candidate: n.config.ID,
+		LastIndex: n.log.LastIndex(),
+		LastTerm:  n.log.LastTerm(),
+	}
+
+	for _, peer := range n.peers() {
+		n.wg.Add(1)
+
+		go func() {
+			defer n.wg.Done()
+
+			ctx, cancel := n.context(n.config.ElectionTimeout)
+			defer cancel()
+
+			res, err := n.transport.RequestVote(ctx, peer, req)
+
+			if err != nil {
+				level.Debug(n.logger).Log("msg", "vote request failed", "peer", peer, "err", err)
+				return
+			}
+
+			n.mutex.Lock()
+			defer n.mutex.Unlock()
+
+			current, _ := n.log.HardState()
+
+			if res.Term > current {
+				n.stepDown(res.Term)
+				return
+			}
+
+			if n.state != Candidate || current != req.Term || !res.Granted {
+				return
+			}
+
+			votes++
+
+			if votes >= n.quorum() {
+				n.becomeLeader()
+			}
+		}()
+	}
+}
+
+func (n *Node) becomeLeader() {
+	term, _ := n.log.HardState()
+
+	n.state = Leader
+	n.setLeader(n.config.ID)
+	n.metrics.leader.Set(1)
+
+	level.Info(n.logger).Log("msg", "elected raft leader", "term", term)
+
+	for _, peer := range n.peers() {
+		n.next[peer] = n.log.LastIndex() + 1
+		n.match[peer] = 0
+	}
+
+	// Entries of earlier terms are only committed along with an entry of the current term.
+	if err := n.appendEntry(nil); err != nil {
+		level.Error(n.logger).Log("msg", "unable to append entry", "err", err)
+		n.stepDown(term)
+		return
+	}
+
+	n.replicateAll()
+}
+
+// appendEntry appends an entry of the current term to the log of the leader.
+func (n *Node) appendEntry(data []byte) error {
+	term, _ := n.log.HardState()
+	e := Entry{Index: n.log.LastIndex() + 1, Term: term, Data: data}
+
+	if err := n.log.Append(e); err != nil {
+		return err
+	}
+
+	n.match[n.config.ID] = e.Index
+	n.advanceCommit()
+
+	return nil
+}
+
+// advanceCommit commits the last entry of the current term held by a majority.
+func (n *Node) advanceCommit() {
+	current, _ := n.log.HardState()
+
+	for index := n.log.LastIndex(); index > n.commit; index-- {
+		if term, _ := n.log.Term(index); term != current {
+			return
+		}
+
+		count := 0
+
+		for _, id := range n.config.Members {
+			if n.match[id] >= index {
+				count++
+			}
+		}
+
+		if count >= n.quorum() {
+			n.setCommit(index)
+			return
+		}
+	}
+}
+
+func (n *Node) setCommit(index uint64) {
+	if index <= n.commit {
+		return
+	}
+
+	n.commit = index
+	n.metrics.commitIndex.Set(float64(index))
+
+	select {
+	case n.applyc <- struct{}{}:
+	default:
+	}
+}
+
+func (n *Node) replicateAll() {
+	if n.stopped {
+		return
+	}
+
+	for _, peer := range n.peers() {
+		if n.replicating[peer] {
+			continue
+		}
+
+		n.replicating[peer] = true
+		n.wg.Add(1)
+
+		go n.replicate(peer)
+	}
+}
+
+// replicate sends entries to the peer until it has the log of the leader.
+func (n *Node) replicate(peer int32) {
+	defer n.wg.Done()
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	defer func() {
+		n.replicating[peer] = false
+	}()
+
+	for n.state == Leader && !n.stopped {
+		term, _ := n.log.HardState()
+		next := n.next[peer]
+
+		var more bool
+
+		if next <= n.log.SnapshotIndex() {
+			more = n.sendSnapshot(peer, term)
+		} else {
+			more = n.sendEntries(peer, term, next)
+		}
+
+		if !more {
+			return
+		}
+	}
+}
+
+// context returns a context for a request to a peer which is canceled when the node is stopped.
+func (n *Node) context(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	go func() {
+		select {
+		case <-n.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// sendEntries is called with the mutex held, which it releases during the request.
+// It reports whether more entries should be sent right away.
+func (n *Node) sendEntries(peer int32, term uint64, next uint64) bool {
+	prevTerm, _ := n.log.Term(next - 1)
+	req := AppendRequest{
+		Term:      term,
+		Leader:    n.config.ID,
+		PrevIndex: next - 1,
+		PrevTerm:  prevTerm,
+		Entries:   n.log.Entries(next, n.config.MaxAppendEntries),
+		Commit:    n.commit,
+	}
+
+	n.mutex.Unlock()
+
+	ctx, cancel := n.context(n.config.ElectionTimeout)
+	res, err := n.transport.AppendEntries(ctx, peer, req)
+	cancel()
+
+	n.mutex.Lock()
+
+	if err != nil {
+		level.Debug(n.logger).Log("msg", "append entries failed", "peer", peer, "err", err)
+		return false
+	}
+
+	if !n.leads(term, res.Term) {
+		return false
+	}
+
+	if !res.Success {
+		n.next[peer] = max(1, min(next-1, res.LastIndex+1))
+		return true
+	}
+
+	match := req.PrevIndex + uint64(len(req.Entries))
+	n.match[peer] = max(n.match[peer], match)
+	n.next[peer] = max(n.next[peer], match+1)
+	n.advanceCommit()
+
+	return n.next[peer] <= n.log.LastIndex()
+}
+
+// sendSnapshot sends the snapshot to a peer which is missing entries removed from the log.
+func (n *Node) sendSnapshot(peer int32, term uint64) bool {
+	s, _, err := n.log.Snapshot()
+
+	if err != nil {
+		level.Error(n.logger).Log("msg", "unable to read snapshot", "err", err)
+		return false
+	}
+
+	n.mutex.Unlock()
+
+	ctx, cancel := n.context(10 * n.config.ElectionTimeout)
+	res, err := n.transport.InstallSnapshot(ctx, peer, SnapshotRequest{Term: term, Leader: n.config.ID, Snapshot: s})
+	cancel()
+
+	n.mutex.Lock()
+
+	if err != nil {
+		level.Warn(n.logger).Log("msg", "install snapshot failed", "peer", peer, "err", err)
+		return false
+	}
+
+	if !n.leads(term, res.Term) {
+		return false
+	}
+
+	n.match[peer] = max(n.match[peer], s.Index)
+	n.next[peer] = max(n.next[peer], s.Index+1)
+
+	return true
+}
+
+// leads checks after a response whether the node still leads the term of the request.
+func (n *Node) leads(term uint64, responseTerm uint64) bool {
+	current, _ := n.log.HardState()
+
+	if responseTerm > current {
+		n.stepDown(responseTerm)
+		return false
+	}
+
+	return n.state == Leader && current == term
+}
+
+// Propose appends data to the log and waits until it is applied to the state machine of
+// the leader, returning its index and the result of the state machine.
+func (n *Node) Propose(ctx context.Context, data []byte) (uint64, error) {
+	n.mutex.Lock()
+
+	if n.stopped {
+		n.mutex.Unlock()
+		return 0, NodeStopped
+	}
+
+	if n.state != Leader {
+		n.mutex.Unlock()
+		return 0, errors.Wrapf(NotLeader, "leader is %d", n.leader)
+	}
+
+	if err := n.appendEntry(data); err != nil {
+		n.mutex.Unlock()
+		return 0, err
+	}
+
+	index := n.log.LastIndex()
+	term, _ := n.log.HardState()
+	done := make(chan error, 1)
+	n.waiters[index] = waiter{term: term, done: done}
+
+	n.replicateAll()
+	n.mutex.Unlock()
+
+	select {
+	case err := <-done:
+		return index, err
+	case <-ctx.Done():
+		n.mutex.Lock()
+		delete(n.waiters, index)
+		n.mutex.Unlock()
+
+		return 0, ctx.Err()
+	case <-n.done:
+		return 0, NodeStopped
+	}
+}
+
+// HandleRequestVote grants the vote of the term to the first candidate with a log at least as recent.
+func (n *Node) HandleRequestVote(req VoteRequest) (VoteResponse, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopped {
+		return VoteResponse{}, NodeStopped
+	}
+
+	term, _ := n.log.HardState()
+
+	if req.Term > term {
+		n.stepDown(req.Term)
+		term = req.Term
+	}
+
+	_, votedFor := n.log.HardState()
+
+	if req.Term < term || (votedFor != None && votedFor != req.Candidate) {
+		return VoteResponse{Term: term}, nil
+	}
+
+	lastTerm := n.log.LastTerm()
+
+	if req.LastTerm < lastTerm || (req.LastTerm == lastTerm && req.LastIndex < n.log.LastIndex()) {
+		return VoteResponse{Term: term}, nil
+	}
+
+	n.setHardState(term, req.Candidate)
+	n.resetDeadline()
+
+	return VoteResponse{Term: term, Granted: true}, nil
+}
+
+// follow accepts the leader of a request of the term, false is returned if the term is outdated.
+func (n *Node) follow(term uint64, leader int32) (uint64, bool) {
+	current, _ := n.log.HardState()
+
+	if term < current {
+		return current, false
+	}
+
+	if term > current || n.state != Follower {
+		n.stepDown(term)
+	}
+
+	n.setLeader(leader)
+	n.resetDeadline()
+
+	return term, true
+}
+
+// HandleAppendEntries appends the entries of the leader to the log if it holds the entry they follow.
+func (n *Node) HandleAppendEntries(req AppendRequest) (AppendResponse, error) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopped {
+		return AppendResponse{}, NodeStopped
+	}
+
+	term, ok := n.follow(req.Term, req.Leader)
+
+	if !ok {
+		return AppendResponse{Term: term, LastIndex: n.log.LastIndex()}, nil
+	}
+
+	if req.PrevIndex > n.log.LastIndex() {
+		return AppendResponse{Term: term, LastIndex: n.log.LastIndex()}, nil
+	}
+
+	entries := req.Entries
+
+	// Entries in the snapshot are committed and therefore match.
+	if req.PrevIndex < n.log.SnapshotIndex() {
+		skip := min(uint64(len(entries)), n.log.SnapshotIndex()-req.PrevIndex)
+		entries = entries[skip:]
+	} else if prevTerm, _ := n.log.Term(req.PrevIndex); prevTerm != req.PrevTerm {
+		return AppendResponse{Term: term, LastIndex: req.PrevIndex - 1}, nil
+	}
+
+	for i, e := range entries {
+		if t, ok := n.log.Term(e.Index); !ok || t != e.Term {
+			if err := n.log.Append(entries[i:]...); err != nil {
+				return AppendResponse{}, err
+			}
+
+			break
+		}
+	}
+
+	n.setCommit(min(req.Commit, req.PrevIndex+uint64(len(req.Entries))))
+
+	return AppendResponse{Term: term, Success: true, LastIndex: n.log.LastIndex()}, nil
+}
+
+// HandleInstallSnapshot replaces the state machine with the snapshot of the leader.
+func (n *Node) HandleInstallSnapshot(req SnapshotRequest) (SnapshotResponse, error) {
+	// The state machine must not be changed by committed entries meanwhile.
+	n.applyMutex.Lock()
+	defer n.applyMutex.Unlock()
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopped {
+		return SnapshotResponse{}, NodeStopped
+	}
+
+	term, ok := n.follow(req.Term, req.Leader)
+
+	if !ok || req.Snapshot.Index <= n.applied {
+		return SnapshotResponse{Term: term}, nil
+	}
+
+	if err := n.log.SaveSnapshot(req.Snapshot); err != nil {
+		return SnapshotResponse{}, err
+	}
+
+	if err := n.sm.Restore(req.Snapshot.Index, req.Snapshot.Data); err != nil {
+		return SnapshotResponse{}, err
+	}
+
+	level.Info(n.logger).Log("msg", "installed raft snapshot", "index", req.Snapshot.Index, "term", req.Snapshot.Term)
+
+	n.metrics.snapshots.Inc()
+	n.applied = req.Snapshot.Index
+	n.setCommit(req.Snapshot.Index)
+
+	return SnapshotResponse{Term: term}, nil
+}
+
+func (n *Node) applyCommitted() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.applyc:
+		case <-n.done:
+			return
+		}
+
+		n.applyMutex.Lock()
+		n.apply()
+		n.applyMutex.Unlock()
+	}
+}
+
+// apply applies the committed entries to the state machine and takes a snapshot once enough
+// entries have been applied since the last one. It is called with the applyMutex held.
+func (n *Node) apply() {
+	for {
+		n.mutex.Lock()
+		entries := n.log.Entries(n.applied+1, min(n.config.MaxAppendEntries, int(n.commit-n.applied)))
+		n.mutex.Unlock()
+
+		if len(entries) == 0 {
+			break
+		}
+
+		results := make([]error, len(entries))
+
+		for i, e := range entries {
+			if len(e.Data) > 0 {
+				results[i] = n.sm.Apply(e.Index, e.Data)
+			}
+		}
+
+		n.mutex.Lock()
+
+		for i, e := range entries {
+			w, ok := n.waiters[e.Index]
+
+			if !ok {
+				continue
+			}
+
+			delete(n.waiters, e.Index)
+
+			// The proposed entry has been replaced by the entry of another leader.
+			if w.term != e.Term {
+				w.done <- NotLeader
+			} else {
+				w.done <- results[i]
+			}
+		}
+
+		n.applied = entries[len(entries)-1].Index
+		n.mutex.Unlock()
+	}
+
+	if n.config.SnapshotEntries == 0 {
+		return
+	}
+
+	n.mutex.Lock()
+	applied := n.applied
+	due := applied-n.log.SnapshotIndex() >= n.config.SnapshotEntries
+	n.mutex.Unlock()
+
+	if !due {
+		return
+	}
+
+	data, err := n.sm.Snapshot()
+
+	if err != nil {
+		level.Error(n.logger).Log("msg", "unable to snapshot state machine", "err", err)
+		return
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	term, _ := n.log.Term(applied)
+
+	if err := n.log.SaveSnapshot(Snapshot{Index: applied, Term: term, Data: data}); err != nil {
+		level.Error(n.logger).Log("msg", "unable to save raft snapshot", "err", err)
+		return
+	}
+
+	n.metrics.snapshots.Inc()
+
+	level.Debug(n.logger).Log("msg", "saved raft snapshot", "index", applied)
+}
+
+// Stop stops the node and closes its log.
+func (n *Node) Stop() error {
+	n.mutex.Lock()
+
+	if n.stopped {
+		n.mutex.Unlock()
+		return NodeStopped
+	}
+
+	n.stopped = true
+	close(n.done)
+	n.mutex.Unlock()
+
+	n.wg.Wait()
+
+	return n.log.Close()
+}
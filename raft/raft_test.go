@@ -0,0 +1,339 @@
+package raft
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSegmentSize = 32 * 1024 * 4
+
+func TestLog(t *testing.T) {
+	dir, err := os.MkdirTemp("", "raft_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenLog(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	term, votedFor := l.HardState()
+	assert.Equal(t, uint64(0), term)
+	assert.Equal(t, None, votedFor)
+	require.NoError(t, l.SetHardState(2, 3))
+
+	require.NoError(t, l.Append(
+		Entry{Index: 1, Term: 1, Data: []byte("a")},
+		Entry{Index: 2, Term: 1, Data: []byte("b")},
+		Entry{Index: 3, Term: 1, Data: []byte("c")},
+	))
+	assert.ErrorIs(t, l.Append(Entry{Index: 5, Term: 1}), InvalidEntries)
+
+	// A new leader replaces the last entry.
+	require.NoError(t, l.Append(Entry{Index: 3, Term: 2, Data: []byte("d")}, Entry{Index: 4, Term: 2, Data: []byte("e")}))
+	require.NoError(t, l.Close())
+
+	l, err = OpenLog(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer l.Close()
+
+	term, votedFor = l.HardState()
+	assert.Equal(t, uint64(2), term)
+	assert.Equal(t, int32(3), votedFor)
+
+	assert.Equal(t, uint64(1), l.FirstIndex())
+	assert.Equal(t, uint64(4), l.LastIndex())
+	assert.Equal(t, uint64(2), l.LastTerm())
+
+	entries := l.Entries(2, 10)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []byte("b"), entries[0].Data)
+	assert.Equal(t, Entry{Index: 3, Term: 2, Data: []byte("d")}, entries[1])
+
+	term, ok := l.Term(3)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), term)
+
+	_, ok = l.Term(5)
+	assert.False(t, ok)
+}
+
+func TestLogSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "raft_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	l, err := OpenLog(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	// Enough data to rotate segments a few times.
+	for i := uint64(1); i <= 100; i++ {
+		require.NoError(t, l.Append(Entry{Index: i, Term: 1, Data: bytes.Repeat([]byte("x"), 8*1024)}))
+	}
+
+	refs, err := wal.SegmentsOf(filepath.Join(dir, walDirName), LogSegmentExt)
+	require.NoError(t, err)
+	segments := len(refs)
+	require.Greater(t, segments, 3)
+
+	require.NoError(t, l.SaveSnapshot(Snapshot{Index: 80, Term: 1, Data: []byte("state")}))
+
+	refs, err = wal.SegmentsOf(filepath.Join(dir, walDirName), LogSegmentExt)
+	require.NoError(t, err)
+	assert.Less(t, len(refs), segments)
+
+	assert.Equal(t, uint64(81), l.FirstIndex())
+	assert.Equal(t, uint64(100), l.LastIndex())
+	assert.Nil(t, l.Entries(80, 10))
+
+	// A snapshot of entries the log does not have replaces all entries.
+	require.NoError(t, l.SaveSnapshot(Snapshot{Index: 90, Term: 2, Data: []byte("leader state")}))
+	assert.Equal(t, uint64(90), l.LastIndex())
+	assert.Equal(t, uint64(2), l.LastTerm())
+	require.NoError(t, l.Close())
+
+	l, err = OpenLog(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.Equal(t, uint64(91), l.FirstIndex())
+	assert.Equal(t, uint64(90), l.LastIndex())
+
+	s, ok, err := l.Snapshot()
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Snapshot{Index: 90, Term: 2, Data: []byte("leader state")}, s)
+
+	refs, err = wal.SegmentsOf(filepath.Join(dir, snapshotDirName), SnapshotExt)
+	require.NoError(t, err)
+	assert.Len(t, refs, 1)
+
+	require.NoError(t, l.Append(Entry{Index: 91, Term: 2, Data: []byte("a")}))
+	assert.Equal(t, uint64(91), l.LastIndex())
+}
+
+// testMachine records the applied entries.
+type testMachine struct {
+	mutex  sync.Mutex
+	values []string
+}
+
+func (m *testMachine) Apply(index uint64, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.values = append(m.values, string(data))
+
+	return nil
+}
+
+func (m *testMachine) Snapshot() ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return json.Marshal(m.values)
+}
+
+func (m *testMachine) Restore(index uint64, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return json.Unmarshal(data, &m.values)
+}
+
+func (m *testMachine) Values() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]string(nil), m.values...)
+}
+
+type testCluster struct {
+	t         *testing.T
+	dir       string
+	members   []int32
+	transport *LocalTransport
+	nodes     map[int32]*Node
+	machines  map[int32]*testMachine
+	snapshots uint64
+}
+
+func newTestCluster(t *testing.T, dir string, size int, snapshots uint64) *testCluster {
+	c := &testCluster{
+		t:         t,
+		dir:       dir,
+		transport: NewLocalTransport(),
+		nodes:     make(map[int32]*Node),
+		machines:  make(map[int32]*testMachine),
+		snapshots: snapshots,
+	}
+
+	for id := int32(1); id <= int32(size); id++ {
+		c.members = append(c.members, id)
+	}
+
+	for _, id := range c.members {
+		c.start(id)
+	}
+
+	return c
+}
+
+func (c *testCluster) start(id int32) {
+	l, err := OpenLog(log.NewNopLogger(), prometheus.NewRegistry(), filepath.Join(c.dir, strconv.Itoa(int(id))), testSegmentSize)
+	require.NoError(c.t, err)
+
+	config := DefaultConfig(id, c.members)
+	config.ElectionTimeout = 100 * time.Millisecond
+	config.HeartbeatInterval = 20 * time.Millisecond
+	config.SnapshotEntries = c.snapshots
+
+	m := &testMachine{}
+	n, err := NewNode(log.NewNopLogger(), prometheus.NewRegistry(), config, l, m, c.transport)
+	require.NoError(c.t, err)
+
+	c.nodes[id] = n
+	c.machines[id] = m
+	c.transport.Add(n)
+}
+
+func (c *testCluster) stop() {
+	for _, n := range c.nodes {
+		n.Stop()
+	}
+}
+
+// leader waits until one of the nodes, except the given ones, is a leader.
+func (c *testCluster) leader(except ...int32) *Node {
+	var leader *Node
+
+	require.Eventually(c.t, func() bool {
+		leader = nil
+
+		for id, n := range c.nodes {
+			if n.State() == Leader && !slices.Contains(except, id) {
+				leader = n
+			}
+		}
+
+		return leader != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return leader
+}
+
+func (c *testCluster) propose(n *Node, values ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, v := range values {
+		_, err := n.Propose(ctx, []byte(v))
+		require.NoError(c.t, err)
+	}
+}
+
+func (c *testCluster) waitApplied(id int32, values []string) {
+	require.Eventually(c.t, func() bool {
+		return assert.ObjectsAreEqual(values, c.machines[id].Values())
+	}, 5*time.Second, 10*time.Millisecond, "node %d applied %v", id, c.machines[id].Values())
+}
+
+func TestReplication(t *testing.T) {
+	dir, err := os.MkdirTemp("", "raft_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newTestCluster(t, dir, 3, 0)
+	defer c.stop()
+
+	leader := c.leader()
+	c.propose(leader, "a", "b", "c")
+
+	for _, id := range c.members {
+		c.waitApplied(id, []string{"a", "b", "c"})
+	}
+
+	for _, n := range c.nodes {
+		if n != leader {
+			_, err := n.Propose(context.Background(), []byte("d"))
+			assert.ErrorIs(t, err, NotLeader)
+			assert.Equal(t, leader.ID(), n.Leader())
+		}
+	}
+
+	// The remaining nodes elect a new leader, the old one follows it once it is back.
+	c.transport.SetConnected(leader.ID(), false)
+
+	next := c.leader(leader.ID())
+	c.propose(next, "d")
+
+	c.transport.SetConnected(leader.ID(), true)
+
+	for _, id := range c.members {
+		c.waitApplied(id, []string{"a", "b", "c", "d"})
+	}
+
+	assert.Eventually(t, func() bool { return leader.State() == Follower }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSnapshotAndRestart(t *testing.T) {
+	dir, err := os.MkdirTemp("", "raft_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newTestCluster(t, dir, 3, 10)
+	defer c.stop()
+
+	leader := c.leader()
+
+	var lagging int32
+
+	for _, id := range c.members {
+		if id != leader.ID() {
+			lagging = id
+		}
+	}
+
+	c.transport.SetConnected(lagging, false)
+
+	var values []string
+
+	for i := 0; i < 25; i++ {
+		values = append(values, fmt.Sprintf("value %d", i))
+	}
+
+	c.propose(leader, values...)
+
+	// The entries the lagging node misses have been replaced by a snapshot.
+	require.Eventually(t, func() bool {
+		leader.mutex.Lock()
+		defer leader.mutex.Unlock()
+
+		return leader.log.SnapshotIndex() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	c.transport.SetConnected(lagging, true)
+	c.waitApplied(lagging, values)
+
+	// Nodes restore their state from the snapshot and the entries after it.
+	require.NoError(t, c.nodes[lagging].Stop())
+	c.start(lagging)
+	c.waitApplied(lagging, values)
+
+	c.propose(c.leader(), "last")
+	c.waitApplied(lagging, append(values, "last"))
+}
@@ -0,0 +1,84 @@
+package raft
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var Unreachable = errors.New("Node unreachable")
+
+// LocalTransport connects the nodes of an in-process cluster, e.g. in tests.
+// Disconnected nodes neither send nor receive messages.
+type LocalTransport struct {
+	mutex        sync.RWMutex
+	nodes        map[int32]*Node
+	disconnected map[int32]bool
+}
+
+func NewLocalTransport() *LocalTransport {
+	return &LocalTransport{
+		nodes:        make(map[int32]*Node),
+		disconnected: make(map[int32]bool),
+	}
+}
+
+// Add makes a node reachable by the others.
+func (t *LocalTransport) Add(n *Node) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.nodes[n.ID()] = n
+}
+
+// SetConnected connects or disconnects a node from the others.
+func (t *LocalTransport) SetConnected(id int32, connected bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.disconnected[id] = !connected
+}
+
+func (t *LocalTransport) node(from int32, to int32) (*Node, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	n, ok := t.nodes[to]
+
+	if !ok || t.disconnected[from] || t.disconnected[to] {
+		return nil, errors.Wrapf(Unreachable, "%d from %d", to, from)
+	}
+
+	return n, nil
+}
+
+func (t *LocalTransport) RequestVote(ctx context.Context, to int32, req VoteRequest) (VoteResponse, error) {
+	n, err := t.node(req.Candidate, to)
+
+	if err != nil {
+		return VoteResponse{}, err
+	}
+
+	return n.HandleRequestVote(req)
+}
+
+func (t *LocalTransport) AppendEntries(ctx context.Context, to int32, req AppendRequest) (AppendResponse, error) {
+	n, err := t.node(req.Leader, to)
+
+	if err != nil {
+		return AppendResponse{}, err
+	}
+
+	return n.HandleAppendEntries(req)
+}
+
+func (t *LocalTransport) InstallSnapshot(ctx context.Context, to int32, req SnapshotRequest) (SnapshotResponse, error) {
+	n, err := t.node(req.Leader, to)
+
+	if err != nil {
+		return SnapshotResponse{}, err
+	}
+
+	return n.HandleInstallSnapshot(req)
+}
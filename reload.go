@@ -0,0 +1,94 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"iris/broker"
+	"iris/config"
+	"iris/quota"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// reloader reads the config file again and applies the settings which change without a
+// restart to the running broker. The other settings keep their running values until a restart.
+type reloader struct {
+	logger log.Logger
+	path   string
+	broker *broker.Broker
+	quotas *quota.Manager
+	levels *levelLogger
+
+	mutex sync.Mutex
+	// config is the config the broker runs with.
+	config config.Config
+	// loaded is the config of the last reload, a restart-only change is logged
+	// by the reload which finds it only.
+	loaded config.Config
+}
+
+// Reload loads the config, a config which fails to load or validate isn't applied at all.
+func (r *reloader) Reload() (config.Changes, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	next, err := config.Load(r.path)
+
+	if err != nil {
+		return config.Changes{}, err
+	}
+
+	// Every reload reports the restart-only settings which differ from the running ones,
+	// one changed back to the value the broker runs with needs no restart.
+	changes := r.config.Diff(next)
+
+	for _, field := range r.loaded.Diff(next).Restart {
+		if slices.Contains(changes.Restart, field) {
+			level.Warn(r.logger).Log("msg", "config change requires a restart", "field", field)
+		}
+	}
+
+	r.loaded = next
+
+	if len(changes.Dynamic) == 0 {
+		level.Info(r.logger).Log("msg", "config reloaded without dynamic changes")
+		return changes, nil
+	}
+
+	r.config = r.config.WithDynamic(next)
+
+	r.levels.SetLevel(r.config.LogLevel)
+	r.quotas.SetOptions(quotaOptions(r.config))
+	r.broker.Reconfigure(dynamicOptions(r.config))
+
+	level.Info(r.logger).Log("msg", "config reloaded", "applied", strings.Join(changes.Dynamic, ","))
+
+	return changes, nil
+}
+
+// levelLogger filters the lines below a level which can change while the broker runs.
+type levelLogger struct {
+	next     log.Logger
+	filtered atomic.Pointer[log.Logger]
+}
+
+func newLevelLogger(next log.Logger, name string) *levelLogger {
+	l := &levelLogger{next: next}
+	l.SetLevel(name)
+
+	return l
+}
+
+// SetLevel keeps the lines of the named level and above, unknown names fall back to info.
+func (l *levelLogger) SetLevel(name string) {
+	filtered := level.NewFilter(l.next, level.Allow(level.ParseDefault(name, level.InfoValue())))
+	l.filtered.Store(&filtered)
+}
+
+func (l *levelLogger) Log(keyvals ...interface{}) error {
+	return (*l.filtered.Load()).Log(keyvals...)
+}
@@ -0,0 +1,503 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonSchema is the supported subset of JSON Schema. Keywords which would
+// change validation but are not supported make a schema invalid, so payloads
+// are never accepted by a schema which is only partially understood.
+type jsonSchema struct {
+	// Boolean schemas, true accepts everything and false nothing.
+	never bool
+
+	types      []string
+	enum       []string
+	properties map[string]*jsonSchema
+	required   []string
+	// additional is nil if additional properties are allowed without restriction.
+	additional *jsonSchema
+	items      *jsonSchema
+
+	minimum, maximum     *float64
+	minLength, maxLength *int
+	minItems, maxItems   *int
+	pattern              *regexp.Regexp
+}
+
+var (
+	jsonTypes = map[string]bool{
+		"null": true, "boolean": true, "object": true, "array": true, "number": true, "integer": true, "string": true,
+	}
+	// Keywords without effect on validation.
+	jsonAnnotations = map[string]bool{
+		"$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+		"default": true, "examples": true, "format": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+	}
+)
+
+func parseJSONSchema(definition []byte) (*jsonSchema, error) {
+	var raw interface{}
+
+	d := json.NewDecoder(bytes.NewReader(definition))
+	d.UseNumber()
+
+	if err := d.Decode(&raw); err != nil {
+		return nil, errors.Wrapf(InvalidSchema, "invalid JSON: %s", err)
+	}
+
+	return compileJSONSchema(raw, "#")
+}
+
+func compileJSONSchema(raw interface{}, path string) (*jsonSchema, error) {
+	switch v := raw.(type) {
+	case bool:
+		return &jsonSchema{never: !v}, nil
+	case map[string]interface{}:
+	default:
+		return nil, errors.Wrapf(InvalidSchema, "%s: a schema must be an object or a boolean", path)
+	}
+
+	s := &jsonSchema{}
+	obj := raw.(map[string]interface{})
+
+	for key, value := range obj {
+		var err error
+
+		switch key {
+		case "type":
+			s.types, err = compileTypes(value)
+		case "enum":
+			values, ok := value.([]interface{})
+
+			if !ok || len(values) == 0 {
+				err = errors.New("enum must be a non-empty array")
+			}
+
+			for _, v := range values {
+				s.enum = append(s.enum, canonicalJSON(v))
+			}
+		case "properties":
+			props, ok := value.(map[string]interface{})
+
+			if !ok {
+				err = errors.New("properties must be an object")
+				break
+			}
+
+			s.properties = make(map[string]*jsonSchema, len(props))
+
+			for name, prop := range props {
+				if s.properties[name], err = compileJSONSchema(prop, path+"/properties/"+name); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			names, ok := value.([]interface{})
+
+			if !ok {
+				err = errors.New("required must be an array")
+			}
+
+			for _, name := range names {
+				str, ok := name.(string)
+
+				if !ok {
+					err = errors.New("required must hold strings")
+					break
+				}
+
+				s.required = append(s.required, str)
+			}
+		case "additionalProperties":
+			s.additional, err = compileJSONSchema(value, path+"/additionalProperties")
+		case "items":
+			s.items, err = compileJSONSchema(value, path+"/items")
+		case "minimum":
+			s.minimum, err = compileNumber(value)
+		case "maximum":
+			s.maximum, err = compileNumber(value)
+		case "minLength":
+			s.minLength, err = compileCount(value)
+		case "maxLength":
+			s.maxLength, err = compileCount(value)
+		case "minItems":
+			s.minItems, err = compileCount(value)
+		case "maxItems":
+			s.maxItems, err = compileCount(value)
+		case "pattern":
+			str, ok := value.(string)
+
+			if !ok {
+				err = errors.New("pattern must be a string")
+				break
+			}
+
+			s.pattern, err = regexp.Compile(str)
+		default:
+			if !jsonAnnotations[key] {
+				err = errors.New("unsupported keyword")
+			}
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(InvalidSchema, "%s/%s: %s", path, key, err)
+		}
+	}
+
+	return s, nil
+}
+
+func compileTypes(value interface{}) ([]string, error) {
+	var types []string
+
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			str, ok := t.(string)
+
+			if !ok {
+				return nil, errors.New("types must be strings")
+			}
+
+			types = append(types, str)
+		}
+	default:
+		return nil, errors.New("type must be a string or an array")
+	}
+
+	for _, t := range types {
+		if !jsonTypes[t] {
+			return nil, errors.Errorf("unknown type %q", t)
+		}
+	}
+
+	return types, nil
+}
+
+func compileNumber(value interface{}) (*float64, error) {
+	n, ok := value.(json.Number)
+
+	if !ok {
+		return nil, errors.New("must be a number")
+	}
+
+	f, err := n.Float64()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func compileCount(value interface{}) (*int, error) {
+	f, err := compileNumber(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if *f < 0 || *f != math.Trunc(*f) {
+		return nil, errors.New("must be a non-negative integer")
+	}
+
+	n := int(*f)
+
+	return &n, nil
+}
+
+// canonicalJSON returns the JSON of a decoded value with sorted object keys, so equal values compare equal.
+func canonicalJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+
+		return "number"
+	}
+
+	return ""
+}
+
+func (s *jsonSchema) Validate(payload []byte) error {
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+
+	if err := d.Decode(&v); err != nil {
+		return errors.Wrapf(InvalidPayload, "invalid JSON: %s", err)
+	}
+
+	if d.More() {
+		return errors.Wrap(InvalidPayload, "invalid JSON: data after the value")
+	}
+
+	if err := s.validate(v, "#"); err != nil {
+		return errors.Wrap(InvalidPayload, err.Error())
+	}
+
+	return nil
+}
+
+func (s *jsonSchema) validate(v interface{}, path string) error {
+	if s.never {
+		return errors.Errorf("%s: no value is allowed", path)
+	}
+
+	t := jsonType(v)
+
+	if len(s.types) > 0 && !containsType(s.types, t) {
+		return errors.Errorf("%s: expected %s, got %s", path, strings.Join(s.types, " or "), t)
+	}
+
+	if s.enum != nil && !contains(s.enum, canonicalJSON(v)) {
+		return errors.Errorf("%s: value is not one of the enum", path)
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+
+		if s.minimum != nil && f < *s.minimum {
+			return errors.Errorf("%s: %s is less than %g", path, v, *s.minimum)
+		}
+
+		if s.maximum != nil && f > *s.maximum {
+			return errors.Errorf("%s: %s is greater than %g", path, v, *s.maximum)
+		}
+	case string:
+		n := len([]rune(v))
+
+		if s.minLength != nil && n < *s.minLength {
+			return errors.Errorf("%s: shorter than %d characters", path, *s.minLength)
+		}
+
+		if s.maxLength != nil && n > *s.maxLength {
+			return errors.Errorf("%s: longer than %d characters", path, *s.maxLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return errors.Errorf("%s: does not match %s", path, s.pattern)
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return errors.Errorf("%s: fewer than %d items", path, *s.minItems)
+		}
+
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return errors.Errorf("%s: more than %d items", path, *s.maxItems)
+		}
+
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return errors.Errorf("%s: missing property %s", path, name)
+			}
+		}
+
+		for name, value := range v {
+			prop, ok := s.properties[name]
+
+			if !ok {
+				prop = s.additional
+			}
+
+			if prop == nil {
+				continue
+			}
+
+			if err := prop.validate(value, path+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsType(types []string, t string) bool {
+	return contains(types, t) || (t == "integer" && contains(types, "number"))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+var anyJSONSchema = &jsonSchema{}
+
+// isAny reports whether the schema accepts every value.
+func (s *jsonSchema) isAny() bool {
+	return !s.never && s.types == nil && s.enum == nil && s.properties == nil && s.required == nil &&
+		s.additional.acceptsAll() && s.items.acceptsAll() && s.minimum == nil && s.maximum == nil &&
+		s.minLength == nil && s.maxLength == nil && s.minItems == nil && s.maxItems == nil && s.pattern == nil
+}
+
+func (s *jsonSchema) acceptsAll() bool {
+	return s == nil || s.isAny()
+}
+
+// jsonCompatible appends the reasons why the reader schema may reject values
+// the writer schema accepts.
+func jsonCompatible(reader *jsonSchema, writer *jsonSchema, path string, issues []string) []string {
+	if writer.never || reader.isAny() {
+		return issues
+	}
+
+	if reader.never {
+		return append(issues, path+": no value is allowed")
+	}
+
+	if len(reader.types) > 0 {
+		if len(writer.types) == 0 {
+			issues = append(issues, path+": type was restricted")
+		}
+
+		for _, t := range writer.types {
+			if !containsType(reader.types, t) {
+				issues = append(issues, fmt.Sprintf("%s: type %s was removed", path, t))
+			}
+		}
+	}
+
+	if reader.enum != nil {
+		if writer.enum == nil {
+			issues = append(issues, path+": enum was added")
+		}
+
+		for _, v := range writer.enum {
+			if !contains(reader.enum, v) {
+				issues = append(issues, fmt.Sprintf("%s: enum value %s was removed", path, v))
+			}
+		}
+	}
+
+	issues = lowerBound(issues, path, "minimum", reader.minimum, writer.minimum)
+	issues = upperBound(issues, path, "maximum", reader.maximum, writer.maximum)
+	issues = lowerBound(issues, path, "minLength", countBound(reader.minLength), countBound(writer.minLength))
+	issues = upperBound(issues, path, "maxLength", countBound(reader.maxLength), countBound(writer.maxLength))
+	issues = lowerBound(issues, path, "minItems", countBound(reader.minItems), countBound(writer.minItems))
+	issues = upperBound(issues, path, "maxItems", countBound(reader.maxItems), countBound(writer.maxItems))
+
+	if reader.pattern != nil && (writer.pattern == nil || reader.pattern.String() != writer.pattern.String()) {
+		issues = append(issues, path+": pattern was changed")
+	}
+
+	for _, name := range reader.required {
+		if !contains(writer.required, name) {
+			issues = append(issues, fmt.Sprintf("%s: property %s became required", path, name))
+		}
+	}
+
+	names := make([]string, 0, len(reader.properties)+len(writer.properties))
+
+	for name := range reader.properties {
+		names = append(names, name)
+	}
+
+	for name := range writer.properties {
+		if _, ok := reader.properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	// Properties one schema doesn't declare are checked against its additional properties.
+	for _, name := range names {
+		r, ok := reader.properties[name]
+
+		if !ok {
+			r = orAny(reader.additional)
+		}
+
+		w, ok := writer.properties[name]
+
+		if !ok {
+			w = orAny(writer.additional)
+		}
+
+		issues = jsonCompatible(r, w, path+"/properties/"+name, issues)
+	}
+
+	issues = jsonCompatible(orAny(reader.additional), orAny(writer.additional), path+"/additionalProperties", issues)
+	issues = jsonCompatible(orAny(reader.items), orAny(writer.items), path+"/items", issues)
+
+	return issues
+}
+
+func orAny(s *jsonSchema) *jsonSchema {
+	if s == nil {
+		return anyJSONSchema
+	}
+
+	return s
+}
+
+func countBound(n *int) *float64 {
+	if n == nil {
+		return nil
+	}
+
+	f := float64(*n)
+
+	return &f
+}
+
+// lowerBound reports a lower bound of the reader above the one of the writer.
+func lowerBound(issues []string, path string, keyword string, reader *float64, writer *float64) []string {
+	if reader != nil && (writer == nil || *writer < *reader) {
+		return append(issues, fmt.Sprintf("%s: %s was raised", path, keyword))
+	}
+
+	return issues
+}
+
+// upperBound reports an upper bound of the reader below the one of the writer.
+func upperBound(issues []string, path string, keyword string, reader *float64, writer *float64) []string {
+	if reader != nil && (writer == nil || *writer > *reader) {
+		return append(issues, fmt.Sprintf("%s: %s was lowered", path, keyword))
+	}
+
+	return issues
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoSchema validates payloads as serialized messages of a type from a set of descriptors.
+type protoSchema struct {
+	desc protoreflect.MessageDescriptor
+}
+
+// parseProtoSchema reads a serialized FileDescriptorSet, the message type is looked up
+// by its full name or is the first message of the last file if the name is empty.
+func parseProtoSchema(definition []byte, messageName string) (*protoSchema, error) {
+	var set descriptorpb.FileDescriptorSet
+
+	if err := proto.Unmarshal(definition, &set); err != nil {
+		return nil, errors.Wrapf(InvalidSchema, "invalid file descriptor set: %s", err)
+	}
+
+	if len(set.GetFile()) == 0 {
+		return nil, errors.Wrap(InvalidSchema, "no files in the file descriptor set")
+	}
+
+	files, err := protodesc.NewFiles(&set)
+
+	if err != nil {
+		return nil, errors.Wrapf(InvalidSchema, "invalid file descriptor set: %s", err)
+	}
+
+	if messageName == "" {
+		last := set.GetFile()[len(set.GetFile())-1]
+
+		if len(last.GetMessageType()) == 0 {
+			return nil, errors.Wrapf(InvalidSchema, "no message in %s", last.GetName())
+		}
+
+		messageName = last.GetMessageType()[0].GetName()
+
+		if last.GetPackage() != "" {
+			messageName = last.GetPackage() + "." + messageName
+		}
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(messageName))
+
+	if err != nil {
+		return nil, errors.Wrapf(InvalidSchema, "unknown message %s", messageName)
+	}
+
+	desc, ok := d.(protoreflect.MessageDescriptor)
+
+	if !ok {
+		return nil, errors.Wrapf(InvalidSchema, "%s is not a message", messageName)
+	}
+
+	return &protoSchema{desc: desc}, nil
+}
+
+func (s *protoSchema) Validate(payload []byte) error {
+	if err := proto.Unmarshal(payload, dynamicpb.NewMessage(s.desc)); err != nil {
+		return errors.Wrapf(InvalidPayload, "invalid %s: %s", s.desc.FullName(), err)
+	}
+
+	return nil
+}
+
+// Kinds sharing a wire type whose values can be read as one another.
+var protoKindGroups = map[protoreflect.Kind]int{
+	protoreflect.Int32Kind: 1, protoreflect.Uint32Kind: 1, protoreflect.Int64Kind: 1, protoreflect.Uint64Kind: 1,
+	protoreflect.BoolKind: 1, protoreflect.EnumKind: 1,
+	protoreflect.Sint32Kind: 2, protoreflect.Sint64Kind: 2,
+	protoreflect.Fixed32Kind: 3, protoreflect.Sfixed32Kind: 3,
+	protoreflect.Fixed64Kind: 4, protoreflect.Sfixed64Kind: 4,
+	protoreflect.StringKind: 5, protoreflect.BytesKind: 5,
+}
+
+// protoCompatible appends the reasons why messages written with the writer
+// descriptor may not be read with the reader descriptor.
+func protoCompatible(reader protoreflect.MessageDescriptor, writer protoreflect.MessageDescriptor, issues []string, seen map[protoreflect.FullName]bool) []string {
+	if reader.FullName() != writer.FullName() {
+		return append(issues, fmt.Sprintf("message %s was renamed to %s", writer.FullName(), reader.FullName()))
+	}
+
+	// Recursive messages are compared once.
+	if seen[reader.FullName()] {
+		return issues
+	}
+
+	seen[reader.FullName()] = true
+
+	rFields := reader.Fields()
+	wFields := writer.Fields()
+
+	for i := 0; i < rFields.Len(); i++ {
+		r := rFields.Get(i)
+		w := wFields.ByNumber(r.Number())
+
+		if w == nil {
+			if r.Cardinality() == protoreflect.Required {
+				issues = append(issues, fmt.Sprintf("%s: required field %d was added", reader.FullName(), r.Number()))
+			}
+
+			continue
+		}
+
+		name := fmt.Sprintf("%s.%s", reader.FullName(), r.Name())
+
+		if (r.Cardinality() == protoreflect.Repeated) != (w.Cardinality() == protoreflect.Repeated) || r.IsMap() != w.IsMap() {
+			issues = append(issues, fmt.Sprintf("%s: cardinality of field %d was changed", name, r.Number()))
+			continue
+		}
+
+		if r.Cardinality() == protoreflect.Required && w.Cardinality() != protoreflect.Required {
+			issues = append(issues, fmt.Sprintf("%s: field %d became required", name, r.Number()))
+		}
+
+		switch {
+		case r.Kind() == w.Kind():
+		case protoKindGroups[r.Kind()] != 0 && protoKindGroups[r.Kind()] == protoKindGroups[w.Kind()]:
+		default:
+			issues = append(issues, fmt.Sprintf("%s: type of field %d was changed from %s to %s", name, r.Number(), w.Kind(), r.Kind()))
+			continue
+		}
+
+		if r.Message() != nil && w.Message() != nil {
+			issues = protoCompatible(r.Message(), w.Message(), issues, seen)
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,620 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type Type string
+
+const (
+	TypeJSON     Type = "json"
+	TypeProtobuf Type = "protobuf"
+)
+
+// Compatibility is checked against the latest version of a subject, or against all of them for the transitive ones.
+type Compatibility string
+
+const (
+	CompatibilityNone Compatibility = "none"
+	// New versions can read what the previous version wrote.
+	CompatibilityBackward           Compatibility = "backward"
+	CompatibilityBackwardTransitive Compatibility = "backward_transitive"
+	// The previous version can read what new versions write.
+	CompatibilityForward           Compatibility = "forward"
+	CompatibilityForwardTransitive Compatibility = "forward_transitive"
+	// Both backward and forward.
+	CompatibilityFull           Compatibility = "full"
+	CompatibilityFullTransitive Compatibility = "full_transitive"
+
+	DefaultCompatibility = CompatibilityBackward
+)
+
+const (
+	// IDHeader holds the decimal ID of the schema of a message value.
+	IDHeader = "iris-schema-id"
+
+	// LatestVersion refers to the latest version of a subject.
+	LatestVersion = 0
+
+	// The schema table is labelled like the internal topics of the broker.
+	schemasTableName = "__schemas"
+
+	schemaPrefix        = "schema/"
+	subjectPrefix       = "subject/"
+	compatibilityPrefix = "compatibility/"
+)
+
+var (
+	InvalidSchema        = errors.New("Invalid schema")
+	InvalidPayload       = errors.New("Payload does not match its schema")
+	InvalidCompatibility = errors.New("Invalid compatibility")
+	IncompatibleSchema   = errors.New("Incompatible schema")
+	UnknownSchema        = errors.New("Unknown schema")
+	UnknownSubject       = errors.New("Unknown subject")
+	UnknownVersion       = errors.New("Unknown version")
+)
+
+// Schema is a registered schema, the definition of a protobuf schema is a serialized FileDescriptorSet.
+type Schema struct {
+	ID          int64  `json:"id"`
+	Type        Type   `json:"type"`
+	Definition  []byte `json:"definition"`
+	MessageName string `json:"messageName,omitempty"`
+}
+
+// SubjectVersion is a version of a schema within a subject.
+type SubjectVersion struct {
+	Subject string
+	Version int
+	Schema  Schema
+}
+
+type validator interface {
+	Validate(payload []byte) error
+}
+
+type compiled struct {
+	Schema
+	validator validator
+}
+
+type subjectVersion struct {
+	version int
+	id      int64
+}
+
+// Registry keeps versioned schemas per subject in a table and validates payloads against them.
+// Schemas with the same definition share their ID across subjects.
+type Registry struct {
+	logger  log.Logger
+	metrics *RegistryMetrics
+	table   *storage.Table
+
+	mutex         sync.RWMutex
+	schemas       map[int64]*compiled
+	fingerprints  map[string]int64
+	subjects      map[string][]subjectVersion
+	compatibility map[string]Compatibility
+	nextID        int64
+}
+
+type RegistryMetrics struct {
+	registered *prometheus.CounterVec
+	rejected   prometheus.Counter
+}
+
+func OpenRegistry(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int) (*Registry, error) {
+	tableRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"topic": schemasTableName, "partition": "0"}, registerer)
+	table, err := storage.OpenTable(logger, tableRegisterer, dir, segmentSize)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open schema table")
+	}
+
+	r := &Registry{
+		logger:        logger,
+		metrics:       NewRegistryMetrics(prometheus.WrapRegistererWithPrefix("schema_", registerer)),
+		table:         table,
+		schemas:       make(map[int64]*compiled),
+		fingerprints:  make(map[string]int64),
+		subjects:      make(map[string][]subjectVersion),
+		compatibility: make(map[string]Compatibility),
+		nextID:        1,
+	}
+
+	if err := r.load(); err != nil {
+		table.Stop()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func NewRegistryMetrics(registerer prometheus.Registerer) *RegistryMetrics {
+	m := &RegistryMetrics{}
+
+	m.registered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "registered_total",
+		Help: "Total number of schema versions registered.",
+	}, []string{"type"})
+	m.rejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "rejected_payloads_total",
+		Help: "Total number of payloads rejected for not matching their schema.",
+	})
+
+	if registerer != nil {
+		registerer.MustRegister(m.registered, m.rejected)
+	}
+
+	return m
+}
+
+func (r *Registry) load() error {
+	var loadErr error
+
+	r.table.Range("", func(key string, value []byte) bool {
+		switch {
+		case strings.HasPrefix(key, schemaPrefix):
+			var s Schema
+
+			if loadErr = json.Unmarshal(value, &s); loadErr != nil {
+				break
+			}
+
+			var c *compiled
+
+			if c, loadErr = compile(s); loadErr != nil {
+				break
+			}
+
+			r.schemas[s.ID] = c
+			r.fingerprints[fingerprint(s)] = s.ID
+			r.nextID = max(r.nextID, s.ID+1)
+		case strings.HasPrefix(key, subjectPrefix):
+			i := strings.LastIndexByte(key, '/')
+			subject := key[len(subjectPrefix):i]
+
+			var version int
+
+			if version, loadErr = strconv.Atoi(key[i+1:]); loadErr != nil {
+				break
+			}
+
+			var v struct {
+				ID int64 `json:"id"`
+			}
+
+			if loadErr = json.Unmarshal(value, &v); loadErr != nil {
+				break
+			}
+
+			r.subjects[subject] = append(r.subjects[subject], subjectVersion{version: version, id: v.ID})
+		case strings.HasPrefix(key, compatibilityPrefix):
+			r.compatibility[key[len(compatibilityPrefix):]] = Compatibility(value)
+		}
+
+		if loadErr != nil {
+			loadErr = errors.Wrapf(loadErr, "invalid schema table entry %s", key)
+			return false
+		}
+
+		return true
+	})
+
+	if loadErr != nil {
+		return loadErr
+	}
+
+	for subject, versions := range r.subjects {
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i].version < versions[j].version
+		})
+
+		for _, v := range versions {
+			if _, ok := r.schemas[v.id]; !ok {
+				return errors.Wrapf(UnknownSchema, "version %d of subject %s refers to schema %d", v.version, subject, v.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func compile(s Schema) (*compiled, error) {
+	c := &compiled{Schema: s}
+
+	var err error
+
+	switch s.Type {
+	case TypeJSON:
+		if s.MessageName != "" {
+			return nil, errors.Wrap(InvalidSchema, "JSON schemas have no message name")
+		}
+
+		c.validator, err = parseJSONSchema(s.Definition)
+	case TypeProtobuf:
+		var p *protoSchema
+
+		if p, err = parseProtoSchema(s.Definition, s.MessageName); err == nil {
+			// The message name is kept, so the schema doesn't depend on the order of its files.
+			c.MessageName = string(p.desc.FullName())
+			c.validator = p
+		}
+	default:
+		return nil, errors.Wrapf(InvalidSchema, "unknown schema type %q", s.Type)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func fingerprint(s Schema) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", s.Type, s.MessageName)
+	h.Write(s.Definition)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func subjectKey(subject string, version int) string {
+	return fmt.Sprintf("%s%s/%010d", subjectPrefix, subject, version)
+}
+
+// incompatibilities returns why data written with the writer schema may not be read with the reader schema.
+func incompatibilities(reader *compiled, writer *compiled) []string {
+	if reader.Type != writer.Type {
+		return []string{fmt.Sprintf("schema type was changed from %s to %s", writer.Type, reader.Type)}
+	}
+
+	switch r := reader.validator.(type) {
+	case *jsonSchema:
+		return jsonCompatible(r, writer.validator.(*jsonSchema), "#", nil)
+	case *protoSchema:
+		return protoCompatible(r.desc, writer.validator.(*protoSchema).desc, nil, make(map[protoreflect.FullName]bool))
+	}
+
+	return nil
+}
+
+func validateCompatibility(c Compatibility) error {
+	switch c {
+	case CompatibilityNone, CompatibilityBackward, CompatibilityBackwardTransitive, CompatibilityForward,
+		CompatibilityForwardTransitive, CompatibilityFull, CompatibilityFullTransitive:
+		return nil
+	}
+
+	return errors.Wrapf(InvalidCompatibility, "unknown compatibility %q", c)
+}
+
+// Compatibility returns the compatibility of the subject, or the default one for an empty subject.
+func (r *Registry) Compatibility(subject string) Compatibility {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.compatibilityOf(subject)
+}
+
+func (r *Registry) compatibilityOf(subject string) Compatibility {
+	if c, ok := r.compatibility[subject]; ok {
+		return c
+	}
+
+	if c, ok := r.compatibility[""]; ok {
+		return c
+	}
+
+	return DefaultCompatibility
+}
+
+// SetCompatibility sets the compatibility of the subject, or the default one for an empty subject.
+func (r *Registry) SetCompatibility(subject string, c Compatibility) error {
+	if err := validateCompatibility(c); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.table.Put(compatibilityPrefix+subject, []byte(c)); err != nil {
+		return err
+	}
+
+	r.compatibility[subject] = c
+
+	return nil
+}
+
+// check returns IncompatibleSchema if the schema can't be registered as the next version of the subject,
+// it must be called with the mutex held.
+func (r *Registry) check(subject string, c *compiled) error {
+	versions := r.subjects[subject]
+
+	if len(versions) == 0 {
+		return nil
+	}
+
+	var backward, forward, transitive bool
+
+	switch r.compatibilityOf(subject) {
+	case CompatibilityNone:
+		return nil
+	case CompatibilityBackward:
+		backward = true
+	case CompatibilityBackwardTransitive:
+		backward, transitive = true, true
+	case CompatibilityForward:
+		forward = true
+	case CompatibilityForwardTransitive:
+		forward, transitive = true, true
+	case CompatibilityFull:
+		backward, forward = true, true
+	case CompatibilityFullTransitive:
+		backward, forward, transitive = true, true, true
+	}
+
+	if !transitive {
+		versions = versions[len(versions)-1:]
+	}
+
+	var issues []string
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		previous := r.schemas[versions[i].id]
+
+		if backward {
+			issues = append(issues, incompatibilities(c, previous)...)
+		}
+
+		if forward {
+			issues = append(issues, incompatibilities(previous, c)...)
+		}
+
+		if len(issues) > 0 {
+			return errors.Wrapf(IncompatibleSchema, "with version %d of %s: %s", versions[i].version, subject, strings.Join(issues, ", "))
+		}
+	}
+
+	return nil
+}
+
+// CheckCompatibility returns IncompatibleSchema if the schema can't be registered as the next version of the subject.
+func (r *Registry) CheckCompatibility(subject string, s Schema) error {
+	c, err := compile(s)
+
+	if err != nil {
+		return err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.check(subject, c)
+}
+
+// Register adds the schema as the next version of the subject. Registering the
+// schema of a version again returns that version.
+func (r *Registry) Register(subject string, s Schema) (SubjectVersion, error) {
+	if subject == "" {
+		return SubjectVersion{}, errors.Wrap(UnknownSubject, "empty subject")
+	}
+
+	c, err := compile(s)
+
+	if err != nil {
+		return SubjectVersion{}, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	fp := fingerprint(c.Schema)
+	id, known := r.fingerprints[fp]
+
+	for _, v := range r.subjects[subject] {
+		if known && v.id == id {
+			return SubjectVersion{Subject: subject, Version: v.version, Schema: r.schemas[id].Schema}, nil
+		}
+	}
+
+	if err := r.check(subject, c); err != nil {
+		return SubjectVersion{}, err
+	}
+
+	if !known {
+		id = r.nextID
+		c.ID = id
+
+		value, err := json.Marshal(c.Schema)
+
+		if err != nil {
+			return SubjectVersion{}, err
+		}
+
+		if err := r.table.Put(schemaPrefix+strconv.FormatInt(id, 10), value); err != nil {
+			return SubjectVersion{}, err
+		}
+
+		r.schemas[id] = c
+		r.fingerprints[fp] = id
+		r.nextID++
+	}
+
+	version := 1
+
+	if versions := r.subjects[subject]; len(versions) > 0 {
+		version = versions[len(versions)-1].version + 1
+	}
+
+	if err := r.table.Put(subjectKey(subject, version), []byte(fmt.Sprintf(`{"id":%d}`, id))); err != nil {
+		return SubjectVersion{}, err
+	}
+
+	r.subjects[subject] = append(r.subjects[subject], subjectVersion{version: version, id: id})
+	r.metrics.registered.WithLabelValues(string(c.Type)).Inc()
+
+	level.Info(r.logger).Log("msg", "schema registered", "subject", subject, "version", version, "id", id, "type", c.Type)
+
+	return SubjectVersion{Subject: subject, Version: version, Schema: r.schemas[id].Schema}, nil
+}
+
+// Schema returns the schema with the given ID.
+func (r *Registry) Schema(id int64) (Schema, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, ok := r.schemas[id]
+
+	if !ok {
+		return Schema{}, errors.Wrapf(UnknownSchema, "no schema with ID %d", id)
+	}
+
+	return c.Schema, nil
+}
+
+// Version returns a version of the subject, LatestVersion returns the latest one.
+func (r *Registry) Version(subject string, version int) (SubjectVersion, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	versions, ok := r.subjects[subject]
+
+	if !ok {
+		return SubjectVersion{}, errors.Wrapf(UnknownSubject, "no subject %s", subject)
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if v := versions[i]; version == LatestVersion || v.version == version {
+			return SubjectVersion{Subject: subject, Version: v.version, Schema: r.schemas[v.id].Schema}, nil
+		}
+	}
+
+	return SubjectVersion{}, errors.Wrapf(UnknownVersion, "no version %d of subject %s", version, subject)
+}
+
+// Subjects returns the sorted names of all subjects.
+func (r *Registry) Subjects() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	subjects := make([]string, 0, len(r.subjects))
+
+	for subject := range r.subjects {
+		subjects = append(subjects, subject)
+	}
+
+	sort.Strings(subjects)
+
+	return subjects
+}
+
+// Versions returns the versions of the subject in ascending order.
+func (r *Registry) Versions(subject string) ([]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	versions, ok := r.subjects[subject]
+
+	if !ok {
+		return nil, errors.Wrapf(UnknownSubject, "no subject %s", subject)
+	}
+
+	res := make([]int, 0, len(versions))
+
+	for _, v := range versions {
+		res = append(res, v.version)
+	}
+
+	return res, nil
+}
+
+// DeleteSubject removes all versions of the subject and returns them. The schemas
+// are kept, so messages written with them can still be read.
+func (r *Registry) DeleteSubject(subject string) ([]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	versions, ok := r.subjects[subject]
+
+	if !ok {
+		return nil, errors.Wrapf(UnknownSubject, "no subject %s", subject)
+	}
+
+	deleted := make([]int, 0, len(versions))
+
+	for _, v := range versions {
+		if err := r.table.Delete(subjectKey(subject, v.version)); err != nil {
+			r.subjects[subject] = versions[len(deleted):]
+			return deleted, err
+		}
+
+		deleted = append(deleted, v.version)
+	}
+
+	delete(r.subjects, subject)
+
+	if err := r.table.Delete(compatibilityPrefix + subject); err != nil {
+		return deleted, err
+	}
+
+	delete(r.compatibility, subject)
+
+	level.Info(r.logger).Log("msg", "subject deleted", "subject", subject, "versions", len(deleted))
+
+	return deleted, nil
+}
+
+// Validate checks the payload against the schema, which must be a version of the subject.
+func (r *Registry) Validate(subject string, id int64, payload []byte) error {
+	r.mutex.RLock()
+
+	c, ok := r.schemas[id]
+	registered := false
+
+	for _, v := range r.subjects[subject] {
+		if v.id == id {
+			registered = true
+			break
+		}
+	}
+
+	r.mutex.RUnlock()
+
+	if !ok {
+		r.metrics.rejected.Inc()
+		return errors.Wrapf(UnknownSchema, "no schema with ID %d", id)
+	}
+
+	if !registered {
+		r.metrics.rejected.Inc()
+		return errors.Wrapf(InvalidPayload, "schema %d is not a version of subject %s", id, subject)
+	}
+
+	if err := c.validator.Validate(payload); err != nil {
+		r.metrics.rejected.Inc()
+		return err
+	}
+
+	return nil
+}
+
+func (r *Registry) Stop() error {
+	return r.table.Stop()
+}
@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"os"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const testSegmentSize = 32 * 1024 * 4
+
+func jsonDef(s string) Schema {
+	return Schema{Type: TypeJSON, Definition: []byte(s)}
+}
+
+func TestJSONSchema(t *testing.T) {
+	s, err := parseJSONSchema([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string", "maxLength": 3, "pattern": "^[a-z]+$"},
+			"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "maxItems": 2},
+			"price": {"type": ["number", "null"]}
+		},
+		"required": ["id"],
+		"additionalProperties": false
+	}`))
+	require.NoError(t, err)
+
+	assert.NoError(t, s.Validate([]byte(`{"id": 1, "name": "abc", "tags": ["a"], "price": 1.5}`)))
+	assert.NoError(t, s.Validate([]byte(`{"id": 2.0, "price": null}`)))
+
+	for _, payload := range []string{
+		`{"name": "abc"}`,
+		`{"id": 0}`,
+		`{"id": 1.5}`,
+		`{"id": 1, "name": "abcd"}`,
+		`{"id": 1, "name": "ABC"}`,
+		`{"id": 1, "tags": ["c"]}`,
+		`{"id": 1, "tags": ["a", "b", "a"]}`,
+		`{"id": 1, "price": "1"}`,
+		`{"id": 1, "other": 1}`,
+		`[]`,
+		`{"id": 1} {}`,
+		`not json`,
+	} {
+		assert.ErrorIs(t, s.Validate([]byte(payload)), InvalidPayload, payload)
+	}
+
+	_, err = parseJSONSchema([]byte(`{"type": "object", "oneOf": []}`))
+	assert.ErrorIs(t, err, InvalidSchema)
+
+	_, err = parseJSONSchema([]byte(`{"type": "decimal"}`))
+	assert.ErrorIs(t, err, InvalidSchema)
+}
+
+func TestJSONCompatibility(t *testing.T) {
+	compatible := func(reader string, writer string) []string {
+		r, err := parseJSONSchema([]byte(reader))
+		require.NoError(t, err)
+
+		w, err := parseJSONSchema([]byte(writer))
+		require.NoError(t, err)
+
+		return jsonCompatible(r, w, "#", nil)
+	}
+
+	base := `{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"], "additionalProperties": false}`
+
+	// Adding an optional property to a closed model can read old data.
+	assert.Empty(t, compatible(`{"type": "object", "properties": {"id": {"type": "number"}, "name": {"type": "string"}}, "required": ["id"], "additionalProperties": false}`, base))
+
+	assert.NotEmpty(t, compatible(`{"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}, "required": ["id", "name"]}`, base))
+	assert.NotEmpty(t, compatible(`{"type": "object", "properties": {"id": {"type": "string"}}}`, base))
+	assert.NotEmpty(t, compatible(`{"type": "object", "properties": {"id": {"type": "integer", "maximum": 10}}}`, base))
+
+	// Removing a property from a closed model rejects old data.
+	assert.NotEmpty(t, compatible(`{"type": "object", "additionalProperties": false}`, base))
+
+	// Adding a typed property to an open model may reject what was written as an additional property.
+	assert.NotEmpty(t, compatible(`{"type": "object", "properties": {"name": {"type": "string"}}}`, `{"type": "object"}`))
+
+	assert.Empty(t, compatible(`{"enum": ["a", "b"]}`, `{"enum": ["a"]}`))
+	assert.NotEmpty(t, compatible(`{"enum": ["a"]}`, `{"enum": ["a", "b"]}`))
+}
+
+func testDescriptors(fieldType descriptorpb.FieldDescriptorProto_Type, extra ...*descriptorpb.FieldDescriptorProto) []byte {
+	fields := append([]*descriptorpb.FieldDescriptorProto{
+		{Name: proto.String("id"), Number: proto.Int32(1), Type: fieldType.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+	}, extra...)
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("orders.proto"),
+		Package:     proto.String("shop"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Order"), Field: fields}},
+	}}}
+
+	data, err := proto.Marshal(set)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func TestRegistry(t *testing.T) {
+	dir, err := os.MkdirTemp("", "schema_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r, err := OpenRegistry(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	v1, err := r.Register("orders", jsonDef(`{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, v1.Version)
+	assert.Equal(t, int64(1), v1.Schema.ID)
+
+	// Registering the same schema again returns its version.
+	again, err := r.Register("orders", v1.Schema)
+	require.NoError(t, err)
+	assert.Equal(t, v1, again)
+
+	// Backward compatibility is the default, new versions can't require new properties.
+	incompatible := jsonDef(`{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id", "name"]}`)
+	_, err = r.Register("orders", incompatible)
+	assert.ErrorIs(t, err, IncompatibleSchema)
+	assert.ErrorIs(t, r.CheckCompatibility("orders", incompatible), IncompatibleSchema)
+
+	v2, err := r.Register("orders", jsonDef(`{"type": "object", "properties": {"id": {"type": "number"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, v2.Version)
+
+	// Forward compatibility: the previous version must read what the new one writes.
+	require.NoError(t, r.SetCompatibility("orders", CompatibilityForward))
+	_, err = r.Register("orders", jsonDef(`{"type": "object", "properties": {"id": {"type": "string"}}}`))
+	assert.ErrorIs(t, err, IncompatibleSchema)
+
+	assert.ErrorIs(t, r.SetCompatibility("orders", "sideways"), InvalidCompatibility)
+
+	require.NoError(t, r.Validate("orders", v1.Schema.ID, []byte(`{"id": 1}`)))
+	assert.ErrorIs(t, r.Validate("orders", v1.Schema.ID, []byte(`{"id": "1"}`)), InvalidPayload)
+	assert.ErrorIs(t, r.Validate("orders", 42, []byte(`{}`)), UnknownSchema)
+	assert.ErrorIs(t, r.Validate("shipping", v1.Schema.ID, []byte(`{"id": 1}`)), InvalidPayload)
+
+	// Schemas are shared between subjects.
+	shared, err := r.Register("invoices", v1.Schema)
+	require.NoError(t, err)
+	assert.Equal(t, v1.Schema.ID, shared.Schema.ID)
+	assert.Equal(t, 1, shared.Version)
+
+	p1, err := r.Register("payments", Schema{Type: TypeProtobuf, Definition: testDescriptors(descriptorpb.FieldDescriptorProto_TYPE_INT64)})
+	require.NoError(t, err)
+	assert.Equal(t, "shop.Order", p1.Schema.MessageName)
+
+	// The varint of the id is cut off.
+	assert.ErrorIs(t, r.Validate("payments", p1.Schema.ID, []byte{0x08}), InvalidPayload)
+	assert.NoError(t, r.Validate("payments", p1.Schema.ID, []byte{0x08, 0x01}))
+
+	name := &descriptorpb.FieldDescriptorProto{Name: proto.String("name"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()}
+
+	_, err = r.Register("payments", Schema{Type: TypeProtobuf, Definition: testDescriptors(descriptorpb.FieldDescriptorProto_TYPE_STRING)})
+	assert.ErrorIs(t, err, IncompatibleSchema)
+
+	p2, err := r.Register("payments", Schema{Type: TypeProtobuf, Definition: testDescriptors(descriptorpb.FieldDescriptorProto_TYPE_UINT64, name)})
+	require.NoError(t, err)
+	assert.Equal(t, 2, p2.Version)
+
+	_, err = r.Register("payments", v1.Schema)
+	assert.ErrorIs(t, err, IncompatibleSchema)
+
+	assert.Equal(t, []string{"invoices", "orders", "payments"}, r.Subjects())
+
+	deleted, err := r.DeleteSubject("invoices")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, deleted)
+
+	require.NoError(t, r.Stop())
+
+	r, err = OpenRegistry(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	assert.Equal(t, []string{"orders", "payments"}, r.Subjects())
+	assert.Equal(t, CompatibilityForward, r.Compatibility("orders"))
+	assert.Equal(t, DefaultCompatibility, r.Compatibility("payments"))
+
+	latest, err := r.Version("payments", LatestVersion)
+	require.NoError(t, err)
+	assert.Equal(t, p2, latest)
+
+	_, err = r.Version("payments", 3)
+	assert.ErrorIs(t, err, UnknownVersion)
+
+	// IDs are not reused after a restart.
+	v3, err := r.Register("orders", jsonDef(`{"type": "object", "properties": {"id": {"type": "number"}}, "additionalProperties": true}`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), v3.Schema.ID)
+	assert.Equal(t, 3, v3.Version)
+}
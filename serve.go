@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"iris/auth"
+	"iris/broker"
+	"iris/config"
+	"iris/controller"
+	"iris/kafka"
+	"iris/quota"
+	"iris/server"
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// aclsDirName is the directory of the ACL table in the data directory, the
+// prefix keeps it apart from topics, whose names can't start with it.
+const aclsDirName = "__acls"
+
+// controllerDirName is the directory of the raft log of the controller in the data directory.
+const controllerDirName = "__controller"
+
+// parseServeFlags returns the path of the config file, the settings themselves are in the
+// file and the IRIS_ environment variables.
+func parseServeFlags(args []string) (string, error) {
+	var path string
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&path, "config", "", "yaml config file, the defaults and the IRIS_ environment apply without it")
+
+	return path, fs.Parse(args)
+}
+
+// brokerOptions maps the storage settings of the config to the broker options.
+func brokerOptions(c config.Config) broker.Options {
+	dynamic := dynamicOptions(c)
+
+	options := broker.DefaultOptions(c.DataDir)
+	options.NodeID = c.NodeID
+	options.SegmentSize = c.Storage.SegmentSize
+	options.Journal = dynamic.Journal
+	options.SyncInterval = dynamic.SyncInterval
+	options.RetentionInterval = dynamic.RetentionInterval
+
+	return options
+}
+
+// dynamicOptions maps the storage settings of the config which change without a restart.
+func dynamicOptions(c config.Config) broker.DynamicOptions {
+	options := broker.DynamicOptions{
+		Journal:           storage.DefaultJournalOptions(),
+		RetentionInterval: c.Storage.RetentionInterval,
+	}
+
+	options.Journal.IndexIntervalBytes = c.Storage.Index.IntervalBytes
+	options.Journal.RetentionTime = c.Storage.Retention.Time
+	options.Journal.RetentionBytes = c.Storage.Retention.Bytes
+
+	switch c.Storage.Fsync.Policy {
+	case config.FsyncAlways:
+		options.Journal.SyncEveryAppend = true
+	case config.FsyncInterval:
+		options.SyncInterval = c.Storage.Fsync.Interval
+	}
+
+	return options
+}
+
+// controllerOptions maps the controller settings of the config to the controller options.
+func controllerOptions(c config.Config) controller.Options {
+	options := controller.DefaultOptions(filepath.Join(c.DataDir, controllerDirName), c.NodeID, c.Controller.Members)
+	options.SegmentSize = c.Storage.SegmentSize
+
+	return options
+}
+
+// quotaOptions maps the quotas of the config to the quota manager options.
+func quotaOptions(c config.Config) quota.Options {
+	return quota.Options{
+		Default:     c.Quotas.Default,
+		Clients:     c.Quotas.Clients,
+		Burst:       c.Quotas.Burst,
+		MaxThrottle: c.Quotas.MaxThrottle,
+	}
+}
+
+// serve runs the broker with all of its listeners until SIGINT or SIGTERM.
+func serve(logger log.Logger, args []string) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	return serveUntil(logger, args, signals)
+}
+
+// serveUntil runs the broker until SIGINT or SIGTERM is received from signals, SIGHUP reloads
+// the config. In-flight requests are drained before the partitions are closed.
+func serveUntil(logger log.Logger, args []string, signals <-chan os.Signal) error {
+	path, err := parseServeFlags(args)
+
+	if err != nil {
+		return err
+	}
+
+	c, err := config.Load(path)
+
+	if err != nil {
+		return err
+	}
+
+	levels := newLevelLogger(logger, c.LogLevel)
+	logger = levels
+
+	serverTLS, err := c.TLS.TLSConfig()
+
+	if err != nil {
+		return err
+	}
+
+	clientTLS, err := c.TLS.ClientTLSConfig()
+
+	if err != nil {
+		return err
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	options := brokerOptions(c)
+	creds := insecure.NewCredentials()
+
+	if clientTLS != nil {
+		creds = credentials.NewTLS(clientTLS)
+	}
+
+	if len(c.Peers) > 0 {
+		replicas := server.NewReplicaClient(c.Peers, grpc.WithTransportCredentials(creds))
+		defer replicas.Close()
+
+		options.ReplicaClient = replicas
+	}
+
+	b, err := broker.NewBroker(log.With(logger, "component", "broker"), registry, options)
+
+	if err != nil {
+		return errors.Wrap(err, "unable to open broker")
+	}
+
+	stopBroker := func() {
+		if err := b.Stop(); err != nil {
+			level.Error(logger).Log("msg", "error stopping broker", "err", err)
+		}
+	}
+
+	// The controller creates the topics of the cluster metadata on the broker and moves the
+	// leadership of their partitions. Without one the broker only serves its local topics.
+	var ctrl *controller.Controller
+
+	if len(c.Controller.Members) > 0 {
+		raftClient := server.NewRaftClient(c.Peers, grpc.WithTransportCredentials(creds))
+		defer raftClient.Close()
+
+		ctrl, err = controller.NewController(log.With(logger, "component", "controller"), registry, controllerOptions(c), raftClient)
+
+		if err != nil {
+			stopBroker()
+			return errors.Wrap(err, "unable to open controller")
+		}
+	}
+
+	stopController := func() {
+		if ctrl == nil {
+			return
+		}
+
+		if err := ctrl.Stop(); err != nil {
+			level.Error(logger).Log("msg", "error stopping controller", "err", err)
+		}
+	}
+
+	var authorizer *auth.Authorizer
+
+	if c.Auth.Authorize {
+		authorizer, err = auth.OpenAuthorizer(log.With(logger, "component", "authorizer"), registry,
+			filepath.Join(c.DataDir, aclsDirName), c.Storage.SegmentSize, c.Auth.SuperUsers)
+
+		if err != nil {
+			stopController()
+			stopBroker()
+			return err
+		}
+
+		defer authorizer.Stop()
+	}
+
+	var authenticator *auth.Authenticator
+
+	if c.Auth.TokensFile != "" {
+		authenticator, err = auth.NewAuthenticator(log.With(logger, "component", "auth"), registry, auth.Options{
+			TokensFile:     c.Auth.TokensFile,
+			AllowAnonymous: c.Auth.AllowAnonymous,
+		})
+
+		if err != nil {
+			stopController()
+			stopBroker()
+			return err
+		}
+	}
+
+	quotas := quota.NewManager(log.With(logger, "component", "quota"), registry, quotaOptions(c))
+
+	srv := &servers{logger: logger, errc: make(chan error, 4)}
+
+	var grpcOptions []grpc.ServerOption
+
+	if serverTLS != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(serverTLS)))
+	}
+
+	reloader := &reloader{logger: logger, path: path, config: c, loaded: c, broker: b, quotas: quotas, levels: levels}
+
+	grpcService := server.NewGRPCService(log.With(logger, "component", "grpc"), b, authenticator, authorizer, quotas)
+	grpcService.SetReloader(reloader)
+
+	if ctrl != nil {
+		grpcService.SetController(ctrl)
+	}
+
+	grpcServer := server.NewGRPCServer(grpcService, grpcOptions...)
+
+	// A listener which can't be opened stops the ones opened before it.
+	fail := func(err error) error {
+		grpcServer.Stop()
+		srv.closeListeners()
+		stopController()
+		stopBroker()
+
+		return err
+	}
+
+	if err := srv.listen("grpc", c.Listeners.GRPC, nil, func(lis net.Listener) error { return grpcServer.Serve(lis) }); err != nil {
+		return fail(err)
+	}
+
+	var httpServer, metricsServer *http.Server
+
+	if c.Listeners.HTTP != "" {
+		httpServer = &http.Server{Handler: server.NewHTTPService(log.With(logger, "component", "http"), b, authenticator, authorizer, quotas).Handler()}
+
+		if err := srv.listen("http", c.Listeners.HTTP, serverTLS, httpServer.Serve); err != nil {
+			return fail(err)
+		}
+	}
+
+	if c.Listeners.Metrics != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		metricsServer = &http.Server{Handler: mux}
+
+		if err := srv.listen("metrics", c.Listeners.Metrics, nil, metricsServer.Serve); err != nil {
+			return fail(err)
+		}
+	}
+
+	var kafkaServer *kafka.Server
+
+	if c.Listeners.Kafka != "" {
+		kafkaOptions := kafka.DefaultOptions()
+		kafkaOptions.NodeID = c.NodeID
+		kafkaServer = kafka.NewServer(log.With(logger, "component", "kafka"), registry, b, authenticator, authorizer, kafkaOptions)
+
+		if err := srv.listen("kafka", c.Listeners.Kafka, serverTLS, kafkaServer.Serve); err != nil {
+			return fail(err)
+		}
+	}
+
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	synced := make(chan struct{})
+
+	go func() {
+		defer close(synced)
+
+		if ctrl == nil {
+			return
+		}
+
+		if err := ctrl.Sync(syncCtx, b); err != nil && !errors.Is(err, context.Canceled) {
+			level.Error(logger).Log("msg", "controller stopped syncing the broker", "err", err)
+		}
+	}()
+
+	level.Info(logger).Log("msg", "serving", "node", c.NodeID, "dir", c.DataDir, "tls", serverTLS != nil, "controller", ctrl != nil)
+
+	err = awaitShutdown(logger, reloader, signals, srv.errc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+	defer cancel()
+
+	// Listeners stop accepting first, then in-flight requests get until the timeout to finish.
+	drained := make(chan struct{})
+
+	go func() {
+		grpcServer.GracefulStop()
+		close(drained)
+	}()
+
+	for _, s := range []*http.Server{httpServer, metricsServer} {
+		if s != nil {
+			if err := s.Shutdown(ctx); err != nil {
+				level.Warn(logger).Log("msg", "error draining http requests", "err", err)
+			}
+		}
+	}
+
+	if kafkaServer != nil {
+		kafkaServer.Stop()
+	}
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		level.Warn(logger).Log("msg", "canceling grpc requests still running after the shutdown timeout")
+		grpcServer.Stop()
+	}
+
+	// The controller stops moving partitions before they are closed.
+	stopSync()
+	<-synced
+	stopController()
+
+	// Stopping the broker flushes and closes the wal of every partition.
+	stopBroker()
+
+	level.Info(logger).Log("msg", "broker stopped")
+
+	return err
+}
+
+// awaitShutdown reloads the config on SIGHUP until SIGINT, SIGTERM or a listener error,
+// which is returned.
+func awaitShutdown(logger log.Logger, r *reloader, signals <-chan os.Signal, errc <-chan error) error {
+	for {
+		select {
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				// An invalid config is not applied at all, the broker keeps running with the current one.
+				if _, err := r.Reload(); err != nil {
+					level.Error(logger).Log("msg", "error reloading config", "err", err)
+				}
+
+				continue
+			}
+
+			level.Info(logger).Log("msg", "shutting down", "signal", sig)
+
+			return nil
+		case err := <-errc:
+			level.Error(logger).Log("msg", "listener failed, shutting down", "err", err)
+
+			return err
+		}
+	}
+}
+
+// servers tracks the listeners of the broker, the first serve error ends the broker.
+type servers struct {
+	logger    log.Logger
+	listeners []net.Listener
+	errc      chan error
+}
+
+// listen serves on addr, over TLS when a config is given. gRPC does its own TLS handshakes.
+func (s *servers) listen(name string, addr string, tlsConfig *tls.Config, serve func(net.Listener) error) error {
+	lis, err := net.Listen("tcp", addr)
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to listen on %s for %s", addr, name)
+	}
+
+	if tlsConfig != nil {
+		lis = tls.NewListener(lis, tlsConfig)
+	}
+
+	s.listeners = append(s.listeners, lis)
+	level.Info(s.logger).Log("msg", "listening", "server", name, "addr", lis.Addr())
+
+	go func() {
+		err := serve(lis)
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, grpc.ErrServerStopped) && !errors.Is(err, kafka.ServerClosed) {
+			s.errc <- errors.Wrapf(err, "%s server failed", name)
+		}
+	}()
+
+	return nil
+}
+
+func (s *servers) closeListeners() {
+	for _, lis := range s.listeners {
+		lis.Close()
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"context"
+
+	"iris/api/irispb"
+	"iris/auth"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AdminService implements the Iris admin gRPC API, it shares the broker and
+// the authorizer of the service it is registered with.
+type AdminService struct {
+	irispb.UnimplementedIrisAdminServer
+
+	service *GRPCService
+}
+
+func (s *AdminService) authorizeAdmin(ctx context.Context) error {
+	return authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, "")
+}
+
+func (s *AdminService) authorizer() (*auth.Authorizer, error) {
+	if s.service.authz == nil {
+		return nil, status.Error(codes.FailedPrecondition, "authorization is disabled")
+	}
+
+	return s.service.authz, nil
+}
+
+func (s *AdminService) CreateACL(ctx context.Context, req *irispb.CreateACLRequest) (*irispb.CreateACLResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	a, err := s.authorizer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	acl, err := aclFromProto(req.GetAcl())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.Grant(acl); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.CreateACLResponse{}, nil
+}
+
+func (s *AdminService) DeleteACL(ctx context.Context, req *irispb.DeleteACLRequest) (*irispb.DeleteACLResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	a, err := s.authorizer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	acl, err := aclFromProto(req.GetAcl())
+
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := a.Revoke(acl)
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.DeleteACLResponse{Found: found}, nil
+}
+
+func (s *AdminService) ListACLs(ctx context.Context, req *irispb.ListACLsRequest) (*irispb.ListACLsResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	a, err := s.authorizer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	acls := a.ACLs()
+	res := &irispb.ListACLsResponse{Acls: make([]*irispb.ACL, 0, len(acls))}
+
+	for _, acl := range acls {
+		res.Acls = append(res.Acls, aclToProto(acl))
+	}
+
+	return res, nil
+}
+
+func (s *AdminService) DescribePartition(ctx context.Context, req *irispb.DescribePartitionRequest) (*irispb.DescribePartitionResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	p, err := s.service.broker.Partition(req.GetTopic(), int(req.GetPartition()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	start, err := p.Journal().StartOffset()
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &irispb.DescribePartitionResponse{
+		Leader:        p.Leader(),
+		HighWatermark: p.HighWatermark(),
+		StartOffset:   start,
+		EndOffset:     p.NextOffset(),
+		LeaderEpoch:   p.LeaderEpoch(),
+	}
+
+	for _, r := range p.Replicas() {
+		state := &irispb.ReplicaState{Id: r.ID, Offset: r.Offset, InSync: r.InSync}
+
+		if !r.Contact.IsZero() {
+			state.LastFetch = r.Contact.UnixMilli()
+		}
+
+		res.Replicas = append(res.Replicas, state)
+	}
+
+	return res, nil
+}
+
+func (s *AdminService) ReassignPartition(ctx context.Context, req *irispb.ReassignPartitionRequest) (*irispb.ReassignPartitionResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.broker.ReassignPartition(ctx, req.GetTopic(), int(req.GetPartition()), req.GetReplicas()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ReassignPartitionResponse{}, nil
+}
+
+func (s *AdminService) DeleteRecords(ctx context.Context, req *irispb.DeleteRecordsRequest) (*irispb.DeleteRecordsResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	start, err := s.service.broker.DeleteRecords(req.GetTopic(), int(req.GetPartition()), req.GetOffset())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.DeleteRecordsResponse{StartOffset: start}, nil
+}
+
+func (s *AdminService) ReloadConfig(ctx context.Context, req *irispb.ReloadConfigRequest) (*irispb.ReloadConfigResponse, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.service.reloader == nil {
+		return nil, status.Error(codes.FailedPrecondition, "config reload is disabled")
+	}
+
+	changes, err := s.service.reloader.Reload()
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ReloadConfigResponse{Applied: changes.Dynamic, RestartRequired: changes.Restart}, nil
+}
+
+var (
+	resourceTypes = map[irispb.ResourceType]auth.ResourceType{
+		irispb.ResourceType_RESOURCE_TYPE_TOPIC:   auth.ResourceTopic,
+		irispb.ResourceType_RESOURCE_TYPE_GROUP:   auth.ResourceGroup,
+		irispb.ResourceType_RESOURCE_TYPE_CLUSTER: auth.ResourceCluster,
+	}
+	patternTypes = map[irispb.PatternType]auth.PatternType{
+		irispb.PatternType_PATTERN_TYPE_LITERAL: auth.PatternLiteral,
+		irispb.PatternType_PATTERN_TYPE_PREFIX:  auth.PatternPrefix,
+	}
+	operations = map[irispb.Operation]auth.Operation{
+		irispb.Operation_OPERATION_PRODUCE: auth.OperationProduce,
+		irispb.Operation_OPERATION_CONSUME: auth.OperationConsume,
+		irispb.Operation_OPERATION_ADMIN:   auth.OperationAdmin,
+	}
+)
+
+func aclFromProto(acl *irispb.ACL) (auth.ACL, error) {
+	resourceType, ok := resourceTypes[acl.GetResourceType()]
+
+	if !ok {
+		return auth.ACL{}, status.Errorf(codes.InvalidArgument, "unknown resource type %s", acl.GetResourceType())
+	}
+
+	patternType, ok := patternTypes[acl.GetPatternType()]
+
+	if !ok {
+		return auth.ACL{}, status.Errorf(codes.InvalidArgument, "unknown pattern type %s", acl.GetPatternType())
+	}
+
+	operation, ok := operations[acl.GetOperation()]
+
+	if !ok {
+		return auth.ACL{}, status.Errorf(codes.InvalidArgument, "unknown operation %s", acl.GetOperation())
+	}
+
+	return auth.ACL{
+		Principal:    acl.GetPrincipal(),
+		ResourceType: resourceType,
+		PatternType:  patternType,
+		Name:         acl.GetName(),
+		Operation:    operation,
+	}, nil
+}
+
+func aclToProto(acl auth.ACL) *irispb.ACL {
+	res := &irispb.ACL{Principal: acl.Principal, Name: acl.Name}
+
+	for k, v := range resourceTypes {
+		if v == acl.ResourceType {
+			res.ResourceType = k
+		}
+	}
+
+	for k, v := range patternTypes {
+		if v == acl.PatternType {
+			res.PatternType = k
+		}
+	}
+
+	for k, v := range operations {
+		if v == acl.Operation {
+			res.Operation = k
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+
+	"iris/auth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authenticateGRPC returns the context of a gRPC call with its principal.
+func authenticateGRPC(ctx context.Context, a *auth.Authenticator) (context.Context, error) {
+	var authorization string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+
+	var state *tls.ConnectionState
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+
+	p, err := a.Authenticate(authorization, state)
+
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return auth.NewContext(ctx, p), nil
+}
+
+func authUnaryInterceptor(a *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateGRPC(ctx, a)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream replaces the context of a stream with the one carrying its principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authStreamInterceptor(a *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGRPC(ss.Context(), a)
+
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authMiddleware authenticates HTTP requests and adds their principal to their context.
+func (s *HTTPService) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.auth.Authenticate(r.Header.Get("Authorization"), r.TLS)
+
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="iris"`)
+			s.writeError(w, status.Error(codes.Unauthenticated, err.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	})
+}
+
+// authorize checks whether the principal of the context may perform the operation,
+// calls without a principal are made by the anonymous principal.
+func authorize(ctx context.Context, a *auth.Authorizer, op auth.Operation, resourceType auth.ResourceType, name string) error {
+	if a == nil {
+		return nil
+	}
+
+	p, ok := auth.FromContext(ctx)
+
+	if !ok {
+		p = auth.Principal{Name: auth.AnonymousName, Method: auth.MethodAnonymous}
+	}
+
+	return toStatus(a.Authorize(p, op, resourceType, name))
+}
+
+// authorizeGroup checks the operation on both the topic and the consumer group.
+func authorizeGroup(ctx context.Context, a *auth.Authorizer, op auth.Operation, topic string, group string) error {
+	if err := authorize(ctx, a, op, auth.ResourceTopic, topic); err != nil {
+		return err
+	}
+
+	return authorize(ctx, a, op, auth.ResourceGroup, group)
+}
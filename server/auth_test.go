@@ -0,0 +1,285 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/broker"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pair tls.Certificate
+}
+
+// newTestCert creates a certificate signed by the parent, or a self-signed CA without a parent.
+func newTestCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key, pair: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}}
+}
+
+func (c *testCert) write(t *testing.T, dir string, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+".key")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+
+	return certFile, keyFile
+}
+
+func TestHTTPMutualTLS(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "iris-ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "iris", ca).write(t, dir, "server")
+
+	config, err := auth.TLSOptions{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, ClientAuth: auth.ClientAuthVerify}.ServerConfig()
+	require.NoError(t, err)
+
+	tokensFile := filepath.Join(dir, "tokens")
+	require.NoError(t, os.WriteFile(tokensFile, []byte("billing secret\n"), 0o600))
+
+	a, err := auth.NewAuthenticator(log.NewNopLogger(), prometheus.NewRegistry(), auth.Options{TokensFile: tokensFile})
+	require.NoError(t, err)
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), broker.DefaultOptions(filepath.Join(dir, "data")))
+	require.NoError(t, err)
+	defer b.Stop()
+
+	s := httptest.NewUnstartedServer(NewHTTPService(log.NewNopLogger(), b, a, nil, nil).Handler())
+	s.TLS = config
+	s.StartTLS()
+	defer s.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}}}
+	}
+
+	get := func(c *http.Client, token string) int {
+		req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/topics/orders/partitions/0/messages", nil)
+		require.NoError(t, err)
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		res, err := c.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	// Authenticated requests get through to the broker, which does not know the topic.
+	assert.Equal(t, http.StatusUnauthorized, get(client(), ""))
+	assert.Equal(t, http.StatusUnauthorized, get(client(), "wrong"))
+	assert.Equal(t, http.StatusNotFound, get(client(), "secret"))
+	assert.Equal(t, http.StatusNotFound, get(client(newTestCert(t, "orders-service", ca).pair), ""))
+
+	// Certificates of other CAs are not accepted, clients don't send them.
+	other := newTestCert(t, "other-ca", nil)
+	assert.Equal(t, http.StatusUnauthorized, get(client(newTestCert(t, "orders-service", other).pair), ""))
+}
+
+func TestGRPCTokenAuth(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokensFile := filepath.Join(dir, "tokens")
+	require.NoError(t, os.WriteFile(tokensFile, []byte("billing secret\n"), 0o600))
+
+	a, err := auth.NewAuthenticator(log.NewNopLogger(), prometheus.NewRegistry(), auth.Options{TokensFile: tokensFile})
+	require.NoError(t, err)
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), broker.DefaultOptions(filepath.Join(dir, "data")))
+	require.NoError(t, err)
+	defer b.Stop()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := NewGRPCServer(NewGRPCService(log.NewNopLogger(), b, a, nil, nil))
+
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := irispb.NewIrisClient(conn)
+	req := &irispb.ProduceRequest{Topic: "orders", Messages: []*irispb.Message{{Value: []byte("v")}}}
+
+	_, err = client.Produce(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	_, err = client.Produce(ctx, req)
+	require.NoError(t, err)
+
+	stream, err := client.Subscribe(context.Background(), &irispb.SubscribeRequest{Topic: "orders"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream, err = client.Subscribe(ctx, &irispb.SubscribeRequest{Topic: "orders"})
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Len(t, res.GetMessages(), 1)
+}
+
+func TestGRPCAuthorization(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokensFile := filepath.Join(dir, "tokens")
+	require.NoError(t, os.WriteFile(tokensFile, []byte("root root-secret\nbilling secret\n"), 0o600))
+
+	a, err := auth.NewAuthenticator(log.NewNopLogger(), prometheus.NewRegistry(), auth.Options{TokensFile: tokensFile})
+	require.NoError(t, err)
+
+	authz, err := auth.OpenAuthorizer(log.NewNopLogger(), prometheus.NewRegistry(), filepath.Join(dir, "acls"), 32*1024*4, []string{"root"})
+	require.NoError(t, err)
+	defer authz.Stop()
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), broker.DefaultOptions(filepath.Join(dir, "data")))
+	require.NoError(t, err)
+	defer b.Stop()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := NewGRPCServer(NewGRPCService(log.NewNopLogger(), b, a, authz, nil))
+
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := irispb.NewIrisClient(conn)
+	admin := irispb.NewIrisAdminClient(conn)
+
+	root := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer root-secret")
+	billing := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	req := &irispb.ProduceRequest{Topic: "billing.invoices", Messages: []*irispb.Message{{Value: []byte("v")}}}
+
+	_, err = client.Produce(billing, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	acl := &irispb.ACL{
+		Principal:    "billing",
+		ResourceType: irispb.ResourceType_RESOURCE_TYPE_TOPIC,
+		PatternType:  irispb.PatternType_PATTERN_TYPE_PREFIX,
+		Name:         "billing.",
+		Operation:    irispb.Operation_OPERATION_PRODUCE,
+	}
+
+	_, err = admin.CreateACL(billing, &irispb.CreateACLRequest{Acl: acl})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = admin.CreateACL(root, &irispb.CreateACLRequest{Acl: acl})
+	require.NoError(t, err)
+
+	_, err = client.Produce(billing, req)
+	require.NoError(t, err)
+
+	// Producing doesn't allow consuming.
+	_, err = client.Fetch(billing, &irispb.FetchRequest{Topic: "billing.invoices"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Fetch(root, &irispb.FetchRequest{Topic: "billing.invoices"})
+	require.NoError(t, err)
+
+	list, err := admin.ListACLs(root, &irispb.ListACLsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetAcls(), 1)
+	assert.Equal(t, "billing.", list.GetAcls()[0].GetName())
+	assert.Equal(t, irispb.PatternType_PATTERN_TYPE_PREFIX, list.GetAcls()[0].GetPatternType())
+
+	deleted, err := admin.DeleteACL(root, &irispb.DeleteACLRequest{Acl: acl})
+	require.NoError(t, err)
+	assert.True(t, deleted.GetFound())
+
+	_, err = client.Produce(billing, req)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
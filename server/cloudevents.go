@@ -0,0 +1,359 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/broker"
+	"iris/cloudevents"
+	"iris/quota"
+	"iris/storage"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func validateEventsMode(mode irispb.CloudEventsMode) error {
+	switch mode {
+	case irispb.CloudEventsMode_CLOUD_EVENTS_MODE_NONE, irispb.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY,
+		irispb.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED:
+		return nil
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown CloudEvents mode %d", mode)
+	}
+}
+
+// eventFromStructured replaces a message holding a structured event by the event
+// in binary mode. Its key becomes the partitionkey extension if the event has none,
+// headers which are not attributes are kept.
+func eventFromStructured(msg *storage.Message) (*storage.Message, error) {
+	e, err := cloudevents.Parse(msg.Value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := e.Extensions[cloudevents.PartitionKey]; !ok && msg.Key != nil {
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+
+		e.Extensions[cloudevents.PartitionKey] = string(msg.Key)
+	}
+
+	res := e.ToMessage()
+	res.Timestamp = msg.Timestamp
+	res.ExpiresAt = msg.ExpiresAt
+	res.Headers = append(res.Headers, passthroughHeaders(msg.Headers)...)
+
+	return res, nil
+}
+
+// passthroughHeaders returns the headers which are not attributes of an event.
+func passthroughHeaders(headers []storage.Header) []storage.Header {
+	var res []storage.Header
+
+	for _, h := range headers {
+		if h.Key != cloudevents.ContentTypeHeader && !strings.HasPrefix(h.Key, cloudevents.HeaderPrefix) {
+			res = append(res, h)
+		}
+	}
+
+	return res
+}
+
+// eventsFromProduce converts produced messages to events stored in binary mode,
+// which they already are in binary mode and only have to be valid.
+func eventsFromProduce(mode irispb.CloudEventsMode, msgs []*storage.Message) error {
+	if mode == irispb.CloudEventsMode_CLOUD_EVENTS_MODE_NONE {
+		return nil
+	}
+
+	for i, msg := range msgs {
+		if mode == irispb.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY {
+			if _, err := cloudevents.FromMessage(msg); err != nil {
+				return errors.Wrapf(err, "message %d", i)
+			}
+
+			continue
+		}
+
+		e, err := eventFromStructured(msg)
+
+		if err != nil {
+			return errors.Wrapf(err, "message %d", i)
+		}
+
+		msgs[i] = e
+	}
+
+	return nil
+}
+
+// eventFilter matches valid events stored in binary mode which match the filter, if it is set.
+type eventFilter struct {
+	filter broker.Filter
+}
+
+func (f eventFilter) Match(msg *storage.Message) bool {
+	if !cloudevents.IsEvent(msg) {
+		return false
+	}
+
+	if _, err := cloudevents.FromMessage(msg); err != nil {
+		return false
+	}
+
+	return f.filter == nil || f.filter.Match(msg)
+}
+
+func (f eventFilter) String() string {
+	if f.filter == nil {
+		return "cloudevents"
+	}
+
+	return "cloudevents and (" + f.filter.String() + ")"
+}
+
+// eventsFilter restricts a fetch to events if a CloudEvents mode is set.
+func eventsFilter(mode irispb.CloudEventsMode, filter broker.Filter) broker.Filter {
+	if mode == irispb.CloudEventsMode_CLOUD_EVENTS_MODE_NONE {
+		return filter
+	}
+
+	return eventFilter{filter: filter}
+}
+
+// eventToStructured returns a copy of a message holding an event in binary mode with
+// the structured event as its value. Its key and headers which are not attributes are kept.
+func eventToStructured(msg *storage.Message) (*storage.Message, error) {
+	e, err := cloudevents.FromMessage(msg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(e)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "encode event")
+	}
+
+	res := *msg
+	res.Value = value
+	res.Headers = passthroughHeaders(msg.Headers)
+
+	return &res, nil
+}
+
+// eventsToProto converts fetched messages, events are sent in structured mode if it is set.
+func eventsToProto(mode irispb.CloudEventsMode, msgs []*storage.Message) ([]*irispb.Message, error) {
+	if mode != irispb.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED {
+		return messagesToProto(msgs), nil
+	}
+
+	ms := make([]*irispb.Message, 0, len(msgs))
+
+	for _, msg := range msgs {
+		e, err := eventToStructured(msg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, messageToProto(e))
+	}
+
+	return ms, nil
+}
+
+const (
+	// Response headers of fetched events, the offset to fetch next and the high watermark of the partition.
+	NextOffsetHeader    = "Iris-Next-Offset"
+	HighWatermarkHeader = "Iris-High-Watermark"
+
+	// Events are fetched in structured mode as a batch, or one at a time in binary mode.
+	EventsModeStructured = "structured"
+	EventsModeBinary     = "binary"
+)
+
+// produceEvents produces the events of the body, a single event in binary or
+// structured mode or a batch of events, depending on the content type.
+func (s *HTTPService) produceEvents(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationProduce, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	partition := -1
+
+	if value := r.URL.Query().Get("partition"); value != "" {
+		p, err := strconv.Atoi(value)
+
+		if err != nil {
+			s.writeError(w, errors.Wrapf(InvalidFormat, "invalid partition %q", value))
+			return
+		}
+
+		partition = p
+	}
+
+	acks, err := broker.ParseAcks(r.URL.Query().Get("acks"))
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBodySize))
+
+	if err != nil {
+		s.writeError(w, errors.Wrapf(InvalidFormat, "invalid request: %s", err))
+		return
+	}
+
+	var events []cloudevents.Event
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case cloudevents.StructuredContentType:
+		var e cloudevents.Event
+
+		e, err = cloudevents.Parse(body)
+		events = append(events, e)
+	case cloudevents.BatchContentType:
+		events, err = cloudevents.ParseBatch(body)
+	default:
+		var e cloudevents.Event
+
+		e, err = cloudevents.FromHTTP(r.Header, body)
+		events = append(events, e)
+	}
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	msgs := make([]*storage.Message, 0, len(events))
+
+	for _, e := range events {
+		msgs = append(msgs, e.ToMessage())
+	}
+
+	// Throttled before the append like gRPC produces.
+	throttled, err := s.throttle(r, quota.KindProduce, messagesSize(msgs))
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res, err := s.broker.Produce(r.Context(), broker.ProduceRequest{
+		Topic:     r.PathValue("topic"),
+		Partition: partition,
+		Messages:  msgs,
+		Acks:      acks,
+	})
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, httpProduceResponse{
+		Partition:      res.Partition,
+		BaseOffset:     res.BaseOffset,
+		ThrottleTimeMs: throttled.Milliseconds(),
+	})
+}
+
+// fetchEvents returns the events of a partition, messages which are not events are skipped.
+// In structured mode the events are sent as a batch, in binary mode the first event is the
+// response and there is no content if there is none.
+func (s *HTTPService) fetchEvents(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationConsume, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	req, _, err := fetchRequest(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+
+	switch mode {
+	case "", EventsModeStructured:
+	case EventsModeBinary:
+		req.MaxMessages = 1
+	default:
+		s.writeError(w, errors.Wrapf(InvalidFormat, "unknown mode %q", mode))
+		return
+	}
+
+	req.Filter = eventFilter{filter: req.Filter}
+
+	res, err := s.broker.Fetch(req)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	events := make([]cloudevents.Event, 0, len(res.Messages))
+
+	for _, msg := range res.Messages {
+		e, err := cloudevents.FromMessage(msg)
+
+		if err != nil {
+			s.writeError(w, err)
+			return
+		}
+
+		events = append(events, e)
+	}
+
+	if _, err := s.throttle(r, quota.KindFetch, messagesSize(res.Messages)); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set(NextOffsetHeader, strconv.FormatUint(res.NextOffset, 10))
+	w.Header().Set(HighWatermarkHeader, strconv.FormatUint(res.HighWatermark, 10))
+
+	if mode != EventsModeBinary {
+		w.Header().Set("Content-Type", cloudevents.BatchContentType)
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(events); err != nil {
+			level.Debug(s.logger).Log("msg", "error writing response", "err", err)
+		}
+
+		return
+	}
+
+	if len(events) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	events[0].WriteHTTPHeaders(w.Header())
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(events[0].Data); err != nil {
+		level.Debug(s.logger).Log("msg", "error writing response", "err", err)
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"iris/api/irispb"
+	"iris/cloudevents"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGRPCCloudEvents(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, stop := newTestClient(t, dir)
+	defer stop()
+
+	ctx := context.Background()
+
+	structured := `{"specversion": "1.0", "id": "1", "source": "/orders", "type": "order.created", "datacontenttype": "application/json", "data": {"id": 1}}`
+
+	_, err = client.Produce(ctx, &irispb.ProduceRequest{
+		Topic:           "orders",
+		Partition:       proto.Int32(0),
+		CloudeventsMode: irispb.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED,
+		Messages: []*irispb.Message{{
+			Key:     []byte("order-1"),
+			Value:   []byte(structured),
+			Headers: []*irispb.Header{{Key: "trace", Value: []byte("abc")}},
+		}},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Produce(ctx, &irispb.ProduceRequest{
+		Topic:           "orders",
+		CloudeventsMode: irispb.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED,
+		Messages:        []*irispb.Message{{Value: []byte(`{"specversion": "1.0"}`)}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Messages produced in binary mode must be events.
+	_, err = client.Produce(ctx, &irispb.ProduceRequest{
+		Topic:           "orders",
+		CloudeventsMode: irispb.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY,
+		Messages:        []*irispb.Message{{Value: []byte("plain")}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Produce(ctx, &irispb.ProduceRequest{
+		Topic:     "orders",
+		Partition: proto.Int32(0),
+		Messages:  []*irispb.Message{{Value: []byte("plain")}},
+	})
+	require.NoError(t, err)
+
+	// The event is stored in binary mode.
+	res, err := client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders", CloudeventsMode: irispb.CloudEventsMode_CLOUD_EVENTS_MODE_BINARY})
+	require.NoError(t, err)
+	require.Len(t, res.GetMessages(), 1)
+
+	msg := res.GetMessages()[0]
+	assert.Equal(t, []byte("order-1"), msg.GetKey())
+	assert.Equal(t, []byte(`{"id": 1}`), msg.GetValue())
+
+	headers := make(map[string]string)
+
+	for _, h := range msg.GetHeaders() {
+		headers[h.GetKey()] = string(h.GetValue())
+	}
+
+	assert.Equal(t, map[string]string{
+		"ce_id":          "1",
+		"ce_source":      "/orders",
+		"ce_specversion": "1.0",
+		"ce_type":        "order.created",
+		"content-type":   "application/json",
+		"trace":          "abc",
+	}, headers)
+
+	// In structured mode the key is the partitionkey extension, other headers are kept.
+	res, err = client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders", CloudeventsMode: irispb.CloudEventsMode_CLOUD_EVENTS_MODE_STRUCTURED})
+	require.NoError(t, err)
+	require.Len(t, res.GetMessages(), 1)
+	assert.Equal(t, uint64(2), res.GetNextOffset())
+
+	msg = res.GetMessages()[0]
+	assert.JSONEq(t, `{"specversion": "1.0", "id": "1", "source": "/orders", "type": "order.created", "datacontenttype": "application/json", "data": {"id": 1}, "partitionkey": "order-1"}`, string(msg.GetValue()))
+	require.Len(t, msg.GetHeaders(), 1)
+	assert.Equal(t, "trace", msg.GetHeaders()[0].GetKey())
+
+	res, err = client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders"})
+	require.NoError(t, err)
+	assert.Len(t, res.GetMessages(), 2)
+}
+
+func TestHTTPCloudEvents(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, stop := newTestHTTPServer(t, dir)
+	defer stop()
+
+	post := func(header http.Header, body string) int {
+		req, err := http.NewRequest(http.MethodPost, s.URL+"/v1/topics/orders/cloudevents?partition=0", strings.NewReader(body))
+		require.NoError(t, err)
+
+		req.Header = header
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, post(http.Header{
+		"Ce-Specversion": {"1.0"},
+		"Ce-Id":          {"1"},
+		"Ce-Source":      {"/orders"},
+		"Ce-Type":        {"order.created"},
+		"Ce-Subject":     {"order%201"},
+		"Content-Type":   {"text/plain"},
+	}, "hello"))
+
+	assert.Equal(t, http.StatusOK, post(http.Header{"Content-Type": {cloudevents.BatchContentType}},
+		`[{"specversion": "1.0", "id": "2", "source": "/orders", "type": "order.created", "data": [1, 2]},
+		  {"specversion": "1.0", "id": "3", "source": "/orders", "type": "order.created", "data_base64": "AAE="}]`))
+
+	assert.Equal(t, http.StatusBadRequest, post(http.Header{"Ce-Id": {"4"}}, "hello"))
+
+	res, err := http.Get(s.URL + "/v1/topics/orders/partitions/0/cloudevents?offset=1")
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, cloudevents.BatchContentType, res.Header.Get("Content-Type"))
+	assert.Equal(t, "3", res.Header.Get(NextOffsetHeader))
+	assert.JSONEq(t, `[
+		{"specversion": "1.0", "id": "2", "source": "/orders", "type": "order.created", "data": [1, 2]},
+		{"specversion": "1.0", "id": "3", "source": "/orders", "type": "order.created", "data_base64": "AAE="}
+	]`, string(body))
+
+	res, err = http.Get(s.URL + "/v1/topics/orders/partitions/0/cloudevents?mode=binary")
+	require.NoError(t, err)
+
+	body, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, "text/plain", res.Header.Get("Content-Type"))
+	assert.Equal(t, "order%201", res.Header.Get("Ce-Subject"))
+	assert.Equal(t, "1", res.Header.Get(NextOffsetHeader))
+
+	res, err = http.Get(s.URL + "/v1/topics/orders/partitions/0/cloudevents?mode=binary&offset=3")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"time"
+
+	"iris/api/irispb"
+	"iris/storage"
+)
+
+// messageFromProto converts a produced message, its ttl counts from visibleAt.
+func messageFromProto(m *irispb.Message, visibleAt time.Time) *storage.Message {
+	msg := &storage.Message{
+		Key:   m.GetKey(),
+		Value: m.GetValue(),
+	}
+
+	if m.GetTimestamp() != 0 {
+		msg.Timestamp = time.UnixMilli(m.GetTimestamp())
+	}
+
+	if m.GetTtl() > 0 {
+		msg.ExpiresAt = visibleAt.Add(time.Duration(m.GetTtl()) * time.Millisecond)
+	}
+
+	for _, h := range m.GetHeaders() {
+		msg.Headers = append(msg.Headers, storage.Header{Key: h.GetKey(), Value: h.GetValue()})
+	}
+
+	return msg
+}
+
+func messagesFromProto(ms []*irispb.Message, visibleAt time.Time) []*storage.Message {
+	msgs := make([]*storage.Message, 0, len(ms))
+
+	for _, m := range ms {
+		msgs = append(msgs, messageFromProto(m, visibleAt))
+	}
+
+	return msgs
+}
+
+func messageToProto(msg *storage.Message) *irispb.Message {
+	m := &irispb.Message{
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp.UnixMilli(),
+		Key:       msg.Key,
+		Value:     msg.Value,
+	}
+
+	if !msg.ExpiresAt.IsZero() {
+		m.ExpiresAt = msg.ExpiresAt.UnixMilli()
+	}
+
+	for _, h := range msg.Headers {
+		m.Headers = append(m.Headers, &irispb.Header{Key: h.Key, Value: h.Value})
+	}
+
+	return m
+}
+
+func messagesToProto(msgs []*storage.Message) []*irispb.Message {
+	ms := make([]*irispb.Message, 0, len(msgs))
+
+	for _, msg := range msgs {
+		ms = append(ms, messageToProto(msg))
+	}
+
+	return ms
+}
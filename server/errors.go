@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+
+	"iris/auth"
+	"iris/broker"
+	"iris/cloudevents"
+	"iris/config"
+	"iris/controller"
+	"iris/raft"
+	"iris/schema"
+	"iris/storage"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps broker and storage errors to gRPC status codes.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var code codes.Code
+
+	switch {
+	case errors.Is(err, broker.UnknownTopic), errors.Is(err, broker.UnknownPartition), errors.Is(err, schema.UnknownSchema),
+		errors.Is(err, schema.UnknownSubject), errors.Is(err, schema.UnknownVersion), errors.Is(err, storage.UnknownSegmentFile):
+		code = codes.NotFound
+	case errors.Is(err, broker.TopicExists):
+		code = codes.AlreadyExists
+	case errors.Is(err, broker.InvalidName), errors.Is(err, broker.InvalidPartitions), errors.Is(err, broker.NoMessages),
+		errors.Is(err, broker.InvalidPolicy), errors.Is(err, broker.InvalidTopicConfig), errors.Is(err, broker.InvalidFilter),
+		errors.Is(err, InvalidDelivery), errors.Is(err, auth.InvalidACL), errors.Is(err, schema.InvalidSchema),
+		errors.Is(err, schema.InvalidPayload), errors.Is(err, schema.InvalidCompatibility), errors.Is(err, cloudevents.InvalidEvent),
+		errors.Is(err, controller.InvalidCommand), errors.Is(err, broker.InvalidAcks):
+		code = codes.InvalidArgument
+	case errors.Is(err, broker.NotQueue), errors.Is(err, broker.NotLeased), errors.Is(err, schema.IncompatibleSchema),
+		errors.Is(err, broker.NotLeader), errors.Is(err, broker.UnknownReplica), errors.Is(err, raft.NotLeader),
+		errors.Is(err, controller.NoBrokers), errors.Is(err, storage.JournalNotEmpty), errors.Is(err, broker.UncleanLeaderElection),
+		errors.Is(err, broker.StaleLeaderEpoch), errors.Is(err, config.InvalidConfig):
+		code = codes.FailedPrecondition
+	case errors.Is(err, auth.PermissionDenied):
+		code = codes.PermissionDenied
+	case errors.Is(err, storage.OffsetOutOfRange):
+		code = codes.OutOfRange
+	case errors.Is(err, broker.BrokerClosed), errors.Is(err, raft.NodeStopped), errors.Is(err, broker.NotEnoughReplicas),
+		errors.Is(err, broker.NotEnoughReplicasAfterAppend):
+		code = codes.Unavailable
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
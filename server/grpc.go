@@ -0,0 +1,418 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/broker"
+	"iris/config"
+	"iris/controller"
+	"iris/quota"
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCService implements the Iris gRPC API on top of a broker.
+type GRPCService struct {
+	irispb.UnimplementedIrisServer
+
+	logger log.Logger
+	broker *broker.Broker
+	// Calls are not authenticated without an authenticator and not authorized without an authorizer.
+	auth  *auth.Authenticator
+	authz *auth.Authorizer
+	// Clients are not throttled without quotas.
+	quotas *quota.Manager
+	// The raft API is only served with a controller.
+	controller *controller.Controller
+	// The config is only reloaded through the admin API with a reloader.
+	reloader ConfigReloader
+}
+
+// ConfigReloader reloads the config of the broker and reports the settings which changed.
+type ConfigReloader interface {
+	Reload() (config.Changes, error)
+}
+
+func NewGRPCService(logger log.Logger, b *broker.Broker, authenticator *auth.Authenticator, authorizer *auth.Authorizer, quotas *quota.Manager) *GRPCService {
+	return &GRPCService{
+		logger: logger,
+		broker: b,
+		auth:   authenticator,
+		authz:  authorizer,
+		quotas: quotas,
+	}
+}
+
+// SetController serves the raft API of the controller, it must be called before the server is created.
+func (s *GRPCService) SetController(c *controller.Controller) {
+	s.controller = c
+}
+
+// SetReloader serves config reloads through the admin API.
+func (s *GRPCService) SetReloader(r ConfigReloader) {
+	s.reloader = r
+}
+
+// NewGRPCServer creates a gRPC server with the service registered,
+// the principal of every call is put into its context.
+func NewGRPCServer(service *GRPCService, opts ...grpc.ServerOption) *grpc.Server {
+	if service.auth != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(authUnaryInterceptor(service.auth)),
+			grpc.ChainStreamInterceptor(authStreamInterceptor(service.auth)),
+		)
+	}
+
+	s := grpc.NewServer(opts...)
+	irispb.RegisterIrisServer(s, service)
+	irispb.RegisterIrisAdminServer(s, &AdminService{service: service})
+	irispb.RegisterIrisSchemaRegistryServer(s, &SchemaService{service: service})
+	irispb.RegisterIrisReplicationServer(s, &ReplicationService{service: service})
+
+	if service.controller != nil {
+		irispb.RegisterIrisRaftServer(s, &RaftService{service: service, controller: service.controller})
+	}
+
+	return s
+}
+
+func (s *GRPCService) CreateTopic(ctx context.Context, req *irispb.CreateTopicRequest) (*irispb.CreateTopicResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationAdmin, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	config := broker.TopicConfig{
+		Partitions:            int(req.GetPartitions()),
+		VisibilityTimeout:     time.Duration(req.GetVisibilityTimeout()) * time.Millisecond,
+		MaxDeliveries:         int(req.GetMaxDeliveries()),
+		MinInSyncReplicas:     int(req.GetMinInsyncReplicas()),
+		UncleanLeaderElection: req.GetUncleanLeaderElection(),
+	}
+
+	for _, replicas := range req.GetReplicas() {
+		config.Replicas = append(config.Replicas, replicas.GetReplicas())
+	}
+
+	switch req.GetMode() {
+	case irispb.TopicMode_TOPIC_MODE_STREAM:
+		config.Mode = broker.StreamMode
+	case irispb.TopicMode_TOPIC_MODE_QUEUE:
+		config.Mode = broker.QueueMode
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown topic mode %d", req.GetMode())
+	}
+
+	_, err := s.broker.CreateTopic(req.GetTopic(), config)
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.CreateTopicResponse{}, nil
+}
+
+func (s *GRPCService) Produce(ctx context.Context, req *irispb.ProduceRequest) (*irispb.ProduceResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationProduce, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	partition := -1
+
+	if req.Partition != nil {
+		partition = int(req.GetPartition())
+	}
+
+	deliverAt, visibleAt, err := deliveryTimes(req.GetDeliverAt(), req.GetDelay(), time.Now())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	msgs := messagesFromProto(req.GetMessages(), visibleAt)
+
+	if err := validateEventsMode(req.GetCloudeventsMode()); err != nil {
+		return nil, err
+	}
+
+	if err := eventsFromProduce(req.GetCloudeventsMode(), msgs); err != nil {
+		return nil, toStatus(err)
+	}
+
+	var acks broker.Acks
+
+	switch req.GetAcks() {
+	case irispb.Acks_ACKS_LEADER:
+		acks = broker.AcksLeader
+	case irispb.Acks_ACKS_NONE:
+		acks = broker.AcksNone
+	case irispb.Acks_ACKS_ALL:
+		acks = broker.AcksAll
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown acks %d", req.GetAcks())
+	}
+
+	// Produces are throttled before the append, a request canceled while it is delayed
+	// hasn't written anything the client could duplicate by retrying.
+	throttled, err := s.throttle(ctx, quota.KindProduce, messagesSize(msgs))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res, err := s.broker.Produce(ctx, broker.ProduceRequest{
+		Topic:     req.GetTopic(),
+		Partition: partition,
+		Messages:  msgs,
+		DeliverAt: deliverAt,
+		Acks:      acks,
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ProduceResponse{
+		Partition:      int32(res.Partition),
+		BaseOffset:     res.BaseOffset,
+		Delayed:        res.Delayed,
+		ThrottleTimeMs: int32(throttled.Milliseconds()),
+	}, nil
+}
+
+func (s *GRPCService) Fetch(ctx context.Context, req *irispb.FetchRequest) (*irispb.FetchResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationConsume, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	if err := validateEventsMode(req.GetCloudeventsMode()); err != nil {
+		return nil, err
+	}
+
+	filter, err := parseFilter(req.GetFilter())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res, err := s.broker.Fetch(broker.FetchRequest{
+		Topic:       req.GetTopic(),
+		Partition:   int(req.GetPartition()),
+		Offset:      req.GetOffset(),
+		MaxMessages: int(req.GetMaxMessages()),
+		Filter:      eventsFilter(req.GetCloudeventsMode(), filter),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	msgs, err := eventsToProto(req.GetCloudeventsMode(), res.Messages)
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	throttled, err := s.throttle(ctx, quota.KindFetch, messagesSize(res.Messages))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.FetchResponse{
+		Messages:       msgs,
+		HighWatermark:  res.HighWatermark,
+		NextOffset:     res.NextOffset,
+		ThrottleTimeMs: int32(throttled.Milliseconds()),
+	}, nil
+}
+
+func (s *GRPCService) Subscribe(req *irispb.SubscribeRequest, stream irispb.Iris_SubscribeServer) error {
+	if err := authorize(stream.Context(), s.authz, auth.OperationConsume, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return err
+	}
+
+	if err := validateEventsMode(req.GetCloudeventsMode()); err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(req.GetFilter())
+
+	if err != nil {
+		return toStatus(err)
+	}
+
+	err = subscribe(stream.Context(), s.broker, broker.FetchRequest{
+		Topic:       req.GetTopic(),
+		Partition:   int(req.GetPartition()),
+		Offset:      req.GetOffset(),
+		MaxMessages: int(req.GetMaxMessages()),
+		Filter:      eventsFilter(req.GetCloudeventsMode(), filter),
+	}, func(res broker.FetchResult) error {
+		msgs, err := eventsToProto(req.GetCloudeventsMode(), res.Messages)
+
+		if err != nil {
+			return err
+		}
+
+		throttled, err := s.throttle(stream.Context(), quota.KindFetch, messagesSize(res.Messages))
+
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(&irispb.SubscribeResponse{
+			Messages:       msgs,
+			HighWatermark:  res.HighWatermark,
+			NextOffset:     res.NextOffset,
+			ThrottleTimeMs: int32(throttled.Milliseconds()),
+		})
+	})
+
+	return toStatus(err)
+}
+
+func (s *GRPCService) Commit(ctx context.Context, req *irispb.CommitRequest) (*irispb.CommitResponse, error) {
+	if err := authorizeGroup(ctx, s.authz, auth.OperationConsume, req.GetTopic(), req.GetGroup()); err != nil {
+		return nil, err
+	}
+
+	err := s.broker.Commit(req.GetGroup(), req.GetTopic(), int(req.GetPartition()), req.GetOffset())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.CommitResponse{}, nil
+}
+
+func (s *GRPCService) Committed(ctx context.Context, req *irispb.CommittedRequest) (*irispb.CommittedResponse, error) {
+	if err := authorizeGroup(ctx, s.authz, auth.OperationConsume, req.GetTopic(), req.GetGroup()); err != nil {
+		return nil, err
+	}
+
+	offset, ok, err := s.broker.Committed(req.GetGroup(), req.GetTopic(), int(req.GetPartition()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.CommittedResponse{Found: ok, Offset: offset}, nil
+}
+
+func (s *GRPCService) Nack(ctx context.Context, req *irispb.NackRequest) (*irispb.NackResponse, error) {
+	if err := authorizeGroup(ctx, s.authz, auth.OperationConsume, req.GetTopic(), req.GetGroup()); err != nil {
+		return nil, err
+	}
+
+	res, err := s.broker.Nack(req.GetGroup(), req.GetTopic(), int(req.GetPartition()), req.GetOffset(), req.GetReason())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.NackResponse{
+		Attempts:     int32(res.Attempts),
+		DeadLettered: res.DeadLettered,
+	}, nil
+}
+
+func (s *GRPCService) SetDeadLetterPolicy(ctx context.Context, req *irispb.SetDeadLetterPolicyRequest) (*irispb.SetDeadLetterPolicyResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationAdmin, auth.ResourceGroup, req.GetGroup()); err != nil {
+		return nil, err
+	}
+
+	err := s.broker.SetDeadLetterPolicy(req.GetGroup(), broker.DeadLetterPolicy{MaxAttempts: int(req.GetMaxAttempts())})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.SetDeadLetterPolicyResponse{}, nil
+}
+
+func (s *GRPCService) Receive(ctx context.Context, req *irispb.ReceiveRequest) (*irispb.ReceiveResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationConsume, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	leases, err := s.broker.Receive(broker.ReceiveRequest{
+		Topic:             req.GetTopic(),
+		MaxMessages:       int(req.GetMaxMessages()),
+		VisibilityTimeout: time.Duration(req.GetVisibilityTimeout()) * time.Millisecond,
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	msgs := make([]*storage.Message, 0, len(leases))
+
+	for _, l := range leases {
+		msgs = append(msgs, l.Message)
+	}
+
+	throttled, err := s.throttle(ctx, quota.KindFetch, messagesSize(msgs))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &irispb.ReceiveResponse{
+		Messages:       make([]*irispb.LeasedMessage, 0, len(leases)),
+		ThrottleTimeMs: int32(throttled.Milliseconds()),
+	}
+
+	for _, l := range leases {
+		res.Messages = append(res.Messages, &irispb.LeasedMessage{
+			Partition:  int32(l.Partition),
+			Message:    messageToProto(l.Message),
+			Deliveries: int32(l.Deliveries),
+			Deadline:   l.Deadline.UnixMilli(),
+		})
+	}
+
+	return res, nil
+}
+
+func (s *GRPCService) Ack(ctx context.Context, req *irispb.AckRequest) (*irispb.AckResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationConsume, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	err := s.broker.Ack(req.GetTopic(), int(req.GetPartition()), req.GetOffset())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.AckResponse{}, nil
+}
+
+func (s *GRPCService) Release(ctx context.Context, req *irispb.ReleaseRequest) (*irispb.ReleaseResponse, error) {
+	if err := authorize(ctx, s.authz, auth.OperationConsume, auth.ResourceTopic, req.GetTopic()); err != nil {
+		return nil, err
+	}
+
+	res, err := s.broker.Release(req.GetTopic(), int(req.GetPartition()), req.GetOffset(), req.GetReason())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ReleaseResponse{
+		Deliveries:   int32(res.Deliveries),
+		DeadLettered: res.DeadLettered,
+	}, nil
+}
+
+// throttle applies the quota of the calling client.
+func (s *GRPCService) throttle(ctx context.Context, kind quota.Kind, bytes int) (time.Duration, error) {
+	return throttle(ctx, s.quotas, quotaClient(ctx, grpcClientID(ctx)), kind, bytes)
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"iris/api/irispb"
+	"iris/broker"
+	"iris/schema"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, dir string) (irispb.IrisClient, func()) {
+	conn, stop := newTestConn(t, dir)
+
+	return irispb.NewIrisClient(conn), stop
+}
+
+func newTestConn(t *testing.T, dir string) (*grpc.ClientConn, func()) {
+	options := broker.DefaultOptions(dir)
+	options.SegmentSize = 32 * 1024 * 4
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+	require.NoError(t, err)
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := NewGRPCServer(NewGRPCService(log.NewNopLogger(), b, nil, nil, nil))
+
+	go s.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	return conn, func() {
+		conn.Close()
+		s.Stop()
+		b.Stop()
+	}
+}
+
+func TestGRPCProduceFetchNack(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, stop := newTestClient(t, dir)
+	defer stop()
+
+	ctx := context.Background()
+
+	_, err = client.CreateTopic(ctx, &irispb.CreateTopicRequest{Topic: "orders", Partitions: 1})
+	require.NoError(t, err)
+
+	produced, err := client.Produce(ctx, &irispb.ProduceRequest{
+		Topic:    "orders",
+		Messages: []*irispb.Message{{Key: []byte("k"), Value: []byte("v"), Headers: []*irispb.Header{{Key: "h", Value: []byte("1")}}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), produced.GetBaseOffset())
+
+	fetched, err := client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders"})
+	require.NoError(t, err)
+	require.Len(t, fetched.GetMessages(), 1)
+	assert.Equal(t, []byte("v"), fetched.GetMessages()[0].GetValue())
+	assert.Equal(t, "h", fetched.GetMessages()[0].GetHeaders()[0].GetKey())
+
+	_, err = client.SetDeadLetterPolicy(ctx, &irispb.SetDeadLetterPolicyRequest{Group: "billing", MaxAttempts: 1})
+	require.NoError(t, err)
+
+	nacked, err := client.Nack(ctx, &irispb.NackRequest{Group: "billing", Topic: "orders", Reason: "boom"})
+	require.NoError(t, err)
+	assert.True(t, nacked.GetDeadLettered())
+
+	committed, err := client.Committed(ctx, &irispb.CommittedRequest{Group: "billing", Topic: "orders"})
+	require.NoError(t, err)
+	assert.True(t, committed.GetFound())
+	assert.Equal(t, uint64(1), committed.GetOffset())
+
+	_, err = client.Fetch(ctx, &irispb.FetchRequest{Topic: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders", Offset: 5})
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
+
+func TestGRPCSubscribeFiltered(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	client, stop := newTestClient(t, dir)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err = client.Fetch(ctx, &irispb.FetchRequest{Topic: "orders", Filter: "key ="})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	produce := func(key string) {
+		_, err := client.Produce(ctx, &irispb.ProduceRequest{
+			Topic:    "orders",
+			Messages: []*irispb.Message{{Key: []byte(key), Value: []byte(key)}},
+		})
+		require.NoError(t, err)
+	}
+
+	produce("eu-1")
+	produce("us-1")
+
+	stream, err := client.Subscribe(ctx, &irispb.SubscribeRequest{Topic: "orders", Filter: "key prefix 'eu-'"})
+	require.NoError(t, err)
+
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Len(t, res.GetMessages(), 1)
+	assert.Equal(t, []byte("eu-1"), res.GetMessages()[0].GetKey())
+
+	// The subscription waits for new messages.
+	produce("us-2")
+	produce("eu-2")
+
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Len(t, res.GetMessages(), 1)
+	assert.Equal(t, []byte("eu-2"), res.GetMessages()[0].GetKey())
+	assert.Equal(t, uint64(4), res.GetNextOffset())
+}
+
+func TestGRPCProduceSchema(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	conn, stop := newTestConn(t, dir)
+	defer stop()
+
+	client := irispb.NewIrisClient(conn)
+	registry := irispb.NewIrisSchemaRegistryClient(conn)
+	ctx := context.Background()
+
+	registered, err := registry.RegisterSchema(ctx, &irispb.RegisterSchemaRequest{
+		Subject: "orders",
+		Schema:  &irispb.Schema{Definition: []byte(`{"type": "object", "required": ["id"]}`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), registered.GetVersion())
+
+	check, err := registry.CheckCompatibility(ctx, &irispb.CheckCompatibilityRequest{
+		Subject: "orders",
+		Schema:  &irispb.Schema{Definition: []byte(`{"type": "object", "required": ["id", "name"]}`)},
+	})
+	require.NoError(t, err)
+	assert.False(t, check.GetCompatible())
+	assert.NotEmpty(t, check.GetReason())
+
+	_, err = registry.RegisterSchema(ctx, &irispb.RegisterSchemaRequest{
+		Subject: "orders",
+		Schema:  &irispb.Schema{Definition: []byte(`{"type": "object", "required": ["id", "name"]}`)},
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	id := []byte(strconv.FormatInt(registered.GetId(), 10))
+	produce := func(value string) error {
+		_, err := client.Produce(ctx, &irispb.ProduceRequest{Topic: "orders", Messages: []*irispb.Message{
+			{Value: []byte(value), Headers: []*irispb.Header{{Key: schema.IDHeader, Value: id}}},
+		}})
+
+		return err
+	}
+
+	require.NoError(t, produce(`{"id": 1}`))
+	assert.Equal(t, codes.InvalidArgument, status.Code(produce(`{"name": "a"}`)))
+
+	_, err = registry.GetSchemaVersion(ctx, &irispb.GetSchemaVersionRequest{Subject: "shipping"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,716 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"iris/auth"
+	"iris/broker"
+	"iris/quota"
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Payloads are sent as base64 strings, or as JSON values in the json format.
+	FormatBase64 = "base64"
+	FormatJSON   = "json"
+
+	// Maximum size of a produce request body.
+	maxHTTPBodySize = 32 * 1024 * 1024
+
+	sseKeepAliveInterval = 15 * time.Second
+)
+
+var InvalidFormat = errors.New("Invalid payload format")
+
+// HTTPService implements the HTTP/JSON gateway of the broker, it mirrors the gRPC API.
+type HTTPService struct {
+	logger log.Logger
+	broker *broker.Broker
+	// Requests are not authenticated without an authenticator and not authorized without an authorizer.
+	auth  *auth.Authenticator
+	authz *auth.Authorizer
+	// Clients are not throttled without quotas.
+	quotas *quota.Manager
+	ws     wsOptions
+}
+
+func NewHTTPService(logger log.Logger, b *broker.Broker, authenticator *auth.Authenticator, authorizer *auth.Authorizer, quotas *quota.Manager) *HTTPService {
+	return &HTTPService{
+		logger: logger,
+		broker: b,
+		auth:   authenticator,
+		authz:  authorizer,
+		quotas: quotas,
+		ws:     defaultWSOptions(),
+	}
+}
+
+// Handler returns the handler serving the routes of the gateway:
+//
+//	POST /v1/topics/{topic}/messages
+//	GET  /v1/topics/{topic}/partitions/{partition}/messages?offset=&max=&filter=&format=
+//	GET  /v1/topics/{topic}/partitions/{partition}/events?offset=&filter=&format=
+//	GET  /v1/topics/{topic}/partitions/{partition}/ws?offset=&timestamp=&filter=&format=
+//	POST /v1/topics/{topic}/cloudevents?partition=&acks=
+//	GET  /v1/topics/{topic}/partitions/{partition}/cloudevents?offset=&max=&filter=&mode=
+//	GET  /v1/groups/{group}/topics/{topic}/partitions/{partition}/offset
+//	POST /v1/groups/{group}/topics/{topic}/partitions/{partition}/offset
+func (s *HTTPService) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /v1/topics/{topic}/messages", s.produce)
+	mux.HandleFunc("GET /v1/topics/{topic}/partitions/{partition}/messages", s.fetch)
+	mux.HandleFunc("GET /v1/topics/{topic}/partitions/{partition}/events", s.events)
+	mux.HandleFunc("GET /v1/topics/{topic}/partitions/{partition}/ws", s.subscribeWS)
+	mux.HandleFunc("POST /v1/topics/{topic}/cloudevents", s.produceEvents)
+	mux.HandleFunc("GET /v1/topics/{topic}/partitions/{partition}/cloudevents", s.fetchEvents)
+	mux.HandleFunc("GET /v1/groups/{group}/topics/{topic}/partitions/{partition}/offset", s.committed)
+	mux.HandleFunc("POST /v1/groups/{group}/topics/{topic}/partitions/{partition}/offset", s.commit)
+
+	if s.auth == nil {
+		return mux
+	}
+
+	return s.authMiddleware(mux)
+}
+
+type httpHeader struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type httpMessage struct {
+	Offset    uint64          `json:"offset"`
+	Timestamp int64           `json:"timestamp,omitempty"`
+	Key       json.RawMessage `json:"key,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Headers   []httpHeader    `json:"headers,omitempty"`
+	// Set on produced messages, in milliseconds.
+	TTL       int64 `json:"ttl,omitempty"`
+	ExpiresAt int64 `json:"expiresAt,omitempty"`
+	// Format is set on fetched messages which are sent as base64 even though
+	// the json format was asked for, because their value is not valid JSON
+	// or their key or headers are not valid UTF-8.
+	Format string `json:"format,omitempty"`
+}
+
+type httpProduceRequest struct {
+	Partition *int          `json:"partition,omitempty"`
+	Format    string        `json:"format,omitempty"`
+	DeliverAt int64         `json:"deliverAt,omitempty"`
+	Delay     int64         `json:"delay,omitempty"`
+	Acks      string        `json:"acks,omitempty"`
+	Messages  []httpMessage `json:"messages"`
+}
+
+type httpProduceResponse struct {
+	Partition  int    `json:"partition"`
+	BaseOffset uint64 `json:"baseOffset"`
+	Delayed    bool   `json:"delayed,omitempty"`
+	// ThrottleTimeMs is how long the response has been delayed because the client exceeded its quota.
+	ThrottleTimeMs int64 `json:"throttleTimeMs,omitempty"`
+}
+
+type httpFetchResponse struct {
+	Messages       []httpMessage `json:"messages"`
+	NextOffset     uint64        `json:"nextOffset"`
+	HighWatermark  uint64        `json:"highWatermark"`
+	ThrottleTimeMs int64         `json:"throttleTimeMs,omitempty"`
+}
+
+type httpOffset struct {
+	Offset uint64 `json:"offset"`
+}
+
+type httpError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func validateFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return FormatBase64, nil
+	case FormatBase64, FormatJSON:
+		return format, nil
+	default:
+		return "", errors.Wrapf(InvalidFormat, "%q", format)
+	}
+}
+
+// decodeText decodes a key or header value, they are JSON strings in the json format.
+func decodeText(raw json.RawMessage, format string) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var text string
+
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return nil, errors.Wrapf(InvalidFormat, "expected a string: %s", err)
+	}
+
+	if format == FormatJSON {
+		return []byte(text), nil
+	}
+
+	bytes, err := base64.StdEncoding.DecodeString(text)
+
+	if err != nil {
+		return nil, errors.Wrapf(InvalidFormat, "invalid base64: %s", err)
+	}
+
+	return bytes, nil
+}
+
+// decodeValue decodes a message value, which is any JSON value in the json format.
+func decodeValue(raw json.RawMessage, format string) ([]byte, error) {
+	if format == FormatJSON {
+		if len(raw) == 0 {
+			return nil, nil
+		}
+
+		return []byte(raw), nil
+	}
+
+	return decodeText(raw, format)
+}
+
+func encodeText(bytes []byte, format string) json.RawMessage {
+	text := string(bytes)
+
+	if format != FormatJSON {
+		text = base64.StdEncoding.EncodeToString(bytes)
+	}
+
+	raw, _ := json.Marshal(text)
+
+	return raw
+}
+
+// jsonSafe reports whether the message can be sent in the json format without loss.
+func jsonSafe(msg *storage.Message) bool {
+	if !utf8.Valid(msg.Key) || (len(msg.Value) > 0 && !json.Valid(msg.Value)) {
+		return false
+	}
+
+	for _, h := range msg.Headers {
+		if !utf8.Valid(h.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func messageFromHTTP(m httpMessage, format string, visibleAt time.Time) (*storage.Message, error) {
+	key, err := decodeText(m.Key, format)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "key")
+	}
+
+	value, err := decodeValue(m.Value, format)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "value")
+	}
+
+	msg := &storage.Message{Key: key, Value: value}
+
+	if m.Timestamp != 0 {
+		msg.Timestamp = time.UnixMilli(m.Timestamp)
+	}
+
+	if m.TTL > 0 {
+		msg.ExpiresAt = visibleAt.Add(time.Duration(m.TTL) * time.Millisecond)
+	}
+
+	for _, h := range m.Headers {
+		value, err := decodeText(h.Value, format)
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "header %s", h.Key)
+		}
+
+		msg.Headers = append(msg.Headers, storage.Header{Key: h.Key, Value: value})
+	}
+
+	return msg, nil
+}
+
+// messageToHTTP converts a fetched message. Messages which can't be sent in the
+// json format without loss are sent as base64 with the format of the message set.
+func messageToHTTP(msg *storage.Message, format string) httpMessage {
+	m := httpMessage{
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp.UnixMilli(),
+	}
+
+	if format == FormatJSON && !jsonSafe(msg) {
+		format = FormatBase64
+		m.Format = FormatBase64
+	}
+
+	if len(msg.Key) > 0 {
+		m.Key = encodeText(msg.Key, format)
+	}
+
+	if len(msg.Value) > 0 {
+		m.Value = json.RawMessage(msg.Value)
+
+		if format != FormatJSON {
+			m.Value = encodeText(msg.Value, format)
+		}
+	}
+
+	if !msg.ExpiresAt.IsZero() {
+		m.ExpiresAt = msg.ExpiresAt.UnixMilli()
+	}
+
+	for _, h := range msg.Headers {
+		m.Headers = append(m.Headers, httpHeader{Key: h.Key, Value: encodeText(h.Value, format)})
+	}
+
+	return m
+}
+
+func messagesToHTTP(msgs []*storage.Message, format string) []httpMessage {
+	ms := make([]httpMessage, 0, len(msgs))
+
+	for _, msg := range msgs {
+		ms = append(ms, messageToHTTP(msg, format))
+	}
+
+	return ms
+}
+
+// httpStatus maps errors to HTTP status codes through their gRPC codes.
+func httpStatus(err error) (int, httpError) {
+	if errors.Is(err, InvalidFormat) {
+		return http.StatusBadRequest, httpError{Code: codes.InvalidArgument.String(), Message: err.Error()}
+	}
+
+	st, _ := status.FromError(toStatus(err))
+
+	var code int
+
+	switch st.Code() {
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.AlreadyExists, codes.FailedPrecondition:
+		code = http.StatusConflict
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.OutOfRange:
+		code = http.StatusRequestedRangeNotSatisfiable
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	default:
+		code = http.StatusInternalServerError
+	}
+
+	return code, httpError{Code: st.Code().String(), Message: st.Message()}
+}
+
+// throttle applies the quota of the client of the request.
+func (s *HTTPService) throttle(r *http.Request, kind quota.Kind, bytes int) (time.Duration, error) {
+	return throttle(r.Context(), s.quotas, quotaClient(r.Context(), r.Header.Get(ClientIDHeader)), kind, bytes)
+}
+
+func (s *HTTPService) writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		level.Debug(s.logger).Log("msg", "error writing response", "err", err)
+	}
+}
+
+func (s *HTTPService) writeError(w http.ResponseWriter, err error) {
+	code, body := httpStatus(err)
+
+	if code == http.StatusInternalServerError {
+		level.Error(s.logger).Log("msg", "error handling request", "err", err)
+	}
+
+	s.writeJSON(w, code, struct {
+		Error httpError `json:"error"`
+	}{body})
+}
+
+func pathPartition(r *http.Request) (int, error) {
+	partition, err := strconv.Atoi(r.PathValue("partition"))
+
+	if err != nil {
+		return 0, errors.Wrapf(broker.UnknownPartition, "%q", r.PathValue("partition"))
+	}
+
+	return partition, nil
+}
+
+func queryUint(r *http.Request, name string) (uint64, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+
+	if err != nil {
+		return 0, errors.Wrapf(InvalidFormat, "invalid %s %q", name, value)
+	}
+
+	return n, nil
+}
+
+func (s *HTTPService) produce(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationProduce, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var req httpProduceRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHTTPBodySize)).Decode(&req); err != nil {
+		s.writeError(w, errors.Wrapf(InvalidFormat, "invalid request: %s", err))
+		return
+	}
+
+	format, err := validateFormat(req.Format)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	deliverAt, visibleAt, err := deliveryTimes(req.DeliverAt, req.Delay, time.Now())
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	acks, err := broker.ParseAcks(req.Acks)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	msgs := make([]*storage.Message, 0, len(req.Messages))
+
+	for i, m := range req.Messages {
+		msg, err := messageFromHTTP(m, format, visibleAt)
+
+		if err != nil {
+			s.writeError(w, errors.Wrapf(err, "message %d", i))
+			return
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	partition := -1
+
+	if req.Partition != nil {
+		partition = *req.Partition
+	}
+
+	// Throttled before the append like gRPC produces.
+	throttled, err := s.throttle(r, quota.KindProduce, messagesSize(msgs))
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res, err := s.broker.Produce(r.Context(), broker.ProduceRequest{
+		Topic:     r.PathValue("topic"),
+		Partition: partition,
+		Messages:  msgs,
+		DeliverAt: deliverAt,
+		Acks:      acks,
+	})
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, httpProduceResponse{
+		Partition:      res.Partition,
+		BaseOffset:     res.BaseOffset,
+		Delayed:        res.Delayed,
+		ThrottleTimeMs: throttled.Milliseconds(),
+	})
+}
+
+// fetchRequest reads the fetch parameters shared by fetch and events.
+func fetchRequest(r *http.Request) (broker.FetchRequest, string, error) {
+	partition, err := pathPartition(r)
+
+	if err != nil {
+		return broker.FetchRequest{}, "", err
+	}
+
+	format, err := validateFormat(r.URL.Query().Get("format"))
+
+	if err != nil {
+		return broker.FetchRequest{}, "", err
+	}
+
+	offset, err := queryUint(r, "offset")
+
+	if err != nil {
+		return broker.FetchRequest{}, "", err
+	}
+
+	max, err := queryUint(r, "max")
+
+	if err != nil {
+		return broker.FetchRequest{}, "", err
+	}
+
+	filter, err := parseFilter(r.URL.Query().Get("filter"))
+
+	if err != nil {
+		return broker.FetchRequest{}, "", err
+	}
+
+	return broker.FetchRequest{
+		Topic:       r.PathValue("topic"),
+		Partition:   partition,
+		Offset:      offset,
+		MaxMessages: int(min(max, broker.DefaultMaxFetchMessages)),
+		Filter:      filter,
+	}, format, nil
+}
+
+func (s *HTTPService) fetch(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationConsume, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	req, format, err := fetchRequest(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	res, err := s.broker.Fetch(req)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	throttled, err := s.throttle(r, quota.KindFetch, messagesSize(res.Messages))
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, httpFetchResponse{
+		Messages:       messagesToHTTP(res.Messages, format),
+		NextOffset:     res.NextOffset,
+		HighWatermark:  res.HighWatermark,
+		ThrottleTimeMs: throttled.Milliseconds(),
+	})
+}
+
+// events streams messages as server-sent events. The id of every event is the offset
+// to continue from, so reconnecting clients resume after the last event they have seen.
+func (s *HTTPService) events(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationConsume, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	req, format, err := fetchRequest(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		offset, err := strconv.ParseUint(id, 10, 64)
+
+		if err != nil {
+			s.writeError(w, errors.Wrapf(InvalidFormat, "invalid last event id %q", id))
+			return
+		}
+
+		req.Offset = offset
+	}
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		s.writeError(w, errors.New("streaming is not supported"))
+		return
+	}
+
+	// Errors before the first event are reported with a status code.
+	if _, err := s.broker.Partition(req.Topic, req.Partition); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// The subscription hands its results over, so only this goroutine writes the response.
+	results := make(chan broker.FetchResult)
+	written := make(chan error)
+	errc := make(chan error, 1)
+
+	go func() {
+		errc <- subscribe(ctx, s.broker, req, func(res broker.FetchResult) error {
+			// Events have no place to report the throttle time, the stream just slows down.
+			if _, err := s.throttle(r.WithContext(ctx), quota.KindFetch, messagesSize(res.Messages)); err != nil {
+				return err
+			}
+
+			select {
+			case results <- res:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			return <-written
+		})
+	}()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+
+		select {
+		case res := <-results:
+			err = writeEvents(w, res, format)
+			written <- err
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case err := <-errc:
+			if !errors.Is(err, context.Canceled) {
+				code, body := httpStatus(err)
+				level.Debug(s.logger).Log("msg", "subscription ended", "status", code, "err", err)
+
+				if data, err := json.Marshal(body); err == nil {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+					flusher.Flush()
+				}
+			}
+
+			return
+		}
+
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+func writeEvents(w http.ResponseWriter, res broker.FetchResult, format string) error {
+	for _, msg := range res.Messages {
+		data, err := json.Marshal(messageToHTTP(msg, format))
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: message\ndata: %s\n\n", msg.Offset+1, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *HTTPService) committed(w http.ResponseWriter, r *http.Request) {
+	if err := authorizeGroup(r.Context(), s.authz, auth.OperationConsume, r.PathValue("topic"), r.PathValue("group")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	partition, err := pathPartition(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	offset, ok, err := s.broker.Committed(r.PathValue("group"), r.PathValue("topic"), partition)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	if !ok {
+		s.writeError(w, status.Errorf(codes.NotFound, "no offset committed by group %s", r.PathValue("group")))
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, httpOffset{Offset: offset})
+}
+
+func (s *HTTPService) commit(w http.ResponseWriter, r *http.Request) {
+	if err := authorizeGroup(r.Context(), s.authz, auth.OperationConsume, r.PathValue("topic"), r.PathValue("group")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	partition, err := pathPartition(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	var req httpOffset
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHTTPBodySize)).Decode(&req); err != nil {
+		s.writeError(w, errors.Wrapf(InvalidFormat, "invalid request: %s", err))
+		return
+	}
+
+	if err := s.broker.Commit(r.PathValue("group"), r.PathValue("topic"), partition, req.Offset); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"iris/broker"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHTTPServer(t *testing.T, dir string) (*httptest.Server, func()) {
+	options := broker.DefaultOptions(dir)
+	options.SegmentSize = 32 * 1024 * 4
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+	require.NoError(t, err)
+
+	s := httptest.NewServer(NewHTTPService(log.NewNopLogger(), b, nil, nil, nil).Handler())
+
+	return s, func() {
+		s.Close()
+		b.Stop()
+	}
+}
+
+func doJSON(t *testing.T, method string, url string, body string, out interface{}) int {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	if out != nil {
+		require.NoError(t, json.NewDecoder(res.Body).Decode(out))
+	}
+
+	return res.StatusCode
+}
+
+func TestHTTPProduceFetchCommit(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, stop := newTestHTTPServer(t, dir)
+	defer stop()
+
+	var produced httpProduceResponse
+
+	code := doJSON(t, http.MethodPost, s.URL+"/v1/topics/orders/messages",
+		`{"format": "json", "partition": 0, "messages": [
+			{"key": "a", "value": {"id": 1}, "headers": [{"key": "h", "value": "1"}]},
+			{"key": "b", "value": "text"}
+		]}`, &produced)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint64(0), produced.BaseOffset)
+
+	// "YmluYXJ5" is "binary", which is not valid JSON.
+	code = doJSON(t, http.MethodPost, s.URL+"/v1/topics/orders/messages",
+		`{"partition": 0, "messages": [{"value": "YmluYXJ5"}]}`, &produced)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint64(2), produced.BaseOffset)
+
+	var fetched httpFetchResponse
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/topics/orders/partitions/0/messages?format=json", "", &fetched)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, fetched.Messages, 3)
+	assert.JSONEq(t, `{"id": 1}`, string(fetched.Messages[0].Value))
+	assert.JSONEq(t, `"a"`, string(fetched.Messages[0].Key))
+	assert.JSONEq(t, `"1"`, string(fetched.Messages[0].Headers[0].Value))
+	assert.JSONEq(t, `"text"`, string(fetched.Messages[1].Value))
+	assert.Equal(t, FormatBase64, fetched.Messages[2].Format)
+	assert.JSONEq(t, `"YmluYXJ5"`, string(fetched.Messages[2].Value))
+	assert.Equal(t, uint64(3), fetched.NextOffset)
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/topics/orders/partitions/0/messages?offset=1&max=1", "", &fetched)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, fetched.Messages, 1)
+	assert.JSONEq(t, `"InRleHQi"`, string(fetched.Messages[0].Value))
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/topics/orders/partitions/0/messages?filter=key+%3D+'b'&format=json", "", &fetched)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, fetched.Messages, 1)
+	assert.Equal(t, uint64(1), fetched.Messages[0].Offset)
+
+	var errBody struct {
+		Error httpError `json:"error"`
+	}
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/topics/unknown/partitions/0/messages", "", &errBody)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "NotFound", errBody.Error.Code)
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/topics/orders/partitions/0/messages?format=xml", "", &errBody)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/groups/billing/topics/orders/partitions/0/offset", "", &errBody)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	var offset httpOffset
+
+	code = doJSON(t, http.MethodPost, s.URL+"/v1/groups/billing/topics/orders/partitions/0/offset", `{"offset": 2}`, &offset)
+	require.Equal(t, http.StatusOK, code)
+
+	code = doJSON(t, http.MethodGet, s.URL+"/v1/groups/billing/topics/orders/partitions/0/offset", "", &offset)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint64(2), offset.Offset)
+}
+
+func TestHTTPEvents(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, stop := newTestHTTPServer(t, dir)
+	defer stop()
+
+	produce := func(value string) {
+		code := doJSON(t, http.MethodPost, s.URL+"/v1/topics/events/messages",
+			`{"format": "json", "messages": [{"value": `+value+`}]}`, nil)
+		require.Equal(t, http.StatusOK, code)
+	}
+
+	produce(`1`)
+	produce(`2`)
+
+	req, err := http.NewRequest(http.MethodGet, s.URL+"/v1/topics/events/partitions/0/events?format=json", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	events := bufio.NewScanner(res.Body)
+
+	next := func() (string, httpMessage) {
+		var id string
+		var msg httpMessage
+
+		for events.Scan() {
+			line := events.Text()
+
+			switch {
+			case line == "":
+				return id, msg
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg))
+			}
+		}
+
+		t.Fatal("event stream ended")
+
+		return "", msg
+	}
+
+	// Resumes after the event with the id 1, the first message.
+	id, msg := next()
+	assert.Equal(t, "2", id)
+	assert.JSONEq(t, `2`, string(msg.Value))
+
+	produce(`3`)
+
+	id, msg = next()
+	assert.Equal(t, "3", id)
+	assert.JSONEq(t, `3`, string(msg.Value))
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"iris/auth"
+	"iris/quota"
+	"iris/storage"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// ClientIDHeader identifies anonymous clients for quotas, it is the gRPC metadata key and the HTTP header.
+	ClientIDHeader = "iris-client-id"
+)
+
+// quotaClient returns the name quotas of the calling client are tracked by,
+// authenticated clients are tracked by their principal.
+func quotaClient(ctx context.Context, clientID string) string {
+	if p, ok := auth.FromContext(ctx); ok && !p.Anonymous() {
+		return p.Name
+	}
+
+	return clientID
+}
+
+func grpcClientID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(ClientIDHeader); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+// throttle records the usage of the client and delays the response while it is over quota,
+// it returns the throttle time to report to the client.
+func throttle(ctx context.Context, q *quota.Manager, client string, kind quota.Kind, bytes int) (time.Duration, error) {
+	if q == nil {
+		return 0, nil
+	}
+
+	d := q.Record(client, kind, bytes, time.Now())
+
+	if d <= 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-timer.C:
+		return d, nil
+	}
+}
+
+// messagesSize returns the number of bytes of the keys, values and headers of the messages.
+func messagesSize(msgs []*storage.Message) int {
+	size := 0
+
+	for _, msg := range msgs {
+		size += len(msg.Key) + len(msg.Value)
+
+		for _, h := range msg.Headers {
+			size += len(h.Key) + len(h.Value)
+		}
+	}
+
+	return size
+}
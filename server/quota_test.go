@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"iris/api/irispb"
+	"iris/broker"
+	"iris/quota"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCProduceThrottled(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), broker.DefaultOptions(dir))
+	require.NoError(t, err)
+	defer b.Stop()
+
+	quotas := quota.NewManager(log.NewNopLogger(), prometheus.NewRegistry(), quota.Options{
+		Clients: map[string]quota.Limits{"noisy": {ProduceBytesPerSecond: 1000}},
+	})
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := NewGRPCServer(NewGRPCService(log.NewNopLogger(), b, nil, nil, quotas))
+
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := irispb.NewIrisClient(conn)
+	noisy := metadata.AppendToOutgoingContext(context.Background(), ClientIDHeader, "noisy")
+	req := &irispb.ProduceRequest{Topic: "orders", Messages: []*irispb.Message{{Value: make([]byte, 1100)}}}
+
+	// The burst covers the first 1000 bytes, the rest is throttled.
+	start := time.Now()
+	res, err := client.Produce(noisy, req)
+	require.NoError(t, err)
+	assert.InDelta(t, 100, res.GetThrottleTimeMs(), 5)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	// Throttled requests are still served.
+	fetched, err := client.Fetch(context.Background(), &irispb.FetchRequest{Topic: "orders"})
+	require.NoError(t, err)
+	assert.Len(t, fetched.GetMessages(), 1)
+	assert.Zero(t, fetched.GetThrottleTimeMs())
+
+	// A produce which times out while it is throttled isn't appended.
+	ctx, cancel := context.WithTimeout(noisy, 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.Produce(ctx, req)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	fetched, err = client.Fetch(context.Background(), &irispb.FetchRequest{Topic: "orders"})
+	require.NoError(t, err)
+	assert.Len(t, fetched.GetMessages(), 1)
+
+	// Other clients have no quota.
+	res, err = client.Produce(context.Background(), req)
+	require.NoError(t, err)
+	assert.Zero(t, res.GetThrottleTimeMs())
+}
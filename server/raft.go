@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/controller"
+	"iris/raft"
+
+	"google.golang.org/grpc"
+)
+
+// RaftService implements the gRPC API the controllers replicate the metadata log with.
+type RaftService struct {
+	irispb.UnimplementedIrisRaftServer
+
+	service    *GRPCService
+	controller *controller.Controller
+}
+
+func (s *RaftService) RequestVote(ctx context.Context, req *irispb.RequestVoteRequest) (*irispb.RequestVoteResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	res, err := s.controller.Node().HandleRequestVote(raft.VoteRequest{
+		Term:      req.GetTerm(),
+		Candidate: req.GetCandidate(),
+		LastIndex: req.GetLastIndex(),
+		LastTerm:  req.GetLastTerm(),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.RequestVoteResponse{Term: res.Term, Granted: res.Granted}, nil
+}
+
+func (s *RaftService) AppendEntries(ctx context.Context, req *irispb.AppendEntriesRequest) (*irispb.AppendEntriesResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	entries := make([]raft.Entry, 0, len(req.GetEntries()))
+
+	for _, e := range req.GetEntries() {
+		entries = append(entries, raft.Entry{Index: e.GetIndex(), Term: e.GetTerm(), Data: e.GetData()})
+	}
+
+	res, err := s.controller.Node().HandleAppendEntries(raft.AppendRequest{
+		Term:      req.GetTerm(),
+		Leader:    req.GetLeader(),
+		PrevIndex: req.GetPrevIndex(),
+		PrevTerm:  req.GetPrevTerm(),
+		Entries:   entries,
+		Commit:    req.GetCommit(),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.AppendEntriesResponse{Term: res.Term, Success: res.Success, LastIndex: res.LastIndex}, nil
+}
+
+func (s *RaftService) InstallSnapshot(ctx context.Context, req *irispb.InstallSnapshotRequest) (*irispb.InstallSnapshotResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	res, err := s.controller.Node().HandleInstallSnapshot(raft.SnapshotRequest{
+		Term:   req.GetTerm(),
+		Leader: req.GetLeader(),
+		Snapshot: raft.Snapshot{
+			Index: req.GetIndex(),
+			Term:  req.GetLastTerm(),
+			Data:  req.GetData(),
+		},
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.InstallSnapshotResponse{Term: res.Term}, nil
+}
+
+// RaftClient sends the messages of a controller to the other controllers over gRPC.
+type RaftClient struct {
+	*peerConns
+}
+
+func NewRaftClient(peers map[int32]string, opts ...grpc.DialOption) *RaftClient {
+	return &RaftClient{peerConns: newPeerConns(peers, opts)}
+}
+
+func (c *RaftClient) client(id int32) (irispb.IrisRaftClient, error) {
+	conn, err := c.conn(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return irispb.NewIrisRaftClient(conn), nil
+}
+
+func (c *RaftClient) RequestVote(ctx context.Context, to int32, req raft.VoteRequest) (raft.VoteResponse, error) {
+	client, err := c.client(to)
+
+	if err != nil {
+		return raft.VoteResponse{}, err
+	}
+
+	res, err := client.RequestVote(ctx, &irispb.RequestVoteRequest{
+		Term:      req.Term,
+		Candidate: req.Candidate,
+		LastIndex: req.LastIndex,
+		LastTerm:  req.LastTerm,
+	})
+
+	if err != nil {
+		return raft.VoteResponse{}, err
+	}
+
+	return raft.VoteResponse{Term: res.GetTerm(), Granted: res.GetGranted()}, nil
+}
+
+func (c *RaftClient) AppendEntries(ctx context.Context, to int32, req raft.AppendRequest) (raft.AppendResponse, error) {
+	client, err := c.client(to)
+
+	if err != nil {
+		return raft.AppendResponse{}, err
+	}
+
+	entries := make([]*irispb.RaftEntry, 0, len(req.Entries))
+
+	for _, e := range req.Entries {
+		entries = append(entries, &irispb.RaftEntry{Index: e.Index, Term: e.Term, Data: e.Data})
+	}
+
+	res, err := client.AppendEntries(ctx, &irispb.AppendEntriesRequest{
+		Term:      req.Term,
+		Leader:    req.Leader,
+		PrevIndex: req.PrevIndex,
+		PrevTerm:  req.PrevTerm,
+		Entries:   entries,
+		Commit:    req.Commit,
+	})
+
+	if err != nil {
+		return raft.AppendResponse{}, err
+	}
+
+	return raft.AppendResponse{Term: res.GetTerm(), Success: res.GetSuccess(), LastIndex: res.GetLastIndex()}, nil
+}
+
+func (c *RaftClient) InstallSnapshot(ctx context.Context, to int32, req raft.SnapshotRequest) (raft.SnapshotResponse, error) {
+	client, err := c.client(to)
+
+	if err != nil {
+		return raft.SnapshotResponse{}, err
+	}
+
+	res, err := client.InstallSnapshot(ctx, &irispb.InstallSnapshotRequest{
+		Term:     req.Term,
+		Leader:   req.Leader,
+		Index:    req.Snapshot.Index,
+		LastTerm: req.Snapshot.Term,
+		Data:     req.Snapshot.Data,
+	})
+
+	if err != nil {
+		return raft.SnapshotResponse{}, err
+	}
+
+	return raft.SnapshotResponse{Term: res.GetTerm()}, nil
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/broker"
+	"iris/storage"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+var UnknownPeer = errors.New("Unknown peer")
+
+// ReplicationService implements the gRPC API followers fetch partitions from their leader with.
+type ReplicationService struct {
+	irispb.UnimplementedIrisReplicationServer
+
+	service *GRPCService
+}
+
+func (s *ReplicationService) ReplicaFetch(ctx context.Context, req *irispb.ReplicaFetchRequest) (*irispb.ReplicaFetchResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	res, err := s.service.broker.ReplicaFetch(ctx, broker.ReplicaFetchRequest{
+		ReplicaID: req.GetReplicaId(),
+		Topic:     req.GetTopic(),
+		Partition: int(req.GetPartition()),
+		Offset:    req.GetOffset(),
+		LastEpoch: req.GetLastEpoch(),
+		MaxBytes:  int(req.GetMaxBytes()),
+		MaxWait:   time.Duration(req.GetMaxWait()) * time.Millisecond,
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	records := make([][]byte, 0, len(res.Messages))
+
+	for _, msg := range res.Messages {
+		records = append(records, storage.EncodeMessage(msg))
+	}
+
+	epochs := make([]*irispb.LeaderEpoch, 0, len(res.Epochs))
+
+	for _, e := range res.Epochs {
+		epochs = append(epochs, &irispb.LeaderEpoch{Epoch: e.Epoch, StartOffset: e.StartOffset})
+	}
+
+	pb := &irispb.ReplicaFetchResponse{
+		Records:        records,
+		HighWatermark:  res.HighWatermark,
+		LogStartOffset: res.LogStartOffset,
+		Epochs:         epochs,
+		Isr:            res.ISR,
+	}
+
+	if d := res.Diverging; d != nil {
+		pb.Diverging = &irispb.LeaderEpochEnd{Epoch: d.Epoch, EndOffset: d.EndOffset}
+	}
+
+	return pb, nil
+}
+
+func (s *ReplicationService) SegmentFiles(ctx context.Context, req *irispb.SegmentFilesRequest) (*irispb.SegmentFilesResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	files, err := s.service.broker.SegmentFiles(req.GetTopic(), int(req.GetPartition()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &irispb.SegmentFilesResponse{Files: make([]*irispb.SegmentFile, 0, len(files))}
+
+	for _, f := range files {
+		res.Files = append(res.Files, &irispb.SegmentFile{Name: f.Name, Size: f.Size})
+	}
+
+	return res, nil
+}
+
+func (s *ReplicationService) ReadSegmentFile(ctx context.Context, req *irispb.ReadSegmentFileRequest) (*irispb.ReadSegmentFileResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	data, err := s.service.broker.ReadSegmentFile(broker.SegmentReadRequest{
+		Topic:     req.GetTopic(),
+		Partition: int(req.GetPartition()),
+		Name:      req.GetName(),
+		Position:  req.GetPosition(),
+		MaxBytes:  int(req.GetMaxBytes()),
+	})
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ReadSegmentFileResponse{Data: data}, nil
+}
+
+func (s *ReplicationService) SetReplicas(ctx context.Context, req *irispb.SetReplicasRequest) (*irispb.SetReplicasResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.broker.SetReplicas(req.GetTopic(), int(req.GetPartition()), req.GetReplicas()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.SetReplicasResponse{}, nil
+}
+
+func (s *ReplicationService) SetLeader(ctx context.Context, req *irispb.SetLeaderRequest) (*irispb.SetLeaderResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, ""); err != nil {
+		return nil, err
+	}
+
+	if err := s.service.broker.SetLeader(req.GetTopic(), int(req.GetPartition()), req.GetLeader(), req.GetEpoch()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.SetLeaderResponse{}, nil
+}
+
+// peerConns holds the connections to the other brokers. Peers maps their node
+// ids to their gRPC addresses, connections are opened on first use.
+type peerConns struct {
+	peers map[int32]string
+	opts  []grpc.DialOption
+
+	mutex sync.Mutex
+	conns map[int32]*grpc.ClientConn
+}
+
+func newPeerConns(peers map[int32]string, opts []grpc.DialOption) *peerConns {
+	return &peerConns{
+		peers: peers,
+		opts:  opts,
+		conns: make(map[int32]*grpc.ClientConn),
+	}
+}
+
+func (c *peerConns) conn(id int32) (*grpc.ClientConn, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if conn, ok := c.conns[id]; ok {
+		return conn, nil
+	}
+
+	addr, ok := c.peers[id]
+
+	if !ok {
+		return nil, errors.Wrapf(UnknownPeer, "%d", id)
+	}
+
+	conn, err := grpc.NewClient(addr, c.opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.conns[id] = conn
+
+	return conn, nil
+}
+
+// Close closes the connections to the peers.
+func (c *peerConns) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var err error
+
+	for id, conn := range c.conns {
+		if cerr := conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+
+		delete(c.conns, id)
+	}
+
+	return err
+}
+
+// ReplicaClient reaches the other replicas of partitions over gRPC.
+type ReplicaClient struct {
+	*peerConns
+}
+
+func NewReplicaClient(peers map[int32]string, opts ...grpc.DialOption) *ReplicaClient {
+	return &ReplicaClient{peerConns: newPeerConns(peers, opts)}
+}
+
+func (c *ReplicaClient) client(id int32) (irispb.IrisReplicationClient, error) {
+	conn, err := c.conn(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return irispb.NewIrisReplicationClient(conn), nil
+}
+
+func (c *ReplicaClient) ReplicaFetch(ctx context.Context, leader int32, req broker.ReplicaFetchRequest) (broker.ReplicaFetchResult, error) {
+	client, err := c.client(leader)
+
+	if err != nil {
+		return broker.ReplicaFetchResult{}, err
+	}
+
+	res, err := client.ReplicaFetch(ctx, &irispb.ReplicaFetchRequest{
+		ReplicaId: req.ReplicaID,
+		Topic:     req.Topic,
+		Partition: int32(req.Partition),
+		Offset:    req.Offset,
+		LastEpoch: req.LastEpoch,
+		MaxBytes:  int32(req.MaxBytes),
+		MaxWait:   req.MaxWait.Milliseconds(),
+	})
+
+	if err != nil {
+		return broker.ReplicaFetchResult{}, err
+	}
+
+	msgs := make([]*storage.Message, 0, len(res.GetRecords()))
+
+	for _, rec := range res.GetRecords() {
+		msg, err := storage.DecodeMessage(rec)
+
+		if err != nil {
+			return broker.ReplicaFetchResult{}, errors.Wrapf(err, "invalid record from leader %d", leader)
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	result := broker.ReplicaFetchResult{
+		Messages:       msgs,
+		HighWatermark:  res.GetHighWatermark(),
+		LogStartOffset: res.GetLogStartOffset(),
+		ISR:            res.GetIsr(),
+	}
+
+	for _, e := range res.GetEpochs() {
+		result.Epochs = append(result.Epochs, storage.Epoch{Epoch: e.GetEpoch(), StartOffset: e.GetStartOffset()})
+	}
+
+	if d := res.GetDiverging(); d != nil {
+		result.Diverging = &broker.EpochEnd{Epoch: d.GetEpoch(), EndOffset: d.GetEndOffset()}
+	}
+
+	return result, nil
+}
+
+func (c *ReplicaClient) SegmentFiles(ctx context.Context, leader int32, topic string, partition int) ([]storage.SegmentFile, error) {
+	client, err := c.client(leader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.SegmentFiles(ctx, &irispb.SegmentFilesRequest{Topic: topic, Partition: int32(partition)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]storage.SegmentFile, 0, len(res.GetFiles()))
+
+	for _, f := range res.GetFiles() {
+		files = append(files, storage.SegmentFile{Name: f.GetName(), Size: f.GetSize()})
+	}
+
+	return files, nil
+}
+
+func (c *ReplicaClient) ReadSegmentFile(ctx context.Context, leader int32, req broker.SegmentReadRequest) ([]byte, error) {
+	client, err := c.client(leader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.ReadSegmentFile(ctx, &irispb.ReadSegmentFileRequest{
+		Topic:     req.Topic,
+		Partition: int32(req.Partition),
+		Name:      req.Name,
+		Position:  req.Position,
+		MaxBytes:  int32(req.MaxBytes),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetData(), nil
+}
+
+func (c *ReplicaClient) SetReplicas(ctx context.Context, id int32, topic string, partition int, replicas []int32) error {
+	client, err := c.client(id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SetReplicas(ctx, &irispb.SetReplicasRequest{Topic: topic, Partition: int32(partition), Replicas: replicas})
+
+	return err
+}
+
+func (c *ReplicaClient) SetLeader(ctx context.Context, id int32, topic string, partition int, leader int32, epoch int32) error {
+	client, err := c.client(id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = client.SetLeader(ctx, &irispb.SetLeaderRequest{Topic: topic, Partition: int32(partition), Leader: leader, Epoch: epoch})
+
+	return err
+}
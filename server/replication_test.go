@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"iris/api/irispb"
+	"iris/broker"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplication(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Every broker listens on localhost and knows the addresses of the others.
+	listeners := make(map[int32]net.Listener)
+	peers := make(map[int32]string)
+
+	for _, id := range []int32{1, 2} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		listeners[id] = lis
+		peers[id] = lis.Addr().String()
+	}
+
+	conns := make(map[int32]*grpc.ClientConn)
+
+	for id, lis := range listeners {
+		replicas := NewReplicaClient(peers, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		defer replicas.Close()
+
+		options := broker.DefaultOptions(filepath.Join(dir, strconv.Itoa(int(id))))
+		options.SegmentSize = 32 * 1024 * 4
+		options.NodeID = id
+		options.ReplicaClient = replicas
+
+		b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), options)
+		require.NoError(t, err)
+		defer b.Stop()
+
+		s := NewGRPCServer(NewGRPCService(log.NewNopLogger(), b, nil, nil, nil))
+		defer s.Stop()
+
+		go s.Serve(lis)
+
+		conn, err := grpc.NewClient(peers[id], grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conns[id] = conn
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, conn := range conns {
+		_, err := irispb.NewIrisClient(conn).CreateTopic(ctx, &irispb.CreateTopicRequest{
+			Topic:      "orders",
+			Partitions: 1,
+			Replicas:   []*irispb.PartitionReplicas{{Replicas: []int32{1, 2}}},
+		})
+		require.NoError(t, err)
+	}
+
+	leader := irispb.NewIrisClient(conns[1])
+	follower := irispb.NewIrisClient(conns[2])
+
+	_, err = leader.Produce(ctx, &irispb.ProduceRequest{
+		Topic:     "orders",
+		Partition: proto.Int32(0),
+		Messages:  []*irispb.Message{{Value: []byte("1")}, {Value: []byte("2")}},
+	})
+	require.NoError(t, err)
+
+	_, err = follower.Produce(ctx, &irispb.ProduceRequest{Topic: "orders", Partition: proto.Int32(0), Messages: []*irispb.Message{{Value: []byte("3")}}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// Subscribers get the messages once the follower has them.
+	stream, err := leader.Subscribe(ctx, &irispb.SubscribeRequest{Topic: "orders", Partition: 0})
+	require.NoError(t, err)
+
+	var values []string
+
+	for len(values) < 2 {
+		res, err := stream.Recv()
+		require.NoError(t, err)
+
+		for _, m := range res.GetMessages() {
+			values = append(values, string(m.GetValue()))
+		}
+	}
+
+	assert.Equal(t, []string{"1", "2"}, values)
+
+	// With acks=all the response waits for the follower.
+	_, err = leader.Produce(ctx, &irispb.ProduceRequest{
+		Topic:     "orders",
+		Partition: proto.Int32(0),
+		Messages:  []*irispb.Message{{Value: []byte("3")}},
+		Acks:      irispb.Acks_ACKS_ALL,
+	})
+	require.NoError(t, err)
+
+	described, err := irispb.NewIrisAdminClient(conns[1]).DescribePartition(ctx, &irispb.DescribePartitionRequest{Topic: "orders", Partition: 0})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), described.GetLeader())
+	assert.Equal(t, uint64(3), described.GetHighWatermark())
+	require.Len(t, described.GetReplicas(), 2)
+	assert.Equal(t, int32(2), described.GetReplicas()[1].GetId())
+	assert.Equal(t, uint64(3), described.GetReplicas()[1].GetOffset())
+	assert.True(t, described.GetReplicas()[1].GetInSync())
+
+	// The partition moves to the follower, which leads it alone then.
+	_, err = irispb.NewIrisAdminClient(conns[2]).ReassignPartition(ctx, &irispb.ReassignPartitionRequest{Topic: "orders", Partition: 0, Replicas: []int32{2}})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = irispb.NewIrisAdminClient(conns[1]).ReassignPartition(ctx, &irispb.ReassignPartitionRequest{Topic: "orders", Partition: 0, Replicas: []int32{2}})
+	require.NoError(t, err)
+
+	described, err = irispb.NewIrisAdminClient(conns[2]).DescribePartition(ctx, &irispb.DescribePartitionRequest{Topic: "orders", Partition: 0})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), described.GetLeader())
+	assert.Len(t, described.GetReplicas(), 1)
+	assert.Equal(t, uint64(3), described.GetEndOffset())
+
+	_, err = follower.Produce(ctx, &irispb.ProduceRequest{Topic: "orders", Partition: proto.Int32(0), Messages: []*irispb.Message{{Value: []byte("4")}}, Acks: irispb.Acks_ACKS_ALL})
+	require.NoError(t, err)
+}
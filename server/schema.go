@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+
+	"iris/api/irispb"
+	"iris/auth"
+	"iris/schema"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SchemaService implements the schema registry gRPC API on the registry of the broker.
+// Reading schemas is allowed to every client, changing a subject needs admin rights on its topic.
+type SchemaService struct {
+	irispb.UnimplementedIrisSchemaRegistryServer
+
+	service *GRPCService
+}
+
+func (s *SchemaService) registry() *schema.Registry {
+	return s.service.broker.Schemas()
+}
+
+func (s *SchemaService) RegisterSchema(ctx context.Context, req *irispb.RegisterSchemaRequest) (*irispb.RegisterSchemaResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceTopic, req.GetSubject()); err != nil {
+		return nil, err
+	}
+
+	sc, err := schemaFromProto(req.GetSchema())
+
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.registry().Register(req.GetSubject(), sc)
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.RegisterSchemaResponse{Id: v.Schema.ID, Version: int32(v.Version)}, nil
+}
+
+func (s *SchemaService) GetSchema(ctx context.Context, req *irispb.GetSchemaRequest) (*irispb.GetSchemaResponse, error) {
+	sc, err := s.registry().Schema(req.GetId())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.GetSchemaResponse{Schema: schemaToProto(sc)}, nil
+}
+
+func (s *SchemaService) GetSchemaVersion(ctx context.Context, req *irispb.GetSchemaVersionRequest) (*irispb.GetSchemaVersionResponse, error) {
+	v, err := s.registry().Version(req.GetSubject(), int(req.GetVersion()))
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.GetSchemaVersionResponse{
+		Subject: v.Subject,
+		Version: int32(v.Version),
+		Schema:  schemaToProto(v.Schema),
+	}, nil
+}
+
+func (s *SchemaService) ListSubjects(ctx context.Context, req *irispb.ListSubjectsRequest) (*irispb.ListSubjectsResponse, error) {
+	return &irispb.ListSubjectsResponse{Subjects: s.registry().Subjects()}, nil
+}
+
+func (s *SchemaService) ListVersions(ctx context.Context, req *irispb.ListVersionsRequest) (*irispb.ListVersionsResponse, error) {
+	versions, err := s.registry().Versions(req.GetSubject())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.ListVersionsResponse{Versions: versionsToProto(versions)}, nil
+}
+
+func (s *SchemaService) DeleteSubject(ctx context.Context, req *irispb.DeleteSubjectRequest) (*irispb.DeleteSubjectResponse, error) {
+	if err := authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceTopic, req.GetSubject()); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.registry().DeleteSubject(req.GetSubject())
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.DeleteSubjectResponse{Versions: versionsToProto(versions)}, nil
+}
+
+func (s *SchemaService) CheckCompatibility(ctx context.Context, req *irispb.CheckCompatibilityRequest) (*irispb.CheckCompatibilityResponse, error) {
+	sc, err := schemaFromProto(req.GetSchema())
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.registry().CheckCompatibility(req.GetSubject(), sc)
+
+	if errors.Is(err, schema.IncompatibleSchema) {
+		return &irispb.CheckCompatibilityResponse{Compatible: false, Reason: err.Error()}, nil
+	}
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.CheckCompatibilityResponse{Compatible: true}, nil
+}
+
+func (s *SchemaService) GetCompatibility(ctx context.Context, req *irispb.GetCompatibilityRequest) (*irispb.GetCompatibilityResponse, error) {
+	c := s.registry().Compatibility(req.GetSubject())
+
+	for k, v := range compatibilities {
+		if v == c {
+			return &irispb.GetCompatibilityResponse{Compatibility: k}, nil
+		}
+	}
+
+	return nil, status.Errorf(codes.Internal, "unknown compatibility %s", c)
+}
+
+func (s *SchemaService) SetCompatibility(ctx context.Context, req *irispb.SetCompatibilityRequest) (*irispb.SetCompatibilityResponse, error) {
+	var err error
+
+	// The default compatibility belongs to the cluster.
+	if req.GetSubject() == "" {
+		err = authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceCluster, "")
+	} else {
+		err = authorize(ctx, s.service.authz, auth.OperationAdmin, auth.ResourceTopic, req.GetSubject())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := compatibilities[req.GetCompatibility()]
+
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown compatibility %s", req.GetCompatibility())
+	}
+
+	if err := s.registry().SetCompatibility(req.GetSubject(), c); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &irispb.SetCompatibilityResponse{}, nil
+}
+
+var (
+	schemaTypes = map[irispb.SchemaType]schema.Type{
+		irispb.SchemaType_SCHEMA_TYPE_JSON:     schema.TypeJSON,
+		irispb.SchemaType_SCHEMA_TYPE_PROTOBUF: schema.TypeProtobuf,
+	}
+	compatibilities = map[irispb.Compatibility]schema.Compatibility{
+		irispb.Compatibility_COMPATIBILITY_NONE:                schema.CompatibilityNone,
+		irispb.Compatibility_COMPATIBILITY_BACKWARD:            schema.CompatibilityBackward,
+		irispb.Compatibility_COMPATIBILITY_BACKWARD_TRANSITIVE: schema.CompatibilityBackwardTransitive,
+		irispb.Compatibility_COMPATIBILITY_FORWARD:             schema.CompatibilityForward,
+		irispb.Compatibility_COMPATIBILITY_FORWARD_TRANSITIVE:  schema.CompatibilityForwardTransitive,
+		irispb.Compatibility_COMPATIBILITY_FULL:                schema.CompatibilityFull,
+		irispb.Compatibility_COMPATIBILITY_FULL_TRANSITIVE:     schema.CompatibilityFullTransitive,
+	}
+)
+
+func schemaFromProto(s *irispb.Schema) (schema.Schema, error) {
+	t, ok := schemaTypes[s.GetType()]
+
+	if !ok {
+		return schema.Schema{}, status.Errorf(codes.InvalidArgument, "unknown schema type %s", s.GetType())
+	}
+
+	return schema.Schema{Type: t, Definition: s.GetDefinition(), MessageName: s.GetMessageName()}, nil
+}
+
+func schemaToProto(s schema.Schema) *irispb.Schema {
+	res := &irispb.Schema{Id: s.ID, Definition: s.Definition, MessageName: s.MessageName}
+
+	for k, v := range schemaTypes {
+		if v == s.Type {
+			res.Type = k
+		}
+	}
+
+	return res
+}
+
+func versionsToProto(versions []int) []int32 {
+	res := make([]int32, 0, len(versions))
+
+	for _, v := range versions {
+		res = append(res, int32(v))
+	}
+
+	return res
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"iris/broker"
+
+	"github.com/pkg/errors"
+)
+
+var InvalidDelivery = errors.New("Only one of deliver at and delay may be set")
+
+// deliveryTimes returns when produced messages are delivered and become visible
+// to consumers, given a unix time in milliseconds or a delay in milliseconds.
+func deliveryTimes(deliverAtMs int64, delayMs int64, now time.Time) (time.Time, time.Time, error) {
+	var deliverAt time.Time
+
+	switch {
+	case deliverAtMs != 0 && delayMs != 0:
+		return time.Time{}, time.Time{}, InvalidDelivery
+	case deliverAtMs != 0:
+		deliverAt = time.UnixMilli(deliverAtMs)
+	case delayMs != 0:
+		deliverAt = now.Add(time.Duration(delayMs) * time.Millisecond)
+	}
+
+	if deliverAt.After(now) {
+		return deliverAt, deliverAt, nil
+	}
+
+	return deliverAt, now, nil
+}
+
+// parseFilter parses the filter expression of a request, an empty expression matches all messages.
+func parseFilter(expr string) (broker.Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+
+	return broker.ParseFilter(expr)
+}
+
+// subscribe fetches the messages of the partition from the offset of the request on
+// and passes every non empty result to send. Once it has caught up it waits for new
+// messages, it only returns when sending fails, the context is done or the broker stops.
+func subscribe(ctx context.Context, b *broker.Broker, req broker.FetchRequest, send func(broker.FetchResult) error) error {
+	for {
+		res, err := b.Fetch(req)
+
+		if err != nil {
+			return err
+		}
+
+		if len(res.Messages) > 0 {
+			if err := send(res); err != nil {
+				return err
+			}
+		}
+
+		req.Offset = max(req.Offset, res.NextOffset)
+
+		if req.Offset < res.HighWatermark {
+			continue
+		}
+
+		if err := b.Wait(ctx, req.Topic, req.Partition, req.Offset); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"iris/auth"
+	"iris/broker"
+	"iris/quota"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Frames sent to WebSocket subscribers.
+	FrameMessages = "messages"
+	FrameError    = "error"
+
+	wsPingInterval = 30 * time.Second
+	wsWriteTimeout = 10 * time.Second
+	// Results waiting to be written to a subscriber.
+	wsMaxPendingResults = 16
+	// wsStallTimeout is how long a subscriber may leave the pending results unread
+	// before it is too far behind and disconnected.
+	wsStallTimeout = 30 * time.Second
+)
+
+var TooFarBehind = errors.New("Subscriber too far behind")
+
+type wsFrame struct {
+	Type          string        `json:"type"`
+	Messages      []httpMessage `json:"messages,omitempty"`
+	NextOffset    uint64        `json:"nextOffset,omitempty"`
+	HighWatermark uint64        `json:"highWatermark,omitempty"`
+	Error         *httpError    `json:"error,omitempty"`
+}
+
+// wsOptions are the limits of WebSocket subscriptions.
+type wsOptions struct {
+	pingInterval time.Duration
+	// pongTimeout is how long a subscriber may not answer pings before it is disconnected.
+	pongTimeout  time.Duration
+	maxPending   int
+	stallTimeout time.Duration
+}
+
+func defaultWSOptions() wsOptions {
+	return wsOptions{
+		pingInterval: wsPingInterval,
+		pongTimeout:  2 * wsPingInterval,
+		maxPending:   wsMaxPendingResults,
+		stallTimeout: wsStallTimeout,
+	}
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 32 * 1024,
+}
+
+// wsRequest reads the subscription of a WebSocket request. It starts at the offset,
+// or at the first message not before the timestamp in unix milliseconds if it is set.
+func (s *HTTPService) wsRequest(r *http.Request) (broker.FetchRequest, string, error) {
+	req, format, err := fetchRequest(r)
+
+	if err != nil {
+		return req, format, err
+	}
+
+	if value := r.URL.Query().Get("timestamp"); value != "" {
+		ts, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return req, format, errors.Wrapf(InvalidFormat, "invalid timestamp %q", value)
+		}
+
+		if req.Offset, err = s.broker.OffsetForTime(req.Topic, req.Partition, time.UnixMilli(ts)); err != nil {
+			return req, format, err
+		}
+	}
+
+	return req, format, nil
+}
+
+// subscribeWS streams the messages of a partition over a WebSocket as JSON frames.
+// Pings keep the connection alive. Fetching pauses while results wait to be written,
+// so the backlog of a subscriber stays in the partition, but subscribers which stop
+// reading for too long are too far behind and disconnected.
+func (s *HTTPService) subscribeWS(w http.ResponseWriter, r *http.Request) {
+	if err := authorize(r.Context(), s.authz, auth.OperationConsume, auth.ResourceTopic, r.PathValue("topic")); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	req, format, err := s.wsRequest(r)
+
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	// Errors before the upgrade are reported with a status code.
+	if _, err := s.broker.Partition(req.Topic, req.Partition); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		// The upgrader has answered the request.
+		level.Debug(s.logger).Log("msg", "websocket upgrade failed", "err", err)
+		return
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// The subscriber only sends control frames, reading handles them and notices when it is gone.
+	conn.SetReadDeadline(time.Now().Add(s.ws.pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(s.ws.pongTimeout))
+	})
+
+	go func() {
+		defer cancel()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	results := make(chan broker.FetchResult, s.ws.maxPending)
+	errc := make(chan error, 1)
+
+	go func() {
+		err := subscribe(ctx, s.broker, req, func(res broker.FetchResult) error {
+			if _, err := s.throttle(r.WithContext(ctx), quota.KindFetch, messagesSize(res.Messages)); err != nil {
+				return err
+			}
+
+			stall := time.NewTimer(s.ws.stallTimeout)
+			defer stall.Stop()
+
+			select {
+			case results <- res:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-stall.C:
+				return errors.Wrapf(TooFarBehind, "%d results unread for %s", len(results), s.ws.stallTimeout)
+			}
+		})
+
+		// The writer may be blocked by the subscriber, so it is disconnected from here.
+		if errors.Is(err, TooFarBehind) {
+			level.Debug(s.logger).Log("msg", "subscription ended", "err", err)
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, TooFarBehind.Error()), time.Now().Add(time.Second))
+			conn.Close()
+		}
+
+		errc <- err
+	}()
+
+	ping := time.NewTicker(s.ws.pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case res := <-results:
+			err = s.writeFrame(conn, wsFrame{
+				Type:          FrameMessages,
+				Messages:      messagesToHTTP(res.Messages, format),
+				NextOffset:    res.NextOffset,
+				HighWatermark: res.HighWatermark,
+			})
+		case <-ping.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+		case err := <-errc:
+			if !errors.Is(err, TooFarBehind) {
+				s.closeWS(conn, err)
+			}
+
+			return
+		}
+
+		if err != nil {
+			level.Debug(s.logger).Log("msg", "websocket write failed", "err", err)
+			return
+		}
+	}
+}
+
+func (s *HTTPService) writeFrame(conn *websocket.Conn, frame wsFrame) error {
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(frame)
+}
+
+// closeWS reports why a subscription ended and closes the connection.
+func (s *HTTPService) closeWS(conn *websocket.Conn, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	code, body := httpStatus(err)
+	level.Debug(s.logger).Log("msg", "subscription ended", "status", code, "err", err)
+
+	if err := s.writeFrame(conn, wsFrame{Type: FrameError, Error: &body}); err != nil {
+		return
+	}
+
+	closeCode := websocket.CloseInternalServerErr
+
+	if errors.Is(err, broker.BrokerClosed) {
+		closeCode = websocket.CloseGoingAway
+	}
+
+	// Close reasons are limited to 123 bytes.
+	reason := body.Message
+
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, reason), time.Now().Add(wsWriteTimeout))
+}
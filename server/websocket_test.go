@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"iris/broker"
+	"iris/storage"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wsURL(s *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + path
+}
+
+func TestHTTPWebSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, stop := newTestHTTPServer(t, dir)
+	defer stop()
+
+	code := doJSON(t, http.MethodPost, s.URL+"/v1/topics/feed/messages",
+		`{"format": "json", "messages": [
+			{"value": 1, "timestamp": 1000},
+			{"value": 2, "timestamp": 2000},
+			{"value": 3, "timestamp": 3000}
+		]}`, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	_, res, err := websocket.DefaultDialer.Dial(wsURL(s, "/v1/topics/unknown/partitions/0/ws"), nil)
+	require.ErrorIs(t, err, websocket.ErrBadHandshake)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Starts at the first message not before the timestamp.
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(s, "/v1/topics/feed/partitions/0/ws?format=json&timestamp=1500"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	var values []string
+
+	for len(values) < 2 {
+		var frame wsFrame
+		require.NoError(t, conn.ReadJSON(&frame))
+		require.Equal(t, FrameMessages, frame.Type)
+
+		for _, m := range frame.Messages {
+			values = append(values, string(m.Value))
+		}
+
+		assert.Equal(t, uint64(3), frame.HighWatermark)
+	}
+
+	assert.Equal(t, []string{"2", "3"}, values)
+
+	code = doJSON(t, http.MethodPost, s.URL+"/v1/topics/feed/messages", `{"format": "json", "messages": [{"value": 4}]}`, nil)
+	require.Equal(t, http.StatusOK, code)
+
+	var frame wsFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	require.Len(t, frame.Messages, 1)
+	assert.Equal(t, uint64(3), frame.Messages[0].Offset)
+	assert.JSONEq(t, `4`, string(frame.Messages[0].Value))
+	assert.Equal(t, uint64(4), frame.NextOffset)
+}
+
+func TestHTTPWebSocketTooFarBehind(t *testing.T) {
+	dir, err := os.MkdirTemp("", "server_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := broker.NewBroker(log.NewNopLogger(), prometheus.NewRegistry(), broker.DefaultOptions(dir))
+	require.NoError(t, err)
+	defer b.Stop()
+
+	service := NewHTTPService(log.NewNopLogger(), b, nil, nil, nil)
+	service.ws.maxPending = 1
+	service.ws.stallTimeout = 100 * time.Millisecond
+
+	s := httptest.NewServer(service.Handler())
+	defer s.Close()
+
+	_, err = b.CreateTopic("feed", broker.TopicConfig{Partitions: 1})
+	require.NoError(t, err)
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(s, "/v1/topics/feed/partitions/0/ws"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The subscriber doesn't read until more than the socket buffers hold has been produced.
+	value := bytes.Repeat([]byte("x"), 64*1024)
+
+	for i := 0; i < 64; i++ {
+		msgs := make([]*storage.Message, 0, 8)
+
+		for j := 0; j < 8; j++ {
+			msgs = append(msgs, &storage.Message{Value: value})
+		}
+
+		_, err := b.Produce(context.Background(), broker.ProduceRequest{Topic: "feed", Partition: 0, Messages: msgs})
+		require.NoError(t, err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	for {
+		var frame wsFrame
+
+		if err = conn.ReadJSON(&frame); err != nil {
+			break
+		}
+	}
+
+	// The close frame is lost if the socket buffers were still full.
+	var closeErr *websocket.CloseError
+
+	if assert.ErrorAs(t, err, &closeErr) {
+		assert.Contains(t, []int{websocket.ClosePolicyViolation, websocket.CloseAbnormalClosure}, closeErr.Code)
+	}
+}
@@ -0,0 +1,446 @@
+package storage
+
+import (
+	"encoding/binary"
+	"os"
+	"sort"
+	"time"
+
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	AckLogSegmentExt  = "acks"
+	AckLogSegmentSize = 32 * 32 * 1024
+
+	// The log is compacted once this many segments were written after the last snapshot.
+	maxAckLogSegments = 4
+
+	ackRecordSize = 1 + 8 + 4 + 8
+)
+
+type ackRecType uint8
+
+const (
+	ackRecLease ackRecType = 1 // Message leased until a deadline.
+	ackRecAck   ackRecType = 2 // Message acknowledged.
+	ackRecFloor ackRecType = 3 // All messages before the offset are acknowledged.
+)
+
+// AckState is the delivery state of a message of a queue.
+type AckState struct {
+	Acked      bool
+	Deliveries int
+	// Deadline is the end of the current lease, the message is visible
+	// to consumers again after it. A zero deadline means not leased.
+	Deadline time.Time
+}
+
+// AckLog keeps the delivery state of the messages of a queue partition in a wal of its own.
+// Records hold absolute values, so replaying the log in order rebuilds the state.
+// It is not safe for concurrent use.
+type AckLog struct {
+	logger  log.Logger
+	dir     string
+	wal     *wal.Wal
+	metrics *AckLogMetrics
+
+	seq     uint64
+	segment uint64
+	// Number of segments on disk, and how many of them the last snapshot took.
+	segments         int
+	snapshotSegments int
+
+	floor  uint64
+	next   uint64
+	states map[uint64]*AckState
+}
+
+type AckLogMetrics struct {
+	leases      prometheus.Counter
+	acks        prometheus.Counter
+	compactions prometheus.Counter
+}
+
+type ackRecord struct {
+	typ        ackRecType
+	offset     uint64
+	deliveries uint32
+	deadline   int64
+}
+
+func encodeAckRecord(rec ackRecord) []byte {
+	bytes := make([]byte, ackRecordSize)
+	bytes[0] = byte(rec.typ)
+	binary.BigEndian.PutUint64(bytes[1:], rec.offset)
+	binary.BigEndian.PutUint32(bytes[9:], rec.deliveries)
+	binary.BigEndian.PutUint64(bytes[13:], uint64(rec.deadline))
+
+	return bytes
+}
+
+func decodeAckRecord(bytes []byte) (ackRecord, error) {
+	if len(bytes) != ackRecordSize {
+		return ackRecord{}, errors.Errorf("invalid ack record size %d", len(bytes))
+	}
+
+	return ackRecord{
+		typ:        ackRecType(bytes[0]),
+		offset:     binary.BigEndian.Uint64(bytes[1:]),
+		deliveries: binary.BigEndian.Uint32(bytes[9:]),
+		deadline:   int64(binary.BigEndian.Uint64(bytes[13:])),
+	}, nil
+}
+
+func OpenAckLog(logger log.Logger, registerer prometheus.Registerer, dir string) (*AckLog, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+
+	a := &AckLog{
+		logger:  logger,
+		dir:     dir,
+		states:  make(map[uint64]*AckState),
+		metrics: NewAckLogMetrics(prometheus.WrapRegistererWithPrefix("storage_acklog_", registerer)),
+	}
+
+	if err := a.replay(); err != nil {
+		return nil, errors.Wrap(err, "unable to replay ack log")
+	}
+
+	// The wal metrics are not registered, they would clash with the ones of the journal of the partition.
+	w, err := wal.NewWal(logger, nil, dir, AckLogSegmentSize, AckLogSegmentExt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.wal = w
+	a.segment = w.ActiveSegmentRef().Index()
+
+	if a.segments == 0 {
+		a.segments = 1
+	}
+
+	return a, nil
+}
+
+func NewAckLogMetrics(registerer prometheus.Registerer) *AckLogMetrics {
+	m := &AckLogMetrics{}
+
+	m.leases = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "leases_total",
+		Help: "Total number of message leases recorded.",
+	})
+
+	m.acks = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "acks_total",
+		Help: "Total number of message acknowledgements recorded.",
+	})
+
+	m.compactions = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "compactions_total",
+		Help: "Total number of ack log compactions.",
+	})
+
+	if registerer != nil {
+		registerer.MustRegister(m.leases, m.acks, m.compactions)
+	}
+
+	return m
+}
+
+func (a *AckLog) replay() error {
+	refs, err := wal.SegmentsOf(a.dir, AckLogSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	a.segments = len(refs)
+
+	for i, ref := range refs {
+		f, err := os.Open(ref.Name())
+
+		if err != nil {
+			return err
+		}
+
+		a.seq = ref.Index()
+		r := wal.NewReader(f)
+
+		for r.Next() {
+			rec, err := decodeAckRecord(r.Record())
+
+			if err != nil {
+				f.Close()
+				return err
+			}
+
+			a.apply(rec)
+			a.seq++
+		}
+
+		f.Close()
+
+		// Only the tail of the last segment may be torn by a crash.
+		if err := r.Err(); err != nil {
+			if i != len(refs)-1 {
+				return errors.Wrapf(err, "segment %s", ref.Name())
+			}
+
+			level.Warn(a.logger).Log("msg", "truncating torn tail of ack log", "segment", ref.Name(), "err", err)
+
+			if err := os.Truncate(ref.Name(), r.Position()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *AckLog) apply(rec ackRecord) {
+	if rec.offset < a.floor {
+		return
+	}
+
+	switch rec.typ {
+	case ackRecFloor:
+		for offset := range a.states {
+			if offset < rec.offset {
+				delete(a.states, offset)
+			}
+		}
+
+		a.floor = rec.offset
+		a.next = max(a.next, rec.offset)
+		return
+	case ackRecLease:
+		s := a.state(rec.offset)
+		s.Deliveries = int(rec.deliveries)
+		s.Deadline = time.Time{}
+
+		if rec.deadline != 0 {
+			s.Deadline = time.UnixMilli(rec.deadline)
+		}
+	case ackRecAck:
+		s := a.state(rec.offset)
+		s.Acked = true
+		s.Deadline = time.Time{}
+	}
+
+	a.next = max(a.next, rec.offset+1)
+
+	for s, ok := a.states[a.floor]; ok && s.Acked; s, ok = a.states[a.floor] {
+		delete(a.states, a.floor)
+		a.floor++
+	}
+}
+
+// unixMilli returns 0 for the zero time, which is stored for messages which are not leased.
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixMilli()
+}
+
+func (a *AckLog) state(offset uint64) *AckState {
+	s, ok := a.states[offset]
+
+	if !ok {
+		s = &AckState{}
+		a.states[offset] = s
+	}
+
+	return s
+}
+
+func (a *AckLog) write(recs ...ackRecord) error {
+	bytes := make([][]byte, 0, len(recs))
+
+	for _, rec := range recs {
+		bytes = append(bytes, encodeAckRecord(rec))
+	}
+
+	refs, err := a.wal.Append(a.seq, bytes...)
+
+	if err != nil {
+		return err
+	}
+
+	a.seq += uint64(len(recs))
+
+	for _, ref := range refs {
+		if ref.Segment != a.segment {
+			a.segment = ref.Segment
+			a.segments++
+		}
+	}
+
+	for _, rec := range recs {
+		a.apply(rec)
+	}
+
+	if a.segments-a.snapshotSegments > maxAckLogSegments {
+		if err := a.compact(); err != nil {
+			level.Error(a.logger).Log("msg", "unable to compact ack log", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// compact writes the current state into a new segment and removes the older ones.
+// Until they are removed, replaying all segments still results in the same state.
+func (a *AckLog) compact() error {
+	if err := a.wal.NextSegment(a.seq); err != nil {
+		return err
+	}
+
+	a.segment = a.seq
+	recs := []ackRecord{{typ: ackRecFloor, offset: a.floor}}
+
+	for offset, s := range a.states {
+		if s.Deliveries > 0 {
+			recs = append(recs, ackRecord{typ: ackRecLease, offset: offset, deliveries: uint32(s.Deliveries), deadline: unixMilli(s.Deadline)})
+		}
+
+		if s.Acked {
+			recs = append(recs, ackRecord{typ: ackRecAck, offset: offset})
+		}
+	}
+
+	bytes := make([][]byte, 0, len(recs))
+
+	for _, rec := range recs {
+		bytes = append(bytes, encodeAckRecord(rec))
+	}
+
+	refs, err := a.wal.Append(a.seq, bytes...)
+
+	if err != nil {
+		return err
+	}
+
+	start := a.segment
+	a.segment = refs[len(refs)-1].Segment
+	a.seq += uint64(len(recs))
+	a.metrics.compactions.Inc()
+
+	segments, err := wal.SegmentsOf(a.dir, AckLogSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	a.segments = 0
+	a.snapshotSegments = 0
+
+	for _, ref := range segments {
+		if ref.Index() >= start {
+			a.segments++
+			a.snapshotSegments++
+			continue
+		}
+
+		if err := os.Remove(ref.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Floor returns the offset before which all messages are acknowledged.
+func (a *AckLog) Floor() uint64 {
+	return a.floor
+}
+
+// Next returns the offset of the first message which has never been leased.
+func (a *AckLog) Next() uint64 {
+	return a.next
+}
+
+// State returns the delivery state of the message.
+func (a *AckLog) State(offset uint64) AckState {
+	if offset < a.floor {
+		return AckState{Acked: true}
+	}
+
+	if s, ok := a.states[offset]; ok {
+		return *s
+	}
+
+	return AckState{}
+}
+
+// Lease records a delivery of the message which is leased until the deadline,
+// the number of deliveries including this one is returned.
+func (a *AckLog) Lease(offset uint64, deadline time.Time) (int, error) {
+	deliveries := a.State(offset).Deliveries + 1
+
+	err := a.write(ackRecord{typ: ackRecLease, offset: offset, deliveries: uint32(deliveries), deadline: unixMilli(deadline)})
+
+	if err != nil {
+		return 0, err
+	}
+
+	a.metrics.leases.Inc()
+
+	return deliveries, nil
+}
+
+// Release ends the lease of the message, so it can be leased again at once.
+func (a *AckLog) Release(offset uint64) error {
+	s := a.State(offset)
+
+	return a.write(ackRecord{typ: ackRecLease, offset: offset, deliveries: uint32(s.Deliveries), deadline: 0})
+}
+
+// Ack records that the message has been processed and must not be delivered again.
+func (a *AckLog) Ack(offset uint64) error {
+	if offset < a.floor {
+		return nil
+	}
+
+	if err := a.write(ackRecord{typ: ackRecAck, offset: offset}); err != nil {
+		return err
+	}
+
+	a.metrics.acks.Inc()
+
+	return nil
+}
+
+// Expired returns up to max leased messages whose lease has ended at the given time, ordered by offset.
+func (a *AckLog) Expired(now time.Time, max int) []uint64 {
+	offsets := make([]uint64, 0)
+
+	for offset, s := range a.states {
+		if !s.Acked && s.Deliveries > 0 && !s.Deadline.After(now) {
+			offsets = append(offsets, offset)
+		}
+	}
+
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	if len(offsets) > max {
+		offsets = offsets[:max]
+	}
+
+	return offsets
+}
+
+func (a *AckLog) Stop() error {
+	return a.wal.Stop()
+}
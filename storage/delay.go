@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DeliverAtHeader holds the unix time in milliseconds a delayed message is due.
+	DeliverAtHeader = "iris-deliver-at"
+)
+
+// DelayStore keeps delayed messages until they are due. Messages are grouped in
+// time buckets by their due time, every bucket is a journal of its own so that
+// a drained bucket can be removed with its segments as a whole.
+type DelayStore struct {
+	logger      log.Logger
+	dir         string
+	segmentSize int
+	width       time.Duration
+
+	mutex   sync.Mutex
+	buckets map[int64]*Journal
+}
+
+func OpenDelayStore(logger log.Logger, dir string, segmentSize int, width time.Duration) (*DelayStore, error) {
+	if width < time.Millisecond {
+		return nil, errors.Errorf("invalid bucket width %s", width)
+	}
+
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+
+	return &DelayStore{
+		logger:      logger,
+		dir:         dir,
+		segmentSize: segmentSize,
+		width:       width,
+		buckets:     make(map[int64]*Journal),
+	}, nil
+}
+
+// BucketOf returns the start of the bucket, in unix milliseconds, which holds messages due at the given time.
+func (d *DelayStore) BucketOf(deliverAt time.Time) int64 {
+	width := d.width.Milliseconds()
+	ms := deliverAt.UnixMilli()
+
+	return ms - ms%width
+}
+
+// BucketEnd returns the time after which no message of the bucket is due anymore.
+func (d *DelayStore) BucketEnd(bucket int64) time.Time {
+	return time.UnixMilli(bucket).Add(d.width)
+}
+
+func (d *DelayStore) bucketDir(bucket int64) string {
+	return filepath.Join(d.dir, fmt.Sprintf("%020d", bucket))
+}
+
+// Add stores the message in the bucket of its due time and returns the bucket and the offset within it.
+func (d *DelayStore) Add(deliverAt time.Time, msg *Message) (int64, uint64, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	bucket := d.BucketOf(deliverAt)
+	j, err := d.bucket(bucket)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	msg.SetHeader(DeliverAtHeader, []byte(strconv.FormatInt(deliverAt.UnixMilli(), 10)))
+
+	offset, err := j.Append(msg)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return bucket, offset, nil
+}
+
+func (d *DelayStore) bucket(bucket int64) (*Journal, error) {
+	if j, ok := d.buckets[bucket]; ok {
+		return j, nil
+	}
+
+	// Buckets come and go, so their metrics are not registered.
+	j, err := NewJournal(log.With(d.logger, "bucket", bucket), nil, d.bucketDir(bucket), d.segmentSize)
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open bucket %d", bucket)
+	}
+
+	d.buckets[bucket] = j
+
+	return j, nil
+}
+
+// Bucket returns the journal of the bucket.
+func (d *DelayStore) Bucket(bucket int64) (*Journal, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.bucket(bucket)
+}
+
+// Buckets lists the stored buckets ordered by time.
+func (d *DelayStore) Buckets() ([]int64, error) {
+	entries, err := os.ReadDir(d.dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := make([]int64, 0, len(entries))
+
+	for _, e := range entries {
+		bucket, err := strconv.ParseInt(e.Name(), 10, 64)
+
+		if !e.IsDir() || err != nil {
+			continue
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i] < buckets[j]
+	})
+
+	return buckets, nil
+}
+
+// Drop removes the bucket unless messages were added after the given offset,
+// it reports whether the bucket has been removed.
+func (d *DelayStore) Drop(bucket int64, next uint64) (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	j, err := d.bucket(bucket)
+
+	if err != nil {
+		return false, err
+	}
+
+	if j.NextOffset() != next {
+		return false, nil
+	}
+
+	delete(d.buckets, bucket)
+
+	if err := j.Stop(); err != nil {
+		level.Error(d.logger).Log("msg", "error stopping bucket", "bucket", bucket, "err", err)
+	}
+
+	return true, os.RemoveAll(d.bucketDir(bucket))
+}
+
+// DeliverAt returns the due time of a message read from the store.
+func DeliverAt(msg *Message) (time.Time, bool) {
+	value, ok := msg.Header(DeliverAtHeader)
+
+	if !ok {
+		return time.Time{}, false
+	}
+
+	ms, err := strconv.ParseInt(string(value), 10, 64)
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.UnixMilli(ms), true
+}
+
+func (d *DelayStore) Stop() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for bucket, j := range d.buckets {
+		if err := j.Stop(); err != nil {
+			level.Error(d.logger).Log("msg", "error stopping bucket", "bucket", bucket, "err", err)
+		}
+	}
+
+	d.buckets = make(map[int64]*Journal)
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DeliveryAttempts counts the failed deliveries of messages per consumer group,
+// the counters are kept in a table so they survive restarts.
+type DeliveryAttempts struct {
+	table *Table
+}
+
+func NewDeliveryAttempts(table *Table) *DeliveryAttempts {
+	return &DeliveryAttempts{table: table}
+}
+
+func attemptsPrefix(group string, topic string, partition int) string {
+	return fmt.Sprintf("attempts/%s/%s/%d/", group, topic, partition)
+}
+
+func attemptsKey(group string, topic string, partition int, offset uint64) string {
+	return attemptsPrefix(group, topic, partition) + strconv.FormatUint(offset, 10)
+}
+
+// Get returns the number of failed deliveries of the message.
+func (d *DeliveryAttempts) Get(group string, topic string, partition int, offset uint64) int {
+	value, ok := d.table.Get(attemptsKey(group, topic, partition, offset))
+
+	if !ok || len(value) != 4 {
+		return 0
+	}
+
+	return int(binary.BigEndian.Uint32(value))
+}
+
+// Failed records a failed delivery of the message and returns the number of attempts so far.
+func (d *DeliveryAttempts) Failed(group string, topic string, partition int, offset uint64) (int, error) {
+	attempts := d.Get(group, topic, partition, offset) + 1
+
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, uint32(attempts))
+
+	if err := d.table.Put(attemptsKey(group, topic, partition, offset), value); err != nil {
+		return 0, err
+	}
+
+	return attempts, nil
+}
+
+// Clear forgets the attempts of the message.
+func (d *DeliveryAttempts) Clear(group string, topic string, partition int, offset uint64) error {
+	return d.table.Delete(attemptsKey(group, topic, partition, offset))
+}
+
+// ClearBelow forgets the attempts of all messages before the given offset,
+// it is called once the group has committed past them.
+func (d *DeliveryAttempts) ClearBelow(group string, topic string, partition int, offset uint64) error {
+	prefix := attemptsPrefix(group, topic, partition)
+	keys := make([]string, 0)
+
+	d.table.Range(prefix, func(key string, value []byte) bool {
+		o, err := strconv.ParseUint(strings.TrimPrefix(key, prefix), 10, 64)
+
+		if err == nil && o < offset {
+			keys = append(keys, key)
+		}
+
+		return true
+	})
+
+	for _, key := range keys {
+		if err := d.table.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
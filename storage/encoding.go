@@ -1,18 +1,201 @@
 package storage
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	indexRecordSize = 16
+	messageVersion  = 2
+)
+
+var InvalidMessage = errors.New("Invalid message")
 
 func EncodeIndex(rec IndexRecord, bytes []byte) {
 	binary.BigEndian.PutUint64(bytes, rec.key)
-	binary.BigEndian.PutUint64(bytes, rec.value)
+	binary.BigEndian.PutUint64(bytes[8:], rec.value)
 }
 
 func DecodeIndex(bytes []byte) IndexRecord {
-	key := binary.BigEndian.Uint64(bytes[:4])
-	value := binary.BigEndian.Uint64(bytes[4:])
+	key := binary.BigEndian.Uint64(bytes[:8])
+	value := binary.BigEndian.Uint64(bytes[8:])
 
 	return IndexRecord{
 		key:   key,
 		value: value,
 	}
 }
+
+// Message format:
+// [ 1 byte version ] [ 8 bytes offset ] [ 8 bytes timestamp in unix nanoseconds ]
+// [ 8 bytes expiry in unix nanoseconds, 0 if the message does not expire ]
+// [ 4 bytes key length ] [ key ] [ 4 bytes value length ] [ value ]
+// [ 2 bytes header count ] ( [ 2 bytes header key length ] [ key ] [ 4 bytes header value length ] [ value ] )*
+// Version 1 messages have no expiry.
+func EncodeMessage(msg *Message) []byte {
+	size := 1 + 8 + 8 + 8 + 4 + len(msg.Key) + 4 + len(msg.Value) + 2
+
+	for _, h := range msg.Headers {
+		size += 2 + len(h.Key) + 4 + len(h.Value)
+	}
+
+	bytes := make([]byte, size)
+	bytes[0] = messageVersion
+	n := 1
+
+	binary.BigEndian.PutUint64(bytes[n:], msg.Offset)
+	n += 8
+
+	binary.BigEndian.PutUint64(bytes[n:], uint64(msg.Timestamp.UnixNano()))
+	n += 8
+
+	if !msg.ExpiresAt.IsZero() {
+		binary.BigEndian.PutUint64(bytes[n:], uint64(msg.ExpiresAt.UnixNano()))
+	}
+	n += 8
+
+	n += putBytes32(bytes[n:], msg.Key)
+	n += putBytes32(bytes[n:], msg.Value)
+
+	binary.BigEndian.PutUint16(bytes[n:], uint16(len(msg.Headers)))
+	n += 2
+
+	for _, h := range msg.Headers {
+		binary.BigEndian.PutUint16(bytes[n:], uint16(len(h.Key)))
+		n += 2
+		n += copy(bytes[n:], h.Key)
+		n += putBytes32(bytes[n:], h.Value)
+	}
+
+	return bytes
+}
+
+func DecodeMessage(bytes []byte) (*Message, error) {
+	d := decoder{bytes: bytes}
+
+	version := d.uint8()
+
+	if version != 1 && version != messageVersion {
+		return nil, errors.Wrapf(InvalidMessage, "unknown version %d", version)
+	}
+
+	msg := &Message{}
+	msg.Offset = d.uint64()
+	msg.Timestamp = time.Unix(0, int64(d.uint64()))
+
+	if version >= 2 {
+		if expiresAt := int64(d.uint64()); expiresAt != 0 {
+			msg.ExpiresAt = time.Unix(0, expiresAt)
+		}
+	}
+	msg.Key = d.bytes32()
+	msg.Value = d.bytes32()
+
+	count := int(d.uint16())
+
+	if count > 0 {
+		msg.Headers = make([]Header, 0, count)
+	}
+
+	for i := 0; i < count; i++ {
+		key := string(d.next(int(d.uint16())))
+		msg.Headers = append(msg.Headers, Header{Key: key, Value: d.bytes32()})
+	}
+
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	if len(d.bytes) != 0 {
+		return nil, errors.Wrap(InvalidMessage, "unexpected trailing bytes")
+	}
+
+	return msg, nil
+}
+
+func putBytes32(dst []byte, src []byte) int {
+	binary.BigEndian.PutUint32(dst, uint32(len(src)))
+
+	return 4 + copy(dst[4:], src)
+}
+
+// decoder reads big endian values and remembers the first short read.
+type decoder struct {
+	bytes []byte
+	err   error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n > len(d.bytes) {
+		d.err = errors.Wrap(InvalidMessage, "unexpected end of message")
+		return nil
+	}
+
+	b := d.bytes[:n:n]
+	d.bytes = d.bytes[n:]
+
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	b := d.next(1)
+
+	if b == nil {
+		return 0
+	}
+
+	return b[0]
+}
+
+func (d *decoder) uint16() uint16 {
+	b := d.next(2)
+
+	if b == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint16(b)
+}
+
+func (d *decoder) uint32() uint32 {
+	b := d.next(4)
+
+	if b == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint32(b)
+}
+
+func (d *decoder) uint64() uint64 {
+	b := d.next(8)
+
+	if b == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *decoder) bytes32() []byte {
+	n := int(d.uint32())
+
+	if n == 0 {
+		return nil
+	}
+
+	b := d.next(n)
+
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"iris/storage/wal"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// The leader epochs of a journal are kept in a single file next to its segments.
+	EpochsSegmentExt = "epochs"
+	epochsTmpExt     = "tmp"
+
+	// NoEpoch is the epoch of messages written before any leader epoch was recorded.
+	NoEpoch = int32(-1)
+)
+
+var InvalidEpoch = errors.New("Invalid leader epoch")
+
+// Epoch is a leader epoch and the offset of the first message its leader appended.
+type Epoch struct {
+	Epoch       int32  `json:"epoch"`
+	StartOffset uint64 `json:"startOffset"`
+}
+
+// Epochs records the offset ranges the leader epochs of a journal cover,
+// an epoch ends where the next one starts. Replicas compare them to find
+// the offset their journals diverge at.
+type Epochs struct {
+	dir string
+
+	mutex   sync.Mutex
+	entries []Epoch
+}
+
+func OpenEpochs(dir string) (*Epochs, error) {
+	e := &Epochs{dir: dir}
+	data, err := os.ReadFile(wal.ToSegmentName(dir, 0, EpochsSegmentExt))
+
+	if os.IsNotExist(err) {
+		return e, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &e.entries); err != nil {
+		return nil, errors.Wrap(err, "invalid leader epochs")
+	}
+
+	return e, nil
+}
+
+// Entries returns the epochs which start at or cover the offset and all later ones.
+func (e *Epochs) Entries(from uint64) []Epoch {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	i := len(e.entries)
+
+	for i > 0 && e.entries[i-1].StartOffset > from {
+		i--
+	}
+
+	return append([]Epoch(nil), e.entries[max(i-1, 0):]...)
+}
+
+// Latest returns the epoch of the last message, NoEpoch if no epoch was recorded.
+func (e *Epochs) Latest() int32 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.entries) == 0 {
+		return NoEpoch
+	}
+
+	return e.entries[len(e.entries)-1].Epoch
+}
+
+// Assign records that the epoch starts at the offset. Epochs which are not
+// newer than the latest one are ignored, an epoch starting at the same offset
+// as the latest one replaces it.
+func (e *Epochs) Assign(epoch int32, offset uint64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if n := len(e.entries); n > 0 {
+		latest := e.entries[n-1]
+
+		if epoch <= latest.Epoch {
+			return nil
+		}
+
+		if offset < latest.StartOffset {
+			return errors.Wrapf(InvalidEpoch, "epoch %d at %d before epoch %d at %d", epoch, offset, latest.Epoch, latest.StartOffset)
+		}
+
+		if offset == latest.StartOffset {
+			e.entries = e.entries[:n-1]
+		}
+	}
+
+	e.entries = append(e.entries, Epoch{Epoch: epoch, StartOffset: offset})
+
+	return e.write()
+}
+
+// EndOffset returns the latest epoch which is not newer than the given one and the offset
+// it ends at, end is the next offset of the journal. NoEpoch is returned with the start of
+// the first epoch if all epochs are newer.
+func (e *Epochs) EndOffset(epoch int32, end uint64) (int32, uint64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for i := len(e.entries) - 1; i >= 0; i-- {
+		if e.entries[i].Epoch <= epoch {
+			if i+1 < len(e.entries) {
+				end = e.entries[i+1].StartOffset
+			}
+
+			return e.entries[i].Epoch, end
+		}
+	}
+
+	if len(e.entries) > 0 {
+		end = min(end, e.entries[0].StartOffset)
+	}
+
+	return NoEpoch, end
+}
+
+// TruncateFrom removes the epochs starting at or after the offset.
+func (e *Epochs) TruncateFrom(offset uint64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	n := len(e.entries)
+
+	for n > 0 && e.entries[n-1].StartOffset >= offset {
+		n--
+	}
+
+	if n == len(e.entries) {
+		return nil
+	}
+
+	e.entries = e.entries[:n]
+
+	return e.write()
+}
+
+// clear forgets all epochs, the file is removed by the caller.
+func (e *Epochs) clear() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.entries = nil
+}
+
+// write replaces the file atomically, so a crash keeps either the old or the new epochs.
+func (e *Epochs) write() error {
+	data, err := json.Marshal(e.entries)
+
+	if err != nil {
+		return err
+	}
+
+	tmp := wal.ToSegmentName(e.dir, 0, epochsTmpExt)
+	f, err := os.Create(tmp)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, wal.ToSegmentName(e.dir, 0, EpochsSegmentExt))
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+
+	"iris/storage/wal"
+)
+
+const (
+	// Every segment of a journal has a file with the latest expiry of its
+	// messages, so retention can drop it without reading the segment.
+	ExpirySegmentExt = "expiry"
+
+	neverExpires = math.MaxInt64
+
+	// The stored expiry is rounded up by this many milliseconds, so the file is
+	// not rewritten for every append of a message with a slightly later expiry.
+	expirySlack = 60 * 1000
+)
+
+// expiryOf returns the expiry of the message in unix milliseconds.
+func expiryOf(msg *Message) int64 {
+	if msg.ExpiresAt.IsZero() {
+		return neverExpires
+	}
+
+	return msg.ExpiresAt.UnixMilli()
+}
+
+func roundExpiry(expiry int64) int64 {
+	if expiry > neverExpires-expirySlack {
+		return neverExpires
+	}
+
+	return expiry + expirySlack
+}
+
+// readSegmentExpiry returns the latest expiry of the messages in the segment,
+// false is returned if the segment has no expiry file.
+func readSegmentExpiry(dir string, segment uint64) (int64, bool, error) {
+	bytes, err := os.ReadFile(wal.ToSegmentName(dir, segment, ExpirySegmentExt))
+
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	if len(bytes) != 8 {
+		return neverExpires, true, nil
+	}
+
+	return int64(binary.BigEndian.Uint64(bytes)), true, nil
+}
+
+func writeSegmentExpiry(dir string, segment uint64, expiry int64) error {
+	f, err := os.OpenFile(wal.ToSegmentName(dir, segment, ExpirySegmentExt), os.O_WRONLY|os.O_CREATE, 0o666)
+
+	if err != nil {
+		return err
+	}
+
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, uint64(expiry))
+
+	if _, err := f.WriteAt(bytes, 0); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
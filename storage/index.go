@@ -1,6 +1,12 @@
 package storage
 
 import (
+	"io"
+	"os"
+	"sort"
+
+	"iris/storage/wal"
+
 	"github.com/prometheus/prometheus/tsdb/wlog"
 )
 
@@ -13,8 +19,19 @@ type IndexRecord struct {
 	value uint64
 }
 
+// Offset returns the offset of the indexed message.
+func (r IndexRecord) Offset() uint64 {
+	return r.key
+}
+
+// Position returns the byte position of the indexed message in its segment.
+func (r IndexRecord) Position() int64 {
+	return int64(r.value)
+}
+
 type Index interface {
 	Add(rec IndexRecord) error
+	Close() error
 }
 
 type OffsetIndex struct {
@@ -25,14 +42,28 @@ type OffsetIndex struct {
 func NewOffsetIndex(file wlog.SegmentFile) Index {
 	ind := &OffsetIndex{
 		file: file,
-		pool: NewBytesPool(16), //16 bytes of offset index record
+		pool: NewBytesPool(indexRecordSize), //16 bytes of offset index record
 	}
 
 	return ind
 }
 
+// OpenOffsetIndex opens the offset index which belongs to the given segment for appending.
+func OpenOffsetIndex(dir string, segment uint64) (Index, error) {
+	file, err := wal.CreateSegment(dir, segment, OffsetIndexSegmentExt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOffsetIndex(file), nil
+}
+
 func (i *OffsetIndex) Add(rec IndexRecord) error {
 	bytes := i.pool.GetBytes()
+	defer i.pool.PutBytes(bytes)
+
+	*bytes = (*bytes)[:indexRecordSize]
 
 	EncodeIndex(rec, *bytes)
 
@@ -42,13 +73,69 @@ func (i *OffsetIndex) Add(rec IndexRecord) error {
 		return err
 	}
 
-	err = i.file.Sync()
+	return i.file.Sync()
+}
+
+func (i *OffsetIndex) Close() error {
+	return i.file.Close()
+}
+
+// ReadOffsetIndex loads all records of the offset index of the given segment,
+// a missing index is treated as an empty one. A torn record at the end is ignored.
+func ReadOffsetIndex(dir string, segment uint64) ([]IndexRecord, error) {
+	f, err := os.Open(wal.ToSegmentName(dir, segment, OffsetIndexSegmentExt))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	bytes, err := io.ReadAll(f)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	i.pool.PutBytes(bytes)
+	recs := make([]IndexRecord, 0, len(bytes)/indexRecordSize)
+
+	for len(bytes) >= indexRecordSize {
+		recs = append(recs, DecodeIndex(bytes[:indexRecordSize]))
+		bytes = bytes[indexRecordSize:]
+	}
+
+	return recs, nil
+}
+
+// TruncateOffsetIndex keeps the first n records of the offset index of the given segment,
+// a missing index is left missing.
+func TruncateOffsetIndex(dir string, segment uint64, n int) error {
+	err := os.Truncate(wal.ToSegmentName(dir, segment, OffsetIndexSegmentExt), int64(n*indexRecordSize))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
 
 	return nil
 }
+
+// lookupIndex returns the position of the closest indexed offset which is not
+// greater than the given one, or 0 when there is no such record.
+func lookupIndex(recs []IndexRecord, offset uint64, limit int64) int64 {
+	i := sort.Search(len(recs), func(i int) bool {
+		return recs[i].key > offset
+	})
+
+	// Entries may point past the data if the segment lost its unsynced tail.
+	for ; i > 0; i-- {
+		if position := int64(recs[i-1].value); position < limit {
+			return position
+		}
+	}
+
+	return 0
+}
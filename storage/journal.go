@@ -1,11 +1,1222 @@
 package storage
 
-import "iris/storage/wal"
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	JournalSegmentExt = "log"
+	// The log start offset of a journal is kept in a single file next to its segments.
+	StartOffsetSegmentExt = "start"
+
+	// An index record is written at most once per this many bytes of a segment by default.
+	DefaultIndexIntervalBytes = 4 * 1024
+)
+
+var (
+	OffsetOutOfRange   = errors.New("Offset out of range")
+	UnknownSegmentFile = errors.New("Unknown segment file")
+	JournalNotEmpty    = errors.New("Journal not empty")
+)
+
+// sealedSegmentExts are the extensions of the files which belong to a segment.
+var sealedSegmentExts = []string{JournalSegmentExt, OffsetIndexSegmentExt, ExpirySegmentExt}
+
+// SegmentFile is a file of a sealed segment, which is not written anymore.
+type SegmentFile struct {
+	// Name is the file name without the directory.
+	Name string
+	Size int64
+}
+
+// JournalOptions are the settings of a journal which may change while it is open.
+type JournalOptions struct {
+	// IndexIntervalBytes is how many bytes of a segment are written at least between two offset index records.
+	IndexIntervalBytes int
+	// SyncEveryAppend flushes the messages to disk before an append returns, otherwise they are
+	// flushed when their segment is sealed or by Sync.
+	SyncEveryAppend bool
+	// RetentionTime is how long sealed segments are kept after they were last written,
+	// zero keeps them regardless of their age.
+	RetentionTime time.Duration
+	// RetentionBytes is the size the segments are kept below by dropping the oldest sealed ones,
+	// zero doesn't limit the size.
+	RetentionBytes int64
+}
+
+func DefaultJournalOptions() JournalOptions {
+	return JournalOptions{IndexIntervalBytes: DefaultIndexIntervalBytes}
+}
+
+// Journal is an offset addressed log of messages on top of a wal,
+// each wal segment gets a sparse offset index next to it.
 type Journal struct {
-	wal   wal.Wal
-	index []Index
+	logger      log.Logger
+	registerer  prometheus.Registerer
+	dir         string
+	segmentSize int
+	metrics     *JournalMetrics
+
+	wal          *wal.Wal
+	epochs       *Epochs
+	index        Index
+	indexSegment uint64
+	lastIndexed  int64
+	// Expiry written for the active segment, 0 if nothing has been written yet.
+	expiry int64
+
+	mutex      sync.RWMutex
+	options    JournalOptions
+	nextOffset uint64
+	// logStart is the offset before which messages were deleted on request,
+	// the segments before it are dropped by retention.
+	logStart uint64
+	// Closed and replaced on every append.
+	appended chan struct{}
 }
 
 type JournalMetrics struct {
+	appendedMessages prometheus.Counter
+	appendedBytes    prometheus.Counter
+	readMessages     prometheus.Counter
+	expiredSegments  prometheus.Counter
+}
+
+func NewJournal(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		logger:      logger,
+		registerer:  registerer,
+		dir:         dir,
+		segmentSize: segmentSize,
+		lastIndexed: -1,
+		options:     DefaultJournalOptions(),
+		appended:    make(chan struct{}),
+		metrics:     NewJournalMetrics(prometheus.WrapRegistererWithPrefix("storage_journal_", registerer)),
+	}
+
+	if err := j.recover(); err != nil {
+		return nil, errors.Wrap(err, "unable to recover journal")
+	}
+
+	logStart, err := readStartOffset(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	j.logStart = logStart
+
+	epochs, err := OpenEpochs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	j.epochs = epochs
+
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// open starts writing to the last segment of the journal.
+func (j *Journal) open() error {
+	w, err := wal.NewWal(j.logger, j.registerer, j.dir, j.segmentSize, JournalSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	j.wal = w
+	j.indexSegment = w.ActiveSegmentRef().Index()
+	j.lastIndexed = -1
+
+	index, err := OpenOffsetIndex(j.dir, j.indexSegment)
+
+	if err != nil {
+		w.Stop()
+		return err
+	}
+
+	j.index = index
+
+	if err := j.loadExpiry(); err != nil {
+		j.close()
+		return err
+	}
+
+	return nil
+}
+
+// close flushes the active segment and closes it together with its offset index.
+func (j *Journal) close() error {
+	if err := j.index.Close(); err != nil {
+		level.Error(j.logger).Log("msg", "close offset index", "err", err)
+	}
+
+	return j.wal.Stop()
+}
+
+func NewJournalMetrics(registerer prometheus.Registerer) *JournalMetrics {
+	m := &JournalMetrics{}
+
+	m.appendedMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "appended_messages_total",
+		Help: "Total number of messages appended to the journal.",
+	})
+
+	m.appendedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "appended_bytes_total",
+		Help: "Total number of encoded message bytes appended to the journal.",
+	})
+
+	m.readMessages = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "read_messages_total",
+		Help: "Total number of messages read from the journal.",
+	})
+
+	m.expiredSegments = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "expired_segments_dropped_total",
+		Help: "Total number of segments dropped because all of their messages expired.",
+	})
+
+	if registerer != nil {
+		registerer.MustRegister(m.appendedMessages, m.appendedBytes, m.readMessages, m.expiredSegments)
+	}
+
+	return m
+}
+
+// recover finds the next offset by scanning the tail of the last segment.
+// A torn record at the end of the segment is cut off, so that new records
+// are not appended behind data the reader can't get past.
+func (j *Journal) recover() error {
+	ref, err := wal.LastSegmentOf(j.dir, JournalSegmentExt)
+
+	if err != nil || ref == nil {
+		return err
+	}
+
+	j.nextOffset = ref.Index()
+
+	f, err := os.OpenFile(ref.Name(), os.O_RDWR, 0o666)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	stat, err := f.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	recs, err := ReadOffsetIndex(j.dir, ref.Index())
+
+	if err != nil {
+		return err
+	}
+
+	position := lookupIndex(recs, ^uint64(0), stat.Size())
+
+	if _, err := f.Seek(position, 0); err != nil {
+		return err
+	}
+
+	r := wal.NewReaderAt(f, position)
+
+	for r.Next() {
+		msg, err := DecodeMessage(r.Record())
+
+		if err != nil {
+			return err
+		}
+
+		j.nextOffset = msg.Offset + 1
+	}
+
+	if err := r.Err(); err != nil {
+		level.Warn(j.logger).Log("msg", "truncating torn tail of segment", "segment", ref.Name(), "position", r.Position(), "err", err)
+
+		return f.Truncate(r.Position())
+	}
+
+	return nil
+}
+
+// loadExpiry reads the expiry of the active segment. A segment with data but
+// without an expiry file may hold messages which never expire.
+func (j *Journal) loadExpiry() error {
+	expiry, ok, err := readSegmentExpiry(j.dir, j.indexSegment)
+
+	if err != nil || ok {
+		j.expiry = expiry
+		return err
+	}
+
+	f, err := os.Stat(wal.ToSegmentName(j.dir, j.indexSegment, JournalSegmentExt))
+
+	if err != nil {
+		return err
+	}
+
+	if f.Size() == 0 {
+		return nil
+	}
+
+	j.expiry = neverExpires
+
+	return writeSegmentExpiry(j.dir, j.indexSegment, neverExpires)
+}
+
+// Append assigns consecutive offsets to the messages and writes them to the wal,
+// the offset of the first message is returned.
+func (j *Journal) Append(msgs ...*Message) (uint64, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	base := j.nextOffset
+	now := time.Now()
+
+	for i, msg := range msgs {
+		msg.Offset = base + uint64(i)
+
+		if msg.Timestamp.IsZero() {
+			msg.Timestamp = now
+		}
+	}
+
+	if err := j.append(base, msgs); err != nil {
+		return 0, err
+	}
+
+	return base, nil
+}
+
+// Replicate writes messages which got their offsets from another journal, like the
+// one of the leader of a partition. They must continue at the next offset without gaps.
+func (j *Journal) Replicate(msgs ...*Message) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for i, msg := range msgs {
+		if expected := j.nextOffset + uint64(i); msg.Offset != expected {
+			return errors.Wrapf(OffsetOutOfRange, "replicated offset %d, expected %d", msg.Offset, expected)
+		}
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	return j.append(j.nextOffset, msgs)
+}
+
+// append writes messages with consecutive offsets starting at base, which is the next offset.
+func (j *Journal) append(base uint64, msgs []*Message) error {
+	recs := make([][]byte, 0, len(msgs))
+	size := 0
+	expiry := int64(0)
+
+	for _, msg := range msgs {
+		expiry = max(expiry, expiryOf(msg))
+
+		rec := EncodeMessage(msg)
+		size += len(rec)
+		recs = append(recs, rec)
+	}
+
+	// The expiry is raised before the append, a crash in between
+	// only keeps the segment longer than needed.
+	active := j.indexSegment
+
+	if expiry > j.expiry {
+		if err := writeSegmentExpiry(j.dir, active, roundExpiry(expiry)); err != nil {
+			return err
+		}
+
+		j.expiry = roundExpiry(expiry)
+	}
+
+	refs, err := j.wal.Append(base, recs...)
+
+	if err != nil {
+		return err
+	}
+
+	if j.options.SyncEveryAppend {
+		if err := j.wal.Sync(); err != nil {
+			return err
+		}
+	}
+
+	if err := j.writeRotatedExpiry(active, refs, msgs); err != nil {
+		level.Error(j.logger).Log("msg", "unable to write segment expiry", "err", err)
+	}
+
+	for i, ref := range refs {
+		if err := j.indexRecord(base+uint64(i), ref); err != nil {
+			level.Error(j.logger).Log("msg", "unable to write offset index", "err", err, "offset", base+uint64(i))
+		}
+	}
+
+	j.nextOffset = base + uint64(len(msgs))
+
+	close(j.appended)
+	j.appended = make(chan struct{})
+
+	j.metrics.appendedMessages.Add(float64(len(msgs)))
+	j.metrics.appendedBytes.Add(float64(size))
+
+	return nil
+}
+
+// writeRotatedExpiry writes the expiry of the segments created by an append.
+// Until then they have no expiry file, which keeps them from being dropped.
+func (j *Journal) writeRotatedExpiry(active uint64, refs []wal.RecordRef, msgs []*Message) error {
+	expiries := make(map[uint64]int64)
+
+	for i, ref := range refs {
+		if ref.Segment != active {
+			expiries[ref.Segment] = max(expiries[ref.Segment], expiryOf(msgs[i]))
+		}
+	}
+
+	for segment, expiry := range expiries {
+		if err := writeSegmentExpiry(j.dir, segment, roundExpiry(expiry)); err != nil {
+			return err
+		}
+
+		if segment == refs[len(refs)-1].Segment {
+			j.expiry = roundExpiry(expiry)
+		}
+	}
+
+	return nil
+}
+
+func (j *Journal) indexRecord(offset uint64, ref wal.RecordRef) error {
+	if ref.Segment != j.indexSegment {
+		if err := j.index.Close(); err != nil {
+			level.Error(j.logger).Log("msg", "error closing previous offset index", "err", err, "segmentId", j.indexSegment)
+		}
+
+		index, err := OpenOffsetIndex(j.dir, ref.Segment)
+
+		if err != nil {
+			return err
+		}
+
+		j.index = index
+		j.indexSegment = ref.Segment
+		j.lastIndexed = -1
+	}
+
+	if j.lastIndexed >= 0 && ref.Position-j.lastIndexed < int64(j.options.IndexIntervalBytes) {
+		return nil
+	}
+
+	if err := j.index.Add(IndexRecord{key: offset, value: uint64(ref.Position)}); err != nil {
+		return err
+	}
+
+	j.lastIndexed = ref.Position
+
+	return nil
+}
+
+// SetOptions changes the settings of the journal, they apply to the following appends.
+func (j *Journal) SetOptions(options JournalOptions) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if options.IndexIntervalBytes <= 0 {
+		options.IndexIntervalBytes = DefaultIndexIntervalBytes
+	}
+
+	j.options = options
+}
+
+// Sync flushes the messages of the active segment to disk.
+func (j *Journal) Sync() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.wal.Sync()
+}
+
+// NextOffset returns the offset the next appended message will get.
+func (j *Journal) NextOffset() uint64 {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	return j.nextOffset
+}
+
+// Appended returns a channel which is closed by the next append,
+// or which is closed already if the offset has been appended before.
+func (j *Journal) Appended(offset uint64) <-chan struct{} {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if offset < j.nextOffset {
+		done := make(chan struct{})
+		close(done)
+
+		return done
+	}
+
+	return j.appended
+}
+
+// StartOffset returns the first offset which is still kept in the journal.
+func (j *Journal) StartOffset() (uint64, error) {
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	if len(refs) == 0 {
+		return j.nextOffset, nil
+	}
+
+	return max(refs[0].Index(), j.logStart), nil
+}
+
+// AdvanceStartOffset deletes the messages before the offset, they can't be read anymore
+// and their segments are dropped by DropBefore. The log start offset never moves back.
+func (j *Journal) AdvanceStartOffset(offset uint64) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if offset > j.nextOffset {
+		return errors.Wrapf(OffsetOutOfRange, "offset %d is after the end of the journal %d", offset, j.nextOffset)
+	}
+
+	if offset <= j.logStart {
+		return nil
+	}
+
+	if err := writeStartOffset(j.dir, offset); err != nil {
+		return err
+	}
+
+	level.Info(j.logger).Log("msg", "advanced log start offset", "offset", offset)
+
+	j.logStart = offset
+
+	return nil
+}
+
+func readStartOffset(dir string) (uint64, error) {
+	bytes, err := os.ReadFile(wal.ToSegmentName(dir, 0, StartOffsetSegmentExt))
+
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(bytes) != 8 {
+		return 0, errors.Errorf("invalid log start offset file of %d bytes", len(bytes))
+	}
+
+	return binary.BigEndian.Uint64(bytes), nil
+}
+
+func writeStartOffset(dir string, offset uint64) error {
+	f, err := os.OpenFile(wal.ToSegmentName(dir, 0, StartOffsetSegmentExt), os.O_WRONLY|os.O_CREATE, 0o666)
+
+	if err != nil {
+		return err
+	}
+
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, offset)
+
+	if _, err := f.WriteAt(bytes, 0); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Read returns up to maxMessages messages starting from the given offset.
+func (j *Journal) Read(offset uint64, maxMessages int) ([]*Message, error) {
+	msgs := make([]*Message, 0)
+
+	err := j.Scan(offset, func(msg *Message) bool {
+		msgs = append(msgs, msg)
+
+		return len(msgs) < maxMessages
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// Scan calls fn for every message starting from the given offset until
+// the end of the journal is reached or fn returns false.
+func (j *Journal) Scan(offset uint64, fn func(msg *Message) bool) error {
+	j.mutex.RLock()
+	start, end := j.logStart, j.nextOffset
+	j.mutex.RUnlock()
+
+	if offset > end {
+		return errors.Wrapf(OffsetOutOfRange, "offset %d is after the end of the journal %d", offset, end)
+	}
+
+	if offset < start {
+		return errors.Wrapf(OffsetOutOfRange, "offset %d is before the log start offset %d", offset, start)
+	}
+
+	if offset == end {
+		return nil
+	}
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	i := sort.Search(len(refs), func(i int) bool {
+		return refs[i].Index() > offset
+	}) - 1
+
+	if i < 0 {
+		return errors.Wrapf(OffsetOutOfRange, "offset %d is before the start of the journal", offset)
+	}
+
+	for ; i < len(refs) && offset < end; i++ {
+		next, stop, err := j.scanSegment(refs[i], offset, end, fn)
+
+		if err != nil {
+			return err
+		}
+
+		if stop {
+			return nil
+		}
+
+		offset = next
+	}
+
+	return nil
+}
+
+// scanSegment reads the messages of one segment, it returns the offset
+// after the last message read and whether fn asked to stop.
+func (j *Journal) scanSegment(ref wal.SegmentRef, offset uint64, end uint64, fn func(msg *Message) bool) (uint64, bool, error) {
+	f, err := os.Open(ref.Name())
+
+	// The segment was dropped by retention since it was listed.
+	if os.IsNotExist(err) {
+		return offset, false, nil
+	}
+
+	if err != nil {
+		return offset, false, err
+	}
+
+	defer f.Close()
+
+	stat, err := f.Stat()
+
+	if err != nil {
+		return offset, false, err
+	}
+
+	recs, err := ReadOffsetIndex(j.dir, ref.Index())
+
+	if err != nil {
+		return offset, false, err
+	}
+
+	position := lookupIndex(recs, offset, stat.Size())
+
+	if _, err := f.Seek(position, 0); err != nil {
+		return offset, false, err
+	}
+
+	r := wal.NewReaderAt(f, position)
+
+	// Records behind end may still be written, so reading stops before them.
+	for offset < end && r.Next() {
+		msg, err := DecodeMessage(r.Record())
+
+		if err != nil {
+			return offset, false, err
+		}
+
+		if msg.Offset < offset {
+			continue
+		}
+
+		offset = msg.Offset + 1
+		j.metrics.readMessages.Inc()
+
+		if !fn(msg) {
+			return offset, true, nil
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return offset, false, errors.Wrapf(err, "unable to read segment %s", ref.Name())
+	}
+
+	return offset, false, nil
+}
+
+// DropExpired removes the sealed segments whose messages have all expired
+// at the given time and returns how many segments were removed.
+func (j *Journal) DropExpired(now time.Time) (int, error) {
+	j.mutex.RLock()
+	active := j.indexSegment
+	j.mutex.RUnlock()
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+
+	for _, ref := range refs {
+		if ref.Index() >= active {
+			break
+		}
+
+		expiry, ok, err := readSegmentExpiry(j.dir, ref.Index())
+
+		if err != nil {
+			return dropped, err
+		}
+
+		if !ok || expiry > now.UnixMilli() {
+			continue
+		}
+
+		if err := j.removeSegment(ref.Index()); err != nil {
+			return dropped, err
+		}
+
+		level.Debug(j.logger).Log("msg", "dropped expired segment", "segment", ref.Name())
+
+		j.metrics.expiredSegments.Inc()
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+// DropOverRetention removes the oldest sealed segments the retention options don't keep anymore,
+// those last written before the retention time and those exceeding the retention bytes.
+// It returns how many segments were removed.
+func (j *Journal) DropOverRetention(now time.Time) (int, error) {
+	j.mutex.RLock()
+	active, options := j.indexSegment, j.options
+	j.mutex.RUnlock()
+
+	if options.RetentionTime <= 0 && options.RetentionBytes <= 0 {
+		return 0, nil
+	}
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	stats := make([]os.FileInfo, len(refs))
+	size := int64(0)
+
+	for i, ref := range refs {
+		stat, err := os.Stat(ref.Name())
+
+		if err != nil {
+			return 0, err
+		}
+
+		stats[i] = stat
+		size += stat.Size()
+	}
+
+	dropped := 0
+
+	// The active segment counts towards the size but is never dropped.
+	for i, ref := range refs {
+		if ref.Index() >= active {
+			break
+		}
+
+		tooOld := options.RetentionTime > 0 && now.Sub(stats[i].ModTime()) > options.RetentionTime
+		tooLarge := options.RetentionBytes > 0 && size > options.RetentionBytes
+
+		if !tooOld && !tooLarge {
+			break
+		}
+
+		if err := j.removeSegment(ref.Index()); err != nil {
+			return dropped, err
+		}
+
+		level.Debug(j.logger).Log("msg", "dropped segment over retention", "segment", ref.Name(), "size", stats[i].Size(), "modified", stats[i].ModTime())
+
+		size -= stats[i].Size()
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+// DropBefore removes the sealed segments which only hold messages before the given offset
+// and returns how many segments were removed.
+func (j *Journal) DropBefore(offset uint64) (int, error) {
+	j.mutex.RLock()
+	active := j.indexSegment
+	j.mutex.RUnlock()
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+
+	// A segment ends where the next one starts.
+	for i := 0; i+1 < len(refs) && refs[i].Index() < active && refs[i+1].Index() <= offset; i++ {
+		if err := j.removeSegment(refs[i].Index()); err != nil {
+			return dropped, err
+		}
+
+		level.Debug(j.logger).Log("msg", "dropped segment", "segment", refs[i].Name(), "before", offset)
+
+		dropped++
+	}
+
+	return dropped, nil
+}
+
+// removeSegment deletes a sealed segment together with its index files.
+func (j *Journal) removeSegment(segment uint64) error {
+	if err := os.Remove(wal.ToSegmentName(j.dir, segment, JournalSegmentExt)); err != nil {
+		return err
+	}
+
+	return j.removeIndexFiles(segment)
+}
+
+// removeIndexFiles deletes the offset index and the expiry of a segment.
+func (j *Journal) removeIndexFiles(segment uint64) error {
+	for _, ext := range []string{OffsetIndexSegmentExt, ExpirySegmentExt} {
+		if err := os.Remove(wal.ToSegmentName(j.dir, segment, ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SegmentFiles lists the files of the sealed segments, so another journal can import a copy of them.
+func (j *Journal) SegmentFiles() ([]SegmentFile, error) {
+	j.mutex.RLock()
+	active := j.indexSegment
+	j.mutex.RUnlock()
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]SegmentFile, 0)
+
+	for _, ref := range refs {
+		if ref.Index() >= active {
+			break
+		}
+
+		for _, ext := range sealedSegmentExts {
+			stat, err := os.Stat(wal.ToSegmentName(j.dir, ref.Index(), ext))
+
+			// Segments need neither an offset index nor an expiry.
+			if os.IsNotExist(err) && ext != JournalSegmentExt {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, SegmentFile{Name: stat.Name(), Size: stat.Size()})
+		}
+	}
+
+	return files, nil
+}
+
+// ReadSegmentFile reads up to maxBytes of a file listed by SegmentFiles from the position on,
+// less bytes are returned at the end of the file.
+func (j *Journal) ReadSegmentFile(name string, position int64, maxBytes int) ([]byte, error) {
+	ref, err := wal.ToSegmentRef(name)
+
+	if err != nil || filepath.Base(name) != name || !slices.Contains(sealedSegmentExts, ref.Extension()) {
+		return nil, errors.Wrapf(UnknownSegmentFile, "%q", name)
+	}
+
+	j.mutex.RLock()
+	active := j.indexSegment
+	j.mutex.RUnlock()
+
+	if ref.Index() >= active {
+		return nil, errors.Wrapf(UnknownSegmentFile, "%s is not sealed", name)
+	}
+
+	f, err := os.Open(filepath.Join(j.dir, name))
+
+	if os.IsNotExist(err) {
+		return nil, errors.Wrapf(UnknownSegmentFile, "%s was removed", name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	buf := make([]byte, maxBytes)
+	n, err := f.ReadAt(buf, position)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// Import moves the segment files copied to dir into the empty journal, which continues
+// after their last message in a new segment, so the copied segments stay unchanged.
+func (j *Journal) Import(dir string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.nextOffset > 0 {
+		return errors.Wrapf(JournalNotEmpty, "next offset %d", j.nextOffset)
+	}
+
+	refs, err := wal.SegmentsOf(dir, JournalSegmentExt)
+
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+
+	if err := j.close(); err != nil {
+		return err
+	}
+
+	if err := j.removeSegment(j.indexSegment); err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		if err := os.Rename(filepath.Join(dir, file.Name()), filepath.Join(j.dir, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	if err := j.recover(); err != nil {
+		return errors.Wrap(err, "unable to recover imported segments")
+	}
+
+	segment, err := wal.CreateSegment(j.dir, j.nextOffset, JournalSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	if err := segment.Close(); err != nil {
+		return err
+	}
+
+	j.expiry = 0
+
+	return j.open()
+}
+
+// Clear removes all messages, the journal starts over at offset 0.
+func (j *Journal) Clear() error {
+	return j.Reset(0)
+}
+
+// Reset removes all messages, the journal starts over empty at the offset. A follower
+// which is behind the log start offset of its leader continues from there.
+func (j *Journal) Reset(offset uint64) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := j.close(); err != nil {
+		return err
+	}
+
+	refs, err := wal.Segments(j.dir)
+
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if err := os.Remove(ref.Name()); err != nil {
+			return err
+		}
+	}
+
+	if offset > 0 {
+		segment, err := wal.CreateSegment(j.dir, offset, JournalSegmentExt)
+
+		if err != nil {
+			return err
+		}
+
+		if err := segment.Close(); err != nil {
+			return err
+		}
+	}
+
+	j.nextOffset = offset
+	j.logStart = offset
+	j.expiry = 0
+	j.epochs.clear()
+
+	return j.open()
+}
+
+// Epochs returns the leader epochs of the messages.
+func (j *Journal) Epochs() *Epochs {
+	return j.epochs
+}
+
+// StartEpoch records that the messages appended from now on belong to the leader epoch.
+func (j *Journal) StartEpoch(epoch int32) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.epochs.Assign(epoch, j.nextOffset)
+}
+
+// TruncateTo removes the messages from the offset on, so the next message gets the offset.
+// The segments after it are removed and the one holding it is cut before its record while
+// the journal stays open.
+func (j *Journal) TruncateTo(offset uint64) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	// An epoch started without messages is still dropped, a follower would
+	// keep sending it to the leader otherwise.
+	if offset >= j.nextOffset {
+		return j.epochs.TruncateFrom(offset)
+	}
+
+	refs, err := wal.SegmentsOf(j.dir, JournalSegmentExt)
+
+	if err != nil {
+		return err
+	}
+
+	// Without a segment holding the offset the wal continues in a new segment starting at it.
+	ref := wal.RecordRef{Segment: offset}
+	i := len(refs) - 1
+
+	for i >= 0 && refs[i].Index() > offset {
+		i--
+	}
+
+	var recs []IndexRecord
+
+	if i >= 0 {
+		ref.Segment = refs[i].Index()
+
+		if recs, err = ReadOffsetIndex(j.dir, ref.Segment); err != nil {
+			return err
+		}
+
+		if ref.Position, err = recordPosition(refs[i], recs, offset); err != nil {
+			return err
+		}
+	}
+
+	if err := j.wal.TruncateTo(ref); err != nil {
+		return err
+	}
+
+	for _, r := range refs[i+1:] {
+		if err := j.removeIndexFiles(r.Index()); err != nil {
+			return err
+		}
+	}
+
+	if err := j.truncateIndex(ref, recs, offset); err != nil {
+		return err
+	}
+
+	level.Info(j.logger).Log("msg", "truncated journal", "offset", offset, "next", j.nextOffset)
+
+	j.nextOffset = offset
+
+	// Nothing before the log start offset is left if the journal is truncated below it.
+	if offset < j.logStart {
+		if err := writeStartOffset(j.dir, offset); err != nil {
+			return err
+		}
+
+		j.logStart = offset
+	}
+
+	if err := j.epochs.TruncateFrom(offset); err != nil {
+		return err
+	}
+
+	// A sealed segment which is active again keeps its expiry, which may only be too late.
+	if ref.Segment != j.indexSegment {
+		j.indexSegment = ref.Segment
+		j.expiry = 0
+
+		return j.loadExpiry()
+	}
+
+	return nil
+}
+
+// recordPosition returns the position of the record of the message with the offset in a segment,
+// the end of the segment if the offset is after its last message.
+func recordPosition(ref wal.SegmentRef, recs []IndexRecord, offset uint64) (int64, error) {
+	f, err := os.Open(ref.Name())
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.Close()
+
+	stat, err := f.Stat()
+
+	if err != nil {
+		return 0, err
+	}
+
+	position := lookupIndex(recs, offset, stat.Size())
+
+	if _, err := f.Seek(position, 0); err != nil {
+		return 0, err
+	}
+
+	r := wal.NewReaderAt(f, position)
+
+	for r.Next() {
+		msg, err := DecodeMessage(r.Record())
+
+		if err != nil {
+			return 0, err
+		}
+
+		if msg.Offset >= offset {
+			return r.Position(), nil
+		}
+	}
+
+	if err := r.Err(); err != nil {
+		return 0, errors.Wrapf(err, "read %s", ref.Name())
+	}
+
+	return stat.Size(), nil
+}
+
+// truncateIndex drops the offset index records of the truncated messages and
+// continues indexing the segment the wal writes to.
+func (j *Journal) truncateIndex(ref wal.RecordRef, recs []IndexRecord, offset uint64) error {
+	if err := j.index.Close(); err != nil {
+		level.Error(j.logger).Log("msg", "error closing offset index", "err", err, "segmentId", j.indexSegment)
+	}
+
+	kept := 0
+
+	for kept < len(recs) && recs[kept].key < offset && int64(recs[kept].value) < ref.Position {
+		kept++
+	}
+
+	if err := TruncateOffsetIndex(j.dir, ref.Segment, kept); err != nil {
+		return err
+	}
+
+	index, err := OpenOffsetIndex(j.dir, ref.Segment)
+
+	if err != nil {
+		return err
+	}
+
+	j.index = index
+	j.lastIndexed = -1
+
+	if kept > 0 {
+		j.lastIndexed = int64(recs[kept-1].value)
+	}
+
+	return nil
+}
+
+// Stop flushes and closes the journal.
+func (j *Journal) Stop() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.close()
 }
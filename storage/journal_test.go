@@ -0,0 +1,618 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"iris/storage/wal"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSegmentSize = 32 * 1024 * 4
+
+func TestMessageEncoding(t *testing.T) {
+	msg := &Message{
+		Key:     []byte("key"),
+		Value:   []byte("value"),
+		Headers: []Header{{Key: "a", Value: []byte("1")}, {Key: "b"}},
+	}
+
+	decoded, err := DecodeMessage(EncodeMessage(msg))
+	require.NoError(t, err)
+
+	assert.Equal(t, msg.Key, decoded.Key)
+	assert.Equal(t, msg.Value, decoded.Value)
+	assert.Equal(t, msg.Headers[0], decoded.Headers[0])
+	assert.Equal(t, "b", decoded.Headers[1].Key)
+
+	_, err = DecodeMessage(EncodeMessage(msg)[:10])
+	assert.ErrorIs(t, err, InvalidMessage)
+
+	expiring := &Message{Value: []byte("value"), ExpiresAt: time.UnixMilli(1000)}
+	decoded, err = DecodeMessage(EncodeMessage(expiring))
+	require.NoError(t, err)
+	assert.True(t, decoded.Expired(time.UnixMilli(1000)))
+	assert.False(t, decoded.Expired(time.UnixMilli(999)))
+
+	// Version 1 messages have no expiry field.
+	v1 := []byte{
+		1,                      // version
+		0, 0, 0, 0, 0, 0, 0, 7, // offset
+		0, 0, 0, 0, 0, 0, 0, 0, // timestamp
+		0, 0, 0, 0, // key length
+		0, 0, 0, 1, 'v', // value
+		0, 0, // header count
+	}
+	decoded, err = DecodeMessage(v1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), decoded.Offset)
+	assert.Equal(t, []byte("v"), decoded.Value)
+	assert.True(t, decoded.ExpiresAt.IsZero())
+}
+
+func TestJournalAppendRead(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	// Enough data to rotate segments a few times.
+	for i := 0; i < 100; i++ {
+		value := append([]byte(fmt.Sprintf("message %d ", i)), bytes.Repeat([]byte("x"), 8*1024)...)
+
+		offset, err := j.Append(&Message{Value: value})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), offset)
+	}
+
+	msgs, err := j.Read(42, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 10)
+
+	for i, msg := range msgs {
+		assert.Equal(t, uint64(42+i), msg.Offset)
+		assert.True(t, bytes.HasPrefix(msg.Value, []byte(fmt.Sprintf("message %d ", 42+i))))
+	}
+
+	msgs, err = j.Read(95, 10)
+	require.NoError(t, err)
+	assert.Len(t, msgs, 5)
+
+	_, err = j.Read(101, 10)
+	assert.ErrorIs(t, err, OffsetOutOfRange)
+
+	require.NoError(t, j.Stop())
+}
+
+func TestJournalRecovery(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	_, err = j.Append(&Message{Value: []byte("first")}, &Message{Value: []byte("second")})
+	require.NoError(t, err)
+	require.NoError(t, j.Stop())
+
+	j, err = NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), j.NextOffset())
+
+	offset, err := j.Append(&Message{Value: []byte("third")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), offset)
+
+	msgs, err := j.Read(0, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, []byte("third"), msgs[2].Value)
+
+	require.NoError(t, j.Stop())
+}
+
+func TestTable(t *testing.T) {
+	dir, err := os.MkdirTemp("", "table_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	require.NoError(t, table.Put("a", []byte("1")))
+	require.NoError(t, table.Put("b", []byte("2")))
+	require.NoError(t, table.Put("a", []byte("3")))
+	require.NoError(t, table.Delete("b"))
+	require.NoError(t, table.Stop())
+
+	table, err = OpenTable(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	value, ok := table.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("3"), value)
+
+	_, ok = table.Get("b")
+	assert.False(t, ok)
+
+	require.NoError(t, table.Stop())
+}
+
+func TestTableCompaction(t *testing.T) {
+	dir, err := os.MkdirTemp("", "table_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	table, err := OpenTable(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	value := make([]byte, 1024)
+
+	for i := 0; i < 10*tableCompactionSlack; i++ {
+		require.NoError(t, table.Put(fmt.Sprintf("key-%d", i%10), value))
+	}
+
+	require.NoError(t, table.Delete("key-9"))
+
+	// Only the live keys and the messages since the last compaction are kept.
+	start, err := table.journal.StartOffset()
+	require.NoError(t, err)
+	assert.Greater(t, start, uint64(8*tableCompactionSlack))
+
+	require.NoError(t, table.Stop())
+
+	table, err = OpenTable(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer table.Stop()
+
+	n := 0
+
+	table.Range("key-", func(key string, value []byte) bool {
+		n++
+		return true
+	})
+
+	assert.Equal(t, 9, n)
+}
+
+func TestJournalDropExpired(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	now := time.Now()
+	value := bytes.Repeat([]byte("x"), 16*1024)
+
+	// The first segments only hold messages expiring within an hour,
+	// the later ones also messages which never expire.
+	for i := 0; i < 20; i++ {
+		_, err := j.Append(&Message{Value: value, ExpiresAt: now.Add(time.Hour)})
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < 20; i++ {
+		_, err := j.Append(&Message{Value: value})
+		require.NoError(t, err)
+	}
+
+	segments, err := wal.SegmentsOf(dir, JournalSegmentExt)
+	require.NoError(t, err)
+	require.True(t, len(segments) > 4)
+
+	dropped, err := j.DropExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+
+	dropped, err = j.DropExpired(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.True(t, dropped > 0)
+
+	start, err := j.StartOffset()
+	require.NoError(t, err)
+	assert.True(t, start > 0 && start <= 20, "start offset %d", start)
+
+	msgs, err := j.Read(start, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 40-int(start), len(msgs))
+
+	require.NoError(t, j.Stop())
+}
+
+func TestJournalDropOverRetention(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	value := bytes.Repeat([]byte("x"), 16*1024)
+
+	for i := 0; i < 40; i++ {
+		_, err := j.Append(&Message{Value: value})
+		require.NoError(t, err)
+	}
+
+	size := func() int64 {
+		segments, err := wal.SegmentsOf(dir, JournalSegmentExt)
+		require.NoError(t, err)
+
+		total := int64(0)
+
+		for _, ref := range segments {
+			stat, err := os.Stat(ref.Name())
+			require.NoError(t, err)
+
+			total += stat.Size()
+		}
+
+		return total
+	}
+
+	require.Greater(t, size(), int64(3*testSegmentSize))
+
+	// Segments are kept without retention options.
+	dropped, err := j.DropOverRetention(time.Now().Add(24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+
+	options := DefaultJournalOptions()
+	options.RetentionBytes = 3 * testSegmentSize
+	j.SetOptions(options)
+
+	dropped, err = j.DropOverRetention(time.Now())
+	require.NoError(t, err)
+	assert.Positive(t, dropped)
+	assert.LessOrEqual(t, size(), int64(3*testSegmentSize))
+
+	start, err := j.StartOffset()
+	require.NoError(t, err)
+	assert.Positive(t, start)
+
+	msgs, err := j.Read(start, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 40-int(start), len(msgs))
+
+	options.RetentionBytes = 0
+	options.RetentionTime = time.Hour
+	j.SetOptions(options)
+
+	dropped, err = j.DropOverRetention(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+
+	// Only the active segment is left once all sealed ones are too old.
+	dropped, err = j.DropOverRetention(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Positive(t, dropped)
+
+	segments, err := wal.SegmentsOf(dir, JournalSegmentExt)
+	require.NoError(t, err)
+	assert.Len(t, segments, 1)
+
+	require.NoError(t, j.Stop())
+}
+
+func TestAckLog(t *testing.T) {
+	dir, err := os.MkdirTemp("", "acklog_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	a, err := OpenAckLog(log.NewNopLogger(), prometheus.NewRegistry(), dir)
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	for i := uint64(0); i < 3; i++ {
+		deliveries, err := a.Lease(i, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, deliveries)
+	}
+
+	require.NoError(t, a.Ack(0))
+	require.NoError(t, a.Ack(2))
+	assert.Equal(t, uint64(1), a.Floor())
+	assert.Equal(t, uint64(3), a.Next())
+	assert.Empty(t, a.Expired(now, 10))
+	assert.Equal(t, []uint64{1}, a.Expired(now.Add(2*time.Minute), 10))
+
+	deliveries, err := a.Lease(1, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, deliveries)
+	require.NoError(t, a.Stop())
+
+	a, err = OpenAckLog(log.NewNopLogger(), prometheus.NewRegistry(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), a.Floor())
+	assert.Equal(t, uint64(3), a.Next())
+	assert.Equal(t, 2, a.State(1).Deliveries)
+	assert.True(t, a.State(2).Acked)
+
+	require.NoError(t, a.Release(1))
+	assert.Equal(t, []uint64{1}, a.Expired(now, 10))
+
+	deliveries, err = a.Lease(1, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 3, deliveries)
+	require.NoError(t, a.Ack(1))
+	assert.Equal(t, uint64(3), a.Floor())
+
+	// Enough records to compact the log a few times.
+	for i := uint64(3); i < 200000; i++ {
+		_, err := a.Lease(i, now)
+		require.NoError(t, err)
+		require.NoError(t, a.Ack(i))
+	}
+
+	segments, err := wal.SegmentsOf(dir, AckLogSegmentExt)
+	require.NoError(t, err)
+	assert.True(t, len(segments) <= maxAckLogSegments+1, "%d segments", len(segments))
+	require.NoError(t, a.Stop())
+
+	a, err = OpenAckLog(log.NewNopLogger(), prometheus.NewRegistry(), dir)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(200000), a.Floor())
+	assert.Equal(t, uint64(200000), a.Next())
+	assert.True(t, a.State(1).Acked)
+	require.NoError(t, a.Stop())
+}
+
+func TestJournalImport(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), filepath.Join(dir, "source"), testSegmentSize)
+	require.NoError(t, err)
+	defer source.Stop()
+
+	for i := 0; i < 50; i++ {
+		_, err := source.Append(&Message{Value: bytes.Repeat([]byte("x"), 8*1024)})
+		require.NoError(t, err)
+	}
+
+	files, err := source.SegmentFiles()
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	_, err = source.ReadSegmentFile("../source/"+files[0].Name, 0, 10)
+	assert.ErrorIs(t, err, UnknownSegmentFile)
+
+	_, err = source.ReadSegmentFile(wal.ToSegmentName("", source.wal.ActiveSegmentRef().Index(), JournalSegmentExt), 0, 10)
+	assert.ErrorIs(t, err, UnknownSegmentFile)
+
+	// A copy of the sealed segments continues where they end.
+	copied := filepath.Join(dir, "copy")
+	require.NoError(t, os.MkdirAll(copied, 0o777))
+
+	for _, f := range files {
+		data, err := source.ReadSegmentFile(f.Name, 0, int(f.Size)+1)
+		require.NoError(t, err)
+		assert.Len(t, data, int(f.Size))
+		require.NoError(t, os.WriteFile(filepath.Join(copied, f.Name), data, 0o666))
+	}
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), filepath.Join(dir, "target"), testSegmentSize)
+	require.NoError(t, err)
+	defer j.Stop()
+
+	require.NoError(t, j.Import(copied))
+	assert.Equal(t, source.wal.ActiveSegmentRef().Index(), j.NextOffset())
+	assert.ErrorIs(t, j.Import(copied), JournalNotEmpty)
+
+	msgs, err := j.Read(0, 100)
+	require.NoError(t, err)
+	assert.Len(t, msgs, int(j.NextOffset()))
+
+	offset, err := j.Append(&Message{Value: []byte("next")})
+	require.NoError(t, err)
+	assert.Equal(t, j.NextOffset()-1, offset)
+
+	// A cleared journal starts over.
+	require.NoError(t, j.Clear())
+	assert.Equal(t, uint64(0), j.NextOffset())
+
+	offset, err = j.Append(&Message{Value: []byte("first")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), offset)
+
+	// A reset journal starts over at the offset.
+	require.NoError(t, j.Reset(100))
+
+	start, err := j.StartOffset()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), start)
+
+	offset, err = j.Append(&Message{Value: []byte("after")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), offset)
+}
+
+func TestJournalTruncate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	require.NoError(t, j.StartEpoch(0))
+
+	for i := 0; i < 30; i++ {
+		_, err := j.Append(&Message{Value: bytes.Repeat([]byte{byte(i)}, 8*1024)})
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, j.StartEpoch(2))
+	require.NoError(t, j.StartEpoch(1))
+
+	for i := 30; i < 50; i++ {
+		_, err := j.Append(&Message{Value: bytes.Repeat([]byte{byte(i)}, 8*1024)})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), j.Epochs().Latest())
+
+	epoch, end := j.Epochs().EndOffset(1, j.NextOffset())
+	assert.Equal(t, int32(0), epoch)
+	assert.Equal(t, uint64(30), end)
+
+	epoch, end = j.Epochs().EndOffset(-1, j.NextOffset())
+	assert.Equal(t, NoEpoch, epoch)
+	assert.Equal(t, uint64(0), end)
+
+	// Truncating removes later segments and the epochs starting after the offset.
+	require.NoError(t, j.TruncateTo(20))
+	assert.Equal(t, uint64(20), j.NextOffset())
+	assert.Equal(t, []Epoch{{Epoch: 0, StartOffset: 0}}, j.Epochs().Entries(0))
+
+	offset, err := j.Append(&Message{Value: []byte("after")})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), offset)
+
+	// Truncating at the end only removes the epochs without messages.
+	require.NoError(t, j.StartEpoch(3))
+	require.NoError(t, j.TruncateTo(j.NextOffset()))
+	assert.Equal(t, []Epoch{{Epoch: 0, StartOffset: 0}}, j.Epochs().Entries(0))
+
+	require.NoError(t, j.Stop())
+
+	// Both the messages and the epochs are kept across a restart.
+	j, err = NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer j.Stop()
+
+	assert.Equal(t, uint64(21), j.NextOffset())
+	assert.Equal(t, int32(0), j.Epochs().Latest())
+
+	msgs, err := j.Read(18, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, byte(19), msgs[1].Value[0])
+	assert.Equal(t, []byte("after"), msgs[2].Value)
+}
+
+func TestJournalTruncateCorrupted(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer j.Stop()
+
+	for i := 0; i < 30; i++ {
+		_, err := j.Append(&Message{Value: bytes.Repeat([]byte{byte(i)}, 8*1024)})
+		require.NoError(t, err)
+	}
+
+	refs, err := wal.SegmentsOf(dir, JournalSegmentExt)
+	require.NoError(t, err)
+	require.Greater(t, len(refs), 1)
+
+	recs, err := ReadOffsetIndex(dir, refs[0].Index())
+	require.NoError(t, err)
+
+	position, err := recordPosition(refs[0], recs, 5)
+	require.NoError(t, err)
+
+	// A damaged record is not mistaken for the position to truncate at.
+	f, err := os.OpenFile(refs[0].Name(), os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff}, position+100)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	_, err = recordPosition(refs[0], recs, 5)
+	assert.ErrorContains(t, err, refs[0].Name())
+
+	assert.Error(t, j.TruncateTo(5))
+	assert.Equal(t, uint64(30), j.NextOffset())
+}
+
+func TestJournalStartOffset(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		_, err := j.Append(&Message{Value: bytes.Repeat([]byte{byte(i)}, 8*1024)})
+		require.NoError(t, err)
+	}
+
+	assert.ErrorIs(t, j.AdvanceStartOffset(51), OffsetOutOfRange)
+	require.NoError(t, j.AdvanceStartOffset(25))
+	require.NoError(t, j.AdvanceStartOffset(10))
+
+	start, err := j.StartOffset()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(25), start)
+
+	_, err = j.Read(24, 1)
+	assert.ErrorIs(t, err, OffsetOutOfRange)
+
+	// Only whole segments before the log start offset are dropped.
+	dropped, err := j.DropBefore(start)
+	require.NoError(t, err)
+	assert.Positive(t, dropped)
+
+	require.NoError(t, j.Stop())
+
+	j, err = NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+	defer j.Stop()
+
+	start, err = j.StartOffset()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(25), start)
+
+	msgs, err := j.Read(25, 1)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, byte(25), msgs[0].Value[0])
+}
+
+func TestJournalIndexInterval(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	j, err := NewJournal(log.NewNopLogger(), prometheus.NewRegistry(), dir, testSegmentSize)
+	require.NoError(t, err)
+
+	// Every message is indexed with an interval smaller than a message.
+	j.SetOptions(JournalOptions{IndexIntervalBytes: 1, SyncEveryAppend: true})
+
+	for i := 0; i < 10; i++ {
+		_, err := j.Append(&Message{Value: []byte(fmt.Sprintf("message %d", i))})
+		require.NoError(t, err)
+	}
+
+	info, err := os.Stat(wal.ToSegmentName(dir, 0, OffsetIndexSegmentExt))
+	require.NoError(t, err)
+	assert.Equal(t, int64(10*indexRecordSize), info.Size())
+
+	msgs, err := j.Read(7, 10)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, uint64(7), msgs[0].Offset)
+
+	require.NoError(t, j.Stop())
+}
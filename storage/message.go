@@ -0,0 +1,50 @@
+package storage
+
+import "time"
+
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message is the envelope stored in a journal for every produced record.
+type Message struct {
+	Offset    uint64
+	Timestamp time.Time
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+
+	// ExpiresAt is the time after which consumers don't see the message anymore,
+	// a zero time keeps it until it is removed by retention.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the message has expired at the given time.
+func (m *Message) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !m.ExpiresAt.After(now)
+}
+
+// Header returns the value of the last header with the given key.
+func (m *Message) Header(key string) ([]byte, bool) {
+	for i := len(m.Headers) - 1; i >= 0; i-- {
+		if m.Headers[i].Key == key {
+			return m.Headers[i].Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// SetHeader replaces all headers with the given key by a single one.
+func (m *Message) SetHeader(key string, value []byte) {
+	headers := m.Headers[:0]
+
+	for _, h := range m.Headers {
+		if h.Key != key {
+			headers = append(headers, h)
+		}
+	}
+
+	m.Headers = append(headers, Header{Key: key, Value: value})
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// TombstoneHeader marks a message which deletes its key from a table.
+	TombstoneHeader = "iris-tombstone"
+
+	// A table is compacted once its journal holds this many messages more than twice its live keys.
+	tableCompactionSlack = 1024
+)
+
+// Table is a key value store kept in a journal. The last message of a key
+// holds its value, the whole journal is replayed into memory on open.
+// The journal is compacted by writing the live values again and dropping
+// the segments before them.
+type Table struct {
+	logger  log.Logger
+	journal *Journal
+
+	mutex  sync.RWMutex
+	values map[string][]byte
+	// Messages before this offset have all been written again by the last compaction.
+	compacted uint64
+}
+
+func OpenTable(logger log.Logger, registerer prometheus.Registerer, dir string, segmentSize int) (*Table, error) {
+	journal, err := NewJournal(logger, registerer, dir, segmentSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{
+		logger:  logger,
+		journal: journal,
+		values:  make(map[string][]byte),
+	}
+
+	start, err := journal.StartOffset()
+
+	if err != nil {
+		journal.Stop()
+		return nil, err
+	}
+
+	t.compacted = start
+
+	err = journal.Scan(start, func(msg *Message) bool {
+		t.apply(msg)
+		return true
+	})
+
+	if err != nil {
+		journal.Stop()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *Table) apply(msg *Message) {
+	if _, ok := msg.Header(TombstoneHeader); ok {
+		delete(t.values, string(msg.Key))
+		return
+	}
+
+	t.values[string(msg.Key)] = msg.Value
+}
+
+func (t *Table) Get(key string) ([]byte, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	value, ok := t.values[key]
+
+	return value, ok
+}
+
+func (t *Table) Put(key string, value []byte) error {
+	return t.write(&Message{Key: []byte(key), Value: value})
+}
+
+func (t *Table) Delete(key string) error {
+	t.mutex.RLock()
+	_, ok := t.values[key]
+	t.mutex.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	return t.write(&Message{Key: []byte(key), Headers: []Header{{Key: TombstoneHeader}}})
+}
+
+func (t *Table) write(msg *Message) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, err := t.journal.Append(msg); err != nil {
+		return err
+	}
+
+	t.apply(msg)
+
+	if t.journal.NextOffset()-t.compacted > uint64(2*len(t.values)+tableCompactionSlack) {
+		if err := t.compact(); err != nil {
+			level.Error(t.logger).Log("msg", "unable to compact table", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// compact rewrites the live values and drops the segments only holding older messages,
+// it must be called with the mutex held.
+func (t *Table) compact() error {
+	offset := t.journal.NextOffset()
+
+	msgs := make([]*Message, 0, len(t.values))
+
+	for key, value := range t.values {
+		msgs = append(msgs, &Message{Key: []byte(key), Value: value})
+	}
+
+	if len(msgs) > 0 {
+		if _, err := t.journal.Append(msgs...); err != nil {
+			return err
+		}
+	}
+
+	t.compacted = offset
+
+	dropped, err := t.journal.DropBefore(offset)
+
+	if err != nil {
+		return err
+	}
+
+	level.Debug(t.logger).Log("msg", "compacted table", "keys", len(t.values), "segments", dropped)
+
+	return nil
+}
+
+// Range calls fn for every key with the given prefix until fn returns false.
+// The keys are visited in no particular order.
+func (t *Table) Range(prefix string, fn func(key string, value []byte) bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	for key, value := range t.values {
+		if strings.HasPrefix(key, prefix) && !fn(key, value) {
+			return
+		}
+	}
+}
+
+func (t *Table) Stop() error {
+	return t.journal.Stop()
+}
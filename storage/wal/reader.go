@@ -2,6 +2,7 @@ package wal
 
 import (
 	"encoding/binary"
+	"fmt"
 	"hash/crc32"
 	"io"
 
@@ -11,21 +12,67 @@ import (
 
 type Reader struct {
 	reader     io.Reader
+	segment    int
 	err        error
 	rec        []byte
+	fragments  []Fragment
 	buf        [pageSize]byte
 	total      uint64
+	start      uint64
 	curRecType recType
 }
 
+// Fragment is the part of a record stored in a single page.
+type Fragment struct {
+	// Type is full, first, middle or last.
+	Type     string
+	Position int64
+	Length   int
+	CRC      uint32
+	// Checksum is the CRC of the data as it was read, it differs from CRC when the data is corrupted.
+	Checksum uint32
+}
+
+// Page returns the index of the page the fragment is in.
+func (f Fragment) Page() int64 {
+	return f.Position / pageSize
+}
+
+// Valid reports whether the data of the fragment matches its CRC.
+func (f Fragment) Valid() bool {
+	return f.CRC == f.Checksum
+}
+
 func NewReader(reader io.Reader) *Reader {
-	return &Reader{reader: reader}
+	return &Reader{reader: reader, segment: -1}
+}
+
+// NewSegmentReader creates a reader for the segment with the index, its corruption errors name the segment.
+func NewSegmentReader(reader io.Reader, segment uint64) *Reader {
+	return &Reader{reader: reader, segment: int(segment)}
+}
+
+// NewReaderAt creates a reader for a segment which has already been consumed
+// up to the given byte position, e.g. after seeking to a position taken from an index.
+func NewReaderAt(reader io.Reader, position int64) *Reader {
+	return &Reader{reader: reader, segment: -1, total: uint64(position), start: uint64(position)}
 }
 
 func (r *Reader) Record() []byte {
 	return r.rec
 }
 
+// Position returns the byte position of the last record read by Next.
+func (r *Reader) Position() int64 {
+	return int64(r.start)
+}
+
+// Fragments returns the fragments of the last record read by Next. When Next fails they
+// are the fragments read before the error, the last one is the corrupted one on a CRC mismatch.
+func (r *Reader) Fragments() []Fragment {
+	return r.fragments
+}
+
 func (r *Reader) Next() bool {
 	err := r.next()
 
@@ -47,6 +94,7 @@ func (r *Reader) next() error {
 	buf := r.buf[recordHeaderSize:]
 
 	r.rec = r.rec[:0]
+	r.fragments = r.fragments[:0]
 
 	i := 0
 	for {
@@ -54,12 +102,15 @@ func (r *Reader) next() error {
 			return errors.Wrap(err, "error reading first header byte")
 		}
 
+		if i == 0 {
+			r.start = r.total
+		}
+
+		position := r.total
 		r.total++
 		r.curRecType = recTypeFromHeader(hdr[0])
 
 		if r.curRecType == recPageTerm {
-			buf = r.buf[1:]
-
 			k := pageSize - (r.total % pageSize)
 
 			if k == pageSize {
@@ -74,7 +125,7 @@ func (r *Reader) next() error {
 
 			r.total += uint64(n)
 
-			for _, c := range buf[:k] {
+			for _, c := range r.buf[:k] {
 				if c != 0 {
 					return errors.New("unexpected non-zero byte in padded page")
 				}
@@ -110,11 +161,21 @@ func (r *Reader) next() error {
 			return errors.Errorf("invalid size: expected %d, got %d", length, n)
 		}
 
-		if c := crc32.Checksum(buf[:length], castagnoliTable); c != crc {
+		c := crc32.Checksum(buf[:length], castagnoliTable)
+
+		r.fragments = append(r.fragments, Fragment{
+			Type:     r.curRecType.String(),
+			Position: int64(position),
+			Length:   int(length),
+			CRC:      crc,
+			Checksum: c,
+		})
+
+		if c != crc {
 			return errors.Errorf("invalid checksum: expected %d, got %d", crc, c)
 		}
 
-		r.rec = append(r.rec, r.buf[:length]...)
+		r.rec = append(r.rec, buf[:length]...)
 
 		if err := validateRecord(r.curRecType, i); err != nil {
 			return err
@@ -128,6 +189,23 @@ func (r *Reader) next() error {
 	}
 }
 
+func (t recType) String() string {
+	switch t {
+	case recPageTerm:
+		return "pageterm"
+	case recFull:
+		return "full"
+	case recFirst:
+		return "first"
+	case recMiddle:
+		return "middle"
+	case recLast:
+		return "last"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
 func validateRecord(typ recType, i int) error {
 	switch typ {
 	case recFull:
@@ -155,6 +233,9 @@ func validateRecord(typ recType, i int) error {
 	}
 }
 
+// Err returns the corruption which stopped Next as a *wlog.CorruptionErr. Its offset is the
+// position of the record which couldn't be read, its segment is -1 unless the reader was
+// created by NewSegmentReader.
 func (r *Reader) Err() error {
 	if r.err == nil {
 		return nil
@@ -162,7 +243,7 @@ func (r *Reader) Err() error {
 
 	return &wlog.CorruptionErr{
 		Err:     r.err,
-		Segment: -1,
-		Offset:  int64(r.total),
+		Segment: r.segment,
+		Offset:  int64(r.start),
 	}
 }
@@ -0,0 +1,111 @@
+package wal
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+// CorruptDirName is the directory in a wal directory corrupted segments are moved to.
+const CorruptDirName = "corrupt"
+
+// Repair fixes the corruption of a segment with the extension in dir, like wlog.Repair. The
+// wal must not be open. The segment is cut off at the offset of the corruption, which must be
+// the start of its first invalid record. The segments after it would leave a hole in the log,
+// they are moved to the corrupt directory along with their files of the sidecar extensions.
+// The offset and the indexes of the moved segments are returned.
+func Repair(dir string, extension string, c *wlog.CorruptionErr, sidecars ...string) (int64, []uint64, error) {
+	if c.Segment < 0 {
+		return 0, nil, errors.Errorf("corruption without segment can't be repaired: %v", c)
+	}
+
+	refs, err := SegmentsOf(dir, extension)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	segment := uint64(c.Segment)
+	i := len(refs) - 1
+
+	for i >= 0 && refs[i].Index() > segment {
+		i--
+	}
+
+	if i < 0 || refs[i].Index() != segment {
+		return 0, nil, errors.Errorf("no %s segment %d in %s", extension, segment, dir)
+	}
+
+	position, err := truncateInvalid(refs[i].Name(), segment, c.Offset)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	moved := make([]uint64, 0)
+
+	for _, ref := range refs[i+1:] {
+		if err := quarantine(dir, ref.Index(), append([]string{extension}, sidecars...)); err != nil {
+			return 0, nil, err
+		}
+
+		moved = append(moved, ref.Index())
+	}
+
+	return position, moved, nil
+}
+
+// truncateInvalid cuts the segment off at the position, where its first invalid record starts.
+func truncateInvalid(name string, segment uint64, position int64) (int64, error) {
+	f, err := os.OpenFile(name, os.O_RDWR, 0o666)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer f.Close()
+
+	r := NewSegmentReader(bufio.NewReader(f), segment)
+
+	for r.Next() {
+	}
+
+	var c *wlog.CorruptionErr
+
+	if !errors.As(r.Err(), &c) {
+		return 0, errors.Errorf("segment %s has no corruption", name)
+	}
+
+	// Records before the position would be lost if it isn't the corruption the reader finds.
+	if c.Offset != position {
+		return 0, errors.Errorf("segment %s is corrupted at %d, not at %d", name, c.Offset, position)
+	}
+
+	if err := f.Truncate(position); err != nil {
+		return 0, err
+	}
+
+	return position, f.Sync()
+}
+
+// quarantine moves the files of a segment with the extensions into the corrupt directory.
+func quarantine(dir string, segment uint64, extensions []string) error {
+	corrupt := filepath.Join(dir, CorruptDirName)
+
+	if err := os.MkdirAll(corrupt, 0o777); err != nil {
+		return err
+	}
+
+	for _, extension := range extensions {
+		err := os.Rename(ToSegmentName(dir, segment, extension), ToSegmentName(corrupt, segment, extension))
+
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "unable to quarantine segment %d", segment)
+		}
+	}
+
+	return nil
+}
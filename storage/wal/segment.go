@@ -48,8 +48,28 @@ func OpenReadSegment(dir string, i uint64, extension string) (*Segment, error) {
 }
 
 
+// Name returns the path of the segment file.
+func (r SegmentRef) Name() string {
+	return r.name
+}
+
+// Index returns the index of the segment, which is the offset the segment starts with.
+func (r SegmentRef) Index() uint64 {
+	return r.index
+}
+
+func (r SegmentRef) Extension() string {
+	return r.extension
+}
+
 func LastSegment(dir string) (*SegmentRef, error) {
-	refs, err := Segments(dir)
+	return LastSegmentOf(dir, "")
+}
+
+// LastSegmentOf returns the last segment with the given extension,
+// an empty extension matches all segments.
+func LastSegmentOf(dir string, extension string) (*SegmentRef, error) {
+	refs, err := SegmentsOf(dir, extension)
 
 	if err != nil {
 		return nil, err
@@ -57,18 +77,18 @@ func LastSegment(dir string) (*SegmentRef, error) {
 
 	if len(refs) == 0 {
 		return nil, nil
-	} else if len(refs) == 1 {
-		return &refs[0], nil
 	}
 
-	sort.Slice(refs, func(i, j int) bool {
-		return refs[i].index < refs[j].index
-	})
-
 	return &refs[len(refs)-1], nil
 }
 
 func Segments(dir string) ([]SegmentRef, error) {
+	return SegmentsOf(dir, "")
+}
+
+// SegmentsOf lists the segments with the given extension ordered by index,
+// an empty extension matches all segments.
+func SegmentsOf(dir string, extension string) ([]SegmentRef, error) {
 	files, err := os.ReadDir(dir)
 
 	if err != nil {
@@ -78,16 +98,28 @@ func Segments(dir string) ([]SegmentRef, error) {
 	refs := make([]SegmentRef, 0, len(files))
 
 	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
 
-        ref, err := ToSegmentRef(file.Name())
+		ref, err := ToSegmentRef(file.Name())
 
 		if err != nil {
 			return nil, errors.Wrap(err, "unable to list segments")
 		}
 
+		if extension != "" && ref.extension != extension {
+			continue
+		}
+
+		ref.name = filepath.Join(dir, ref.name)
 		refs = append(refs, ref)
 	}
 
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].index < refs[j].index
+	})
+
 	return refs, nil
 }
 
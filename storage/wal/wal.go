@@ -1,8 +1,10 @@
 package wal
 
 import (
+	"bufio"
 	"encoding/binary"
 	"hash/crc32"
+	"os"
 	"sync"
 	"time"
 
@@ -16,12 +18,17 @@ const (
 	pageSize           = 32 * 1024 // 32KB
 	recordHeaderSize   = 7
 	DefaultSegmentSize = 1 * 1024 * 1024 * 1024
+
+	// PageSize is the unit segments are written in, their size must be a multiple of it.
+	PageSize = pageSize
 )
 
 var (
 	InvalidSegmentSize = errors.New("Invalid segment size")
+	RecordTooLarge     = errors.New("Record does not fit into a segment")
 	WalClosed          = errors.New("Journal closed")
 	WalAlreadyClosed   = errors.New("Journal already closed")
+	InvalidTruncation  = errors.New("Invalid truncation")
 )
 
 var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
@@ -95,7 +102,7 @@ func NewWal(logger log.Logger, registerer prometheus.Registerer, dir string, seg
 
 	wal.metrics = NewWalMetrics(prometheus.WrapRegistererWithPrefix("storage_wal_", registerer))
 
-	lastSegmentRef, err := LastSegment(dir)
+	lastSegmentRef, err := LastSegmentOf(dir, extension)
 
 	if err != nil {
 		return nil, err
@@ -104,13 +111,10 @@ func NewWal(logger log.Logger, registerer prometheus.Registerer, dir string, seg
 	var segment *Segment
 	writeSegmentInd := uint64(0)
 
+	// Keep appending to the last segment, setSegment restores the page
+	// state so writes continue right after the last flushed byte.
 	if lastSegmentRef != nil {
-		//TODO
-		//load the latest of add + 1 to create new segment index
-		// journal.lastOffset =
-		// writeSegmentInd = lastSegmentRef.index +
-
-		writeSegmentInd = 2
+		writeSegmentInd = lastSegmentRef.index
 	}
 
 	segment, err = CreateSegment(dir, writeSegmentInd, extension)
@@ -119,7 +123,9 @@ func NewWal(logger log.Logger, registerer prometheus.Registerer, dir string, seg
 		return nil, err
 	}
 
-	wal.setSegment(segment)
+	if err := wal.setSegment(segment); err != nil {
+		return nil, err
+	}
 
 	go wal.run()
 
@@ -164,6 +170,12 @@ func (j *Wal) setSegment(segment *Segment) error {
 
 	j.donePages = int(stat.Size() / pageSize)
 
+	// A segment that was not closed cleanly ends in the middle of a page,
+	// the written part of that page is treated as already flushed.
+	j.page.reset()
+	j.page.alloc = int(stat.Size() % pageSize)
+	j.page.flushed = j.page.alloc
+
 	return nil
 }
 
@@ -201,16 +213,41 @@ func (j *Wal) flushPage(clear bool) error {
 }
 
 func (w *Wal) Log(vOffset uint64, recs ...[]byte) error {
+	_, err := w.Append(vOffset, recs...)
+
+	return err
+}
+
+// RecordRef points to the first fragment of a record written to the wal.
+type RecordRef struct {
+	Segment  uint64
+	Position int64
+}
+
+// Append writes the records like Log and returns where each of them starts.
+// vOffset is the virtual offset of the first record, the following records get
+// consecutive offsets which are used as the index of the segment on rotation.
+func (w *Wal) Append(vOffset uint64, recs ...[]byte) ([]RecordRef, error) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
+	if w.closed {
+		return nil, WalClosed
+	}
+
+	refs := make([]RecordRef, 0, len(recs))
+
 	for i, r := range recs {
-		if err := w.log(r, vOffset, i == len(recs)-1); err != nil {
+		ref, err := w.log(r, vOffset+uint64(i), i == len(recs)-1)
+
+		if err != nil {
 			w.metrics.writesFailed.Inc()
-			return err
+			return nil, err
 		}
+
+		refs = append(refs, ref)
 	}
-	return nil
+	return refs, nil
 }
 
 // First Byte of header format:
@@ -235,23 +272,32 @@ func recTypeFromHeader(header byte) recType {
 	return recType(header & recTypeMask)
 }
 
-func (j *Wal) log(rec []byte, baseOffset uint64, final bool) error {
+func (j *Wal) log(rec []byte, baseOffset uint64, final bool) (RecordRef, error) {
 	if j.page.full() {
 		if err := j.flushPage(true); err != nil {
-			return err
+			return RecordRef{}, err
 		}
 	}
 
+	if len(rec) > (pageSize-recordHeaderSize)*j.pagesPerSegment() {
+		return RecordRef{}, RecordTooLarge
+	}
+
 	left := j.page.remaining() - recordHeaderSize //free bytes in active page
 	leftPageCount := j.pagesPerSegment() - j.donePages - 1
 	left += (pageSize - recordHeaderSize) * leftPageCount //pages left for active segmet
 
 	if len(rec) > left {
 		if err := j.nextSegment(true, baseOffset); err != nil {
-			return err
+			return RecordRef{}, err
 		}
 	}
 
+	ref := RecordRef{
+		Segment:  j.segment.i,
+		Position: int64(j.donePages*pageSize + j.page.alloc),
+	}
+
 	for i := 0; i == 0 || len(rec) > 0; i++ {
 		page := j.page
 
@@ -285,7 +331,7 @@ func (j *Wal) log(rec []byte, baseOffset uint64, final bool) error {
 
 		if j.page.full() {
 			if err := j.flushPage(true); err != nil {
-				return err
+				return RecordRef{}, err
 			}
 		}
 
@@ -294,11 +340,11 @@ func (j *Wal) log(rec []byte, baseOffset uint64, final bool) error {
 
 	if final && j.page.alloc > 0 {
 		if err := j.flushPage(false); err != nil {
-			return err
+			return RecordRef{}, err
 		}
 	}
 
-	return nil
+	return ref, nil
 }
 
 func (j *Wal) nextSegment(async bool, offset uint64) error {
@@ -340,6 +386,124 @@ func (j *Wal) nextSegment(async bool, offset uint64) error {
 	return nil
 }
 
+// NextSegment seals the active segment and continues writing to a new one
+// with the given index, which must be greater than the active one.
+func (w *Wal) NextSegment(vOffset uint64) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if vOffset <= w.segment.i {
+		return errors.Errorf("segment index %d is not after the active segment %d", vOffset, w.segment.i)
+	}
+
+	return w.nextSegment(true, vOffset)
+}
+
+// TruncateTo removes the record the ref points to and all records after it. The position
+// must be the start of a record or the end of the last one in the segment. Later segments
+// are deleted and writing continues at the position, a segment which doesn't exist is
+// created if the position is 0.
+func (w *Wal) TruncateTo(ref RecordRef) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return WalClosed
+	}
+
+	if ref.Segment > w.segment.i {
+		return errors.Wrapf(InvalidTruncation, "segment %d is after the active segment %d", ref.Segment, w.segment.i)
+	}
+
+	name := ToSegmentName(w.dir, ref.Segment, w.extension)
+
+	if err := checkRecordBoundary(name, ref.Position); err != nil {
+		return err
+	}
+
+	refs, err := SegmentsOf(w.dir, w.extension)
+
+	if err != nil {
+		return err
+	}
+
+	segment := w.segment
+
+	if segment.i != ref.Segment {
+		if segment, err = CreateSegment(w.dir, ref.Segment, w.extension); err != nil {
+			return err
+		}
+
+		if err := w.segment.Close(); err != nil {
+			level.Error(w.logger).Log("msg", "error closing truncated segment", "err", err, "segmentId", w.segment.i)
+		}
+	}
+
+	for _, r := range refs {
+		if r.index > ref.Segment {
+			if err := os.Remove(r.name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := os.Truncate(name, ref.Position); err != nil {
+		return err
+	}
+
+	if err := w.fsync(segment); err != nil {
+		return err
+	}
+
+	// The page state is restored from the new size, so the cut part of the page is overwritten.
+	return w.setSegment(segment)
+}
+
+// checkRecordBoundary fails unless the position is the start of a record of the segment
+// or the end of its last complete record.
+func checkRecordBoundary(name string, position int64) error {
+	f, err := os.Open(name)
+
+	if os.IsNotExist(err) && position == 0 {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	r := NewReader(bufio.NewReader(f))
+	end := int64(0)
+
+	for end < position && r.Next() {
+		if r.Position() >= position {
+			break
+		}
+
+		end = int64(r.total)
+	}
+
+	if end == position || r.err == nil && r.Position() == position {
+		return nil
+	}
+
+	return errors.Wrapf(InvalidTruncation, "position %d of %s is not a record boundary", position, name)
+}
+
+// Sync flushes the written records of the active segment to disk.
+func (w *Wal) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return WalClosed
+	}
+
+	return w.fsync(w.segment)
+}
+
 func (j *Wal) fsync(s *Segment) error {
 	now := time.Now()
 	err := s.Sync()
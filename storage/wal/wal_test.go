@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/wlog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -211,7 +213,8 @@ func TestWalReadMultipleSegments(t *testing.T) {
 	expected := make([][]byte, numRecords)
 
 	for i := 0; i < numRecords; i++ {
-		data := []byte(fmt.Sprintf("test record %d", i))
+		// Records of half a page, so that a segment of two pages holds only a few of them
+		data := append([]byte(fmt.Sprintf("test record %d ", i)), bytes.Repeat([]byte("x"), pageSize/2)...)
 		expected[i] = data
 		err = w.Log(uint64(i), data)
 		require.NoError(t, err)
@@ -257,6 +260,41 @@ func TestWalReadMultipleSegments(t *testing.T) {
 	}
 }
 
+func TestWalReadFragmentedRecord(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logger := log.NewNopLogger()
+	registry := prometheus.NewRegistry()
+	extension := "wal"
+
+	w, err := NewWal(logger, registry, dir, pageSize*4, extension)
+	require.NoError(t, err)
+
+	// The long record is split into fragments over three pages
+	short := []byte("short record")
+	long := bytes.Repeat([]byte("fragmented record "), pageSize/8)
+
+	require.NoError(t, w.Log(uint64(0), short))
+	require.NoError(t, w.Log(uint64(1), long))
+	require.NoError(t, w.Stop())
+
+	segment, err := OpenReadSegment(dir, 0, extension)
+	require.NoError(t, err)
+	defer segment.Close()
+
+	var records [][]byte
+
+	reader := NewReader(segment)
+	for reader.Next() {
+		records = append(records, append([]byte{}, reader.Record()...))
+	}
+
+	require.NoError(t, reader.Err())
+	assert.Equal(t, [][]byte{short, long}, records)
+}
+
 func TestWalCorruption(t *testing.T) {
 	dir, err := os.MkdirTemp("", "wal_test")
 	require.NoError(t, err)
@@ -304,3 +342,208 @@ func TestWalCorruption(t *testing.T) {
 		assert.NotEqual(t, testData, record, "Corrupted data should not match original")
 	}
 }
+
+func TestWalTruncateTo(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWal(log.NewNopLogger(), prometheus.NewRegistry(), dir, pageSize*4, "wal")
+	require.NoError(t, err)
+
+	var refs []RecordRef
+
+	for i := 0; i < 40; i++ {
+		ref, err := w.Append(uint64(i), bytes.Repeat([]byte{byte(i)}, 10*1024))
+		require.NoError(t, err)
+
+		refs = append(refs, ref[0])
+	}
+
+	cut := refs[15]
+	require.Greater(t, w.ActiveSegmentRef().Index(), cut.Segment)
+
+	// Only positions at which a record starts are accepted.
+	err = w.TruncateTo(RecordRef{Segment: cut.Segment, Position: cut.Position + 1})
+	assert.ErrorIs(t, err, InvalidTruncation)
+
+	err = w.TruncateTo(RecordRef{Segment: w.ActiveSegmentRef().Index() + 1})
+	assert.ErrorIs(t, err, InvalidTruncation)
+
+	require.NoError(t, w.TruncateTo(cut))
+	assert.Equal(t, cut.Segment, w.ActiveSegmentRef().Index())
+
+	segments, err := SegmentsOf(dir, "wal")
+	require.NoError(t, err)
+	assert.Equal(t, cut.Segment, segments[len(segments)-1].Index())
+
+	// Writing continues where the removed record started.
+	ref, err := w.Append(15, []byte("after"))
+	require.NoError(t, err)
+	assert.Equal(t, cut, ref[0])
+
+	require.NoError(t, w.Stop())
+
+	segment, err := OpenReadSegment(dir, cut.Segment, "wal")
+	require.NoError(t, err)
+	defer segment.Close()
+
+	r := NewReader(segment)
+	var records [][]byte
+
+	for r.Next() {
+		records = append(records, append([]byte{}, r.Record()...))
+	}
+
+	require.NoError(t, r.Err())
+	require.NotEmpty(t, records)
+	assert.Equal(t, []byte("after"), records[len(records)-1])
+	assert.Equal(t, byte(14), records[len(records)-2][0])
+}
+
+func TestReaderFragments(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWal(log.NewNopLogger(), prometheus.NewRegistry(), dir, pageSize*4, "wal")
+	require.NoError(t, err)
+
+	require.NoError(t, w.Log(0, []byte("small")))
+	// Spans the rest of the first page and the start of the second one.
+	require.NoError(t, w.Log(1, bytes.Repeat([]byte("x"), pageSize)))
+	require.NoError(t, w.Stop())
+
+	segment, err := OpenReadSegment(dir, 0, "wal")
+	require.NoError(t, err)
+
+	r := NewReader(segment)
+
+	require.True(t, r.Next())
+	assert.Equal(t, []Fragment{{Type: "full", Position: 0, Length: 5, CRC: r.Fragments()[0].CRC, Checksum: r.Fragments()[0].CRC}}, r.Fragments())
+
+	require.True(t, r.Next())
+	fragments := r.Fragments()
+	require.Len(t, fragments, 2)
+	assert.Equal(t, "first", fragments[0].Type)
+	assert.Equal(t, int64(recordHeaderSize+5), fragments[0].Position)
+	assert.Equal(t, int64(0), fragments[0].Page())
+	assert.Equal(t, "last", fragments[1].Type)
+	assert.Equal(t, int64(1), fragments[1].Page())
+	assert.Equal(t, pageSize, fragments[0].Length+fragments[1].Length)
+	assert.True(t, fragments[0].Valid() && fragments[1].Valid())
+	require.NoError(t, segment.Close())
+
+	// A corrupted fragment is reported with the checksum of the data as read.
+	f, err := os.OpenFile(ToSegmentName(dir, 0, "wal"), os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte("y"), pageSize+recordHeaderSize)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	segment, err = OpenReadSegment(dir, 0, "wal")
+	require.NoError(t, err)
+	defer segment.Close()
+
+	r = NewReader(segment)
+	require.True(t, r.Next())
+	assert.False(t, r.Next())
+	assert.Error(t, r.Err())
+
+	fragments = r.Fragments()
+	require.Len(t, fragments, 2)
+	assert.True(t, fragments[0].Valid())
+	assert.False(t, fragments[1].Valid())
+}
+
+func TestRepair(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wal_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWal(log.NewNopLogger(), prometheus.NewRegistry(), dir, pageSize*4, "wal")
+	require.NoError(t, err)
+
+	refs, err := w.Append(0, []byte("first"), []byte("second"))
+	require.NoError(t, err)
+	require.NoError(t, w.NextSegment(2))
+
+	tail, err := w.Append(2, []byte("third"), []byte("fourth"))
+	require.NoError(t, err)
+	require.NoError(t, w.Stop())
+
+	corrupt := func(segment uint64, position int64) {
+		f, err := os.OpenFile(ToSegmentName(dir, segment, "wal"), os.O_WRONLY, 0)
+		require.NoError(t, err)
+		_, err = f.WriteAt([]byte("!"), position+recordHeaderSize)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+	}
+
+	// The corruption names the segment and the start of the invalid record.
+	corruption := func(segment uint64) *wlog.CorruptionErr {
+		f, err := os.Open(ToSegmentName(dir, segment, "wal"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		r := NewSegmentReader(f, segment)
+
+		for r.Next() {
+		}
+
+		var c *wlog.CorruptionErr
+		require.ErrorAs(t, r.Err(), &c)
+		assert.Equal(t, int(segment), c.Segment)
+
+		return c
+	}
+
+	// The damaged tail of the last segment is cut off before the invalid record.
+	corrupt(tail[1].Segment, tail[1].Position)
+
+	c := corruption(tail[1].Segment)
+	assert.Equal(t, tail[1].Position, c.Offset)
+
+	// An offset which isn't where the corruption is doesn't cut off valid records.
+	_, _, err = Repair(dir, "wal", &wlog.CorruptionErr{Segment: c.Segment, Offset: tail[0].Position})
+	assert.Error(t, err)
+
+	position, moved, err := Repair(dir, "wal", c)
+	require.NoError(t, err)
+	assert.Equal(t, tail[1].Position, position)
+	assert.Empty(t, moved)
+
+	info, err := os.Stat(ToSegmentName(dir, tail[1].Segment, "wal"))
+	require.NoError(t, err)
+	assert.Equal(t, tail[1].Position, info.Size())
+
+	// A corrupted sealed segment is cut off as well, the segments after it are moved
+	// aside with their sidecar files so the log has no hole.
+	corrupt(refs[1].Segment, refs[1].Position)
+	require.NoError(t, os.WriteFile(ToSegmentName(dir, tail[0].Segment, "index"), nil, 0o666))
+
+	c = corruption(refs[1].Segment)
+	assert.Equal(t, refs[1].Position, c.Offset)
+
+	position, moved, err = Repair(dir, "wal", c, "index")
+	require.NoError(t, err)
+	assert.Equal(t, refs[1].Position, position)
+	assert.Equal(t, []uint64{tail[0].Segment}, moved)
+
+	segments, err := SegmentsOf(dir, "")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	assert.Equal(t, refs[0].Segment, segments[0].Index())
+
+	info, err = os.Stat(ToSegmentName(dir, refs[0].Segment, "wal"))
+	require.NoError(t, err)
+	assert.Equal(t, refs[1].Position, info.Size())
+
+	for _, extension := range []string{"wal", "index"} {
+		_, err := os.Stat(ToSegmentName(filepath.Join(dir, CorruptDirName), tail[0].Segment, extension))
+		assert.NoError(t, err)
+	}
+
+	_, _, err = Repair(dir, "wal", &wlog.CorruptionErr{Segment: -1})
+	assert.Error(t, err)
+}
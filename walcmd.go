@@ -0,0 +1,525 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"iris/raft"
+	"iris/storage"
+	"iris/storage/wal"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+const walUsage = `Usage: iris wal <command> [flags] <path>
+
+Commands:
+  dump     print the records of a segment
+  verify   check the segments and offset indexes in a directory
+`
+
+// walCommand runs the offline tools working on the segment files of a stopped broker.
+func walCommand(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, walUsage)
+		return errors.New("missing wal command")
+	}
+
+	switch args[0] {
+	case "dump":
+		return dump(os.Stdout, args[1:])
+	case "verify":
+		return verify(os.Stdout, args[1:])
+	default:
+		fmt.Fprint(os.Stderr, walUsage)
+		return errors.Errorf("unknown wal command %q", args[0])
+	}
+}
+
+// Payload formats of dump.
+const (
+	payloadHex      = "hex"
+	payloadUTF8     = "utf8"
+	payloadEnvelope = "envelope"
+	payloadNone     = "none"
+)
+
+type dumpOptions struct {
+	payload string
+	json    bool
+}
+
+// dumpedRecord is a record of a segment as printed by dump, the JSON output has one per line.
+type dumpedRecord struct {
+	Position  int64            `json:"position"`
+	Page      int64            `json:"page"`
+	Length    int              `json:"length"`
+	Fragments []dumpedFragment `json:"fragments"`
+	// Payload is a string for the hex and utf8 formats and a dumpedMessage for envelopes.
+	Payload interface{} `json:"payload,omitempty"`
+	// Error is why the envelope couldn't be decoded or the corruption the record ends with.
+	Error string `json:"error,omitempty"`
+}
+
+type dumpedFragment struct {
+	Type     string `json:"type"`
+	Position int64  `json:"position"`
+	Page     int64  `json:"page"`
+	Length   int    `json:"length"`
+	CRC      string `json:"crc"`
+}
+
+type dumpedMessage struct {
+	Offset    uint64            `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	ExpiresAt *time.Time        `json:"expiresAt,omitempty"`
+	Key       string            `json:"key,omitempty"`
+	Value     string            `json:"value"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// dump prints the records of a segment file with the fragments they are stored in. Reading stops
+// at the first corruption, which is printed with the fragments read up to it and returned.
+func dump(out io.Writer, args []string) error {
+	var o dumpOptions
+
+	fs := flag.NewFlagSet("wal dump", flag.ContinueOnError)
+	fs.StringVar(&o.payload, "payload", payloadHex, "payload format: hex, utf8, envelope or none")
+	fs.BoolVar(&o.json, "json", false, "print a JSON object per record")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch o.payload {
+	case payloadHex, payloadUTF8, payloadEnvelope, payloadNone:
+	default:
+		return errors.Errorf("unknown payload format %q", o.payload)
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("expected the path of a segment")
+	}
+
+	path := fs.Arg(0)
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	r := newSegmentReader(f, path)
+	records := 0
+
+	for r.Next() {
+		if err := printRecord(w, o, newDumpedRecord(r, o.payload)); err != nil {
+			return err
+		}
+
+		records++
+	}
+
+	if err := segmentCorruption(r.Err(), path); err != nil {
+		// The fragments read before the corruption show where it is.
+		record := newDumpedRecord(r, payloadNone)
+		record.Error = err.Error()
+
+		if err := printRecord(w, o, record); err != nil {
+			return err
+		}
+
+		return err
+	}
+
+	if !o.json {
+		fmt.Fprintf(w, "%d records\n", records)
+	}
+
+	return nil
+}
+
+func newDumpedRecord(r *wal.Reader, payload string) dumpedRecord {
+	record := dumpedRecord{
+		Position: r.Position(),
+		Page:     r.Position() / wal.PageSize,
+		Length:   len(r.Record()),
+	}
+
+	for _, f := range r.Fragments() {
+		crc := "ok"
+
+		if !f.Valid() {
+			crc = fmt.Sprintf("mismatch (stored %08x, computed %08x)", f.CRC, f.Checksum)
+		}
+
+		record.Fragments = append(record.Fragments, dumpedFragment{
+			Type:     f.Type,
+			Position: f.Position,
+			Page:     f.Page(),
+			Length:   f.Length,
+			CRC:      crc,
+		})
+	}
+
+	switch payload {
+	case payloadHex:
+		record.Payload = hex.EncodeToString(r.Record())
+	case payloadUTF8:
+		record.Payload = strings.ToValidUTF8(string(r.Record()), string(utf8.RuneError))
+	case payloadEnvelope:
+		msg, err := storage.DecodeMessage(r.Record())
+
+		if err != nil {
+			record.Error = err.Error()
+			break
+		}
+
+		record.Payload = newDumpedMessage(msg)
+	}
+
+	return record
+}
+
+func newDumpedMessage(msg *storage.Message) dumpedMessage {
+	m := dumpedMessage{
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp.UTC(),
+		Key:       string(msg.Key),
+		Value:     string(msg.Value),
+	}
+
+	if !msg.ExpiresAt.IsZero() {
+		expiresAt := msg.ExpiresAt.UTC()
+		m.ExpiresAt = &expiresAt
+	}
+
+	if len(msg.Headers) > 0 {
+		m.Headers = make(map[string]string, len(msg.Headers))
+
+		for _, h := range msg.Headers {
+			m.Headers[h.Key] = string(h.Value)
+		}
+	}
+
+	return m
+}
+
+func printRecord(w io.Writer, o dumpOptions, record dumpedRecord) error {
+	if o.json {
+		return json.NewEncoder(w).Encode(record)
+	}
+
+	fragments := make([]string, 0, len(record.Fragments))
+	crc := "ok"
+
+	for _, f := range record.Fragments {
+		fragments = append(fragments, fmt.Sprintf("%s@%d/%d", f.Type, f.Position, f.Length))
+
+		if f.CRC != "ok" {
+			crc = f.CRC
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "position=%d page=%d length=%d fragments=%s crc=%q\n",
+		record.Position, record.Page, record.Length, strings.Join(fragments, ","), crc)
+
+	if err != nil {
+		return err
+	}
+
+	switch payload := record.Payload.(type) {
+	case string:
+		_, err = fmt.Fprintf(w, "  %s\n", payload)
+	case dumpedMessage:
+		_, err = fmt.Fprintf(w, "  offset=%d timestamp=%s key=%q value=%q headers=%v", payload.Offset,
+			payload.Timestamp.Format(time.RFC3339Nano), payload.Key, payload.Value, payload.Headers)
+
+		if err == nil && payload.ExpiresAt != nil {
+			_, err = fmt.Fprintf(w, " expiresAt=%s", payload.ExpiresAt.Format(time.RFC3339Nano))
+		}
+
+		if err == nil {
+			_, err = fmt.Fprintln(w)
+		}
+	}
+
+	if err == nil && record.Error != "" {
+		_, err = fmt.Fprintf(w, "  error: %s\n", record.Error)
+	}
+
+	return err
+}
+
+// newSegmentReader reads the segment file at path, its corruption errors name the segment
+// unless the file name isn't the one of a segment.
+func newSegmentReader(f *os.File, path string) *wal.Reader {
+	ref, err := wal.ToSegmentRef(path)
+
+	if err != nil {
+		return wal.NewReader(bufio.NewReader(f))
+	}
+
+	return wal.NewSegmentReader(bufio.NewReader(f), ref.Index())
+}
+
+// corruption is a CorruptionErr of a reader in its segment file. wlog names segments
+// differently, so the error names the file itself.
+type corruption struct {
+	*wlog.CorruptionErr
+	path string
+}
+
+func (c *corruption) Error() string {
+	return fmt.Sprintf("corruption in segment %s at %d: %s", c.path, c.Offset, c.Err)
+}
+
+func (c *corruption) Unwrap() error {
+	return c.CorruptionErr
+}
+
+// segmentCorruption names the file of a corruption reported by the reader of the segment at path,
+// other errors are returned as they are.
+func segmentCorruption(err error, path string) error {
+	var c *wlog.CorruptionErr
+
+	if !errors.As(err, &c) {
+		return err
+	}
+
+	c.Dir = filepath.Dir(path)
+
+	return &corruption{CorruptionErr: c, path: path}
+}
+
+// walExtensions are the extensions of the segments written by a wal, the other files next to
+// them have their own formats.
+var walExtensions = []string{storage.JournalSegmentExt, storage.AckLogSegmentExt, raft.LogSegmentExt}
+
+// verifier checks the segments of a directory tree and counts the problems it finds.
+type verifier struct {
+	out      io.Writer
+	repair   bool
+	segments int
+	problems int
+	repaired int
+}
+
+// verify checks every wal segment below a directory and the offset indexes of the journal
+// segments. With -repair a corrupted segment is cut off before its first invalid record, the
+// segments after it are moved to the corrupt directory next to it and an offset index is
+// truncated before its first wrong record.
+// The broker must not be running.
+func verify(out io.Writer, args []string) error {
+	v := &verifier{out: out}
+
+	fs := flag.NewFlagSet("wal verify", flag.ContinueOnError)
+	fs.BoolVar(&v.repair, "repair", false, "repair the problems found")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("expected a directory")
+	}
+
+	err := filepath.WalkDir(fs.Arg(0), func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+
+		if d.Name() == wal.CorruptDirName {
+			return filepath.SkipDir
+		}
+
+		return v.verifyDir(path)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "checked %d segments, %d problems, %d repaired\n", v.segments, v.problems, v.repaired)
+
+	if unrepaired := v.problems - v.repaired; unrepaired > 0 {
+		return errors.Errorf("%d problems are not repaired", unrepaired)
+	}
+
+	return nil
+}
+
+func (v *verifier) verifyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return err
+	}
+
+	segments := make(map[string][]uint64)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		// Files of other formats live next to segments, only segment names are considered.
+		ref, err := wal.ToSegmentRef(entry.Name())
+
+		if err != nil {
+			continue
+		}
+
+		segments[ref.Extension()] = append(segments[ref.Extension()], ref.Index())
+	}
+
+	for _, extension := range walExtensions {
+		indexes := segments[extension]
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+		for _, index := range indexes {
+			// The segments after a repaired one may be moved aside already.
+			if _, err := os.Stat(wal.ToSegmentName(dir, index, extension)); os.IsNotExist(err) {
+				continue
+			}
+
+			if err := v.verifySegment(dir, extension, index); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// verifySegment reads all records of a segment, journal segments are checked against their offset index.
+func (v *verifier) verifySegment(dir string, extension string, index uint64) error {
+	v.segments++
+
+	path := wal.ToSegmentName(dir, index, extension)
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	journal := extension == storage.JournalSegmentExt
+	// The offsets of the messages by the position of their record.
+	offsets := make(map[int64]uint64)
+
+	r := wal.NewSegmentReader(bufio.NewReader(f), index)
+
+	for r.Next() {
+		if !journal {
+			continue
+		}
+
+		msg, err := storage.DecodeMessage(r.Record())
+
+		if err != nil {
+			v.problem("invalid message in segment %s at %d: %v", path, r.Position(), err)
+			continue
+		}
+
+		offsets[r.Position()] = msg.Offset
+	}
+
+	if err := segmentCorruption(r.Err(), path); err != nil {
+		var c *corruption
+
+		if !errors.As(err, &c) {
+			return err
+		}
+
+		v.problem("%v", c)
+
+		if v.repair {
+			sidecars := []string(nil)
+
+			if journal {
+				sidecars = []string{storage.OffsetIndexSegmentExt, storage.ExpirySegmentExt}
+			}
+
+			position, moved, err := wal.Repair(dir, extension, c.CorruptionErr, sidecars...)
+
+			if err != nil {
+				return errors.Wrapf(err, "unable to repair segment %s", path)
+			}
+
+			v.repaired++
+
+			fmt.Fprintf(v.out, "truncated %s at %d\n", path, position)
+
+			for _, index := range moved {
+				fmt.Fprintf(v.out, "moved %s to %s\n", wal.ToSegmentName(dir, index, extension), filepath.Join(dir, wal.CorruptDirName))
+			}
+		}
+	}
+
+	if !journal {
+		return nil
+	}
+
+	return v.verifyIndex(dir, index, offsets)
+}
+
+// verifyIndex checks that every record of the offset index of a segment points at the start
+// of the record of its message.
+func (v *verifier) verifyIndex(dir string, segment uint64, offsets map[int64]uint64) error {
+	recs, err := storage.ReadOffsetIndex(dir, segment)
+
+	if err != nil {
+		return err
+	}
+
+	path := wal.ToSegmentName(dir, segment, storage.OffsetIndexSegmentExt)
+
+	for i, rec := range recs {
+		offset, ok := offsets[rec.Position()]
+
+		switch {
+		case !ok:
+			v.problem("index %s: record %d of offset %d points at %d, where no message starts", path, i, rec.Offset(), rec.Position())
+		case offset != rec.Offset():
+			v.problem("index %s: record %d of offset %d points at the message of offset %d", path, i, rec.Offset(), offset)
+		default:
+			continue
+		}
+
+		// Lookups only need a prefix of the records, they scan the segment from the last one on.
+		if v.repair {
+			if err := storage.TruncateOffsetIndex(dir, segment, i); err != nil {
+				return errors.Wrapf(err, "unable to repair index %s", path)
+			}
+
+			v.repaired++
+			fmt.Fprintf(v.out, "truncated %s to %d records\n", path, i)
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
+func (v *verifier) problem(format string, args ...interface{}) {
+	v.problems++
+	fmt.Fprintf(v.out, format+"\n", args...)
+}